@@ -0,0 +1,124 @@
+// Package apperr defines the API's error model. Handlers report failures as
+// *Error values (or any error, which is translated by From), and the
+// middleware.ErrorHandler renders them in one envelope:
+//
+//	{
+//	  "success": false,
+//	  "error": {
+//	    "code": "validation_failed",
+//	    "message": "request validation failed",
+//	    "details": [{"field": "title", "code": "required", "message": "title is required"}]
+//	  }
+//	}
+//
+// "code" is stable and meant for programs; "message" is for humans and may
+// change. "details" is present only for field-level validation failures,
+// and for not_eligible errors, where they list the rules the user fails.
+package apperr
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Code is a machine-readable error identifier.
+type Code string
+
+const (
+	CodeBadRequest   Code = "bad_request"
+	CodeValidation   Code = "validation_failed"
+	CodeUnauthorized Code = "unauthorized"
+	CodeForbidden    Code = "forbidden"
+	CodeNotEligible  Code = "not_eligible"
+	CodeNotFound     Code = "not_found"
+	CodeConflict     Code = "conflict"
+	CodePrecondition Code = "precondition_failed"
+	CodeTooMany      Code = "too_many_requests"
+	CodeTimeout      Code = "timeout"
+	CodeUpstream     Code = "upstream_error"
+	CodeInternal     Code = "internal_error"
+)
+
+var statusByCode = map[Code]int{
+	CodeBadRequest:   http.StatusBadRequest,
+	CodeValidation:   http.StatusUnprocessableEntity,
+	CodeUnauthorized: http.StatusUnauthorized,
+	CodeForbidden:    http.StatusForbidden,
+	CodeNotEligible:  http.StatusForbidden,
+	CodeNotFound:     http.StatusNotFound,
+	CodeConflict:     http.StatusConflict,
+	CodePrecondition: http.StatusPreconditionFailed,
+	CodeTooMany:      http.StatusTooManyRequests,
+	CodeTimeout:      http.StatusGatewayTimeout,
+	CodeUpstream:     http.StatusBadGateway,
+	CodeInternal:     http.StatusInternalServerError,
+}
+
+// FieldError describes one invalid input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error is an API error with its HTTP semantics.
+type Error struct {
+	Code    Code         `json:"code"`
+	Message string       `json:"message"`
+	Details []FieldError `json:"details,omitempty"`
+	// Err is the underlying cause. It is logged, never sent to clients.
+	Err error `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status code for the error.
+func (e *Error) Status() int {
+	if status, ok := statusByCode[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Wrap attaches a cause to the error for logging.
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error   { return New(CodeBadRequest, message) }
+func Unauthorized(message string) *Error { return New(CodeUnauthorized, message) }
+func Forbidden(message string) *Error    { return New(CodeForbidden, message) }
+func NotFound(message string) *Error     { return New(CodeNotFound, message) }
+func Conflict(message string) *Error     { return New(CodeConflict, message) }
+func Precondition(message string) *Error { return New(CodePrecondition, message) }
+func TooMany(message string) *Error      { return New(CodeTooMany, message) }
+
+// Validation reports invalid input, optionally field by field.
+func Validation(message string, details ...FieldError) *Error {
+	return &Error{Code: CodeValidation, Message: message, Details: details}
+}
+
+// NotEligible refuses a user who doesn't meet a resource's rules, with a
+// detail per failed rule.
+func NotEligible(message string, details ...FieldError) *Error {
+	return &Error{Code: CodeNotEligible, Message: message, Details: details}
+}
+
+// Internal hides err behind a generic message.
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Message: "internal server error", Err: err}
+}
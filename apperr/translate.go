@@ -0,0 +1,155 @@
+package apperr
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"InfluenceIQ/store"
+)
+
+// From translates any error into an *Error. Errors that are already *Error
+// pass through; store, database, validation and context errors get a
+// matching code; anything else becomes an internal error.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var validationErrs validator.ValidationErrors
+	var pgErr *pgconn.PgError
+	var liteErr *sqlite.Error
+
+	switch {
+	case errors.As(err, &validationErrs):
+		return fromValidation(validationErrs)
+	case errors.As(err, &pgErr):
+		return fromPostgres(pgErr)
+	case errors.As(err, &liteErr):
+		return fromSQLite(liteErr)
+	case errors.Is(err, store.ErrNotFound), errors.Is(err, sql.ErrNoRows):
+		// pgx.ErrNoRows wraps sql.ErrNoRows, so this covers both drivers.
+		return NotFound("resource not found").Wrap(err)
+	case errors.Is(err, store.ErrConflict):
+		return Conflict("resource conflicts with existing data").Wrap(err)
+	case errors.Is(err, store.ErrInvalidCursor):
+		return BadRequest("invalid cursor").Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return New(CodeTimeout, "request timed out").Wrap(err)
+	}
+	return Internal(err)
+}
+
+// FromBinding translates an error returned by gin's ShouldBind methods.
+// Anything other than a validation failure means the body is malformed.
+func FromBinding(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+
+	switch {
+	case errors.As(err, &validationErrs):
+		return fromValidation(validationErrs)
+	case errors.As(err, &typeErr):
+		return Validation("request validation failed", FieldError{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: fmt.Sprintf("%s must be a %s", typeErr.Field, typeErr.Type),
+		}).Wrap(err)
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return BadRequest("request body is not valid JSON").Wrap(err)
+	}
+	return BadRequest("malformed request body: " + err.Error()).Wrap(err)
+}
+
+// fromPostgres maps SQLSTATE codes; see
+// https://www.postgresql.org/docs/current/errcodes-appendix.html.
+func fromPostgres(err *pgconn.PgError) *Error {
+	switch err.Code {
+	case "23505": // unique_violation
+		return Conflict("resource already exists").Wrap(err)
+	case "23503": // foreign_key_violation
+		return Conflict("referenced resource does not exist or is still in use").Wrap(err)
+	case "23514", "23502", "22001", "22003": // check, not null, too long, out of range
+		return Validation("value violates a data constraint").Wrap(err)
+	case "22P02", "22007", "22008": // invalid text representation, datetime format/overflow
+		return BadRequest("malformed value").Wrap(err)
+	case "40001", "40P01": // serialization_failure, deadlock_detected
+		return Conflict("concurrent update, please retry").Wrap(err)
+	case "57014": // query_canceled
+		return New(CodeTimeout, "request timed out").Wrap(err)
+	}
+	return Internal(err)
+}
+
+func fromSQLite(err *sqlite.Error) *Error {
+	switch err.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return Conflict("resource already exists").Wrap(err)
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return Conflict("referenced resource does not exist or is still in use").Wrap(err)
+	case sqlite3.SQLITE_CONSTRAINT_CHECK, sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		return Validation("value violates a data constraint").Wrap(err)
+	case sqlite3.SQLITE_BUSY:
+		return Conflict("concurrent update, please retry").Wrap(err)
+	}
+	return Internal(err)
+}
+
+func fromValidation(errs validator.ValidationErrors) *Error {
+	details := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		details = append(details, FieldError{
+			Field:   fe.Field(),
+			Code:    fe.Tag(),
+			Message: fieldMessage(fe),
+		})
+	}
+	return Validation("request validation failed", details...)
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fe.Field() + " is required"
+	case "email":
+		return fe.Field() + " must be a valid email address"
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", fe.Field(), fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", fe.Field(), fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", fe.Field(), strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "excludes":
+		return fmt.Sprintf("%s must not contain %q", fe.Field(), fe.Param())
+	}
+	return fmt.Sprintf("%s failed the %q rule", fe.Field(), fe.Tag())
+}
+
+// UseJSONFieldNames makes validation errors report fields by their JSON
+// names ("email_or_username") rather than Go names ("EmailOrUsername").
+func UseJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return f.Name
+		}
+		return name
+	})
+}
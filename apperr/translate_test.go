@@ -0,0 +1,156 @@
+package apperr
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "modernc.org/sqlite"
+
+	"InfluenceIQ/store"
+)
+
+// sqliteErr returns the error SQLite reports for a statement run after
+// setup on a fresh database.
+func sqliteErr(t *testing.T, setup, stmt string) error {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(setup); err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(stmt)
+	if err == nil {
+		t.Fatalf("%s succeeded", stmt)
+	}
+	return err
+}
+
+func TestFrom(t *testing.T) {
+	const schema = `CREATE TABLE parents (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE CHECK (name <> ''));
+		CREATE TABLE children (parent_id INTEGER NOT NULL REFERENCES parents (id));
+		INSERT INTO parents (id, name) VALUES (1, 'a');`
+	forbidden := Forbidden("not yours")
+
+	tests := []struct {
+		name       string
+		err        func(t *testing.T) error
+		wantCode   Code
+		wantStatus int
+	}{
+		{"already an *Error", func(*testing.T) error { return fmt.Errorf("loading: %w", forbidden) }, CodeForbidden, http.StatusForbidden},
+		{"store not found", func(*testing.T) error { return fmt.Errorf("user 7: %w", store.ErrNotFound) }, CodeNotFound, http.StatusNotFound},
+		{"no rows", func(*testing.T) error { return sql.ErrNoRows }, CodeNotFound, http.StatusNotFound},
+		{"store conflict", func(*testing.T) error { return fmt.Errorf("create: %w", store.ErrConflict) }, CodeConflict, http.StatusConflict},
+		{"invalid cursor", func(*testing.T) error { return store.ErrInvalidCursor }, CodeBadRequest, http.StatusBadRequest},
+		{"deadline", func(*testing.T) error { return context.DeadlineExceeded }, CodeTimeout, http.StatusGatewayTimeout},
+		{"postgres unique", func(*testing.T) error { return &pgconn.PgError{Code: "23505"} }, CodeConflict, http.StatusConflict},
+		{"postgres foreign key", func(*testing.T) error { return &pgconn.PgError{Code: "23503"} }, CodeConflict, http.StatusConflict},
+		{"postgres check", func(*testing.T) error { return &pgconn.PgError{Code: "23514"} }, CodeValidation, http.StatusUnprocessableEntity},
+		{"postgres bad value", func(*testing.T) error { return &pgconn.PgError{Code: "22P02"} }, CodeBadRequest, http.StatusBadRequest},
+		{"postgres serialization", func(*testing.T) error { return &pgconn.PgError{Code: "40001"} }, CodeConflict, http.StatusConflict},
+		{"postgres canceled", func(*testing.T) error { return &pgconn.PgError{Code: "57014"} }, CodeTimeout, http.StatusGatewayTimeout},
+		{"postgres other", func(*testing.T) error { return &pgconn.PgError{Code: "53300"} }, CodeInternal, http.StatusInternalServerError},
+		{"sqlite unique", func(t *testing.T) error {
+			return sqliteErr(t, schema, `INSERT INTO parents (name) VALUES ('a')`)
+		}, CodeConflict, http.StatusConflict},
+		{"sqlite primary key", func(t *testing.T) error {
+			return sqliteErr(t, schema, `INSERT INTO parents (id, name) VALUES (1, 'b')`)
+		}, CodeConflict, http.StatusConflict},
+		{"sqlite foreign key", func(t *testing.T) error {
+			return sqliteErr(t, schema, `INSERT INTO children (parent_id) VALUES (2)`)
+		}, CodeConflict, http.StatusConflict},
+		{"sqlite not null", func(t *testing.T) error {
+			return sqliteErr(t, schema, `INSERT INTO parents (name) VALUES (NULL)`)
+		}, CodeValidation, http.StatusUnprocessableEntity},
+		{"sqlite check", func(t *testing.T) error {
+			return sqliteErr(t, schema, `INSERT INTO parents (name) VALUES ('')`)
+		}, CodeValidation, http.StatusUnprocessableEntity},
+		{"anything else", func(*testing.T) error { return errors.New("disk on fire") }, CodeInternal, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err(t)
+			got := From(err)
+			if got.Code != tt.wantCode || got.Status() != tt.wantStatus {
+				t.Errorf("From(%v) = %s (%d), want %s (%d)", err, got.Code, got.Status(), tt.wantCode, tt.wantStatus)
+			}
+			if got.Code != CodeForbidden && !errors.Is(got, err) {
+				t.Errorf("From(%v) dropped the cause", err)
+			}
+		})
+	}
+}
+
+func TestInternalHidesCause(t *testing.T) {
+	body, err := json.Marshal(From(errors.New("password=hunter2")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"code":"internal_error","message":"internal server error"}`
+	if string(body) != want {
+		t.Errorf("marshaled %s, want %s", body, want)
+	}
+}
+
+func TestFromBinding(t *testing.T) {
+	UseJSONFieldNames()
+	type signup struct {
+		Username string `json:"username" binding:"required,min=3,excludes=@"`
+		Email    string `json:"email" binding:"required,email"`
+		Role     string `json:"role" binding:"omitempty,oneof=brand influencer"`
+		Age      int    `json:"age" binding:"omitempty,max=130"`
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		wantCode    Code
+		wantDetails []FieldError
+	}{
+		{"missing fields", `{}`, CodeValidation, []FieldError{
+			{"username", "required", "username is required"},
+			{"email", "required", "email is required"},
+		}},
+		{"every rule", `{"username":"a@","email":"nope","role":"admin","age":200}`, CodeValidation, []FieldError{
+			{"username", "min", "username must be at least 3"},
+			{"email", "email", "email must be a valid email address"},
+			{"role", "oneof", "role must be one of: brand, influencer"},
+			{"age", "max", "age must be at most 130"},
+		}},
+		{"at sign in username", `{"username":"ada@example.com","email":"ada@example.com"}`, CodeValidation, []FieldError{
+			{"username", "excludes", `username must not contain "@"`},
+		}},
+		{"wrong type", `{"username":"ada","email":"ada@example.com","age":"old"}`, CodeValidation, []FieldError{
+			{"age", "type", "age must be a int"},
+		}},
+		{"not JSON", `{"username":`, CodeBadRequest, nil},
+		{"empty body", ``, CodeBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req signup
+			err := binding.JSON.BindBody([]byte(tt.body), &req)
+			if err == nil {
+				t.Fatal("binding succeeded")
+			}
+			got := FromBinding(err)
+			if got.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", got.Code, tt.wantCode)
+			}
+			if fmt.Sprint(got.Details) != fmt.Sprint(tt.wantDetails) {
+				t.Errorf("details = %+v, want %+v", got.Details, tt.wantDetails)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/database"
+	"InfluenceIQ/migrations"
+)
+
+const usage = `usage: migrate <command> [config flags]
+
+commands:
+  up      apply all pending migrations
+  down    roll back the most recent migration
+  redo    roll back the most recent migration and apply it again
+  status  list migrations and whether they are applied`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(os.Args[2:])
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Migrations only ever run against the primary.
+	cfg.Database.ReplicaURLs = nil
+	db, err := database.Open(context.Background(), cfg.Database)
+	if err != nil {
+		log.Fatalf("Unable to connect to %s database: %v", cfg.Database.Driver, err)
+	}
+	defer db.Close()
+
+	m, err := migrations.New(db)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch os.Args[1] {
+	case "up":
+		applied, err := m.Up(ctx)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+		for _, mig := range applied {
+			fmt.Printf("Applied %04d_%s\n", mig.Version, mig.Name)
+		}
+
+	case "down":
+		mig, err := m.Down(ctx)
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		if mig == nil {
+			fmt.Println("No migrations to roll back")
+			return
+		}
+		fmt.Printf("Rolled back %04d_%s\n", mig.Version, mig.Name)
+
+	case "redo":
+		mig, err := m.Redo(ctx)
+		if err != nil {
+			log.Fatalf("Redo failed: %v", err)
+		}
+		if mig == nil {
+			fmt.Println("No migrations to redo")
+			return
+		}
+		fmt.Printf("Redid %04d_%s\n", mig.Version, mig.Name)
+
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			log.Fatalf("Status failed: %v", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, state)
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/sqlstore"
+)
+
+const usage = `usage: setrole <email or username> <viewer|influencer|brand|admin> [config flags]
+
+Changes a user's role and logs them out everywhere, e.g. to appoint the
+first admin. Later changes can go through PUT /api/admin/users/:id/role.`
+
+var roles = []string{models.RoleViewer, models.RoleInfluencer, models.RoleBrand, models.RoleAdmin}
+
+func main() {
+	if len(os.Args) < 3 || !slices.Contains(roles, os.Args[2]) {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	login, role := os.Args[1], os.Args[2]
+
+	cfg, err := config.Load(os.Args[3:])
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	cfg.Database.ReplicaURLs = nil
+	db, err := database.Open(context.Background(), cfg.Database)
+	if err != nil {
+		log.Fatalf("Unable to connect to %s database: %v", cfg.Database.Driver, err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s := sqlstore.New(db)
+	user, err := s.Users.GetByLogin(ctx, login)
+	if errors.Is(err, store.ErrNotFound) {
+		log.Fatalf("No user with email or username %q", login)
+	}
+	if err != nil {
+		log.Fatalf("Looking up %q failed: %v", login, err)
+	}
+
+	err = s.WithTx(ctx, func(tx *store.Store) error {
+		if err := tx.Users.SetRole(ctx, user.ID, role); err != nil {
+			return err
+		}
+		return tx.Tokens.RevokeUserRefresh(ctx, user.ID)
+	})
+	if err != nil {
+		log.Fatalf("Changing the role failed: %v", err)
+	}
+	fmt.Printf("%s (user %d) is now %s\n", user.Username, user.ID, role)
+}
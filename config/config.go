@@ -0,0 +1,607 @@
+// Package config loads the application settings. Values come from, in
+// increasing order of precedence: built-in defaults, an optional YAML or
+// TOML file, environment variables (a .env file is honoured if present),
+// and command-line flags.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+)
+
+type Config struct {
+	HTTP     HTTPConfig     `yaml:"http"`
+	Database DatabaseConfig `yaml:"database"`
+	JWT      JWTConfig      `yaml:"jwt"`
+	Account  AccountConfig  `yaml:"account"`
+	MFA      MFAConfig      `yaml:"mfa"`
+	Login    LoginConfig    `yaml:"login"`
+	Mail     MailConfig     `yaml:"mail"`
+	OIDC     OIDCConfig     `yaml:"oidc"`
+	AI       AIConfig       `yaml:"ai"`
+	Jobs     JobsConfig     `yaml:"jobs"`
+}
+
+type HTTPConfig struct {
+	Addr              string        `yaml:"addr"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout bounds how long in-flight requests may take to finish
+	// once shutdown starts.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set.
+	TLSCertFile string `yaml:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file"`
+	// TrustedProxies are the IPs or CIDR ranges of reverse proxies whose
+	// X-Forwarded-For header names the client. Without them the client is
+	// the connection's peer.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	// Driver is "postgres" or "sqlite".
+	Driver string `yaml:"driver"`
+	// URL is a Postgres connection string or a SQLite file path.
+	URL            string        `yaml:"url"`
+	AutoMigrate    bool          `yaml:"auto_migrate"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	// Pool sizing. SQLite only honours MaxConns.
+	MaxConns        int32         `yaml:"max_conns"`
+	MinConns        int32         `yaml:"min_conns"`
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time"`
+	// ReplicaURLs are read replicas of URL, each with its own pool sized
+	// like the primary's. Read-only queries are spread across the healthy
+	// ones, which are re-checked every ReplicaCheckInterval.
+	ReplicaURLs          []string      `yaml:"replica_urls"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval"`
+}
+
+type JWTConfig struct {
+	// SigningKeyFile is a PEM private key that signs access tokens: RSA for
+	// RS256 or Ed25519 for EdDSA. When empty a temporary key is generated
+	// at startup, which is only fit for development.
+	SigningKeyFile string `yaml:"signing_key_file"`
+	// VerificationKeyFiles are PEM keys whose tokens are still accepted
+	// and which are published in the JWKS, e.g. the previous signing key
+	// during a rotation or the next one before it takes over.
+	VerificationKeyFiles []string `yaml:"verification_key_files"`
+	// Issuer is the iss claim of every token.
+	Issuer string `yaml:"issuer"`
+	// TTL is the lifetime of access tokens. Clients renew them with a
+	// refresh token, which lasts RefreshTTL from its last use.
+	TTL        time.Duration `yaml:"ttl"`
+	RefreshTTL time.Duration `yaml:"refresh_ttl"`
+}
+
+type AccountConfig struct {
+	// LinkBaseURL is where the links in account emails point, normally
+	// the frontend, which posts the token back to the API.
+	LinkBaseURL string `yaml:"link_base_url"`
+	// How long email verification, password reset and organization
+	// invitation links stay valid.
+	VerificationTTL  time.Duration `yaml:"verification_ttl"`
+	PasswordResetTTL time.Duration `yaml:"password_reset_ttl"`
+	InvitationTTL    time.Duration `yaml:"invitation_ttl"`
+}
+
+type MFAConfig struct {
+	// Issuer labels the account in authenticator apps.
+	Issuer string `yaml:"issuer"`
+	// ChallengeTTL bounds the time between the password and the second
+	// factor of a login.
+	ChallengeTTL time.Duration `yaml:"challenge_ttl"`
+}
+
+type LoginConfig struct {
+	// LimiterDriver is where failed logins are counted: "memory" for a
+	// single instance, or "database" to share the counts between
+	// instances.
+	LimiterDriver string `yaml:"limiter_driver"`
+	// Window is how long failed logins are remembered.
+	Window time.Duration `yaml:"window"`
+	// After DelayAfter failures of one account, each further attempt
+	// must wait twice as long as the one before, from a second up to
+	// MaxDelay.
+	DelayAfter int           `yaml:"delay_after"`
+	MaxDelay   time.Duration `yaml:"max_delay"`
+	// LockoutAfter failures lock the account for LockoutDuration and mail
+	// its owner a link to unlock it.
+	LockoutAfter    int           `yaml:"lockout_after"`
+	LockoutDuration time.Duration `yaml:"lockout_duration"`
+	// IPLimit failures from one client IP, whatever the accounts, block
+	// the IP for LockoutDuration.
+	IPLimit int `yaml:"ip_limit"`
+}
+
+type MailConfig struct {
+	// Driver is "smtp", or "outbox" to write each message to a file in
+	// OutboxDir instead of sending it.
+	Driver    string `yaml:"driver"`
+	From      string `yaml:"from"`
+	OutboxDir string `yaml:"outbox_dir"`
+	// SMTPAddr is host:port. STARTTLS is used when the server offers it;
+	// SMTPUsername and SMTPPassword enable PLAIN authentication.
+	SMTPAddr     string `yaml:"smtp_addr"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+}
+
+type OIDCConfig struct {
+	// Providers are the OpenID Connect providers users can log in with,
+	// keyed by the name used in URLs, e.g. "google".
+	Providers map[string]OIDCProvider `yaml:"providers"`
+	// StateTTL bounds how long a user may take to log in at a provider.
+	StateTTL time.Duration `yaml:"state_ttl"`
+}
+
+type OIDCProvider struct {
+	// Issuer is the provider's issuer URL; the rest of its configuration
+	// is discovered from Issuer/.well-known/openid-configuration.
+	Issuer       string `yaml:"issuer"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	// RedirectURL is where the provider sends the user back, normally a
+	// page of the frontend that posts the code and state to the API. It
+	// defaults to account.link_base_url + "/oauth/callback/<name>".
+	RedirectURL string `yaml:"redirect_url"`
+	// Scopes are requested besides openid; the default is email and
+	// profile.
+	Scopes []string `yaml:"scopes"`
+}
+
+type AIConfig struct {
+	// GeminiAPIKey may be empty; the AI endpoints then report an error.
+	GeminiAPIKey string        `yaml:"gemini_api_key"`
+	GeminiModel  string        `yaml:"gemini_model"`
+	Timeout      time.Duration `yaml:"timeout"`
+}
+
+type JobsConfig struct {
+	// Enabled runs the scheduled jobs in this instance. Instances sharing a
+	// Postgres database take turns, so each scheduled time runs once.
+	Enabled bool `yaml:"enabled"`
+	// Schedules are cron expressions evaluated in UTC, e.g. "*/5 * * * *",
+	// or "@every 10m"; an empty one turns its job off.
+	// CloseExpiredSchedule closes campaigns whose deadline passed,
+	// ExpireApplicationsSchedule expires applications pending for longer
+	// than ApplicationTTL and RemindersSchedule emails brands whose
+	// campaign's deadline is less than ReminderLead away.
+	CloseExpiredSchedule       string        `yaml:"close_expired_schedule"`
+	ExpireApplicationsSchedule string        `yaml:"expire_applications_schedule"`
+	ApplicationTTL             time.Duration `yaml:"application_ttl"`
+	RemindersSchedule          string        `yaml:"reminders_schedule"`
+	ReminderLead               time.Duration `yaml:"reminder_lead"`
+	// RunRetention is how long the record of each run is kept.
+	RunRetention time.Duration `yaml:"run_retention"`
+}
+
+// Default returns the settings used when nothing else is configured.
+func Default() Config {
+	return Config{
+		HTTP: HTTPConfig{
+			Addr:              ":8080",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver:          "postgres",
+			ConnectTimeout:  5 * time.Second,
+			MaxConns:        10,
+			MaxConnLifetime: time.Hour,
+			MaxConnIdleTime: 30 * time.Minute,
+
+			ReplicaCheckInterval: 5 * time.Second,
+		},
+		JWT: JWTConfig{
+			Issuer:     "influenceiq",
+			TTL:        15 * time.Minute,
+			RefreshTTL: 30 * 24 * time.Hour,
+		},
+		Account: AccountConfig{
+			LinkBaseURL:      "http://localhost:3000",
+			VerificationTTL:  48 * time.Hour,
+			PasswordResetTTL: time.Hour,
+			InvitationTTL:    7 * 24 * time.Hour,
+		},
+		MFA: MFAConfig{
+			Issuer:       "InfluenceIQ",
+			ChallengeTTL: 5 * time.Minute,
+		},
+		Login: LoginConfig{
+			LimiterDriver:   "memory",
+			Window:          15 * time.Minute,
+			DelayAfter:      3,
+			MaxDelay:        30 * time.Second,
+			LockoutAfter:    10,
+			LockoutDuration: 30 * time.Minute,
+			IPLimit:         100,
+		},
+		Mail: MailConfig{
+			Driver:    "outbox",
+			From:      "InfluenceIQ <no-reply@influenceiq.local>",
+			OutboxDir: "outbox",
+		},
+		OIDC: OIDCConfig{
+			StateTTL: 10 * time.Minute,
+		},
+		AI: AIConfig{
+			GeminiModel: "gemini-2.5-flash",
+			Timeout:     30 * time.Second,
+		},
+		Jobs: JobsConfig{
+			Enabled:                    true,
+			CloseExpiredSchedule:       "*/5 * * * *",
+			ExpireApplicationsSchedule: "15 * * * *",
+			ApplicationTTL:             30 * 24 * time.Hour,
+			RemindersSchedule:          "0 9 * * *",
+			ReminderLead:               72 * time.Hour,
+			RunRetention:               30 * 24 * time.Hour,
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the file named by -config
+// or CONFIG_FILE, the environment and args, then validates it.
+func Load(args []string) (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("load .env: %w", err)
+	}
+
+	cfg := Default()
+
+	fset := flag.NewFlagSet("influenceiq", flag.ContinueOnError)
+	configFile := fset.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	addr := fset.String("addr", "", "HTTP listen address")
+	driver := fset.String("db-driver", "", "database driver: postgres or sqlite")
+	dbURL := fset.String("database-url", "", "database URL or SQLite file path")
+	autoMigrate := fset.Bool("auto-migrate", false, "apply pending migrations on boot")
+	if err := fset.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+
+	// Only flags given explicitly override earlier sources.
+	fset.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			cfg.HTTP.Addr = *addr
+		case "db-driver":
+			cfg.Database.Driver = *driver
+		case "database-url":
+			cfg.Database.URL = *dbURL
+		case "auto-migrate":
+			cfg.Database.AutoMigrate = *autoMigrate
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadFile decodes a YAML or TOML file over cfg. TOML is re-encoded as YAML
+// first so that both formats share the yaml tags and duration syntax.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+	case ".toml":
+		var raw map[string]any
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		if data, err = yaml.Marshal(raw); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported format (use .yaml, .yml or .toml)", path)
+	}
+
+	if err := yaml.UnmarshalWithOptions(data, cfg, yaml.Strict()); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+func applyEnv(cfg *Config) error {
+	strs := map[string]*string{
+		"HTTP_ADDR":            &cfg.HTTP.Addr,
+		"TLS_CERT_FILE":        &cfg.HTTP.TLSCertFile,
+		"TLS_KEY_FILE":         &cfg.HTTP.TLSKeyFile,
+		"DB_DRIVER":            &cfg.Database.Driver,
+		"DATABASE_URL":         &cfg.Database.URL,
+		"JWT_SIGNING_KEY_FILE": &cfg.JWT.SigningKeyFile,
+		"JWT_ISSUER":           &cfg.JWT.Issuer,
+		"APP_BASE_URL":         &cfg.Account.LinkBaseURL,
+		"MFA_ISSUER":           &cfg.MFA.Issuer,
+		"LOGIN_LIMITER_DRIVER": &cfg.Login.LimiterDriver,
+		"MAIL_DRIVER":          &cfg.Mail.Driver,
+		"MAIL_FROM":            &cfg.Mail.From,
+		"MAIL_OUTBOX_DIR":      &cfg.Mail.OutboxDir,
+		"SMTP_ADDR":            &cfg.Mail.SMTPAddr,
+		"SMTP_USERNAME":        &cfg.Mail.SMTPUsername,
+		"SMTP_PASSWORD":        &cfg.Mail.SMTPPassword,
+		"GEMINI_API_KEY":       &cfg.AI.GeminiAPIKey,
+		"GEMINI_MODEL":         &cfg.AI.GeminiModel,
+
+		"JOBS_CLOSE_EXPIRED_SCHEDULE":       &cfg.Jobs.CloseExpiredSchedule,
+		"JOBS_EXPIRE_APPLICATIONS_SCHEDULE": &cfg.Jobs.ExpireApplicationsSchedule,
+		"JOBS_REMINDERS_SCHEDULE":           &cfg.Jobs.RemindersSchedule,
+	}
+	for key, dst := range strs {
+		if v := os.Getenv(key); v != "" {
+			*dst = v
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"HTTP_READ_TIMEOUT":           &cfg.HTTP.ReadTimeout,
+		"HTTP_READ_HEADER_TIMEOUT":    &cfg.HTTP.ReadHeaderTimeout,
+		"HTTP_WRITE_TIMEOUT":          &cfg.HTTP.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":           &cfg.HTTP.IdleTimeout,
+		"HTTP_SHUTDOWN_TIMEOUT":       &cfg.HTTP.ShutdownTimeout,
+		"DB_CONNECT_TIMEOUT":          &cfg.Database.ConnectTimeout,
+		"DB_MAX_CONN_LIFETIME":        &cfg.Database.MaxConnLifetime,
+		"DB_MAX_CONN_IDLE_TIME":       &cfg.Database.MaxConnIdleTime,
+		"DB_REPLICA_CHECK_INTERVAL":   &cfg.Database.ReplicaCheckInterval,
+		"JWT_TTL":                     &cfg.JWT.TTL,
+		"JWT_REFRESH_TTL":             &cfg.JWT.RefreshTTL,
+		"EMAIL_VERIFICATION_TTL":      &cfg.Account.VerificationTTL,
+		"PASSWORD_RESET_TTL":          &cfg.Account.PasswordResetTTL,
+		"ORGANIZATION_INVITATION_TTL": &cfg.Account.InvitationTTL,
+		"MFA_CHALLENGE_TTL":           &cfg.MFA.ChallengeTTL,
+		"LOGIN_WINDOW":                &cfg.Login.Window,
+		"LOGIN_MAX_DELAY":             &cfg.Login.MaxDelay,
+		"LOGIN_LOCKOUT_DURATION":      &cfg.Login.LockoutDuration,
+		"OIDC_STATE_TTL":              &cfg.OIDC.StateTTL,
+		"AI_TIMEOUT":                  &cfg.AI.Timeout,
+		"JOBS_APPLICATION_TTL":        &cfg.Jobs.ApplicationTTL,
+		"JOBS_REMINDER_LEAD":          &cfg.Jobs.ReminderLead,
+		"JOBS_RUN_RETENTION":          &cfg.Jobs.RunRetention,
+	}
+	for key, dst := range durations {
+		if v := os.Getenv(key); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = d
+		}
+	}
+
+	ints := map[string]*int32{
+		"DB_MAX_CONNS": &cfg.Database.MaxConns,
+		"DB_MIN_CONNS": &cfg.Database.MinConns,
+	}
+	for key, dst := range ints {
+		if v := os.Getenv(key); v != "" {
+			n, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = int32(n)
+		}
+	}
+
+	counts := map[string]*int{
+		"LOGIN_DELAY_AFTER":   &cfg.Login.DelayAfter,
+		"LOGIN_LOCKOUT_AFTER": &cfg.Login.LockoutAfter,
+		"LOGIN_IP_LIMIT":      &cfg.Login.IPLimit,
+	}
+	for key, dst := range counts {
+		if v := os.Getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = n
+		}
+	}
+
+	applyOIDCEnv(&cfg.OIDC)
+
+	lists := map[string]*[]string{
+		"DB_REPLICA_URLS":            &cfg.Database.ReplicaURLs,
+		"JWT_VERIFICATION_KEY_FILES": &cfg.JWT.VerificationKeyFiles,
+		"HTTP_TRUSTED_PROXIES":       &cfg.HTTP.TrustedProxies,
+	}
+	for key, dst := range lists {
+		if v := os.Getenv(key); v != "" {
+			*dst = splitList(v)
+		}
+	}
+
+	bools := map[string]*bool{
+		"AUTO_MIGRATE": &cfg.Database.AutoMigrate,
+		"JOBS_ENABLED": &cfg.Jobs.Enabled,
+	}
+	for key, dst := range bools {
+		if v := os.Getenv(key); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = b
+		}
+	}
+	return nil
+}
+
+// applyOIDCEnv configures the providers listed in OIDC_PROVIDERS from
+// OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and _SCOPES,
+// where NAME is the provider name in upper case with dashes replaced by
+// underscores. Providers from the config file keep settings that have no
+// variable.
+func applyOIDCEnv(cfg *OIDCConfig) {
+	names := os.Getenv("OIDC_PROVIDERS")
+	if names == "" {
+		return
+	}
+	if cfg.Providers == nil {
+		cfg.Providers = map[string]OIDCProvider{}
+	}
+	for _, name := range splitList(names) {
+		p := cfg.Providers[name]
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		for key, dst := range map[string]*string{
+			"ISSUER":        &p.Issuer,
+			"CLIENT_ID":     &p.ClientID,
+			"CLIENT_SECRET": &p.ClientSecret,
+			"REDIRECT_URL":  &p.RedirectURL,
+		} {
+			if v := os.Getenv(prefix + key); v != "" {
+				*dst = v
+			}
+		}
+		if v := os.Getenv(prefix + "SCOPES"); v != "" {
+			p.Scopes = splitList(v)
+		}
+		cfg.Providers[name] = p
+	}
+}
+
+// splitList splits a comma- or space-separated environment variable.
+func splitList(v string) []string {
+	return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.HTTP.Addr != "", "http.addr is required")
+	check(c.HTTP.ReadTimeout > 0 && c.HTTP.ReadHeaderTimeout > 0 && c.HTTP.WriteTimeout > 0 && c.HTTP.IdleTimeout > 0,
+		"http timeouts must be positive")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout must be positive")
+	check((c.HTTP.TLSCertFile == "") == (c.HTTP.TLSKeyFile == ""),
+		"http.tls_cert_file and http.tls_key_file must be set together")
+	for i, proxy := range c.HTTP.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		check(err == nil || net.ParseIP(proxy) != nil, "http.trusted_proxies[%d] must be an IP or CIDR range, got %q", i, proxy)
+	}
+
+	check(c.Database.Driver == "postgres" || c.Database.Driver == "sqlite",
+		"database.driver must be postgres or sqlite, got %q", c.Database.Driver)
+	check(c.Database.URL != "", "database.url (DATABASE_URL) is required")
+	check(c.Database.ConnectTimeout > 0, "database.connect_timeout must be positive")
+	check(c.Database.MaxConns >= 1, "database.max_conns must be at least 1")
+	check(c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxConns,
+		"database.min_conns must be between 0 and database.max_conns")
+	check(c.Database.ReplicaCheckInterval > 0, "database.replica_check_interval must be positive")
+	for i, url := range c.Database.ReplicaURLs {
+		check(url != "" && url != c.Database.URL, "database.replica_urls[%d] must be set and differ from database.url", i)
+	}
+
+	check(c.JWT.Issuer != "", "jwt.issuer is required")
+	check(c.JWT.TTL > 0, "jwt.ttl must be positive")
+	check(c.JWT.RefreshTTL > c.JWT.TTL, "jwt.refresh_ttl must be longer than jwt.ttl")
+
+	base, err := url.Parse(c.Account.LinkBaseURL)
+	check(err == nil && base.IsAbs() && base.Host != "", "account.link_base_url (APP_BASE_URL) must be an absolute URL")
+	check(c.Account.VerificationTTL > 0 && c.Account.PasswordResetTTL > 0 && c.Account.InvitationTTL > 0,
+		"account.verification_ttl, account.password_reset_ttl and account.invitation_ttl must be positive")
+
+	check(c.MFA.Issuer != "" && !strings.Contains(c.MFA.Issuer, ":"), "mfa.issuer is required and may not contain a colon")
+	check(c.MFA.ChallengeTTL > 0, "mfa.challenge_ttl must be positive")
+
+	check(c.Login.LimiterDriver == "memory" || c.Login.LimiterDriver == "database",
+		"login.limiter_driver must be memory or database, got %q", c.Login.LimiterDriver)
+	check(c.Login.Window > 0 && c.Login.MaxDelay > 0 && c.Login.LockoutDuration > 0,
+		"login.window, login.max_delay and login.lockout_duration must be positive")
+	check(c.Login.DelayAfter >= 1 && c.Login.DelayAfter <= c.Login.LockoutAfter,
+		"login.delay_after must be between 1 and login.lockout_after")
+	check(c.Login.IPLimit >= c.Login.LockoutAfter, "login.ip_limit must be at least login.lockout_after")
+
+	switch c.Mail.Driver {
+	case "outbox":
+		check(c.Mail.OutboxDir != "", "mail.outbox_dir is required with the outbox driver")
+	case "smtp":
+		_, _, err := net.SplitHostPort(c.Mail.SMTPAddr)
+		check(err == nil, "mail.smtp_addr (SMTP_ADDR) must be host:port")
+	default:
+		check(false, "mail.driver must be smtp or outbox, got %q", c.Mail.Driver)
+	}
+	_, err = mail.ParseAddress(c.Mail.From)
+	check(err == nil, "mail.from must be a valid address")
+
+	check(c.OIDC.StateTTL > 0, "oidc.state_ttl must be positive")
+	for name, p := range c.OIDC.Providers {
+		check(validProviderName(name), "oidc.providers: name %q must be lower-case letters, digits and dashes", name)
+		issuer, err := url.Parse(p.Issuer)
+		check(err == nil && issuer.Host != "" && (issuer.Scheme == "https" || issuer.Scheme == "http" && isLoopback(issuer.Hostname())),
+			"oidc.providers.%s.issuer must be an https URL (http only for localhost)", name)
+		check(p.ClientID != "", "oidc.providers.%s.client_id is required", name)
+		if p.RedirectURL != "" {
+			redirect, err := url.Parse(p.RedirectURL)
+			check(err == nil && redirect.IsAbs() && redirect.Host != "", "oidc.providers.%s.redirect_url must be an absolute URL", name)
+		}
+	}
+
+	check(c.AI.GeminiModel != "", "ai.gemini_model is required")
+	check(c.AI.Timeout > 0, "ai.timeout must be positive")
+
+	check(c.Jobs.ApplicationTTL > 0 && c.Jobs.ReminderLead > 0 && c.Jobs.RunRetention > 0,
+		"jobs.application_ttl, jobs.reminder_lead and jobs.run_retention must be positive")
+
+	return errors.Join(errs...)
+}
+
+func validProviderName(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
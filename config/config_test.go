@@ -0,0 +1,173 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isolate runs the test in an empty directory, so that no .env file is
+// picked up, with the variables Load reads in these tests cleared.
+func isolate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, key := range []string{"CONFIG_FILE", "HTTP_ADDR", "DB_DRIVER", "DATABASE_URL", "JWT_TTL", "DB_MAX_CONNS", "AUTO_MIGRATE"} {
+		t.Setenv(key, "")
+	}
+	return dir
+}
+
+// loaded are the settings TestLoadPrecedence looks at.
+type loaded struct {
+	addr, driver, url string
+	ttl               time.Duration
+	maxConns          int32
+	autoMigrate       bool
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	const yamlFile = "http:\n  addr: \":7000\"\ndatabase:\n  driver: sqlite\n  url: file.db\njwt:\n  ttl: 5m\n"
+	const tomlFile = "[http]\naddr = \":7000\"\n[database]\ndriver = \"sqlite\"\nurl = \"file.db\"\n[jwt]\nttl = \"5m\"\n"
+	fromFile := loaded{":7000", "sqlite", "file.db", 5 * time.Minute, 10, false}
+	tests := []struct {
+		name     string
+		file     string
+		fileBody string
+		env      map[string]string
+		args     []string
+		want     loaded
+	}{
+		{"defaults", "", "", map[string]string{"DATABASE_URL": "postgres://db"}, nil,
+			loaded{":8080", "postgres", "postgres://db", 15 * time.Minute, 10, false}},
+		{"yaml file over defaults", "app.yaml", yamlFile, nil, nil, fromFile},
+		{"toml file over defaults", "app.toml", tomlFile, nil, nil, fromFile},
+		{"environment over file", "app.yaml", yamlFile, map[string]string{"HTTP_ADDR": ":7100", "JWT_TTL": "10m", "DB_MAX_CONNS": "3"}, nil,
+			loaded{":7100", "sqlite", "file.db", 10 * time.Minute, 3, false}},
+		{"flags over environment", "app.yaml", yamlFile, map[string]string{"HTTP_ADDR": ":7100", "AUTO_MIGRATE": "false"},
+			[]string{"-addr", ":7200", "-database-url", "other.db", "-auto-migrate"},
+			loaded{":7200", "sqlite", "other.db", 5 * time.Minute, 10, true}},
+		{"flags not given keep earlier values", "app.yaml", yamlFile, map[string]string{"HTTP_ADDR": ":7100", "AUTO_MIGRATE": "true"},
+			[]string{"-database-url", "other.db"},
+			loaded{":7100", "sqlite", "other.db", 5 * time.Minute, 10, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := isolate(t)
+			args := tt.args
+			if tt.file != "" {
+				path := filepath.Join(dir, tt.file)
+				if err := os.WriteFile(path, []byte(tt.fileBody), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = append([]string{"-config", path}, args...)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := Load(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := loaded{cfg.HTTP.Addr, cfg.Database.Driver, cfg.Database.URL, cfg.JWT.TTL, cfg.Database.MaxConns, cfg.Database.AutoMigrate}
+			if got != tt.want {
+				t.Errorf("loaded %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		fileBody string
+		env      map[string]string
+		wantErr  string
+	}{
+		{"unknown file key", "app.yaml", "http:\n  adress: \":7000\"\n", nil, "adress"},
+		{"unsupported format", "app.json", "{}", nil, "unsupported format"},
+		{"bad duration", "", "", map[string]string{"DATABASE_URL": "x", "JWT_TTL": "soon"}, "JWT_TTL"},
+		{"bad number", "", "", map[string]string{"DATABASE_URL": "x", "DB_MAX_CONNS": "many"}, "DB_MAX_CONNS"},
+		{"invalid result", "", "", map[string]string{"DB_DRIVER": "mysql"}, "database.driver must be postgres or sqlite"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := isolate(t)
+			var args []string
+			if tt.file != "" {
+				path := filepath.Join(dir, tt.file)
+				if err := os.WriteFile(path, []byte(tt.fileBody), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = []string{"-config", path}
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load(args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		// want are parts of the error, none if the config is valid.
+		want []string
+	}{
+		{"defaults with a database", func(*Config) {}, nil},
+		{"missing database url", func(c *Config) { c.Database.URL = "" }, []string{"database.url (DATABASE_URL) is required"}},
+		{"every error at once", func(c *Config) {
+			c.HTTP.Addr = ""
+			c.JWT.Issuer = ""
+			c.Mail.Driver = "pigeon"
+			c.AI.GeminiModel = ""
+		}, []string{"http.addr is required", "jwt.issuer is required", "mail.driver must be smtp or outbox", "ai.gemini_model is required"}},
+		{"half of TLS", func(c *Config) { c.HTTP.TLSCertFile = "cert.pem" }, []string{"must be set together"}},
+		{"bad trusted proxy", func(c *Config) { c.HTTP.TrustedProxies = []string{"10.0.0.0/8", "proxy"} },
+			[]string{`http.trusted_proxies[1] must be an IP or CIDR range, got "proxy"`}},
+		{"min conns above max", func(c *Config) { c.Database.MinConns = 20 }, []string{"database.min_conns"}},
+		{"replica equals primary", func(c *Config) { c.Database.ReplicaURLs = []string{c.Database.URL} }, []string{"database.replica_urls[0]"}},
+		{"refresh not longer than access", func(c *Config) { c.JWT.RefreshTTL = c.JWT.TTL }, []string{"jwt.refresh_ttl"}},
+		{"relative link base", func(c *Config) { c.Account.LinkBaseURL = "/app" }, []string{"account.link_base_url"}},
+		{"colon in MFA issuer", func(c *Config) { c.MFA.Issuer = "Acme:IQ" }, []string{"mfa.issuer"}},
+		{"delay after lockout", func(c *Config) { c.Login.DelayAfter = c.Login.LockoutAfter + 1 }, []string{"login.delay_after"}},
+		{"plain http provider", func(c *Config) {
+			c.OIDC.Providers = map[string]OIDCProvider{"Google": {Issuer: "http://accounts.example.com", ClientID: "id"}}
+		}, []string{`name "Google"`, "oidc.providers.Google.issuer must be an https URL"}},
+		{"http provider on localhost", func(c *Config) {
+			c.OIDC.Providers = map[string]OIDCProvider{"dev": {Issuer: "http://127.0.0.1:9000", ClientID: "id"}}
+		}, nil},
+		{"smtp without port", func(c *Config) { c.Mail.Driver, c.Mail.SMTPAddr = "smtp", "mail.example.com" }, []string{"mail.smtp_addr"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Database.URL = "postgres://db"
+			tt.change(&cfg)
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate = nil, want %q", tt.want)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate = %v, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
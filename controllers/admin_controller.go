@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"InfluenceIQ/apperr"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	store *store.Store
+}
+
+func NewAdminController(s *store.Store) *AdminController {
+	return &AdminController{store: s}
+}
+
+// PUT /api/admin/users/:id/role
+// Changes a user's role. Every session of the user is revoked, which ends
+// their refresh tokens and makes the access tokens they hold stop working
+// at once, so that they log in again and get tokens carrying the new role.
+func (h *AdminController) SetUserRole(c *gin.Context) {
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required,oneof=viewer influencer brand admin"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var user *models.User
+	err := h.store.WithTx(ctx, func(tx *store.Store) error {
+		var err error
+		user, err = tx.Users.GetByID(ctx, id)
+		if errors.Is(err, store.ErrNotFound) {
+			return apperr.NotFound("user not found")
+		}
+		if err != nil {
+			return err
+		}
+		if user.Role == req.Role {
+			return nil
+		}
+
+		if err := tx.Users.SetRole(ctx, id, req.Role); err != nil {
+			return err
+		}
+		user.Role = req.Role
+
+		// Keep a brand's or influencer's profile in line with the role.
+		if req.Role == models.RoleBrand || req.Role == models.RoleInfluencer {
+			profile, err := tx.Profiles.GetByUserID(ctx, id)
+			switch {
+			case errors.Is(err, store.ErrNotFound):
+			case err != nil:
+				return err
+			case profile.AccountType != req.Role:
+				profile.AccountType = req.Role
+				if err := tx.Profiles.Update(ctx, profile); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Tokens.RevokeUserRefresh(ctx, id)
+	})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, user)
+}
+
+// GET /api/admin/mfa-policy
+func (h *AdminController) GetMFAPolicy(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	policies, err := h.store.MFA.ListPolicies(ctx)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond(c, http.StatusOK, mfaPolicyData(policies))
+}
+
+// PUT /api/admin/mfa-policy
+// Sets the roles and organizations whose users must use two-factor
+// authentication. Either list may be left out to keep it as it is. Users
+// without 2FA are asked to set it up at their next login.
+func (h *AdminController) SetMFAPolicy(c *gin.Context) {
+	var req struct {
+		RequiredRoles         []string `json:"required_roles" binding:"dive,oneof=viewer influencer brand admin"`
+		RequiredOrganizations []int    `json:"required_organizations" binding:"dive,min=1"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.RequiredRoles == nil && req.RequiredOrganizations == nil {
+		fail(c, apperr.Validation("nothing to change", apperr.FieldError{
+			Field:   "required_roles",
+			Code:    "required",
+			Message: "required_roles or required_organizations is required",
+		}))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var policies []models.MFAPolicy
+	err := h.store.WithTx(ctx, func(tx *store.Store) error {
+		if req.RequiredRoles != nil {
+			roles := slices.Compact(slices.Sorted(slices.Values(req.RequiredRoles)))
+			if err := tx.MFA.ReplacePolicies(ctx, models.MFAScopeRole, roles); err != nil {
+				return err
+			}
+		}
+		if req.RequiredOrganizations != nil {
+			orgs := []string{}
+			for _, id := range slices.Compact(slices.Sorted(slices.Values(req.RequiredOrganizations))) {
+				if _, err := tx.Organizations.GetByID(ctx, id); err != nil {
+					if errors.Is(err, store.ErrNotFound) {
+						err = apperr.Validation("unknown organization", apperr.FieldError{
+							Field:   "required_organizations",
+							Code:    "not_found",
+							Message: fmt.Sprintf("organization %d doesn't exist", id),
+						}).Wrap(err)
+					}
+					return err
+				}
+				orgs = append(orgs, strconv.Itoa(id))
+			}
+			if err := tx.MFA.ReplacePolicies(ctx, models.MFAScopeOrganization, orgs); err != nil {
+				return err
+			}
+		}
+		var err error
+		policies, err = tx.MFA.ListPolicies(ctx)
+		return err
+	})
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond(c, http.StatusOK, mfaPolicyData(policies))
+}
+
+func mfaPolicyData(policies []models.MFAPolicy) gin.H {
+	roles, orgs := []string{}, []int{}
+	for _, p := range policies {
+		switch p.Scope {
+		case models.MFAScopeRole:
+			roles = append(roles, p.Value)
+		case models.MFAScopeOrganization:
+			if id, err := strconv.Atoi(p.Value); err == nil {
+				orgs = append(orgs, id)
+			}
+		}
+	}
+	slices.Sort(orgs)
+	return gin.H{"required_roles": roles, "required_organizations": orgs}
+}
+
+// DELETE /api/admin/users/:id/mfa
+// Removes a user's two-factor setup and recovery codes, for users who lost
+// both. They can log in with their password alone, or are asked to set up
+// 2FA again if a policy requires it.
+func (h *AdminController) ResetUserMFA(c *gin.Context) {
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.MFA.DeleteTOTP(ctx, id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			err = apperr.NotFound("two-factor authentication is not set up for this user").Wrap(err)
+		}
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "two-factor authentication reset"})
+}
+
+// PUT /api/admin/users/:id/verification
+// Marks an influencer's profile as verified, or not, after checking their
+// accounts. Campaigns can be limited to verified profiles.
+func (h *AdminController) SetProfileVerification(c *gin.Context) {
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Verified *bool `json:"verified" binding:"required"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var profile *models.Profile
+	err := h.store.WithTx(ctx, func(tx *store.Store) error {
+		if err := tx.Profiles.SetVerified(ctx, id, *req.Verified); err != nil {
+			return err
+		}
+		var err error
+		profile, err = tx.Profiles.GetByUserID(ctx, id)
+		return err
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		err = apperr.NotFound("user has no profile").Wrap(err)
+	}
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, profile)
+}
+
+// GET /api/admin/security-events
+// Lists the security audit log, newest first. Filters: type, user_id, ip.
+func (h *AdminController) ListSecurityEvents(c *gin.Context) {
+	q := newQuery(c)
+	filter := store.SecurityEventFilter{
+		Type: q.oneOf("type",
+			models.SecurityEventAccountLocked, models.SecurityEventAccountUnlocked,
+			models.SecurityEventIPBlocked, models.SecurityEventLoginAfterFailures),
+		UserID: q.int("user_id"),
+		IP:     c.Query("ip"),
+	}
+	opts := list(q, store.SecurityEventSorts)
+	if err := q.err(); err != nil {
+		fail(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	page, err := h.store.SecurityEvents.List(ctx, filter, opts)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	respondPage(c, page, opts)
+}
+
+// GET /api/admin/job-runs
+// Lists the runs of scheduled jobs, newest first. Filters: job, status.
+func (h *AdminController) ListJobRuns(c *gin.Context) {
+	q := newQuery(c)
+	filter := store.JobRunFilter{
+		Job:    c.Query("job"),
+		Status: q.oneOf("status", models.JobRunning, models.JobSucceeded, models.JobFailed),
+	}
+	opts := list(q, store.JobRunSorts)
+	if err := q.err(); err != nil {
+		fail(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	page, err := h.store.JobRuns.List(ctx, filter, opts)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	respondPage(c, page, opts)
+}
@@ -4,19 +4,27 @@ import (
 	"fmt"
 	"net/http"
 
+	"InfluenceIQ/apperr"
 	"InfluenceIQ/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GenerateCampaignIdea(c *gin.Context) {
+type AIController struct {
+	gemini *services.GeminiClient
+}
+
+func NewAIController(gemini *services.GeminiClient) *AIController {
+	return &AIController{gemini: gemini}
+}
+
+func (h *AIController) GenerateCampaignIdea(c *gin.Context) {
 	var req struct {
 		Product      string  `json:"product" binding:"required"`
 		TargetMarket string  `json:"target_market"`
 		Budget       float64 `json:"budget"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -25,23 +33,22 @@ func GenerateCampaignIdea(c *gin.Context) {
 		req.Product, req.TargetMarket, req.Budget,
 	)
 
-	text, err := services.CallGemini(prompt)
+	text, err := h.gemini.CallGemini(prompt)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		fail(c, apperr.New(apperr.CodeUpstream, "AI provider request failed").Wrap(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "idea": text})
+	respond(c, http.StatusOK, gin.H{"idea": text})
 }
 
-func RecommendInfluencers(c *gin.Context) {
+func (h *AIController) RecommendInfluencers(c *gin.Context) {
 	var req struct {
 		Category string  `json:"category" binding:"required"`
 		Budget   float64 `json:"budget"`
 		Audience string  `json:"audience"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -50,22 +57,21 @@ func RecommendInfluencers(c *gin.Context) {
 		req.Category, req.Budget, req.Audience,
 	)
 
-	text, err := services.CallGemini(prompt)
+	text, err := h.gemini.CallGemini(prompt)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		fail(c, apperr.New(apperr.CodeUpstream, "AI provider request failed").Wrap(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "recommendations": text})
+	respond(c, http.StatusOK, gin.H{"recommendations": text})
 }
 
-func GenerateCaptions(c *gin.Context) {
+func (h *AIController) GenerateCaptions(c *gin.Context) {
 	var req struct {
 		Theme string `json:"theme" binding:"required"`
 		Tone  string `json:"tone"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -74,11 +80,11 @@ func GenerateCaptions(c *gin.Context) {
 		req.Theme, req.Tone,
 	)
 
-	text, err := services.CallGemini(prompt)
+	text, err := h.gemini.CallGemini(prompt)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		fail(c, apperr.New(apperr.CodeUpstream, "AI provider request failed").Wrap(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "captions": text})
+	respond(c, http.StatusOK, gin.H{"captions": text})
 }
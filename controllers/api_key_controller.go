@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"InfluenceIQ/apperr"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController struct {
+	keys *services.APIKeyService
+}
+
+func NewAPIKeyController(keys *services.APIKeyService) *APIKeyController {
+	return &APIKeyController{keys: keys}
+}
+
+// POST /api/auth/api-keys
+// The key is only shown in this response.
+func (h *APIKeyController) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name      string     `json:"name" binding:"required,max=100"`
+		Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=campaigns:read campaigns:write applications:read applications:manage"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	key, err := h.keys.Create(ctx, userID, req.Name, req.Scopes, req.ExpiresAt)
+	switch {
+	case errors.Is(err, services.ErrAPIKeyExpiry):
+		err = apperr.BadRequest("expires_at must be in the future").Wrap(err)
+	case errors.Is(err, services.ErrAPIKeyLimit):
+		err = apperr.Conflict("You have too many active API keys; revoke one first").Wrap(err)
+	}
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond(c, http.StatusCreated, key)
+}
+
+// GET /api/auth/api-keys
+func (h *APIKeyController) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	keys, err := h.keys.List(ctx, userID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond(c, http.StatusOK, keys)
+}
+
+// DELETE /api/auth/api-keys/:id
+func (h *APIKeyController) Revoke(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.keys.Revoke(ctx, userID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		err = apperr.NotFound("API key not found or already revoked").Wrap(err)
+	}
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "API key revoked"})
+}
@@ -1,161 +1,254 @@
 package controllers
 
 import (
-	"InfluenceIQ/config"
+	"InfluenceIQ/apperr"
 	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
 	"context"
+	"errors"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+type ApplicationController struct {
+	store *store.Store
+	orgs  *services.OrganizationService
+}
+
+func NewApplicationController(s *store.Store, orgs *services.OrganizationService) *ApplicationController {
+	return &ApplicationController{store: s, orgs: orgs}
+}
+
 // POST /api/campaigns/:id/apply
-func ApplyToCampaign(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+func (h *ApplicationController) ApplyToCampaign(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	campaignID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid campaign id"})
+	campaignID, ok := pathID(c, "id")
+	if !ok {
 		return
 	}
 
 	var req struct {
 		Message string `json:"message"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	// Prevent duplicate applications
-	if _, err := models.GetApplicationByCampaignAndInfluencer(ctx, campaignID, userID.(int)); err == nil {
-		c.JSON(http.StatusConflict, gin.H{"success": false, "error": "already applied"})
-		return
-	}
-
 	app := models.CampaignApplication{
 		CampaignID:   campaignID,
-		InfluencerID: userID.(int),
+		InfluencerID: userID,
 		Message:      req.Message,
 		Status:       "pending",
 	}
 
-	if err := models.CreateApplication(ctx, &app); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "failed to apply"})
+	// Duplicates are rejected by the unique (campaign_id, influencer_id)
+	// constraint, so concurrent requests can't both get through. The
+	// campaign stays locked until the application is in, so closing it
+	// can't slip in between and miss the application when rejecting the
+	// pending ones.
+	err := h.store.WithTx(ctx, func(tx *store.Store) error {
+		campaign, err := tx.Campaigns.GetForUpdate(ctx, campaignID)
+		if errors.Is(err, store.ErrNotFound) {
+			return apperr.NotFound("campaign not found")
+		}
+		if err != nil {
+			return err
+		}
+		if !campaign.AcceptsApplications() {
+			return apperr.Conflict("campaign isn't accepting applications")
+		}
+		if err := checkEligibility(ctx, tx, campaign, userID); err != nil {
+			return err
+		}
+		if err := tx.Applications.Create(ctx, &app); err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				return apperr.Conflict("already applied").Wrap(err)
+			}
+			return err
+		}
+		return tx.Campaigns.IncrementCounters(ctx, campaignID, 1, 0)
+	})
+	if err != nil {
+		fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"success": true, "data": app})
+	respond(c, http.StatusCreated, app)
 }
 
-// GET /api/applications/mine
-func GetMyApplications(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+// checkEligibility refuses influencers without a profile or whose profile
+// doesn't meet the campaign's rules, listing every rule they fail.
+func checkEligibility(ctx context.Context, tx *store.Store, campaign *models.Campaign, userID int) error {
+	profile, err := tx.Profiles.GetByUserID(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.NotEligible("create a profile before applying", apperr.FieldError{
+			Field:   "profile",
+			Code:    "required",
+			Message: "campaigns check applicants against their profile",
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	reasons := campaign.Eligibility.Check(profile)
+	if len(reasons) == 0 {
+		return nil
+	}
+	details := make([]apperr.FieldError, 0, len(reasons))
+	for _, r := range reasons {
+		details = append(details, apperr.FieldError{Field: r.Criterion, Code: r.Code, Message: r.Message})
+	}
+	return apperr.NotEligible("you don't meet the campaign's eligibility criteria", details...)
+}
+
+// GET /api/applications/mine?status=&campaign_id=&sort=&limit=&cursor=
+func (h *ApplicationController) GetMyApplications(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	q := newQuery(c)
+	filter := store.ApplicationFilter{
+		InfluencerID: userID,
+		CampaignID:   q.int("campaign_id"),
+		Status:       q.oneOf("status", "pending", "accepted", "rejected", "expired"),
+	}
+	opts := list(q, store.ApplicationSorts)
+	if err := q.err(); err != nil {
+		fail(c, err)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	apps, err := models.GetApplicationsByInfluencer(ctx, userID.(int))
+	page, err := h.store.Applications.List(ctx, filter, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "failed to fetch applications"})
+		fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": apps})
+	respondPage(c, page, opts)
 }
 
-// GET /api/campaigns/:id/applications
-func GetApplicationsForCampaign(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+// GET /api/campaigns/:id/applications?status=&sort=&limit=&cursor=
+func (h *ApplicationController) GetApplicationsForCampaign(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	campaignID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid campaign id"})
+	campaignID, ok := pathID(c, "id")
+	if !ok {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	// Verify campaign ownership
-	var brandID int
-	err = config.DB.QueryRow(ctx, `SELECT brand_id FROM campaigns WHERE id = $1`, campaignID).Scan(&brandID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "campaign not found"})
+	q := newQuery(c)
+	filter := store.ApplicationFilter{
+		CampaignID: campaignID,
+		Status:     q.oneOf("status", "pending", "accepted", "rejected", "expired"),
+	}
+	opts := list(q, store.ApplicationSorts)
+	if err := q.err(); err != nil {
+		fail(c, err)
 		return
 	}
-	if brandID != userID.(int) {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "not your campaign"})
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := managedCampaign(ctx, h.store.Campaigns, h.orgs, userID, campaignID); err != nil {
+		fail(c, err)
 		return
 	}
 
-	apps, err := models.GetApplicationsByCampaign(ctx, campaignID)
+	page, err := h.store.Applications.List(ctx, filter, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "failed to fetch applications"})
+		fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": apps})
+	respondPage(c, page, opts)
 }
 
 // PUT /api/applications/:id/status
-func UpdateApplicationStatus(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+// Expired applications can't change any more (409).
+func (h *ApplicationController) UpdateApplicationStatus(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	appID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid application id"})
+	appID, ok := pathID(c, "id")
+	if !ok {
 		return
 	}
 
 	var req struct {
 		Status string `json:"status" binding:"required,oneof=pending accepted rejected"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	var campaignID, brandID int
-	err = config.DB.QueryRow(ctx,
-		`SELECT c.id, c.brand_id FROM campaigns c
-		 JOIN campaign_applications a ON a.campaign_id = c.id
-		 WHERE a.id = $1`, appID).Scan(&campaignID, &brandID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "application or campaign not found"})
-		return
-	}
-	if brandID != userID.(int) {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": "not your campaign"})
-		return
-	}
+	// The status change and the campaign's accepted counter move together.
+	err := h.store.WithTx(ctx, func(tx *store.Store) error {
+		app, err := tx.Applications.GetByID(ctx, appID)
+		if errors.Is(err, store.ErrNotFound) {
+			return apperr.NotFound("application not found")
+		}
+		if err != nil {
+			return err
+		}
+		campaign, err := managedCampaign(ctx, tx.Campaigns, h.orgs, userID, app.CampaignID)
+		if err != nil {
+			return err
+		}
+		if campaign.Finished() {
+			return apperr.Conflict("campaign is " + campaign.Status)
+		}
+		// Expired applications are final, like those of finished campaigns.
+		if app.Status == "expired" {
+			return apperr.Conflict("application has expired")
+		}
+		if app.Status == req.Status {
+			return nil
+		}
 
-	if err := models.UpdateApplicationStatus(ctx, appID, req.Status); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "failed to update status"})
+		if err := tx.Applications.UpdateStatus(ctx, appID, app.Status, req.Status); err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				return apperr.Conflict("application was modified concurrently, retry").Wrap(err)
+			}
+			return err
+		}
+		accepted := 0
+		if req.Status == "accepted" {
+			accepted = 1
+		} else if app.Status == "accepted" {
+			accepted = -1
+		}
+		if accepted == 0 {
+			return nil
+		}
+		return tx.Campaigns.IncrementCounters(ctx, campaign.ID, 0, accepted)
+	})
+	if err != nil {
+		fail(c, err)
 		return
 	}
 
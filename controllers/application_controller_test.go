@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"InfluenceIQ/models"
+)
+
+func TestUpdateApplicationStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		campaign string
+		from     string
+		userID   func(f *campaignFixture) int
+		body     string
+		code     int
+		// want is the application's status afterwards and accepted the
+		// campaign's accepted count.
+		want     string
+		accepted int
+	}{
+		{"accept", "brand/published", "pending", nil, `{"status":"accepted"}`, http.StatusOK, "accepted", 1},
+		{"reject accepted", "brand/published", "accepted", nil, `{"status":"rejected"}`, http.StatusOK, "rejected", -1},
+		{"unchanged", "brand/published", "rejected", nil, `{"status":"rejected"}`, http.StatusOK, "rejected", 0},
+		{"accept expired", "brand/published", "expired", nil, `{"status":"accepted"}`, http.StatusConflict, "expired", 0},
+		{"reopen expired", "brand/closed", "expired", nil, `{"status":"pending"}`, http.StatusConflict, "expired", 0},
+		{"expire by hand", "brand/published", "pending", nil, `{"status":"expired"}`, http.StatusUnprocessableEntity, "pending", 0},
+		{"finished campaign", "brand/completed", "pending", nil, `{"status":"accepted"}`, http.StatusConflict, "pending", 0},
+		{"someone else's", "org/published", "pending", nil, `{"status":"accepted"}`, http.StatusForbidden, "pending", 0},
+		{"organization member", "org/published", "pending", func(f *campaignFixture) int { return f.member },
+			`{"status":"accepted"}`, http.StatusOK, "accepted", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCampaignFixture(t)
+			ctx := context.Background()
+			ctrl := NewApplicationController(f.store, f.orgs)
+			f.router.PUT("/application/:id/status", ctrl.UpdateApplicationStatus)
+
+			campaignID := f.campaigns[tt.campaign]
+			app := models.CampaignApplication{CampaignID: campaignID, InfluencerID: 99, Status: tt.from}
+			if err := f.store.Applications.Create(ctx, &app); err != nil {
+				t.Fatal(err)
+			}
+			userID := f.brand
+			if tt.userID != nil {
+				userID = tt.userID(f)
+			}
+			before, err := f.store.Campaigns.GetByID(ctx, campaignID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := serve(f.router, testRequest{
+				method: http.MethodPut,
+				path:   fmt.Sprintf("/application/%d/status", app.ID),
+				userID: userID,
+				body:   tt.body,
+				header: map[string]string{"Content-Type": "application/json"},
+			})
+			expectStatus(t, w, tt.code)
+
+			got, err := f.store.Applications.GetByID(ctx, app.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.want {
+				t.Errorf("status = %q, want %q", got.Status, tt.want)
+			}
+			after, err := f.store.Campaigns.GetByID(ctx, campaignID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := after.AcceptedCount - before.AcceptedCount; d != tt.accepted {
+				t.Errorf("accepted count moved by %d, want %d", d, tt.accepted)
+			}
+		})
+	}
+}
+
+func TestApplyToCampaign(t *testing.T) {
+	picky := models.Eligibility{MinFollowers: 10000, Platforms: []string{"youtube"}}
+	tests := []struct {
+		name     string
+		campaign string
+		// eligibility replaces the campaign's rules when set.
+		eligibility *models.Eligibility
+		profile     bool
+		applied     bool
+		code        int
+		// details are the fields of the error's details.
+		details []string
+	}{
+		{name: "eligible", campaign: "brand/published", profile: true, code: http.StatusCreated},
+		{name: "organization campaign", campaign: "org/published", profile: true, code: http.StatusCreated},
+		{name: "no profile", campaign: "brand/published", code: http.StatusForbidden, details: []string{"profile"}},
+		{name: "fails every rule", campaign: "brand/published", eligibility: &picky, profile: true,
+			code: http.StatusForbidden, details: []string{"min_followers", "platforms"}},
+		{name: "already applied", campaign: "brand/published", profile: true, applied: true, code: http.StatusConflict},
+		{name: "draft", campaign: "brand/draft", profile: true, code: http.StatusConflict},
+		{name: "paused", campaign: "brand/paused", profile: true, code: http.StatusConflict},
+		{name: "closed", campaign: "brand/closed", profile: true, code: http.StatusConflict},
+		{name: "unknown campaign", profile: true, code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCampaignFixture(t)
+			ctx := context.Background()
+			ctrl := NewApplicationController(f.store, f.orgs)
+			f.router.POST("/apply/:id", ctrl.ApplyToCampaign)
+
+			influencer := &models.User{Username: "ada", Email: "ada@example.com", Role: models.RoleInfluencer}
+			if err := f.store.Users.Create(ctx, influencer); err != nil {
+				t.Fatal(err)
+			}
+			if tt.profile {
+				p := &models.Profile{UserID: influencer.ID, DisplayName: "Ada", AccountType: models.RoleInfluencer,
+					FollowerCount: 500, Platforms: []string{"tiktok"}}
+				if err := f.store.Profiles.Create(ctx, p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			campaignID, ok := f.campaigns[tt.campaign]
+			if !ok {
+				campaignID = 9999
+			}
+			if tt.eligibility != nil {
+				c, err := f.store.Campaigns.GetByID(ctx, campaignID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				c.Eligibility = *tt.eligibility
+				if err := f.store.Campaigns.Update(ctx, c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.applied {
+				app := models.CampaignApplication{CampaignID: campaignID, InfluencerID: influencer.ID, Status: "rejected"}
+				if err := f.store.Applications.Create(ctx, &app); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			w := serve(f.router, testRequest{
+				method: http.MethodPost,
+				path:   fmt.Sprintf("/apply/%d", campaignID),
+				userID: influencer.ID,
+				body:   `{"message":"Hi!"}`,
+				header: map[string]string{"Content-Type": "application/json"},
+			})
+			expectStatus(t, w, tt.code)
+
+			var resp struct {
+				Error struct {
+					Details []struct {
+						Field string `json:"field"`
+					} `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			var fields []string
+			for _, d := range resp.Error.Details {
+				fields = append(fields, d.Field)
+			}
+			if !slices.Equal(fields, tt.details) {
+				t.Errorf("error details = %v, want %v", fields, tt.details)
+			}
+
+			if tt.code != http.StatusCreated {
+				return
+			}
+			app := decode[models.CampaignApplication](t, w)
+			if app.Status != "pending" || app.InfluencerID != influencer.ID || app.Message != "Hi!" {
+				t.Errorf("application = %+v, want a pending one from user %d", app, influencer.ID)
+			}
+			c, err := f.store.Campaigns.GetByID(ctx, campaignID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.ApplicationCount != 1 {
+				t.Errorf("application count = %d, want 1", c.ApplicationCount)
+			}
+		})
+	}
+}
+
+func TestListApplications(t *testing.T) {
+	f := newCampaignFixture(t)
+	ctx := context.Background()
+	ctrl := NewApplicationController(f.store, f.orgs)
+	f.router.GET("/application/mine", ctrl.GetMyApplications)
+	f.router.GET("/campaign/:id/applications", ctrl.GetApplicationsForCampaign)
+
+	const influencer, other = 100, 101
+	for _, app := range []models.CampaignApplication{
+		{CampaignID: f.campaigns["brand/published"], InfluencerID: influencer, Status: "pending"},
+		{CampaignID: f.campaigns["brand/closed"], InfluencerID: influencer, Status: "accepted"},
+		{CampaignID: f.campaigns["org/published"], InfluencerID: influencer, Status: "rejected"},
+		{CampaignID: f.campaigns["brand/published"], InfluencerID: other, Status: "accepted"},
+	} {
+		if err := f.store.Applications.Create(ctx, &app); err != nil {
+			t.Fatal(err)
+		}
+	}
+	published := f.campaigns["brand/published"]
+
+	tests := []struct {
+		name   string
+		userID int
+		path   string
+		code   int
+		// want are the campaign and influencer of each listed application.
+		want [][2]int
+	}{
+		{"mine", influencer, "/application/mine?sort=created_at", http.StatusOK, [][2]int{
+			{published, influencer}, {f.campaigns["brand/closed"], influencer}, {f.campaigns["org/published"], influencer},
+		}},
+		{"mine by status", influencer, "/application/mine?status=accepted", http.StatusOK, [][2]int{
+			{f.campaigns["brand/closed"], influencer},
+		}},
+		{"mine by campaign", influencer, fmt.Sprintf("/application/mine?campaign_id=%d", published), http.StatusOK, [][2]int{
+			{published, influencer},
+		}},
+		{"mine with unknown status", influencer, "/application/mine?status=lost", http.StatusUnprocessableEntity, nil},
+		{"campaign", f.brand, fmt.Sprintf("/campaign/%d/applications?sort=created_at", published), http.StatusOK, [][2]int{
+			{published, influencer}, {published, other},
+		}},
+		{"campaign by status", f.brand, fmt.Sprintf("/campaign/%d/applications?status=accepted", published), http.StatusOK, [][2]int{
+			{published, other},
+		}},
+		{"organization campaign as member", f.member, fmt.Sprintf("/campaign/%d/applications", f.campaigns["org/published"]),
+			http.StatusOK, [][2]int{{f.campaigns["org/published"], influencer}}},
+		{"someone else's campaign", f.outsider, fmt.Sprintf("/campaign/%d/applications", published), http.StatusForbidden, nil},
+		{"unknown campaign", f.brand, "/campaign/9999/applications", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(f.router, testRequest{method: http.MethodGet, path: tt.path, userID: tt.userID})
+			expectStatus(t, w, tt.code)
+			if tt.code != http.StatusOK {
+				return
+			}
+			var got [][2]int
+			for _, app := range decode[[]models.CampaignApplication](t, w) {
+				got = append(got, [2]int{app.CampaignID, app.InfluencerID})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("listed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,17 @@
 package controllers
 
 import (
-	"InfluenceIQ/config"
+	"InfluenceIQ/apperr"
 	"InfluenceIQ/models"
-	"InfluenceIQ/utils"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
+	"cmp"
 	"context"
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,75 +19,118 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+type AuthController struct {
+	users    store.UserRepository
+	tokens   *services.TokenService
+	accounts *services.AccountService
+	mfa      *services.MFAService
+	guard    *services.LoginGuard
+}
+
+func NewAuthController(users store.UserRepository, tokens *services.TokenService, accounts *services.AccountService, mfa *services.MFAService, guard *services.LoginGuard) *AuthController {
+	return &AuthController{users: users, tokens: tokens, accounts: accounts, mfa: mfa, guard: guard}
+}
+
 // ---------- SIGNUP ----------
-func Signup(c *gin.Context) {
+func (h *AuthController) Signup(c *gin.Context) {
 	var input models.SignupInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &input) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
 	// 1. Check if email or username already exists
-	var exists bool
-	err := config.DB.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE email=$1 OR username=$2)`,
-		input.Email, input.Username).Scan(&exists)
+	exists, err := h.users.Exists(ctx, input.Email, input.Username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		fail(c, err)
 		return
 	}
 	if exists {
-		c.JSON(http.StatusConflict, gin.H{"error": "Email or username already in use"})
+		fail(c, apperr.Conflict("Email or username already in use"))
 		return
 	}
 
 	// 2. Hash password
 	hashed, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		fail(c, err)
 		return
 	}
 
 	// 3. Insert new user
-	var userID int
-	query := `
-		INSERT INTO users (username, email, password, full_name, role)
-		VALUES ($1, $2, $3, $4, COALESCE($5, 'viewer'))
-		RETURNING user_id
-	`
-	err = config.DB.QueryRow(ctx, query,
-		strings.TrimSpace(input.Username),
-		strings.TrimSpace(input.Email),
-		string(hashed),
-		strings.TrimSpace(input.FullName),
-		"viewer",
-	).Scan(&userID)
+	user := models.User{
+		Username:     strings.TrimSpace(input.Username),
+		Email:        models.NormalizeEmail(input.Email),
+		PasswordHash: string(hashed),
+		FullName:     strings.TrimSpace(input.FullName),
+		Role:         cmp.Or(input.AccountType, models.RoleViewer),
+	}
+	if err := h.users.Create(ctx, &user); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			err = apperr.Conflict("Email or username already in use").Wrap(err)
+		}
+		fail(c, err)
+		return
+	}
 
+	// 4. Ask the user to verify their email address. The account works
+	// without it, with restrictions, and the email can be resent.
+	if err := h.accounts.SendVerification(ctx, &user); err != nil {
+		log.Printf("Sending verification email to user %d failed: %v", user.ID, err)
+	}
+
+	// 5. Issue access and refresh tokens, unless the role requires 2FA
+	result, err := h.mfa.Login(ctx, &user, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		fail(c, err)
 		return
 	}
 
-	// 4. Generate JWT token
-	token, err := utils.GenerateToken(userID, 0, "viewer")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+	loginResponse(c, http.StatusCreated, "Signup successful", &user, result)
+}
+
+// loginResponse answers a successful first login factor with tokens, or
+// with the challenge to answer at /api/auth/mfa/verify.
+func loginResponse(c *gin.Context, status int, message string, user *models.User, result *services.LoginResult) {
+	if result.Challenge != nil {
+		c.JSON(status, gin.H{
+			"success": true,
+			"message": "Second factor required",
+			"data": gin.H{
+				"mfa_required":        true,
+				"mfa_token":           result.Challenge.Token,
+				"expires_at":          result.Challenge.ExpiresAt,
+				"enrollment_required": result.Challenge.EnrollmentRequired,
+			},
+		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Signup successful",
+	c.JSON(status, gin.H{
+		"success": true,
+		"message": message,
+		"data":    authData(user, result.Tokens),
+	})
+}
+
+// authData is the payload of a successful signup or login.
+func authData(user *models.User, pair *services.TokenPair) gin.H {
+	return gin.H{
 		"user": gin.H{
-			"id":       userID,
-			"username": input.Username,
-			"email":    input.Email,
-			"fullName": input.FullName,
-			"role":     "viewer",
+			"id":            user.ID,
+			"username":      user.Username,
+			"email":         user.Email,
+			"fullName":      user.FullName,
+			"role":          user.Role,
+			"emailVerified": user.EmailVerified(),
 		},
-		"token": token,
-	})
+		"token":              pair.AccessToken,
+		"expires_at":         pair.ExpiresAt,
+		"refresh_token":      pair.RefreshToken,
+		"refresh_expires_at": pair.RefreshExpiresAt,
+	}
 }
 
 type LoginRequest struct {
@@ -90,52 +139,243 @@ type LoginRequest struct {
 }
 
 // ---------- LOGIN ----------
-func Login(c *gin.Context) {
+func (h *AuthController) Login(c *gin.Context) {
 	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	var userID int
-	var email, username, fullName, role, hashedPassword string
+	login, ip := strings.TrimSpace(req.EmailOrUsername), c.ClientIP()
+	user, err := h.users.GetByLogin(ctx, login)
+	if errors.Is(err, store.ErrNotFound) {
+		user, err = nil, nil
+	}
+	if err != nil {
+		fail(c, err)
+		return
+	}
 
-	query := `
-		SELECT user_id, email, username, full_name, role, password
-		FROM users
-		WHERE email = $1 OR username = $2
-	`
-	err := config.DB.QueryRow(ctx, query, strings.TrimSpace(req.EmailOrUsername), strings.TrimSpace(req.EmailOrUsername)).
-		Scan(&userID, &email, &username, &fullName, &role, &hashedPassword)
+	if err := h.guard.Check(ctx, ip, login, user); err != nil {
+		fail(c, loginBlocked(c, err))
+		return
+	}
+	if user == nil || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		if err := h.guard.Failed(ctx, ip, login, user); err != nil {
+			fail(c, err)
+			return
+		}
+		fail(c, apperr.Unauthorized("Invalid credentials"))
+		return
+	}
 
+	result, err := h.mfa.Login(ctx, user, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		fail(c, err)
 		return
 	}
+	// With a second factor to check, the failures are only cleared once
+	// MFAService.Verify accepts a code.
+	if result.Tokens != nil {
+		if err := h.guard.Succeeded(ctx, ip, login, user); err != nil {
+			fail(c, err)
+			return
+		}
+	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	loginResponse(c, http.StatusOK, "Login successful", user, result)
+}
+
+// loginBlocked reports a *services.LoginBlockedError as 429 with a
+// Retry-After header.
+func loginBlocked(c *gin.Context, err error) error {
+	var blocked *services.LoginBlockedError
+	if !errors.As(err, &blocked) {
+		return err
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+	if blocked.Locked {
+		return apperr.TooMany("Account locked after too many failed logins; use the link we emailed or try again later").Wrap(err)
+	}
+	return apperr.TooMany("Too many failed logins; try again later").Wrap(err)
+}
+
+// ---------- REFRESH ----------
+func (h *AuthController) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	pair, err := h.tokens.Refresh(ctx, req.RefreshToken, clientInfo(c))
+	switch {
+	case errors.Is(err, services.ErrRefreshTokenReused):
+		err = apperr.Unauthorized("Refresh token was already used; log in again").Wrap(err)
+	case errors.Is(err, services.ErrInvalidRefreshToken):
+		err = apperr.Unauthorized("Invalid or expired refresh token").Wrap(err)
+	}
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, pair)
+}
+
+// ---------- LOGOUT ----------
+// Revokes the access token used for the request and ends its session and,
+// if given, the refresh token's whole family.
+func (h *AuthController) Logout(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	token, err := utils.GenerateToken(userID, 0, role)
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.tokens.Logout(ctx, userID, c.GetString("jti"), c.GetTime("token_expires_at"), c.GetString("sid"), req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "logged out"})
+}
+
+// ---------- EMAIL VERIFICATION ----------
+func (h *AuthController) VerifyEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.accounts.VerifyEmail(ctx, req.Token); err != nil {
+		if errors.Is(err, services.ErrInvalidUserToken) {
+			err = apperr.BadRequest("Invalid or expired verification link").Wrap(err)
+		}
+		fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "email address verified"})
+}
+
+// Sends the signed-in user a new verification link.
+func (h *AuthController) ResendVerification(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.users.GetByID(ctx, userID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if user.EmailVerified() {
+		fail(c, apperr.Conflict("Email address is already verified"))
+		return
+	}
+
+	if err := h.accounts.SendVerification(ctx, user); err != nil {
+		fail(c, apperr.New(apperr.CodeUpstream, "Sending the verification email failed").Wrap(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "verification email sent"})
+}
+
+// ---------- ACCOUNT UNLOCK ----------
+// Lifts a lockout with {"token"} from the link mailed when it started.
+func (h *AuthController) Unlock(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.guard.Unlock(ctx, req.Token, c.ClientIP()); err != nil {
+		if errors.Is(err, services.ErrInvalidUserToken) {
+			err = apperr.BadRequest("Invalid or expired unlock link").Wrap(err)
+		}
+		fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "account unlocked"})
+}
+
+// ---------- PASSWORD RESET ----------
+// Always succeeds for a well-formed address, whether or not it has an
+// account.
+func (h *AuthController) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.accounts.ForgotPassword(ctx, strings.TrimSpace(req.Email)); err != nil {
+		fail(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"user": gin.H{
-			"id":       userID,
-			"username": username,
-			"email":    email,
-			"fullName": fullName,
-			"role":     role,
-		},
-		"token": token,
+		"success": true,
+		"message": "if the address has an account, a password reset link is on its way",
 	})
 }
+
+func (h *AuthController) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required,min=6"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.accounts.ResetPassword(ctx, req.Token, req.Password); err != nil {
+		if errors.Is(err, services.ErrInvalidUserToken) {
+			err = apperr.BadRequest("Invalid or expired reset link").Wrap(err)
+		}
+		fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "password updated; log in again"})
+}
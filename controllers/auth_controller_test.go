@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/mailer"
+	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store/memory"
+)
+
+func TestSignup(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		code int
+		// wantEmail is the stored address of the new account.
+		wantEmail string
+	}{
+		{"viewer", `{"username":"ada","email":"ada@example.com","password":"secret1"}`, http.StatusCreated, "ada@example.com"},
+		{"email stored in lower case", `{"username":"ada","email":"Ada@Example.COM","password":"secret1","account_type":"brand"}`,
+			http.StatusCreated, "ada@example.com"},
+		{"username with @", `{"username":"grace@example.com","email":"mallory@example.com","password":"secret1"}`,
+			http.StatusUnprocessableEntity, ""},
+		{"email taken in another case", `{"username":"grace2","email":"GRACE@example.com","password":"secret1"}`,
+			http.StatusConflict, ""},
+		{"username taken", `{"username":"grace","email":"other@example.com","password":"secret1"}`, http.StatusConflict, ""},
+		{"admin account type", `{"username":"ada","email":"ada@example.com","password":"secret1","account_type":"admin"}`,
+			http.StatusUnprocessableEntity, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := memory.New()
+			tokens, err := services.NewTokenService(st, config.JWTConfig{Issuer: "test", TTL: time.Minute, RefreshTTL: time.Hour})
+			if err != nil {
+				t.Fatal(err)
+			}
+			accounts := services.NewAccountService(st,
+				mailer.NewOutbox(config.MailConfig{From: "InfluenceIQ <noreply@example.com>", OutboxDir: t.TempDir()}),
+				config.AccountConfig{})
+			guard := services.NewLoginGuard(st, accounts, config.LoginConfig{})
+			mfa := services.NewMFAService(st, tokens, guard, config.MFAConfig{})
+			ctrl := NewAuthController(st.Users, tokens, accounts, mfa, guard)
+			r := newTestRouter()
+			r.POST("/signup", ctrl.Signup)
+
+			if err := st.Users.Create(ctx, &models.User{Username: "grace", Email: "grace@example.com", Role: models.RoleViewer}); err != nil {
+				t.Fatal(err)
+			}
+
+			w := serve(r, testRequest{
+				method: http.MethodPost,
+				path:   "/signup",
+				body:   tt.body,
+				header: map[string]string{"Content-Type": "application/json"},
+			})
+			expectStatus(t, w, tt.code)
+			if tt.wantEmail == "" {
+				return
+			}
+			user, err := st.Users.GetByEmail(ctx, tt.wantEmail)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Email != tt.wantEmail {
+				t.Errorf("stored email %q, want %q", user.Email, tt.wantEmail)
+			}
+		})
+	}
+}
@@ -1,190 +1,488 @@
 package controllers
 
 import (
-	"InfluenceIQ/config"
+	"InfluenceIQ/apperr"
 	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
 	"context"
+	"errors"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+type CampaignController struct {
+	campaigns store.CampaignRepository
+	profiles  store.ProfileRepository
+	orgs      *services.OrganizationService
+	lifecycle *services.CampaignService
+}
+
+func NewCampaignController(campaigns store.CampaignRepository, profiles store.ProfileRepository, orgs *services.OrganizationService, lifecycle *services.CampaignService) *CampaignController {
+	return &CampaignController{campaigns: campaigns, profiles: profiles, orgs: orgs, lifecycle: lifecycle}
+}
+
+// managedCampaign loads a campaign the user may manage, i.e. one of their
+// organization's or, outside organizations, their own.
+func managedCampaign(ctx context.Context, campaigns store.CampaignRepository, orgs *services.OrganizationService, userID, id int) (*models.Campaign, error) {
+	campaign, err := campaigns.GetByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.NotFound("campaign not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	ok, err := orgs.CanManageCampaign(ctx, userID, campaign)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, apperr.Forbidden("not your campaign")
+	}
+	return campaign, nil
+}
+
+// visibleCampaign loads a campaign the user may see: a listed one, or one
+// they manage. Others are reported as not found, so that drafts don't leak.
+func visibleCampaign(ctx context.Context, campaigns store.CampaignRepository, orgs *services.OrganizationService, userID, id int) (*models.Campaign, error) {
+	campaign, err := campaigns.GetByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.NotFound("campaign not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Listed() {
+		return campaign, nil
+	}
+	ok, err := orgs.CanManageCampaign(ctx, userID, campaign)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, apperr.NotFound("campaign not found")
+	}
+	return campaign, nil
+}
+
+// campaignFields are the fields brands set when creating a campaign and
+// may change later.
+type campaignFields struct {
+	Title       string    `json:"title" binding:"required"`
+	Description string    `json:"description" binding:"required"`
+	Category    string    `json:"category"`
+	Budget      float64   `json:"budget" binding:"gte=0"`
+	Deadline    time.Time `json:"deadline"`
+	// Eligibility is checked against influencers' profiles when they apply.
+	Eligibility models.Eligibility `json:"eligibility"`
+}
+
 // POST /api/campaigns
-func CreateCampaign(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+// Campaigns start as drafts; see Publish.
+func (h *CampaignController) CreateCampaign(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	var req struct {
-		Title       string    `json:"title" binding:"required"`
-		Description string    `json:"description" binding:"required"`
-		Category    string    `json:"category"`
-		Budget      float64   `json:"budget"`
-		Deadline    time.Time `json:"deadline"`
+	var req campaignFields
+	if !bindJSON(c, &req) {
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	// Campaigns of organization members belong to the organization.
+	orgID, err := h.orgs.OrganizationID(ctx, userID)
+	if err != nil {
+		fail(c, err)
 		return
 	}
 
 	campaign := models.Campaign{
-		BrandID:     userID.(int),
-		Title:       req.Title,
-		Description: req.Description,
-		Category:    req.Category,
-		Budget:      req.Budget,
-		Deadline:    req.Deadline,
-		Status:      "active",
+		BrandID:        userID,
+		OrganizationID: orgID,
+		Title:          req.Title,
+		Description:    req.Description,
+		Category:       req.Category,
+		Budget:         req.Budget,
+		Deadline:       req.Deadline,
+		Eligibility:    req.Eligibility,
+		Status:         models.CampaignDraft,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := models.CreateCampaign(ctx, &campaign); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "failed to create campaign"})
+	if err := h.campaigns.Create(ctx, &campaign); err != nil {
+		fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"success": true, "data": campaign})
+	respond(c, http.StatusCreated, campaign)
 }
 
-// GET /api/campaigns
-func GetAllCampaigns(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// campaignFilter reads the filters shared by the campaign listings; status
+// may be one of statuses.
+func campaignFilter(q *query, statuses ...string) store.CampaignFilter {
+	f := store.CampaignFilter{
+		BrandID:        q.int("brand_id"),
+		OrganizationID: q.int("organization_id"),
+		Category:       q.c.Query("category"),
+		MinBudget:      q.float("min_budget"),
+		MaxBudget:      q.float("max_budget"),
+		DeadlineFrom:   q.time("deadline_from"),
+		DeadlineTo:     q.time("deadline_to"),
+	}
+	if status := q.oneOf("status", statuses...); status != "" {
+		f.Statuses = []string{status}
+	}
+	return f
+}
 
-	// Optionally, you can make a helper GetAllCampaigns(ctx)
-	query := `
-		SELECT id, brand_id, title, description, category, budget, deadline, status, created_at, updated_at
-		FROM campaigns
-		ORDER BY created_at DESC
-	`
-	rows, err := config.DB.Query(ctx, query)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "failed to fetch campaigns"})
+// GET /api/campaigns?category=&status=&brand_id=&organization_id=&min_budget=&max_budget=
+// &deadline_from=&deadline_to=&eligible=&sort=&limit=&cursor=
+// Lists published, paused and closed campaigns, or those of status; brands
+// find their others under GetMyCampaigns. With eligible=true it lists the
+// campaigns the user's profile can apply to now: published ones whose
+// deadline is ahead and whose eligibility rules the profile meets.
+func (h *CampaignController) GetAllCampaigns(c *gin.Context) {
+	q := newQuery(c)
+	filter := campaignFilter(q, models.ListedCampaignStatuses...)
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = models.ListedCampaignStatuses
+	}
+	eligible := q.oneOf("eligible", "true", "false") == "true"
+	opts := list(q, store.CampaignSorts)
+	if err := q.err(); err != nil {
+		fail(c, err)
 		return
 	}
-	defer rows.Close()
 
-	var campaigns []models.Campaign
-	for rows.Next() {
-		var camp models.Campaign
-		if err := rows.Scan(
-			&camp.ID, &camp.BrandID, &camp.Title, &camp.Description, &camp.Category,
-			&camp.Budget, &camp.Deadline, &camp.Status, &camp.CreatedAt, &camp.UpdatedAt,
-		); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "scan error"})
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if eligible {
+		profile, err := h.profiles.GetByUserID(ctx, c.GetInt("user_id"))
+		if errors.Is(err, store.ErrNotFound) {
+			err = apperr.Validation("invalid query parameters", apperr.FieldError{
+				Field:   "eligible",
+				Code:    "profile_required",
+				Message: "create a profile to list the campaigns you're eligible for",
+			})
+		}
+		if err != nil {
+			fail(c, err)
 			return
 		}
-		campaigns = append(campaigns, camp)
+		filter.EligibleFor = profile
+		filter.Statuses = []string{models.CampaignPublished}
+		if now := time.Now(); filter.DeadlineFrom == nil || filter.DeadlineFrom.Before(now) {
+			filter.DeadlineFrom = &now
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": campaigns})
+	page, err := h.campaigns.List(ctx, filter, opts)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	respondPage(c, page, opts)
 }
 
-// GET /api/campaigns/mine
-func GetMyCampaigns(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+// GET /api/campaigns/mine, with the same parameters as GetAllCampaigns
+// Lists the campaigns the user manages, in any status: their
+// organization's, or their own outside organizations.
+func (h *CampaignController) GetMyCampaigns(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	q := newQuery(c)
+	filter := campaignFilter(q, models.CampaignDraft, models.CampaignPublished, models.CampaignPaused,
+		models.CampaignClosed, models.CampaignCompleted, models.CampaignCancelled)
+	opts := list(q, store.CampaignSorts)
+	if err := q.err(); err != nil {
+		fail(c, err)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	campaigns, err := models.GetCampaignByID(ctx, userID.(int))
+	orgID, err := h.orgs.OrganizationID(ctx, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "failed to fetch campaigns"})
+		fail(c, err)
+		return
+	}
+	if orgID != nil {
+		filter.OrganizationID = *orgID
+	} else {
+		filter.BrandID = userID
+		filter.OrganizationID = 0
+		filter.Personal = true
+	}
+
+	page, err := h.campaigns.List(ctx, filter, opts)
+	if err != nil {
+		fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": campaigns})
+	respondPage(c, page, opts)
 }
 
 // GET /api/campaigns/:id
-func GetCampaignByID(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid campaign id"})
+// Campaigns that aren't listed are only found by the brands who manage
+// them.
+func (h *CampaignController) GetCampaignByID(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	campaign, err := models.GetCampaignByID(ctx, id)
+	campaign, err := visibleCampaign(ctx, h.campaigns, h.orgs, userID, id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "campaign not found"})
+		fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": campaign})
+	c.Header("ETag", etag(campaign.Version))
+	respond(c, http.StatusOK, campaign)
 }
 
-// PUT /api/campaigns/:id
-func UpdateCampaign(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+// PATCH /api/campaign/:id
+// Takes a JSON merge patch of the campaign's fields. With If-Match the
+// update only applies while the campaign is at that ETag; either way it
+// fails with 412 if someone else changed the campaign in the meantime.
+// Finished campaigns can't be edited, and running ones must stay ready to
+// publish.
+func (h *CampaignController) UpdateCampaign(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	campaign, err := managedCampaign(ctx, h.campaigns, h.orgs, userID, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if !ifMatch(c, campaign.Version) {
+		c.Header("ETag", etag(campaign.Version))
+		fail(c, apperr.Precondition("campaign has changed; fetch it again and reapply your changes"))
+		return
+	}
+	if campaign.Finished() {
+		fail(c, apperr.Conflict("Can't edit a "+campaign.Status+" campaign").Wrap(services.ErrCampaignFinished))
+		return
+	}
+
+	fields := campaignFields{
+		Title:       campaign.Title,
+		Description: campaign.Description,
+		Category:    campaign.Category,
+		Budget:      campaign.Budget,
+		Deadline:    campaign.Deadline,
+		Eligibility: campaign.Eligibility,
+	}
+	if !applyMergePatch(c, &fields) {
+		return
+	}
+	campaign.Title = fields.Title
+	campaign.Description = fields.Description
+	campaign.Category = fields.Category
+	campaign.Budget = fields.Budget
+	campaign.Deadline = fields.Deadline
+	campaign.Eligibility = fields.Eligibility
+	if err := h.lifecycle.CheckEdit(campaign); err != nil {
+		fail(c, readinessErr(err, "a "+campaign.Status+" campaign needs a budget and a future deadline"))
 		return
 	}
 
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
+	err = h.campaigns.Update(ctx, campaign)
+	switch {
+	case errors.Is(err, store.ErrConflict):
+		err = apperr.Precondition("campaign has changed; fetch it again and reapply your changes").Wrap(err)
+	case errors.Is(err, store.ErrNotFound):
+		err = apperr.NotFound("campaign not found").Wrap(err)
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid id"})
+		fail(c, err)
 		return
 	}
 
-	var req models.Campaign
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	c.Header("ETag", etag(campaign.Version))
+	respond(c, http.StatusOK, campaign)
+}
+
+// DELETE /api/campaigns/:id
+func (h *CampaignController) DeleteCampaign(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	req.ID = id
-	req.BrandID = userID.(int)
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	if err := models.UpdateCampaign(ctx, &req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "failed to update campaign"})
+	if _, err := managedCampaign(ctx, h.campaigns, h.orgs, userID, id); err != nil {
+		fail(c, err)
+		return
+	}
+	if err := h.campaigns.Delete(ctx, id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			err = apperr.NotFound("campaign not found")
+		}
+		fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "campaign updated"})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "campaign deleted"})
 }
 
-// DELETE /api/campaigns/:id
-func DeleteCampaign(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized"})
+// POST /api/campaign/:id/publish
+// Opens a draft for applications. It needs a budget and a future deadline.
+func (h *CampaignController) Publish(c *gin.Context) {
+	h.transition(c, services.CampaignPublish)
+}
+
+// POST /api/campaign/:id/pause
+// Stops taking applications for a while.
+func (h *CampaignController) Pause(c *gin.Context) {
+	h.transition(c, services.CampaignPause)
+}
+
+// POST /api/campaign/:id/resume
+// Takes applications again, as long as the deadline hasn't passed.
+func (h *CampaignController) Resume(c *gin.Context) {
+	h.transition(c, services.CampaignResume)
+}
+
+// POST /api/campaign/:id/close
+// Ends selection and rejects the pending applications.
+func (h *CampaignController) Close(c *gin.Context) {
+	h.transition(c, services.CampaignClose)
+}
+
+// POST /api/campaign/:id/complete
+func (h *CampaignController) Complete(c *gin.Context) {
+	h.transition(c, services.CampaignComplete)
+}
+
+// POST /api/campaign/:id/cancel
+// Calls off a campaign that isn't completed and rejects the pending
+// applications.
+func (h *CampaignController) Cancel(c *gin.Context) {
+	h.transition(c, services.CampaignCancel)
+}
+
+func (h *CampaignController) transition(c *gin.Context, action services.CampaignAction) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := pathID(c, "id")
+	if !ok {
 		return
 	}
 
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	existing, err := managedCampaign(ctx, h.campaigns, h.orgs, userID, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	campaign, err := h.lifecycle.Transition(ctx, id, userID, action)
+	switch {
+	case errors.Is(err, services.ErrInvalidTransition):
+		err = apperr.Conflict("Can't " + string(action) + " a " + existing.Status + " campaign").Wrap(err)
+	case errors.Is(err, services.ErrBudgetRequired), errors.Is(err, services.ErrDeadlineRequired):
+		err = readinessErr(err, "campaign isn't ready to "+string(action))
+	case errors.Is(err, store.ErrNotFound):
+		err = apperr.NotFound("campaign not found").Wrap(err)
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "invalid id"})
+		fail(c, err)
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	c.Header("ETag", etag(campaign.Version))
+	respond(c, http.StatusOK, campaign)
+}
+
+// readinessErr reports a campaign that lacks what publishing requires as a
+// validation error of the field at fault.
+func readinessErr(err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrBudgetRequired):
+		return apperr.Validation(message, apperr.FieldError{
+			Field:   "budget",
+			Code:    "required",
+			Message: "budget must be greater than 0",
+		}).Wrap(err)
+	case errors.Is(err, services.ErrDeadlineRequired):
+		return apperr.Validation(message, apperr.FieldError{
+			Field:   "deadline",
+			Code:    "invalid",
+			Message: "deadline must be in the future",
+		}).Wrap(err)
+	}
+	return err
+}
+
+// GET /api/campaign/:id/transitions
+// The campaign's status history, oldest first.
+func (h *CampaignController) Transitions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	if err := models.DeleteCampaign(ctx, id, userID.(int)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "failed to delete campaign"})
+	if _, err := managedCampaign(ctx, h.campaigns, h.orgs, userID, id); err != nil {
+		fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "campaign deleted"})
+	transitions, err := h.lifecycle.Transitions(ctx, id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond(c, http.StatusOK, transitions)
 }
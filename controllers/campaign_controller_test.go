@@ -0,0 +1,384 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+// campaignFixture is a memory store with brands, an organization and
+// campaigns in every status, served by the campaign and deliverable
+// routes.
+type campaignFixture struct {
+	store  *store.Store
+	orgs   *services.OrganizationService
+	router *gin.Engine
+	// brand works alone, owner and member share org, outsider is a brand
+	// without campaigns.
+	brand, owner, member, outsider int
+	org                            int
+	// campaigns maps "brand/<status>" and "org/<status>" to campaign IDs.
+	campaigns map[string]int
+}
+
+func newCampaignFixture(t *testing.T) *campaignFixture {
+	t.Helper()
+	ctx := context.Background()
+	st := memory.New()
+	f := &campaignFixture{store: st, campaigns: map[string]int{}}
+
+	newUser := func(name string) int {
+		u := &models.User{Username: name, Email: name + "@example.com", Role: "brand"}
+		if err := st.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		return u.ID
+	}
+	f.brand, f.owner, f.member, f.outsider = newUser("brand"), newUser("owner"), newUser("member"), newUser("outsider")
+
+	org := &models.Organization{Name: "Acme"}
+	if err := st.Organizations.Create(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+	f.org = org.ID
+	for _, m := range []models.OrganizationMember{
+		{OrganizationID: org.ID, UserID: f.owner, Role: models.OrgRoleOwner},
+		{OrganizationID: org.ID, UserID: f.member, Role: models.OrgRoleMember},
+	} {
+		if err := st.Organizations.AddMember(ctx, &m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, status := range []string{
+		models.CampaignDraft, models.CampaignPublished, models.CampaignPaused,
+		models.CampaignClosed, models.CampaignCompleted, models.CampaignCancelled,
+	} {
+		for _, owner := range []string{"brand", "org"} {
+			c := models.Campaign{
+				BrandID:     f.brand,
+				Title:       owner + " " + status,
+				Description: "Posts about the launch",
+				Budget:      100,
+				Deadline:    time.Now().Add(24 * time.Hour),
+				Status:      status,
+			}
+			if owner == "org" {
+				c.BrandID, c.OrganizationID = f.owner, &f.org
+			}
+			if err := st.Campaigns.Create(ctx, &c); err != nil {
+				t.Fatal(err)
+			}
+			f.campaigns[owner+"/"+status] = c.ID
+		}
+	}
+
+	f.orgs = services.NewOrganizationService(st, nil, config.AccountConfig{})
+	campaigns := NewCampaignController(st.Campaigns, st.Profiles, f.orgs, services.NewCampaignService(st))
+	deliverables := NewDeliverableController(st, f.orgs, services.NewDeliverableService(st))
+
+	f.router = newTestRouter()
+	f.router.GET("/campaign/", campaigns.GetAllCampaigns)
+	f.router.GET("/campaign/me", campaigns.GetMyCampaigns)
+	f.router.GET("/campaign/:id", campaigns.GetCampaignByID)
+	f.router.PATCH("/campaign/:id", campaigns.UpdateCampaign)
+	f.router.POST("/campaign/:id/publish", campaigns.Publish)
+	f.router.POST("/campaign/:id/close", campaigns.Close)
+	f.router.GET("/campaign/:id/transitions", campaigns.Transitions)
+	f.router.GET("/campaign/:id/deliverables", deliverables.List)
+	return f
+}
+
+func TestCampaignVisibility(t *testing.T) {
+	f := newCampaignFixture(t)
+
+	listed := map[string]bool{
+		models.CampaignDraft:     false,
+		models.CampaignPublished: true,
+		models.CampaignPaused:    true,
+		models.CampaignClosed:    true,
+		models.CampaignCompleted: false,
+		models.CampaignCancelled: false,
+	}
+	users := []struct {
+		name    string
+		id      int
+		manages string
+	}{
+		{"brand", f.brand, "brand"},
+		{"organization owner", f.owner, "org"},
+		{"organization member", f.member, "org"},
+		{"outsider", f.outsider, ""},
+	}
+	for key, id := range f.campaigns {
+		owner, status, _ := strings.Cut(key, "/")
+		for _, u := range users {
+			want := http.StatusNotFound
+			if listed[status] || u.manages == owner {
+				want = http.StatusOK
+			}
+			for _, path := range []string{"/campaign/%d", "/campaign/%d/deliverables"} {
+				path := fmt.Sprintf(path, id)
+				t.Run(u.name+" GET "+path+" ("+key+")", func(t *testing.T) {
+					w := serve(f.router, testRequest{method: http.MethodGet, path: path, userID: u.id})
+					expectStatus(t, w, want)
+				})
+			}
+		}
+	}
+}
+
+func TestListCampaignsStatuses(t *testing.T) {
+	f := newCampaignFixture(t)
+
+	tests := []struct {
+		name   string
+		path   string
+		userID int
+		code   int
+		want   []string
+	}{
+		{"listing defaults to listed statuses", "/campaign/", f.owner, http.StatusOK, []string{
+			"brand closed", "brand paused", "brand published", "org closed", "org paused", "org published",
+		}},
+		{"listing by listed status", "/campaign/?status=paused", f.outsider, http.StatusOK, []string{
+			"brand paused", "org paused",
+		}},
+		{"listing drafts", "/campaign/?status=draft", f.brand, http.StatusUnprocessableEntity, nil},
+		{"listing cancelled", "/campaign/?status=cancelled", f.outsider, http.StatusUnprocessableEntity, nil},
+		{"own drafts", "/campaign/me?status=draft", f.member, http.StatusOK, []string{"org draft"}},
+		{"own campaigns", "/campaign/me", f.brand, http.StatusOK, []string{
+			"brand cancelled", "brand closed", "brand completed", "brand draft", "brand paused", "brand published",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(f.router, testRequest{method: http.MethodGet, path: tt.path, userID: tt.userID})
+			expectStatus(t, w, tt.code)
+			if tt.want == nil {
+				return
+			}
+			var titles []string
+			for _, c := range decode[[]models.Campaign](t, w) {
+				titles = append(titles, c.Title)
+			}
+			slices.Sort(titles)
+			if !slices.Equal(titles, tt.want) {
+				t.Errorf("titles = %q, want %q", titles, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateCampaign(t *testing.T) {
+	tests := []struct {
+		name     string
+		campaign string
+		body     string
+		ifMatch  string
+		code     int
+		// check looks at the campaign after a successful update.
+		check func(t *testing.T, c models.Campaign)
+	}{
+		{
+			name:     "edits a draft",
+			campaign: "brand/draft",
+			body:     `{"title":"New title","category":null}`,
+			code:     http.StatusOK,
+			check: func(t *testing.T, c models.Campaign) {
+				if c.Title != "New title" || c.Category != "" || c.Description == "" {
+					t.Errorf("campaign = %+v, want a new title, no category and the old description", c)
+				}
+			},
+		},
+		{
+			name:     "matching If-Match",
+			campaign: "brand/published",
+			body:     `{"budget":250}`,
+			ifMatch:  `"1"`,
+			code:     http.StatusOK,
+			check: func(t *testing.T, c models.Campaign) {
+				if c.Budget != 250 || c.Version != 2 {
+					t.Errorf("budget %v at version %d, want 250 at 2", c.Budget, c.Version)
+				}
+			},
+		},
+		{"stale If-Match", "brand/draft", `{"title":"New title"}`, `"7"`, http.StatusPreconditionFailed, nil},
+		{"read-only member", "brand/draft", `{"status":"published"}`, "", http.StatusUnprocessableEntity, nil},
+		{"not an object", "brand/draft", `["title"]`, "", http.StatusBadRequest, nil},
+		{"completed", "brand/completed", `{"title":"New title"}`, "", http.StatusConflict, nil},
+		{"cancelled", "brand/cancelled", `{"title":"New title"}`, "", http.StatusConflict, nil},
+		{"published loses its budget", "brand/published", `{"budget":0}`, "", http.StatusUnprocessableEntity, nil},
+		{"published loses its deadline", "brand/published", `{"deadline":null}`, "", http.StatusUnprocessableEntity, nil},
+		{"paused deadline in the past", "brand/paused", `{"deadline":"2001-01-01T00:00:00Z"}`, "", http.StatusUnprocessableEntity, nil},
+		{"draft without budget", "brand/draft", `{"budget":0,"deadline":null}`, "", http.StatusOK, nil},
+		{"closed without budget", "brand/closed", `{"budget":0}`, "", http.StatusOK, nil},
+		{"someone else's", "org/draft", `{"title":"New title"}`, "", http.StatusForbidden, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCampaignFixture(t)
+			id := f.campaigns[tt.campaign]
+			req := testRequest{
+				method: http.MethodPatch,
+				path:   fmt.Sprintf("/campaign/%d", id),
+				userID: f.brand,
+				body:   tt.body,
+				header: map[string]string{"Content-Type": "application/json"},
+			}
+			if tt.ifMatch != "" {
+				req.header["If-Match"] = tt.ifMatch
+			}
+			before, err := f.store.Campaigns.GetByID(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := serve(f.router, req)
+			expectStatus(t, w, tt.code)
+
+			after, err := f.store.Campaigns.GetByID(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.code != http.StatusOK {
+				if after.Version != before.Version {
+					t.Errorf("version moved from %d to %d on a failed update", before.Version, after.Version)
+				}
+				if tt.code == http.StatusPreconditionFailed && w.Header().Get("ETag") != etag(before.Version) {
+					t.Errorf("ETag = %q, want the current %q", w.Header().Get("ETag"), etag(before.Version))
+				}
+				return
+			}
+			if w.Header().Get("ETag") != etag(after.Version) {
+				t.Errorf("ETag = %q, want %q", w.Header().Get("ETag"), etag(after.Version))
+			}
+			if tt.check != nil {
+				tt.check(t, *after)
+			}
+		})
+	}
+}
+
+func TestCampaignTransitionEndpoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		campaign string
+		action   string
+		userID   func(f *campaignFixture) int
+		code     int
+		want     string
+	}{
+		{"publish a draft", "brand/draft", "publish", nil, http.StatusOK, models.CampaignPublished},
+		{"close a published one", "brand/published", "close", nil, http.StatusOK, models.CampaignClosed},
+		{"publish a closed one", "brand/closed", "publish", nil, http.StatusConflict, models.CampaignClosed},
+		{"organization member", "org/draft", "publish", func(f *campaignFixture) int { return f.member }, http.StatusOK, models.CampaignPublished},
+		{"someone else's", "org/draft", "publish", nil, http.StatusForbidden, models.CampaignDraft},
+		{"unknown campaign", "", "publish", nil, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCampaignFixture(t)
+			id, ok := f.campaigns[tt.campaign]
+			if !ok {
+				id = 9999
+			}
+			userID := f.brand
+			if tt.userID != nil {
+				userID = tt.userID(f)
+			}
+
+			w := serve(f.router, testRequest{method: http.MethodPost, path: fmt.Sprintf("/campaign/%d/%s", id, tt.action), userID: userID})
+			expectStatus(t, w, tt.code)
+			if tt.want == "" {
+				return
+			}
+
+			c, err := f.store.Campaigns.GetByID(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Status != tt.want {
+				t.Errorf("status = %q, want %q", c.Status, tt.want)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			w = serve(f.router, testRequest{method: http.MethodGet, path: fmt.Sprintf("/campaign/%d/transitions", id), userID: userID})
+			expectStatus(t, w, http.StatusOK)
+			if history := decode[[]models.CampaignTransition](t, w); len(history) != 1 || history[0].To != tt.want {
+				t.Errorf("transitions = %+v, want one to %s", history, tt.want)
+			}
+		})
+	}
+}
+
+func TestListEligibleCampaigns(t *testing.T) {
+	f := newCampaignFixture(t)
+	ctx := context.Background()
+
+	influencer := &models.User{Username: "influencer", Email: "influencer@example.com", Role: "influencer"}
+	if err := f.store.Users.Create(ctx, influencer); err != nil {
+		t.Fatal(err)
+	}
+	profile := &models.Profile{UserID: influencer.ID, DisplayName: "Sunny", AccountType: "influencer", FollowerCount: 1000}
+	if err := f.store.Profiles.Create(ctx, profile); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []models.Campaign{
+		{Title: "brand expired", Deadline: time.Now().Add(-time.Hour)},
+		{Title: "brand too big", Deadline: time.Now().Add(time.Hour), Eligibility: models.Eligibility{MinFollowers: 5000}},
+	} {
+		c.BrandID, c.Description, c.Budget, c.Status = f.brand, "Posts", 100, models.CampaignPublished
+		if err := f.store.Campaigns.Create(ctx, &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	open := []string{"brand published", "org published"}
+	tests := []struct {
+		name   string
+		path   string
+		userID int
+		code   int
+		want   []string
+	}{
+		{"eligible", "/campaign/?eligible=true", influencer.ID, http.StatusOK, open},
+		{"status is overridden", "/campaign/?eligible=true&status=paused", influencer.ID, http.StatusOK, open},
+		{"past deadlines are overridden", "/campaign/?eligible=true&deadline_from=2001-01-01", influencer.ID, http.StatusOK, open},
+		{"later deadlines narrow", "/campaign/?eligible=true&deadline_from=2999-01-01", influencer.ID, http.StatusOK, nil},
+		{"without eligible", "/campaign/?status=published", influencer.ID, http.StatusOK, []string{
+			"brand expired", "brand published", "brand too big", "org published",
+		}},
+		{"without a profile", "/campaign/?eligible=true", f.outsider, http.StatusUnprocessableEntity, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(f.router, testRequest{method: http.MethodGet, path: tt.path, userID: tt.userID})
+			expectStatus(t, w, tt.code)
+			if tt.code != http.StatusOK {
+				return
+			}
+			var titles []string
+			for _, c := range decode[[]models.Campaign](t, w) {
+				titles = append(titles, c.Title)
+			}
+			slices.Sort(titles)
+			if !slices.Equal(titles, tt.want) {
+				t.Errorf("titles = %q, want %q", titles, tt.want)
+			}
+		})
+	}
+}
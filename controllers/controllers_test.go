@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"InfluenceIQ/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter returns a router that renders errors like the real one and
+// signs requests in as the user in their X-User-ID header, in place of the
+// auth middleware.
+func newTestRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(middleware.ErrorHandler(), func(c *gin.Context) {
+		if id, err := strconv.Atoi(c.GetHeader("X-User-ID")); err == nil {
+			c.Set("user_id", id)
+		}
+	})
+	return r
+}
+
+// testRequest describes a request for serve; userID 0 sends it signed out.
+type testRequest struct {
+	method string
+	path   string
+	userID int
+	body   string
+	header map[string]string
+}
+
+func serve(r *gin.Engine, req testRequest) *httptest.ResponseRecorder {
+	var body io.Reader
+	if req.body != "" {
+		body = strings.NewReader(req.body)
+	}
+	hr := httptest.NewRequest(req.method, req.path, body)
+	if req.userID != 0 {
+		hr.Header.Set("X-User-ID", strconv.Itoa(req.userID))
+	}
+	for k, v := range req.header {
+		hr.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, hr)
+	return w
+}
+
+// decode unmarshals the data of a successful response.
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var resp struct {
+		Data T `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding %s: %v", w.Body, err)
+	}
+	return resp.Data
+}
+
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, want, w.Body)
+	}
+}
@@ -0,0 +1,415 @@
+package controllers
+
+import (
+	"InfluenceIQ/apperr"
+	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeliverableController struct {
+	store        *store.Store
+	orgs         *services.OrganizationService
+	deliverables *services.DeliverableService
+}
+
+func NewDeliverableController(s *store.Store, orgs *services.OrganizationService, deliverables *services.DeliverableService) *DeliverableController {
+	return &DeliverableController{store: s, orgs: orgs, deliverables: deliverables}
+}
+
+// deliverableFields are what brands set on a deliverable.
+type deliverableFields struct {
+	Type         string     `json:"type" binding:"required,oneof=post story reel video"`
+	Platform     string     `json:"platform" binding:"required,oneof=instagram tiktok youtube facebook x linkedin twitch blog other"`
+	Quantity     int        `json:"quantity" binding:"required,min=1"`
+	DueAt        *time.Time `json:"due_at"`
+	Requirements string     `json:"requirements"`
+}
+
+// deliverableErr maps the service's errors for changes to deliverables.
+func deliverableErr(err error, campaign *models.Campaign) error {
+	switch {
+	case errors.Is(err, services.ErrCampaignFinished):
+		return apperr.Conflict("campaign is " + campaign.Status).Wrap(err)
+	case errors.Is(err, services.ErrDeliverableInUse):
+		return apperr.Conflict("deliverable has submissions").Wrap(err)
+	case errors.Is(err, store.ErrNotFound):
+		return apperr.NotFound("deliverable not found").Wrap(err)
+	}
+	return err
+}
+
+// POST /api/campaign/:id/deliverables
+func (h *DeliverableController) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	campaignID, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req deliverableFields
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	campaign, err := managedCampaign(ctx, h.store.Campaigns, h.orgs, userID, campaignID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	d := models.Deliverable{
+		Type:         req.Type,
+		Platform:     req.Platform,
+		Quantity:     req.Quantity,
+		DueAt:        req.DueAt,
+		Requirements: req.Requirements,
+	}
+	if err := h.deliverables.Create(ctx, campaign, &d); err != nil {
+		fail(c, deliverableErr(err, campaign))
+		return
+	}
+
+	respond(c, http.StatusCreated, d)
+}
+
+// GET /api/campaign/:id/deliverables
+func (h *DeliverableController) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	campaignID, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := visibleCampaign(ctx, h.store.Campaigns, h.orgs, userID, campaignID); err != nil {
+		fail(c, err)
+		return
+	}
+
+	deliverables, err := h.deliverables.List(ctx, campaignID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, deliverables)
+}
+
+// PUT /api/campaign/:id/deliverables/:deliverable_id
+func (h *DeliverableController) Update(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	campaignID, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "deliverable_id")
+	if !ok {
+		return
+	}
+
+	var req deliverableFields
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	campaign, err := managedCampaign(ctx, h.store.Campaigns, h.orgs, userID, campaignID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	d := models.Deliverable{
+		ID:           id,
+		Type:         req.Type,
+		Platform:     req.Platform,
+		Quantity:     req.Quantity,
+		DueAt:        req.DueAt,
+		Requirements: req.Requirements,
+	}
+	if err := h.deliverables.Update(ctx, campaign, &d); err != nil {
+		fail(c, deliverableErr(err, campaign))
+		return
+	}
+
+	respond(c, http.StatusOK, d)
+}
+
+// DELETE /api/campaign/:id/deliverables/:deliverable_id
+// Only deliverables without submissions can be deleted.
+func (h *DeliverableController) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	campaignID, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "deliverable_id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	campaign, err := managedCampaign(ctx, h.store.Campaigns, h.orgs, userID, campaignID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if err := h.deliverables.Delete(ctx, campaign, id); err != nil {
+		fail(c, deliverableErr(err, campaign))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "deliverable deleted"})
+}
+
+// GET /api/campaign/:id/deliverables/progress
+// Rolls the accepted applications' progress up to the campaign.
+func (h *DeliverableController) CampaignProgress(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	campaignID, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	campaign, err := managedCampaign(ctx, h.store.Campaigns, h.orgs, userID, campaignID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	progress, err := h.deliverables.CampaignDelivery(ctx, campaign)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, progress)
+}
+
+// GET /api/application/:id/deliverables
+// The application's progress with each deliverable, for the influencer
+// who applied and the brands managing the campaign.
+func (h *DeliverableController) ApplicationProgress(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	appID, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	app, err := h.store.Applications.GetByID(ctx, appID)
+	if errors.Is(err, store.ErrNotFound) {
+		err = apperr.NotFound("application not found")
+	}
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if app.InfluencerID != userID {
+		campaign, err := h.store.Campaigns.GetByID(ctx, app.CampaignID)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		manages, err := h.orgs.CanManageCampaign(ctx, userID, campaign)
+		if err != nil {
+			fail(c, err)
+			return
+		}
+		if !manages {
+			fail(c, apperr.Forbidden("not your application"))
+			return
+		}
+	}
+
+	progress, err := h.deliverables.ApplicationDelivery(ctx, app)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, progress)
+}
+
+// POST /api/application/:id/submissions
+// Submits a draft, or the live link, for one of the campaign's
+// deliverables. Only accepted applications can submit.
+func (h *DeliverableController) Submit(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	appID, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		DeliverableID int    `json:"deliverable_id" binding:"required"`
+		Kind          string `json:"kind" binding:"required,oneof=draft live"`
+		URL           string `json:"url" binding:"required,url,max=2048"`
+		Note          string `json:"note"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	app, err := h.store.Applications.GetByID(ctx, appID)
+	if errors.Is(err, store.ErrNotFound) {
+		err = apperr.NotFound("application not found")
+	}
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if app.InfluencerID != userID {
+		fail(c, apperr.Forbidden("not your application"))
+		return
+	}
+
+	sub := models.DeliverableSubmission{
+		DeliverableID: req.DeliverableID,
+		Kind:          req.Kind,
+		URL:           req.URL,
+		Note:          req.Note,
+		Status:        models.SubmissionPending,
+	}
+	err = h.deliverables.Submit(ctx, app, &sub)
+	switch {
+	case errors.Is(err, services.ErrNotAccepted):
+		err = apperr.Conflict("application isn't accepted").Wrap(err)
+	case errors.Is(err, services.ErrCampaignNotRunning):
+		err = apperr.Conflict("campaign isn't taking submissions").Wrap(err)
+	case errors.Is(err, services.ErrDeliverableDone):
+		err = apperr.Conflict("deliverable is already completed").Wrap(err)
+	case errors.Is(err, store.ErrNotFound):
+		err = apperr.NotFound("deliverable not found").Wrap(err)
+	}
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, sub)
+}
+
+// POST /api/submission/:id/approve
+// Approves a pending submission, with an optional comment.
+func (h *DeliverableController) Approve(c *gin.Context) {
+	var req struct {
+		Comment string `json:"comment"`
+	}
+	// The body is optional.
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+	h.review(c, true, req.Comment)
+}
+
+// POST /api/submission/:id/request-revision
+// Sends a pending submission back with a comment on what to change.
+func (h *DeliverableController) RequestRevision(c *gin.Context) {
+	var req struct {
+		Comment string `json:"comment" binding:"required"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+	h.review(c, false, req.Comment)
+}
+
+func (h *DeliverableController) review(c *gin.Context, approve bool, comment string) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	_, d, err := h.deliverables.Submission(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		err = apperr.NotFound("submission not found")
+	}
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	campaign, err := managedCampaign(ctx, h.store.Campaigns, h.orgs, userID, d.CampaignID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if campaign.Finished() {
+		fail(c, apperr.Conflict("campaign is "+campaign.Status))
+		return
+	}
+
+	sub, err := h.deliverables.Review(ctx, userID, id, approve, comment)
+	switch {
+	case errors.Is(err, services.ErrSubmissionReviewed):
+		err = apperr.Conflict("submission was already reviewed").Wrap(err)
+	case errors.Is(err, store.ErrNotFound):
+		err = apperr.NotFound("submission not found").Wrap(err)
+	}
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, sub)
+}
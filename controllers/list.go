@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"InfluenceIQ/apperr"
+	"InfluenceIQ/store"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// query reads typed query-string parameters, collecting a field error for
+// each invalid one so that they can all be reported together.
+type query struct {
+	c    *gin.Context
+	errs []apperr.FieldError
+}
+
+func newQuery(c *gin.Context) *query {
+	return &query{c: c}
+}
+
+func (q *query) invalid(name, code, message string) {
+	q.errs = append(q.errs, apperr.FieldError{Field: name, Code: code, Message: message})
+}
+
+func (q *query) int(name string) int {
+	v := q.c.Query(name)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		q.invalid(name, "invalid", name+" must be a positive integer")
+		return 0
+	}
+	return n
+}
+
+func (q *query) float(name string) *float64 {
+	v := q.c.Query(name)
+	if v == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		q.invalid(name, "invalid", name+" must be a non-negative number")
+		return nil
+	}
+	return &f
+}
+
+// time accepts RFC 3339 timestamps or plain dates (midnight UTC).
+func (q *query) time(name string) *time.Time {
+	v := q.c.Query(name)
+	if v == "" {
+		return nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return &t
+		}
+	}
+	q.invalid(name, "invalid", name+" must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+	return nil
+}
+
+func (q *query) oneOf(name string, allowed ...string) string {
+	v := q.c.Query(name)
+	if v == "" {
+		return ""
+	}
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	q.invalid(name, "oneof", name+" must be one of: "+strings.Join(allowed, ", "))
+	return ""
+}
+
+// list reads the limit, sort and cursor parameters shared by all listings.
+func list[T any](q *query, spec store.SortSpec[T]) store.ListOptions {
+	var opts store.ListOptions
+
+	if v := q.c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > store.MaxLimit {
+			q.invalid("limit", "range", "limit must be between 1 and "+strconv.Itoa(store.MaxLimit))
+		}
+		opts.Limit = n
+	}
+
+	sort, err := spec.ParseSort(q.c.Query("sort"))
+	if err != nil {
+		q.invalid("sort", "oneof", "sort must be one of: "+strings.Join(spec.FieldNames(), ", ")+
+			" (prefix with - for descending)")
+		return opts
+	}
+	opts.Sort = sort
+
+	if v := q.c.Query("cursor"); v != "" {
+		if opts.Cursor, err = store.DecodeCursor(v); err != nil {
+			q.invalid("cursor", "invalid", "cursor is malformed")
+			return opts
+		}
+	}
+
+	normalized, err := spec.Normalize(opts)
+	if errors.Is(err, store.ErrInvalidCursor) {
+		q.invalid("cursor", "invalid", "cursor does not match the requested sort")
+	}
+	return normalized
+}
+
+// err returns a validation error listing every invalid parameter, or nil.
+func (q *query) err() error {
+	if len(q.errs) == 0 {
+		return nil
+	}
+	return apperr.Validation("invalid query parameters", q.errs...)
+}
+
+// respondPage writes a page of results with cursors and ready-made links
+// to the neighbouring pages, which keep the request's other parameters.
+func respondPage[T any](c *gin.Context, page store.Page[T], opts store.ListOptions) {
+	items := page.Items
+	if items == nil {
+		items = []T{}
+	}
+
+	pagination := gin.H{"limit": opts.Limit, "sort": opts.Sort.String()}
+	link := func(name string, cursor *store.Cursor) {
+		if cursor == nil {
+			pagination[name+"_cursor"] = nil
+			pagination[name] = nil
+			return
+		}
+		encoded := cursor.Encode()
+		params := c.Request.URL.Query()
+		params.Set("cursor", encoded)
+		pagination[name+"_cursor"] = encoded
+		pagination[name] = c.Request.URL.Path + "?" + params.Encode()
+	}
+	link("next", page.Next)
+	link("prev", page.Prev)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": items, "pagination": pagination})
+}
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"InfluenceIQ/apperr"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAController struct {
+	users store.UserRepository
+	mfa   *services.MFAService
+}
+
+func NewMFAController(users store.UserRepository, mfa *services.MFAService) *MFAController {
+	return &MFAController{users: users, mfa: mfa}
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// GET /api/auth/mfa
+func (h *MFAController) Status(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.users.GetByID(ctx, userID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	status, err := h.mfa.Status(ctx, user)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond(c, http.StatusOK, status)
+}
+
+// POST /api/auth/mfa/totp
+// Starts setting up an authenticator app. 2FA is only turned on once a code
+// from the app is confirmed.
+func (h *MFAController) Enroll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.users.GetByID(ctx, userID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	enrollment, err := h.mfa.Enroll(ctx, user)
+	if err != nil {
+		fail(c, mfaError(err))
+		return
+	}
+	respond(c, http.StatusOK, enrollment)
+}
+
+// POST /api/auth/mfa/totp/confirm
+// Turns 2FA on and returns the recovery codes, which are only shown once.
+func (h *MFAController) Confirm(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req mfaCodeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	codes, err := h.mfa.Confirm(ctx, userID, req.Code)
+	if err != nil {
+		fail(c, mfaError(err))
+		return
+	}
+	respond(c, http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// DELETE /api/auth/mfa/totp
+func (h *MFAController) Disable(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.users.GetByID(ctx, userID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	if err := h.mfa.Disable(ctx, user, req.Code); err != nil {
+		fail(c, mfaError(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "two-factor authentication turned off"})
+}
+
+// POST /api/auth/mfa/recovery-codes
+// Replaces the recovery codes, e.g. when few are left.
+func (h *MFAController) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req mfaCodeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	codes, err := h.mfa.RegenerateRecoveryCodes(ctx, userID, req.Code)
+	if err != nil {
+		fail(c, mfaError(err))
+		return
+	}
+	respond(c, http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// POST /api/auth/mfa/setup
+// Starts setting up an authenticator app during a login that a policy
+// blocks until 2FA is on. The code from the app then goes to Verify.
+func (h *MFAController) Setup(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	enrollment, err := h.mfa.EnrollForChallenge(ctx, req.MFAToken)
+	if err != nil {
+		fail(c, mfaError(err))
+		return
+	}
+	respond(c, http.StatusOK, enrollment)
+}
+
+// POST /api/auth/mfa/verify
+// Finishes a login with a code from the authenticator app or a recovery
+// code. Wrong codes count as failed logins of the account (429 once it is
+// blocked).
+func (h *MFAController) Verify(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	login, err := h.mfa.Verify(ctx, req.MFAToken, req.Code, clientInfo(c))
+	if err != nil {
+		fail(c, loginBlocked(c, mfaError(err)))
+		return
+	}
+
+	data := authData(login.User, login.Tokens)
+	if login.RecoveryCodes != nil {
+		data["recovery_codes"] = login.RecoveryCodes
+	}
+	if login.RecoveryCodesLeft != nil {
+		data["recovery_codes_left"] = *login.RecoveryCodesLeft
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Login successful",
+		"data":    data,
+	})
+}
+
+// mfaError translates MFAService errors into API errors.
+func mfaError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFAToken):
+		return apperr.Unauthorized("Invalid or expired login; log in again").Wrap(err)
+	case errors.Is(err, services.ErrInvalidMFACode):
+		return apperr.Unauthorized("Invalid code").Wrap(err)
+	case errors.Is(err, services.ErrMFAAlreadyEnabled):
+		return apperr.Conflict("Two-factor authentication is already on").Wrap(err)
+	case errors.Is(err, services.ErrMFANotEnrolled):
+		return apperr.Conflict("Set up an authenticator app first").Wrap(err)
+	case errors.Is(err, services.ErrMFARequired):
+		return apperr.Forbidden("Two-factor authentication is required for your account").Wrap(err)
+	}
+	return err
+}
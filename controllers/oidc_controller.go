@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"InfluenceIQ/apperr"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCController struct {
+	oidc *services.OIDCService
+	mfa  *services.MFAService
+}
+
+func NewOIDCController(oidc *services.OIDCService, mfa *services.MFAService) *OIDCController {
+	return &OIDCController{oidc: oidc, mfa: mfa}
+}
+
+// GET /api/auth/oidc/providers
+func (h *OIDCController) Providers(c *gin.Context) {
+	respond(c, http.StatusOK, gin.H{"providers": h.oidc.Providers()})
+}
+
+// POST /api/auth/oidc/:provider/start
+// Returns the provider URL to send the user to. The frontend keeps the
+// state and checks that the provider redirects back with the same one.
+func (h *OIDCController) Start(c *gin.Context) {
+	h.start(c, nil)
+}
+
+// POST /api/auth/identities/:provider
+// Like Start, but finishing the login links the provider to the signed-in
+// user.
+func (h *OIDCController) StartLink(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	h.start(c, &userID)
+}
+
+func (h *OIDCController) start(c *gin.Context, linkUserID *int) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	authURL, state, err := h.oidc.Start(ctx, c.Param("provider"), linkUserID)
+	if err != nil {
+		fail(c, oidcError(err))
+		return
+	}
+	respond(c, http.StatusOK, gin.H{"authorization_url": authURL, "state": state})
+}
+
+// POST /api/auth/oidc/:provider/callback
+// Finishes the login with the code and state the provider redirected back
+// with and returns the same payload as a password login, including its
+// second factor challenge.
+func (h *OIDCController) Callback(c *gin.Context) {
+	var req struct {
+		Code  string `json:"code" binding:"required"`
+		State string `json:"state" binding:"required"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	login, err := h.oidc.Finish(ctx, c.Param("provider"), req.Code, req.State)
+	if err != nil {
+		fail(c, oidcError(err))
+		return
+	}
+
+	result, err := h.mfa.Login(ctx, login.User, clientInfo(c))
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	status, message := http.StatusOK, "Login successful"
+	switch {
+	case login.Created:
+		status, message = http.StatusCreated, "Signup successful"
+	case login.Linked:
+		message = "Account linked"
+	}
+	loginResponse(c, status, message, login.User, result)
+}
+
+// GET /api/auth/identities
+func (h *OIDCController) Identities(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	identities, err := h.oidc.Identities(ctx, userID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	respond(c, http.StatusOK, identities)
+}
+
+// DELETE /api/auth/identities/:provider
+func (h *OIDCController) Unlink(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.oidc.Unlink(ctx, userID, c.Param("provider"))
+	if errors.Is(err, store.ErrNotFound) {
+		err = apperr.NotFound("No identity linked at this provider").Wrap(err)
+	}
+	if err != nil {
+		fail(c, oidcError(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "identity unlinked"})
+}
+
+// oidcError translates OIDCService errors into API errors.
+func oidcError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrUnknownProvider):
+		return apperr.NotFound("Unknown identity provider").Wrap(err)
+	case errors.Is(err, services.ErrInvalidOAuthState):
+		return apperr.BadRequest("Invalid or expired login; start again").Wrap(err)
+	case errors.Is(err, services.ErrProviderRejected), errors.Is(err, services.ErrInvalidIDToken):
+		return apperr.Unauthorized("The identity provider login failed; start again").Wrap(err)
+	case errors.Is(err, services.ErrProviderUnavailable):
+		return apperr.New(apperr.CodeUpstream, "The identity provider is unavailable").Wrap(err)
+	case errors.Is(err, services.ErrIdentityLinked):
+		return apperr.Conflict("This provider account is linked to another user, or you already linked another account at this provider").Wrap(err)
+	case errors.Is(err, services.ErrEmailUnverified):
+		return apperr.Forbidden("The identity provider has not verified your email address").Wrap(err)
+	case errors.Is(err, services.ErrAccountNotLinkable):
+		return apperr.Conflict("An account with this email address exists; log in with your password and verify the address, then link the provider").Wrap(err)
+	case errors.Is(err, services.ErrLastLoginMethod):
+		return apperr.Conflict("Set a password before unlinking your only identity provider").Wrap(err)
+	}
+	return err
+}
@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"InfluenceIQ/apperr"
+	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationController struct {
+	users store.UserRepository
+	orgs  *services.OrganizationService
+}
+
+func NewOrganizationController(users store.UserRepository, orgs *services.OrganizationService) *OrganizationController {
+	return &OrganizationController{users: users, orgs: orgs}
+}
+
+type organizationRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// POST /api/organization
+// The creator becomes its owner, and their campaigns move into it.
+func (h *OrganizationController) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req organizationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	org, err := h.orgs.Create(ctx, userID, req.Name)
+	if err != nil {
+		fail(c, orgError(err))
+		return
+	}
+	respond(c, http.StatusCreated, org)
+}
+
+// GET /api/organization
+func (h *OrganizationController) Get(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	org, err := h.orgs.Get(ctx, userID)
+	if err != nil {
+		fail(c, orgError(err))
+		return
+	}
+	respond(c, http.StatusOK, org)
+}
+
+// PUT /api/organization
+func (h *OrganizationController) Rename(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req organizationRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	org, err := h.orgs.Rename(ctx, userID, req.Name)
+	if err != nil {
+		fail(c, orgError(err))
+		return
+	}
+	respond(c, http.StatusOK, org)
+}
+
+// DELETE /api/organization
+// Campaigns go back to the brands who created them.
+func (h *OrganizationController) Delete(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.orgs.Delete(ctx, userID); err != nil {
+		fail(c, orgError(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "organization deleted"})
+}
+
+// PUT /api/organization/members/:user_id
+func (h *OrganizationController) SetMemberRole(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	memberID, ok := pathID(c, "user_id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required,oneof=owner admin member"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.orgs.SetMemberRole(ctx, userID, memberID, req.Role); err != nil {
+		fail(c, orgError(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "role updated"})
+}
+
+// DELETE /api/organization/members/:user_id
+// Removing yourself leaves the organization.
+func (h *OrganizationController) RemoveMember(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	memberID, ok := pathID(c, "user_id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.orgs.RemoveMember(ctx, userID, memberID); err != nil {
+		fail(c, orgError(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "member removed"})
+}
+
+// POST /api/organization/invitations
+func (h *OrganizationController) Invite(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+		Role  string `json:"role" binding:"omitempty,oneof=admin member"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.OrgRoleMember
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	inviter, err := h.users.GetByID(ctx, userID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	inv, err := h.orgs.Invite(ctx, inviter, req.Email, req.Role)
+	if err != nil {
+		fail(c, orgError(err))
+		return
+	}
+	respond(c, http.StatusCreated, inv)
+}
+
+// GET /api/organization/invitations
+func (h *OrganizationController) ListInvitations(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	invitations, err := h.orgs.ListInvitations(ctx, userID)
+	if err != nil {
+		fail(c, orgError(err))
+		return
+	}
+	respond(c, http.StatusOK, invitations)
+}
+
+// DELETE /api/organization/invitations/:id
+func (h *OrganizationController) RevokeInvitation(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.orgs.RevokeInvitation(ctx, userID, id); err != nil {
+		fail(c, orgError(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "invitation revoked"})
+}
+
+// POST /api/organization/invitations/accept
+// Joins with {"token"} from the emailed link, which only works for the
+// invited email address.
+func (h *OrganizationController) Accept(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.users.GetByID(ctx, userID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	org, err := h.orgs.Accept(ctx, user, req.Token)
+	if err != nil {
+		fail(c, orgError(err))
+		return
+	}
+	respond(c, http.StatusOK, org)
+}
+
+// orgError translates OrganizationService errors into API errors.
+func orgError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrNoOrganization):
+		return apperr.NotFound("You don't belong to an organization").Wrap(err)
+	case errors.Is(err, services.ErrInOrganization):
+		return apperr.Conflict("You already belong to an organization; leave it first").Wrap(err)
+	case errors.Is(err, services.ErrOrgPermission):
+		return apperr.Forbidden("Your role in the organization doesn't allow this").Wrap(err)
+	case errors.Is(err, services.ErrLastOwner):
+		return apperr.Conflict("The organization needs an owner; make someone else owner first").Wrap(err)
+	case errors.Is(err, services.ErrAlreadyMember):
+		return apperr.Conflict("That user is already a member").Wrap(err)
+	case errors.Is(err, services.ErrInvalidInvitation):
+		return apperr.BadRequest("Invalid or expired invitation, or it was sent to another email address").Wrap(err)
+	case errors.Is(err, store.ErrNotFound):
+		return apperr.NotFound("Not found in your organization").Wrap(err)
+	}
+	return err
+}
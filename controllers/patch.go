@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"InfluenceIQ/apperr"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// applyMergePatch applies the request body, a JSON merge patch (RFC 7386),
+// to dst: members of the patch replace those of dst, null members reset
+// them to their zero value and members dst doesn't have are rejected. The
+// result is validated like a bound request body.
+func applyMergePatch(c *gin.Context, dst any) bool {
+	var body any
+	if err := c.ShouldBindBodyWith(&body, binding.JSON); err != nil {
+		fail(c, apperr.FromBinding(err))
+		return false
+	}
+	patch, ok := body.(map[string]any)
+	if !ok {
+		fail(c, apperr.BadRequest("request body must be a JSON object"))
+		return false
+	}
+
+	var doc map[string]any
+	current, err := json.Marshal(dst)
+	if err == nil {
+		err = json.Unmarshal(current, &doc)
+	}
+	if err != nil {
+		fail(c, err)
+		return false
+	}
+
+	var unknown []apperr.FieldError
+	for name := range patch {
+		if _, ok := doc[name]; !ok {
+			unknown = append(unknown, apperr.FieldError{
+				Field:   name,
+				Code:    "read_only",
+				Message: name + " can't be changed here",
+			})
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Slice(unknown, func(i, j int) bool { return unknown[i].Field < unknown[j].Field })
+		fail(c, apperr.Validation("request validation failed", unknown...))
+		return false
+	}
+
+	merged, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		fail(c, err)
+		return false
+	}
+	// Members the merge removed must end up as zero values.
+	reflect.ValueOf(dst).Elem().SetZero()
+	if err := binding.JSON.BindBody(merged, dst); err != nil {
+		fail(c, apperr.FromBinding(err))
+		return false
+	}
+	return true
+}
+
+// mergePatch merges patch into target as RFC 7386 describes.
+func mergePatch(target, patch map[string]any) map[string]any {
+	for name, value := range patch {
+		switch value := value.(type) {
+		case nil:
+			delete(target, name)
+		case map[string]any:
+			t, _ := target[name].(map[string]any)
+			if t == nil {
+				t = map[string]any{}
+			}
+			target[name] = mergePatch(t, value)
+		default:
+			target[name] = value
+		}
+	}
+	return target
+}
+
+// etag is the entity tag of a resource at version.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatch reports whether the request's If-Match header, if any, matches
+// version. Weak tags never match, as RFC 9110 requires.
+func ifMatch(c *gin.Context, version int) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag(version) {
+			return true
+		}
+	}
+	return false
+}
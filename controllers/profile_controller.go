@@ -1,85 +1,169 @@
 package controllers
 
 import (
+	"InfluenceIQ/apperr"
 	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+type ProfileController struct {
+	store  *store.Store
+	tokens *services.TokenService
+}
+
+func NewProfileController(s *store.Store, tokens *services.TokenService) *ProfileController {
+	return &ProfileController{store: s, tokens: tokens}
+}
+
+// claimAccountType makes accountType the role of a user who doesn't have
+// one yet and reports whether it did. Brands and influencers can't switch,
+// and admins keep their role whatever their profile says.
+func claimAccountType(ctx context.Context, tx *store.Store, user *models.User, accountType string) (bool, error) {
+	switch user.Role {
+	case models.RoleViewer:
+		if err := tx.Users.SetRole(ctx, user.ID, accountType); err != nil {
+			return false, err
+		}
+		user.Role = accountType
+		return true, nil
+	case models.RoleAdmin, accountType:
+		return false, nil
+	}
+	return false, apperr.Validation("account type can't change", apperr.FieldError{
+		Field:   "account_type",
+		Code:    "immutable",
+		Message: "account_type must stay " + user.Role,
+	})
+}
+
+// saveProfile runs save in a transaction after claiming the profile's
+// account type for the current user. When that changed the user's role it
+// reissues their tokens, which then carry the new role.
+func (h *ProfileController) saveProfile(ctx context.Context, c *gin.Context, p *models.Profile, save func(tx *store.Store) error) (*services.TokenPair, error) {
+	var user *models.User
+	var roleChanged bool
+	err := h.store.WithTx(ctx, func(tx *store.Store) error {
+		var err error
+		if user, err = tx.Users.GetByID(ctx, p.UserID); err != nil {
+			return err
+		}
+		if roleChanged, err = claimAccountType(ctx, tx, user, p.AccountType); err != nil {
+			return err
+		}
+		return save(tx)
+	})
+	if err != nil || !roleChanged {
+		return nil, err
+	}
+	return h.tokens.Reissue(ctx, user, c.GetString("jti"), c.GetTime("token_expires_at"), c.GetString("sid"), clientInfo(c))
+}
+
+// withTokens adds reissued tokens to a response body.
+func withTokens(body gin.H, pair *services.TokenPair) gin.H {
+	if pair != nil {
+		body["tokens"] = pair
+	}
+	return body
+}
+
 // POST /api/profile/create
-func CreateProfileHandler(c *gin.Context) {
+func (h *ProfileController) CreateProfileHandler(c *gin.Context) {
 	var input models.Profile
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+	if !bindJSON(c, &input) {
 		return
 	}
 
 	userID := c.GetInt("user_id") // from JWT middleware
 	input.UserID = userID
+	input.VerifiedAt = nil // only admins verify profiles
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	if err := models.CreateProfile(ctx, &input); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create profile"})
+	pair, err := h.saveProfile(ctx, c, &input, func(tx *store.Store) error {
+		err := tx.Profiles.Create(ctx, &input)
+		if errors.Is(err, store.ErrConflict) {
+			err = apperr.Conflict("Profile already exists").Wrap(err)
+		}
+		return err
+	})
+	if err != nil {
+		fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Profile created", "profile": input})
+	c.JSON(http.StatusCreated, withTokens(gin.H{"success": true, "message": "Profile created", "data": input}, pair))
 }
 
 // GET /api/profile/me
-func GetMyProfileHandler(c *gin.Context) {
+func (h *ProfileController) GetMyProfileHandler(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	profile, err := models.GetProfileByUserID(ctx, userID)
+	profile, err := h.store.Profiles.GetByUserID(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		fail(c, apperr.NotFound("Profile not found"))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, profile)
+	respond(c, http.StatusOK, profile)
 }
 
 // PUT /api/profile/update
-func UpdateMyProfileHandler(c *gin.Context) {
+func (h *ProfileController) UpdateMyProfileHandler(c *gin.Context) {
 	var input models.Profile
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+	if !bindJSON(c, &input) {
 		return
 	}
 
 	userID := c.GetInt("user_id")
 	input.UserID = userID
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	if err := models.UpdateProfile(ctx, &input); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+	pair, err := h.saveProfile(ctx, c, &input, func(tx *store.Store) error {
+		err := tx.Profiles.Update(ctx, &input)
+		if errors.Is(err, store.ErrNotFound) {
+			err = apperr.NotFound("Profile not found")
+		}
+		return err
+	})
+	if err != nil {
+		fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Profile updated"})
+	c.JSON(http.StatusOK, withTokens(gin.H{"success": true, "message": "Profile updated"}, pair))
 }
 
 // DELETE /api/profile/delete
-func DeleteMyProfileHandler(c *gin.Context) {
+func (h *ProfileController) DeleteMyProfileHandler(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	if err := models.DeleteProfile(ctx, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete profile"})
+	if err := h.store.Profiles.Delete(ctx, userID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			err = apperr.NotFound("Profile not found")
+		}
+		fail(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Profile deleted"})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Profile deleted"})
 }
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newProfileRouter serves the profile handlers on a memory store.
+func newProfileRouter(t *testing.T) (*gin.Engine, *store.Store) {
+	t.Helper()
+	st := memory.New()
+	tokens, err := services.NewTokenService(st, config.JWTConfig{Issuer: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl := NewProfileController(st, tokens)
+
+	r := newTestRouter()
+	r.POST("/profile/create", ctrl.CreateProfileHandler)
+	r.GET("/profile/me", ctrl.GetMyProfileHandler)
+	r.PUT("/profile/update", ctrl.UpdateMyProfileHandler)
+	r.DELETE("/profile/delete", ctrl.DeleteMyProfileHandler)
+	return r, st
+}
+
+func TestCreateProfile(t *testing.T) {
+	const influencer = `{"display_name":"Ada","account_type":"influencer","platforms":["tiktok"]}`
+	tests := []struct {
+		name string
+		role string
+		// existing is created before the request when set.
+		existing *models.Profile
+		body     string
+		code     int
+		// wantRole is the user's role afterwards; reissued tokens come
+		// back whenever it changed.
+		wantRole string
+	}{
+		{"viewer claims account type", models.RoleViewer, nil, influencer, http.StatusCreated, models.RoleInfluencer},
+		{"same account type", models.RoleInfluencer, nil, influencer, http.StatusCreated, models.RoleInfluencer},
+		{"admin keeps role", models.RoleAdmin, nil, influencer, http.StatusCreated, models.RoleAdmin},
+		{"brand can't become influencer", models.RoleBrand, nil, influencer, http.StatusUnprocessableEntity, models.RoleBrand},
+		{"already exists", models.RoleInfluencer, &models.Profile{DisplayName: "Ada", AccountType: models.RoleInfluencer},
+			influencer, http.StatusConflict, models.RoleInfluencer},
+		{"unknown account type", models.RoleViewer, nil, `{"display_name":"Ada","account_type":"admin"}`,
+			http.StatusUnprocessableEntity, models.RoleViewer},
+		{"unknown platform", models.RoleViewer, nil, `{"display_name":"Ada","account_type":"influencer","platforms":["myspace"]}`,
+			http.StatusUnprocessableEntity, models.RoleViewer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			r, st := newProfileRouter(t)
+			user := &models.User{Username: "ada", Email: "ada@example.com", Role: tt.role}
+			if err := st.Users.Create(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			if tt.existing != nil {
+				tt.existing.UserID = user.ID
+				if err := st.Profiles.Create(ctx, tt.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			w := serve(r, testRequest{
+				method: http.MethodPost,
+				path:   "/profile/create",
+				userID: user.ID,
+				body:   tt.body,
+				header: map[string]string{"Content-Type": "application/json"},
+			})
+			expectStatus(t, w, tt.code)
+
+			got, err := st.Users.GetByID(ctx, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Role != tt.wantRole {
+				t.Errorf("role = %q, want %q", got.Role, tt.wantRole)
+			}
+			var resp struct {
+				Tokens *services.TokenPair `json:"tokens"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if reissued := resp.Tokens != nil; reissued != (tt.wantRole != tt.role) {
+				t.Errorf("tokens reissued = %v, want %v", reissued, !reissued)
+			}
+		})
+	}
+}
+
+func TestProfileLifecycle(t *testing.T) {
+	ctx := context.Background()
+	r, st := newProfileRouter(t)
+	user := &models.User{Username: "ada", Email: "ada@example.com", Role: models.RoleInfluencer}
+	if err := st.Users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	asJSON := map[string]string{"Content-Type": "application/json"}
+
+	steps := []struct {
+		name string
+		req  testRequest
+		code int
+		// want is the display name GET /profile/me returns afterwards, or
+		// empty when there is no profile.
+		want string
+	}{
+		{"get before create", testRequest{method: http.MethodGet, path: "/profile/me"}, http.StatusNotFound, ""},
+		{"update before create", testRequest{method: http.MethodPut, path: "/profile/update",
+			body: `{"display_name":"Ada","account_type":"influencer"}`, header: asJSON}, http.StatusNotFound, ""},
+		{"create", testRequest{method: http.MethodPost, path: "/profile/create",
+			body: `{"display_name":"Ada","account_type":"influencer","verified_at":"2026-01-01T00:00:00Z"}`, header: asJSON},
+			http.StatusCreated, "Ada"},
+		{"update", testRequest{method: http.MethodPut, path: "/profile/update",
+			body: `{"display_name":"Ada L.","account_type":"influencer"}`, header: asJSON}, http.StatusOK, "Ada L."},
+		{"switch account type", testRequest{method: http.MethodPut, path: "/profile/update",
+			body: `{"display_name":"Ada Inc.","account_type":"brand"}`, header: asJSON}, http.StatusUnprocessableEntity, "Ada L."},
+		{"delete", testRequest{method: http.MethodDelete, path: "/profile/delete"}, http.StatusOK, ""},
+		{"delete again", testRequest{method: http.MethodDelete, path: "/profile/delete"}, http.StatusNotFound, ""},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			step.req.userID = user.ID
+			expectStatus(t, serve(r, step.req), step.code)
+
+			w := serve(r, testRequest{method: http.MethodGet, path: "/profile/me", userID: user.ID})
+			if step.want == "" {
+				expectStatus(t, w, http.StatusNotFound)
+				return
+			}
+			expectStatus(t, w, http.StatusOK)
+			profile := decode[models.Profile](t, w)
+			if profile.DisplayName != step.want {
+				t.Errorf("display name = %q, want %q", profile.DisplayName, step.want)
+			}
+			// Only admins verify profiles.
+			if profile.Verified() {
+				t.Error("profile verified by its owner")
+			}
+		})
+	}
+}
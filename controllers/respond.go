@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"InfluenceIQ/apperr"
+	"InfluenceIQ/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respond writes the success envelope: {"success": true, "data": ...}.
+func respond(c *gin.Context, status int, data any) {
+	c.JSON(status, gin.H{"success": true, "data": data})
+}
+
+// fail hands err to middleware.ErrorHandler, which renders the error
+// envelope. Errors other than *apperr.Error are translated by apperr.From.
+func fail(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// bindJSON decodes the request body into dst, reporting malformed or
+// invalid input as a 400/422. It returns false if the handler should stop.
+func bindJSON(c *gin.Context, dst any) bool {
+	if err := c.ShouldBindJSON(dst); err != nil {
+		fail(c, apperr.FromBinding(err))
+		return false
+	}
+	return true
+}
+
+// pathID parses the integer path parameter name.
+func pathID(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		fail(c, apperr.Validation("invalid path parameter", apperr.FieldError{
+			Field:   name,
+			Code:    "invalid",
+			Message: name + " must be a positive integer",
+		}))
+		return 0, false
+	}
+	return id, true
+}
+
+// currentUserID returns the authenticated user's ID set by AuthMiddleware.
+func currentUserID(c *gin.Context) (int, bool) {
+	userID := c.GetInt("user_id")
+	if userID == 0 {
+		fail(c, apperr.Unauthorized("unauthorized"))
+		return 0, false
+	}
+	return userID, true
+}
+
+// clientInfo describes the client of the request for its session record.
+func clientInfo(c *gin.Context) services.Client {
+	return services.Client{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"InfluenceIQ/store"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSearchLimit caps the hits returned per kind.
+const maxSearchLimit = 50
+
+type SearchController struct {
+	search store.SearchRepository
+}
+
+func NewSearchController(search store.SearchRepository) *SearchController {
+	return &SearchController{search: search}
+}
+
+// GET /api/search?q=&type=all|campaigns|profiles&category=&account_type=&limit=
+func (h *SearchController) Search(c *gin.Context) {
+	q := newQuery(c)
+
+	text := strings.TrimSpace(c.Query("q"))
+	switch {
+	case text == "":
+		q.invalid("q", "required", "q is required")
+	case len(text) > 200:
+		q.invalid("q", "max", "q must be at most 200 characters")
+	}
+
+	kind := q.oneOf("type", "all", "campaigns", "profiles")
+	limit := store.DefaultLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			q.invalid("limit", "range", "limit must be between 1 and "+strconv.Itoa(maxSearchLimit))
+		}
+		limit = n
+	}
+
+	search := store.SearchQuery{
+		Text:        text,
+		Campaigns:   kind != "profiles",
+		Profiles:    kind != "campaigns",
+		Category:    c.Query("category"),
+		AccountType: q.oneOf("account_type", "influencer", "brand"),
+		Limit:       limit,
+	}
+	if err := q.err(); err != nil {
+		fail(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	results, err := h.search.Search(ctx, search)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, results)
+}
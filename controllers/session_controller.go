@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"InfluenceIQ/apperr"
+	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionController struct {
+	tokens *services.TokenService
+}
+
+func NewSessionController(tokens *services.TokenService) *SessionController {
+	return &SessionController{tokens: tokens}
+}
+
+// session is a session as listed to its user.
+type session struct {
+	models.Session
+	Device  string `json:"device"`
+	Current bool   `json:"current"`
+}
+
+// GET /api/auth/sessions
+// Lists the user's active sessions, most recently used first. The one the
+// request was made with is marked current.
+func (h *SessionController) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	sessions, err := h.tokens.Sessions(ctx, userID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	sid := c.GetString("sid")
+	list := make([]session, len(sessions))
+	for i, s := range sessions {
+		list[i] = session{Session: s, Device: deviceName(s.UserAgent), Current: s.FamilyID == sid}
+	}
+	respond(c, http.StatusOK, list)
+}
+
+// DELETE /api/auth/sessions/:id
+func (h *SessionController) Revoke(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.tokens.RevokeSession(ctx, userID, id)
+	if errors.Is(err, services.ErrSessionNotFound) {
+		err = apperr.NotFound("session not found or already ended").Wrap(err)
+	}
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "session revoked"})
+}
+
+// DELETE /api/auth/sessions
+// Logs the user out everywhere, including this session.
+func (h *SessionController) RevokeAll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.tokens.RevokeSessions(ctx, userID); err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "all sessions revoked"})
+}
+
+// deviceName turns a User-Agent into a rough label such as "Chrome on
+// Windows". It only needs to help users recognise their own devices.
+func deviceName(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := ""
+	for _, b := range []struct{ token, name string }{
+		// Order matters: Edge and Opera also claim to be Chrome, and
+		// Chrome claims to be Safari.
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+		{"PostmanRuntime/", "Postman"},
+	} {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	platform := ""
+	for _, p := range []struct{ token, name string }{
+		// Android and iOS user agents also mention Linux and Mac OS X.
+		{"Android", "Android"},
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, p.token) {
+			platform = p.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+	return "Unknown device"
+}
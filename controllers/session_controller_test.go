@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store/memory"
+)
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	tokens, err := services.NewTokenService(st, config.JWTConfig{Issuer: "test", TTL: time.Minute, RefreshTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrl := NewSessionController(tokens)
+	r := newTestRouter()
+	r.GET("/sessions", ctrl.List)
+	r.DELETE("/sessions/:id", ctrl.Revoke)
+
+	var users []*models.User
+	for _, name := range []string{"ada", "bob"} {
+		u := &models.User{Username: name, Email: name + "@example.com", Role: models.RoleInfluencer}
+		if err := st.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tokens.Issue(ctx, u, services.Client{UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)"}); err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, u)
+	}
+	ada, bob := users[0], users[1]
+
+	list := func(t *testing.T, user *models.User) []models.Session {
+		t.Helper()
+		w := serve(r, testRequest{method: http.MethodGet, path: "/sessions", userID: user.ID})
+		expectStatus(t, w, http.StatusOK)
+		return decode[[]models.Session](t, w)
+	}
+	adas := list(t, ada)
+	if len(adas) != 1 || adas[0].UserID != ada.ID {
+		t.Fatalf("ada's sessions = %+v, want her one", adas)
+	}
+	path := "/sessions/" + strconv.Itoa(adas[0].ID)
+
+	steps := []struct {
+		name string
+		req  testRequest
+		code int
+		// wantAda is how many sessions ada has afterwards.
+		wantAda int
+	}{
+		{"signed out", testRequest{method: http.MethodDelete, path: path}, http.StatusUnauthorized, 1},
+		{"someone else's session", testRequest{method: http.MethodDelete, path: path, userID: bob.ID}, http.StatusNotFound, 1},
+		{"own session", testRequest{method: http.MethodDelete, path: path, userID: ada.ID}, http.StatusOK, 0},
+		{"already revoked", testRequest{method: http.MethodDelete, path: path, userID: ada.ID}, http.StatusNotFound, 0},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			expectStatus(t, serve(r, step.req), step.code)
+			if got := list(t, ada); len(got) != step.wantAda {
+				t.Errorf("ada has %d sessions, want %d", len(got), step.wantAda)
+			}
+			if got := list(t, bob); len(got) != 1 {
+				t.Errorf("bob has %d sessions, want 1", len(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"InfluenceIQ/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WellKnownController struct {
+	tokens *services.TokenService
+}
+
+func NewWellKnownController(tokens *services.TokenService) *WellKnownController {
+	return &WellKnownController{tokens: tokens}
+}
+
+// GET /.well-known/jwks.json
+// Serves the public keys access tokens are signed with, so that other
+// services can verify them. The document is a plain JWK Set rather than
+// the usual response envelope, as JWKS clients expect.
+func (h *WellKnownController) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.tokens.JWKS())
+}
@@ -0,0 +1,103 @@
+// Package database hides the differences between the supported SQL
+// backends behind a small query interface. Queries are written with
+// Postgres-style $N placeholders and NOW(); the SQLite backend accepts both.
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"InfluenceIQ/config"
+)
+
+// Dialect identifies the SQL flavour of a connection.
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// Row is a single result row, as returned by QueryRow.
+type Row interface {
+	Scan(dest ...any) error
+}
+
+// Rows is an iterator over a query result.
+type Rows interface {
+	Next() bool
+	Scan(dest ...any) error
+	Err() error
+	Close()
+}
+
+// Querier runs statements against a database or within a transaction.
+type Querier interface {
+	// Exec runs a statement and returns the number of rows it affected.
+	Exec(ctx context.Context, query string, args ...any) (int64, error)
+	Query(ctx context.Context, query string, args ...any) (Rows, error)
+	QueryRow(ctx context.Context, query string, args ...any) Row
+	Dialect() Dialect
+}
+
+// Tx is an open transaction.
+type Tx interface {
+	Querier
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// DB is a connection pool for one of the supported backends.
+type DB interface {
+	Querier
+	Begin(ctx context.Context) (Tx, error)
+	Ping(ctx context.Context) error
+	Close()
+}
+
+// Open connects to the database described by cfg. With replica URLs
+// configured it returns a *Routed; see Reader.
+func Open(ctx context.Context, cfg config.DatabaseConfig) (DB, error) {
+	primary, err := open(ctx, cfg)
+	if err != nil || len(cfg.ReplicaURLs) == 0 {
+		return primary, err
+	}
+	return newRouted(ctx, primary, cfg), nil
+}
+
+func open(ctx context.Context, cfg config.DatabaseConfig) (DB, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+
+	switch Dialect(cfg.Driver) {
+	case Postgres:
+		return OpenPostgres(ctx, cfg)
+	case SQLite:
+		return OpenSQLite(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+}
+
+// InTx runs fn inside a transaction on db, committing when fn returns nil
+// and rolling back on error or panic.
+func InTx(ctx context.Context, db DB, fn func(tx Tx) error) (err error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(ctx)
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
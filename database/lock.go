@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+)
+
+// Locker is implemented by databases that can hold a lock for every
+// instance sharing them.
+type Locker interface {
+	// TryLock takes the lock named name without waiting. ok is false if
+	// another holder has it; otherwise release must be called to let it go.
+	TryLock(ctx context.Context, name string) (release func(), ok bool, err error)
+}
+
+// TryLock takes the named lock on db. Postgres uses a session-level
+// advisory lock so that it is also exclusive across instances; other
+// databases serve a single instance and always grant it.
+func TryLock(ctx context.Context, db DB, name string) (release func(), ok bool, err error) {
+	if l, isLocker := db.(Locker); isLocker {
+		return l.TryLock(ctx, name)
+	}
+	return func() {}, true, nil
+}
+
+// TryLock holds the lock on a connection taken from the pool until it is
+// released, since advisory locks belong to the session that took them.
+func (d *PostgresDB) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := d.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	key := lockKey(name)
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&ok); err != nil || !ok {
+		conn.Release()
+		return nil, false, err
+	}
+
+	release := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			// Closing the session is the only other way to let go of the
+			// lock; the pool drops closed connections.
+			_ = conn.Conn().Close(ctx)
+		}
+		conn.Release()
+	}
+	return release, true, nil
+}
+
+// TryLock takes the lock on the primary.
+func (r *Routed) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	return TryLock(ctx, r.primary, name)
+}
+
+// lockKey maps a lock name to an advisory lock key.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
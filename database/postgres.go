@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"InfluenceIQ/config"
+)
+
+// PostgresDB is a DB backed by a pgx connection pool.
+type PostgresDB struct {
+	pgQuerier
+	pool *pgxpool.Pool
+}
+
+// OpenPostgres creates a pgx pool sized by cfg and verifies it with a ping.
+func OpenPostgres(ctx context.Context, cfg config.DatabaseConfig) (*PostgresDB, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.MaxConns = cfg.MaxConns
+	poolConfig.MinConns = cfg.MinConns
+	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return NewPostgres(pool), nil
+}
+
+// NewPostgres wraps an existing pgx pool.
+func NewPostgres(pool *pgxpool.Pool) *PostgresDB {
+	return &PostgresDB{pgQuerier: pgQuerier{pool}, pool: pool}
+}
+
+// Pool exposes the underlying pgx pool for Postgres-specific features.
+func (d *PostgresDB) Pool() *pgxpool.Pool {
+	return d.pool
+}
+
+func (d *PostgresDB) Begin(ctx context.Context) (Tx, error) {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pgTx{pgQuerier: pgQuerier{tx}, tx: tx}, nil
+}
+
+func (d *PostgresDB) Ping(ctx context.Context) error {
+	return d.pool.Ping(ctx)
+}
+
+func (d *PostgresDB) Close() {
+	d.pool.Close()
+}
+
+// pgxQuerier is the subset of pgx shared by pools and transactions.
+type pgxQuerier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type pgQuerier struct {
+	q pgxQuerier
+}
+
+func (p pgQuerier) Exec(ctx context.Context, query string, args ...any) (int64, error) {
+	tag, err := p.q.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (p pgQuerier) Query(ctx context.Context, query string, args ...any) (Rows, error) {
+	return p.q.Query(ctx, query, args...)
+}
+
+func (p pgQuerier) QueryRow(ctx context.Context, query string, args ...any) Row {
+	return p.q.QueryRow(ctx, query, args...)
+}
+
+func (pgQuerier) Dialect() Dialect {
+	return Postgres
+}
+
+type pgTx struct {
+	pgQuerier
+	tx pgx.Tx
+}
+
+func (t *pgTx) Commit(ctx context.Context) error {
+	return t.tx.Commit(ctx)
+}
+
+func (t *pgTx) Rollback(ctx context.Context) error {
+	return t.tx.Rollback(ctx)
+}
@@ -0,0 +1,241 @@
+package database
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"InfluenceIQ/config"
+)
+
+// Routed is a DB that sends everything to the primary except queries made
+// through Reader, which are spread round-robin over the healthy read
+// replicas. Replicas are health-checked by Monitor; when none is healthy,
+// reads fall back to the primary.
+type Routed struct {
+	primary  DB
+	replicas []*replica
+	next     atomic.Uint64
+	interval time.Duration
+}
+
+type replica struct {
+	index   int
+	cfg     config.DatabaseConfig
+	mu      sync.Mutex
+	db      DB // nil until the replica has been reached once
+	checked bool
+	healthy atomic.Bool
+}
+
+// newRouted wraps primary with a replica pool per URL in cfg.ReplicaURLs
+// and checks them once. Replicas that can't be reached yet are retried by
+// Monitor rather than failing startup.
+func newRouted(ctx context.Context, primary DB, cfg config.DatabaseConfig) *Routed {
+	r := &Routed{primary: primary, interval: cfg.ReplicaCheckInterval}
+	for i, url := range cfg.ReplicaURLs {
+		replicaCfg := cfg
+		replicaCfg.URL = url
+		replicaCfg.ReplicaURLs = nil
+		r.replicas = append(r.replicas, &replica{index: i, cfg: replicaCfg})
+	}
+	r.check(ctx)
+	return r
+}
+
+// Monitor re-checks replica health every ReplicaCheckInterval until ctx is
+// done. Run it as a server worker.
+func (r *Routed) Monitor(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.check(ctx)
+		}
+	}
+}
+
+func (r *Routed) check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, rep := range r.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rep.check(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (rep *replica) check(ctx context.Context) {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+
+	var err error
+	if rep.db == nil {
+		var db DB
+		if db, err = open(ctx, rep.cfg); err == nil {
+			rep.db = db
+		}
+	} else {
+		pingCtx, cancel := context.WithTimeout(ctx, rep.cfg.ConnectTimeout)
+		err = rep.db.Ping(pingCtx)
+		cancel()
+	}
+
+	// Log transitions only, plus the first failure.
+	healthy := err == nil
+	was := rep.healthy.Swap(healthy)
+	switch {
+	case healthy && !was:
+		log.Printf(" Replica %d is healthy", rep.index)
+	case !healthy && (was || !rep.checked) && ctx.Err() == nil:
+		log.Printf("Replica %d is unavailable: %v", rep.index, err)
+	}
+	rep.checked = true
+}
+
+// Reader returns a Querier for read-only queries. Each query goes to the
+// next healthy replica, unless ctx carries a session that has already
+// written, in which case it reads from the primary to see its own writes.
+func (r *Routed) Reader() Querier {
+	return routedReader{r}
+}
+
+func (r *Routed) pick(ctx context.Context) Querier {
+	if isPinned(ctx) {
+		return r.primary
+	}
+	n := len(r.replicas)
+	start := r.next.Add(1)
+	for i := range n {
+		rep := r.replicas[(start+uint64(i))%uint64(n)]
+		if rep.healthy.Load() {
+			return rep.db
+		}
+	}
+	return r.primary
+}
+
+// Primary returns the primary database.
+func (r *Routed) Primary() DB {
+	return r.primary
+}
+
+func (r *Routed) Exec(ctx context.Context, query string, args ...any) (int64, error) {
+	pin(ctx)
+	return r.primary.Exec(ctx, query, args...)
+}
+
+// Query and QueryRow read from the primary without pinning the session,
+// since reads that need the primary's freshest data, such as the token
+// denylist on every request, would otherwise keep all later reads off the
+// replicas. Statements other than SELECT, e.g. INSERT ... RETURNING, write
+// and pin it.
+func (r *Routed) Query(ctx context.Context, query string, args ...any) (Rows, error) {
+	if writes(query) {
+		pin(ctx)
+	}
+	return r.primary.Query(ctx, query, args...)
+}
+
+func (r *Routed) QueryRow(ctx context.Context, query string, args ...any) Row {
+	if writes(query) {
+		pin(ctx)
+	}
+	return r.primary.QueryRow(ctx, query, args...)
+}
+
+func (r *Routed) Begin(ctx context.Context) (Tx, error) {
+	pin(ctx)
+	return r.primary.Begin(ctx)
+}
+
+func (r *Routed) Dialect() Dialect {
+	return r.primary.Dialect()
+}
+
+func (r *Routed) Ping(ctx context.Context) error {
+	return r.primary.Ping(ctx)
+}
+
+func (r *Routed) Close() {
+	for _, rep := range r.replicas {
+		rep.mu.Lock()
+		if rep.db != nil {
+			rep.db.Close()
+		}
+		rep.mu.Unlock()
+	}
+	r.primary.Close()
+}
+
+type routedReader struct {
+	r *Routed
+}
+
+func (rr routedReader) Exec(ctx context.Context, query string, args ...any) (int64, error) {
+	// Writes never go to a replica, even through the reader.
+	return rr.r.Exec(ctx, query, args...)
+}
+
+func (rr routedReader) Query(ctx context.Context, query string, args ...any) (Rows, error) {
+	return rr.r.pick(ctx).Query(ctx, query, args...)
+}
+
+func (rr routedReader) QueryRow(ctx context.Context, query string, args ...any) Row {
+	return rr.r.pick(ctx).QueryRow(ctx, query, args...)
+}
+
+func (rr routedReader) Dialect() Dialect {
+	return rr.r.Dialect()
+}
+
+// Reader returns the Querier read-only queries should use: the replica
+// router of a Routed DB, or db itself.
+func Reader(db DB) Querier {
+	if r, ok := db.(*Routed); ok {
+		return r.Reader()
+	}
+	return db
+}
+
+type sessionKey struct{}
+
+// session records whether a request has written to the primary.
+type session struct {
+	pinned atomic.Bool
+}
+
+// WithSession returns a context that tracks read-your-writes: after a
+// statement writes to the primary with it, reads made with it through
+// Reader also go to the primary. Attach one per request.
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// writes reports whether query may change data: anything but a SELECT.
+// Common table expressions count as writes since they may hold one.
+func writes(query string) bool {
+	query = strings.TrimLeftFunc(query, unicode.IsSpace)
+	word := query[:len(query)-len(strings.TrimLeftFunc(query, unicode.IsLetter))]
+	return !strings.EqualFold(word, "SELECT")
+}
+
+func pin(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.pinned.Store(true)
+	}
+}
+
+func isPinned(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.pinned.Load()
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeDB records which statements reach it.
+type fakeDB struct {
+	queries []string
+}
+
+// noRows is an empty result.
+type noRows struct{}
+
+func (noRows) Next() bool        { return false }
+func (noRows) Scan(...any) error { return nil }
+func (noRows) Err() error        { return nil }
+func (noRows) Close()            {}
+
+func (d *fakeDB) Dialect() Dialect           { return Postgres }
+func (d *fakeDB) Ping(context.Context) error { return nil }
+func (d *fakeDB) Close()                     {}
+
+func (d *fakeDB) Exec(_ context.Context, query string, _ ...any) (int64, error) {
+	d.queries = append(d.queries, query)
+	return 1, nil
+}
+
+func (d *fakeDB) Query(_ context.Context, query string, _ ...any) (Rows, error) {
+	d.queries = append(d.queries, query)
+	return noRows{}, nil
+}
+
+func (d *fakeDB) QueryRow(_ context.Context, query string, _ ...any) Row {
+	d.queries = append(d.queries, query)
+	return noRows{}
+}
+
+func (d *fakeDB) Begin(context.Context) (Tx, error) {
+	return nil, nil
+}
+
+// newFakeRouted returns a Routed over a primary and one healthy replica.
+func newFakeRouted() (*Routed, *fakeDB, *fakeDB) {
+	primary, replicaDB := &fakeDB{}, &fakeDB{}
+	rep := &replica{db: replicaDB, checked: true}
+	rep.healthy.Store(true)
+	return &Routed{primary: primary, replicas: []*replica{rep}}, primary, replicaDB
+}
+
+func TestRoutedReadsAfterPrimaryStatements(t *testing.T) {
+	const listing = "SELECT id FROM campaigns"
+	tests := []struct {
+		name string
+		// before runs on the Routed DB with the request's session, as the
+		// request's earlier steps would.
+		before func(ctx context.Context, r *Routed)
+		want   string
+	}{
+		{
+			name:   "fresh request",
+			before: func(context.Context, *Routed) {},
+			want:   "replica",
+		},
+		{
+			name: "after authentication reads",
+			before: func(ctx context.Context, r *Routed) {
+				r.QueryRow(ctx, "\n\t\tSELECT EXISTS (SELECT 1 FROM denied_tokens WHERE jti = $1)")
+				r.Query(ctx, "SELECT\n\tid FROM sessions WHERE family_id = $1")
+			},
+			want: "replica",
+		},
+		{
+			name: "after an insert returning its ID",
+			before: func(ctx context.Context, r *Routed) {
+				r.QueryRow(ctx, "\n\t\tINSERT INTO campaigns (title) VALUES ($1)\n\t\tRETURNING id")
+			},
+			want: "primary",
+		},
+		{
+			name: "after an update",
+			before: func(ctx context.Context, r *Routed) {
+				r.Exec(ctx, "UPDATE campaigns SET title = $1")
+			},
+			want: "primary",
+		},
+		{
+			name: "after a transaction",
+			before: func(ctx context.Context, r *Routed) {
+				r.Begin(ctx)
+			},
+			want: "primary",
+		},
+		{
+			name: "after a common table expression",
+			before: func(ctx context.Context, r *Routed) {
+				r.QueryRow(ctx, "WITH moved AS (DELETE FROM sessions RETURNING id) SELECT count(*) FROM moved")
+			},
+			want: "primary",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, primary, replicaDB := newFakeRouted()
+			ctx := WithSession(context.Background())
+			tt.before(ctx, r)
+			primary.queries, replicaDB.queries = nil, nil
+
+			if _, err := r.Reader().Query(ctx, listing); err != nil {
+				t.Fatal(err)
+			}
+			got := "replica"
+			if len(primary.queries) == 1 {
+				got = "primary"
+			}
+			if got != tt.want {
+				t.Errorf("listing went to the %s, want the %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoutedReaderFallsBackToPrimary(t *testing.T) {
+	r, primary, _ := newFakeRouted()
+	r.replicas[0].healthy.Store(false)
+
+	r.Reader().QueryRow(context.Background(), "SELECT 1")
+	if len(primary.queries) != 1 {
+		t.Errorf("with no healthy replica the read went elsewhere: primary got %q", primary.queries)
+	}
+}
+
+func TestRoutedReaderNeverWritesToReplica(t *testing.T) {
+	r, primary, replicaDB := newFakeRouted()
+
+	if _, err := r.Reader().Exec(context.Background(), "DELETE FROM sessions"); err != nil {
+		t.Fatal(err)
+	}
+	if len(primary.queries) != 1 || len(replicaDB.queries) != 0 {
+		t.Errorf("write through the reader: primary got %q, replica got %q", primary.queries, replicaDB.queries)
+	}
+}
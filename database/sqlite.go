@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+
+	"InfluenceIQ/config"
+)
+
+// sqliteTimeFormat matches what the driver writes with _time_format=sqlite,
+// so timestamps produced by NOW() and by Go compare correctly as text.
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+func init() {
+	// Let shared queries call NOW() as they would on Postgres.
+	sqlite.MustRegisterScalarFunction("now", 0, func(_ *sqlite.FunctionContext, _ []driver.Value) (driver.Value, error) {
+		return time.Now().UTC().Format(sqliteTimeFormat), nil
+	})
+}
+
+// SQLiteDB is a DB backed by an embedded SQLite database file.
+type SQLiteDB struct {
+	sqlQuerier
+	db *sql.DB
+}
+
+// OpenSQLite opens the database file at cfg.URL, creating it if needed.
+// The path may be a plain file name or a "file:" URI; ":memory:" is also
+// accepted.
+func OpenSQLite(ctx context.Context, cfg config.DatabaseConfig) (*SQLiteDB, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(cfg.URL))
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(int(cfg.MaxConns))
+	db.SetConnMaxLifetime(cfg.MaxConnLifetime)
+	db.SetConnMaxIdleTime(cfg.MaxConnIdleTime)
+	if cfg.URL == ":memory:" {
+		// Every connection would otherwise get its own empty database.
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteDB{sqlQuerier: sqlQuerier{db}, db: db}, nil
+}
+
+// sqliteDSN adds the pragmas every connection needs: enforced foreign keys,
+// a busy timeout instead of immediate SQLITE_BUSY errors, WAL for concurrent
+// readers, and a sortable text encoding for time.Time values. Transactions
+// take the write lock up front (BEGIN IMMEDIATE) so that a read-then-write
+// transaction waits for its turn instead of failing with SQLITE_BUSY.
+func sqliteDSN(path string) string {
+	if path != ":memory:" && !strings.HasPrefix(path, "file:") {
+		path = "file:" + path
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite&_txlock=immediate"
+}
+
+func (d *SQLiteDB) Begin(ctx context.Context) (Tx, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteTx{sqlQuerier: sqlQuerier{tx}, tx: tx}, nil
+}
+
+func (d *SQLiteDB) Ping(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
+func (d *SQLiteDB) Close() {
+	d.db.Close()
+}
+
+// stdQuerier is the subset of database/sql shared by *sql.DB and *sql.Tx.
+type stdQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type sqlQuerier struct {
+	q stdQuerier
+}
+
+func (s sqlQuerier) Exec(ctx context.Context, query string, args ...any) (int64, error) {
+	res, err := s.q.ExecContext(ctx, query, normalizeArgs(args)...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s sqlQuerier) Query(ctx context.Context, query string, args ...any) (Rows, error) {
+	rows, err := s.q.QueryContext(ctx, query, normalizeArgs(args)...)
+	if err != nil {
+		return nil, err
+	}
+	return sqlRows{rows}, nil
+}
+
+func (s sqlQuerier) QueryRow(ctx context.Context, query string, args ...any) Row {
+	return s.q.QueryRowContext(ctx, query, normalizeArgs(args)...)
+}
+
+func (sqlQuerier) Dialect() Dialect {
+	return SQLite
+}
+
+// normalizeArgs stores times in UTC so that text comparisons in SQL agree
+// with chronological order.
+func normalizeArgs(args []any) []any {
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			args[i] = v.UTC()
+		case *time.Time:
+			if v != nil {
+				args[i] = v.UTC()
+			}
+		}
+	}
+	return args
+}
+
+type sqlRows struct {
+	*sql.Rows
+}
+
+func (r sqlRows) Close() {
+	r.Rows.Close()
+}
+
+type sqliteTx struct {
+	sqlQuerier
+	tx *sql.Tx
+}
+
+func (t *sqliteTx) Commit(ctx context.Context) error {
+	return t.tx.Commit()
+}
+
+func (t *sqliteTx) Rollback(ctx context.Context) error {
+	return t.tx.Rollback()
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"InfluenceIQ/config"
+)
+
+func TestNormalizeArgs(t *testing.T) {
+	paris := time.FixedZone("CEST", 2*60*60)
+	at := time.Date(2026, 5, 1, 14, 0, 0, 0, paris)
+	var nilTime *time.Time
+
+	tests := []struct {
+		name string
+		arg  any
+		want any
+	}{
+		{"time", at, at.UTC()},
+		{"time pointer", &at, at.UTC()},
+		{"nil time pointer", nilTime, nilTime},
+		{"other values", "2026-05-01", "2026-05-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeArgs([]any{tt.arg})[0]
+			if got != tt.want {
+				t.Errorf("normalizeArgs(%v) = %v, want %v", tt.arg, got, tt.want)
+			}
+			if tm, ok := got.(time.Time); ok && tm.Location() != time.UTC {
+				t.Errorf("location = %v, want UTC", tm.Location())
+			}
+		})
+	}
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	const pragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite&_txlock=immediate"
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"data/app.db", "file:data/app.db?" + pragmas},
+		{"file:app.db", "file:app.db?" + pragmas},
+		{"file:app.db?mode=ro", "file:app.db?mode=ro&" + pragmas},
+		{":memory:", ":memory:?" + pragmas},
+	}
+	for _, tt := range tests {
+		if got := sqliteDSN(tt.path); got != tt.want {
+			t.Errorf("sqliteDSN(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestOpenSQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenSQLite(ctx, config.DatabaseConfig{URL: filepath.Join(t.TempDir(), "test.db"), MaxConns: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	pragmas := []struct {
+		name string
+		want string
+	}{
+		{"foreign_keys", "1"},
+		{"busy_timeout", "5000"},
+		{"journal_mode", "wal"},
+	}
+	for _, p := range pragmas {
+		var got string
+		if err := db.QueryRow(ctx, "PRAGMA "+p.name).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != p.want {
+			t.Errorf("%s = %s, want %s", p.name, got, p.want)
+		}
+	}
+
+	// Times written from Go and by NOW() compare in chronological order.
+	if _, err := db.Exec(ctx, `CREATE TABLE events (at DATETIME NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	earlier := time.Now().Add(-time.Hour).In(time.FixedZone("UTC+14", 14*60*60))
+	if _, err := db.Exec(ctx, `INSERT INTO events (at) VALUES ($1)`, earlier); err != nil {
+		t.Fatal(err)
+	}
+	var before bool
+	if err := db.QueryRow(ctx, `SELECT at < NOW() FROM events`).Scan(&before); err != nil {
+		t.Fatal(err)
+	}
+	if !before {
+		t.Error("a time an hour ago doesn't sort before NOW()")
+	}
+	var got time.Time
+	if err := db.QueryRow(ctx, `SELECT at FROM events`).Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(earlier) {
+		t.Errorf("read back %v, want %v", got, earlier)
+	}
+}
@@ -0,0 +1,81 @@
+// Package mailer sends the application's emails through a pluggable
+// Mailer: SMTP in production, or an outbox directory of .eml files for
+// local development and tests.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"InfluenceIQ/config"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the Mailer selected by cfg.Driver, which Config.Validate has
+// already checked.
+func New(cfg config.MailConfig) Mailer {
+	if cfg.Driver == "smtp" {
+		return NewSMTP(cfg)
+	}
+	return NewOutbox(cfg)
+}
+
+// encode renders msg as an RFC 5322 message from the given sender.
+func encode(from string, msg Message) ([]byte, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("mail from: %w", err)
+	}
+	recipient, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("mail to: %w", err)
+	}
+
+	var b bytes.Buffer
+	header := func(key, value string) {
+		// Drop line breaks so that values can't inject headers.
+		value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+		fmt.Fprintf(&b, "%s: %s\r\n", key, value)
+	}
+	header("From", sender.String())
+	header("To", recipient.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+rand.Text()+"@"+domain(sender.Address)+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	b.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write([]byte(strings.ReplaceAll(msg.Text, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func domain(address string) string {
+	_, d, _ := strings.Cut(address, "@")
+	return d
+}
@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"InfluenceIQ/config"
+)
+
+// Outbox writes each message to its own .eml file instead of sending it,
+// for local development and tests. The files open in any mail client.
+type Outbox struct {
+	dir  string
+	from string
+}
+
+func NewOutbox(cfg config.MailConfig) *Outbox {
+	return &Outbox{dir: cfg.OutboxDir, from: cfg.From}
+}
+
+func (m *Outbox) Send(ctx context.Context, msg Message) error {
+	data, err := encode(m.from, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return fmt.Errorf("outbox: %w", err)
+	}
+
+	// Timestamped names keep the files in the order they were sent.
+	name := time.Now().UTC().Format("20060102T150405.000000000Z") + "-" + rand.Text()[:8] + ".eml"
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("outbox: %w", err)
+	}
+	log.Printf(" Mail to %s written to %s", msg.To, path)
+	return nil
+}
@@ -0,0 +1,90 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+
+	"InfluenceIQ/config"
+)
+
+// SMTP sends messages through an SMTP server, upgrading to TLS with
+// STARTTLS whenever the server supports it.
+type SMTP struct {
+	addr     string
+	host     string
+	from     string
+	username string
+	password string
+}
+
+func NewSMTP(cfg config.MailConfig) *SMTP {
+	host, _, _ := net.SplitHostPort(cfg.SMTPAddr)
+	return &SMTP{
+		addr:     cfg.SMTPAddr,
+		host:     host,
+		from:     cfg.From,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+	}
+}
+
+func (m *SMTP) Send(ctx context.Context, msg Message) error {
+	data, err := encode(m.from, msg)
+	if err != nil {
+		return err
+	}
+	// encode has validated both addresses.
+	sender, _ := mail.ParseAddress(m.from)
+	recipient, _ := mail.ParseAddress(msg.To)
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("smtp dial: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if m.username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted
+		// connection to anything but localhost.
+		if err := c.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := c.Mail(sender.Address); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	if err := c.Rcpt(recipient.Address); err != nil {
+		return fmt.Errorf("smtp rcpt to: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	return c.Quit()
+}
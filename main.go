@@ -1,36 +1,131 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 
+	"InfluenceIQ/apperr"
 	"InfluenceIQ/config"
+	"InfluenceIQ/database"
+	"InfluenceIQ/mailer"
 	"InfluenceIQ/middleware"
+	"InfluenceIQ/migrations"
 	"InfluenceIQ/routes"
+	"InfluenceIQ/scheduler"
+	"InfluenceIQ/server"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/sqlstore"
 )
 
 func main() {
-	// Load environment variables
-	err := godotenv.Load()
+	// Load and validate configuration
+	cfg, err := config.Load(os.Args[1:])
 	if err != nil {
-		log.Println("  Warning: .env file not found, using system environment")
+		log.Fatalf("Invalid configuration: %v", err)
 	}
 
 	// Connect to DB
-	config.ConnectDB()
-	defer config.CloseDB()
+	db, err := database.Open(context.Background(), cfg.Database)
+	if err != nil {
+		log.Fatalf("Unable to connect to %s database: %v", cfg.Database.Driver, err)
+	}
+	log.Printf(" Connected to %s", cfg.Database.Driver)
+
+	// Optionally bring the schema up to date before serving
+	if cfg.Database.AutoMigrate {
+		runMigrations(db)
+	}
 
-	// Initialize router
-	router := gin.Default()
-	router.Use(middleware.CORSMiddleware())
+	// Initialize router. ErrorHandler sits outside Recovery so that panics
+	// are rendered in the same error envelope as everything else.
+	apperr.UseJSONFieldNames()
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
+	router.Use(gin.Logger(), middleware.ErrorHandler(), middleware.Recovery(), middleware.CORSMiddleware(), middleware.DBSession())
+	router.NoRoute(middleware.NotFound)
 
 	// Create /api group for all routes
+	st := sqlstore.New(db)
+	tokens, err := services.NewTokenService(st, cfg.JWT)
+	if err != nil {
+		log.Fatalf("Unable to load JWT keys: %v", err)
+	}
+	mail := mailer.New(cfg.Mail)
+	accounts := services.NewAccountService(st, mail, cfg.Account)
+	guard := services.NewLoginGuard(st, accounts, cfg.Login)
 	api := router.Group("/api")
-	routes.RegisterAuthRoutes(api)
+	routes.RegisterAuthRoutes(api, st, tokens, accounts, guard, cfg)
+	routes.RegisterWellKnownRoutes(router, tokens)
+
+	// Serve until SIGINT/SIGTERM, then drain requests, stop workers and
+	// only then release the database.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Start server
-	log.Println(" Server running on http://localhost:8080")
-	router.Run(":8080")
+	srv := server.New(cfg.HTTP, router)
+	srv.Go(tokens.PurgeExpired)
+	srv.Go(guard.PurgeStale)
+	if routed, ok := db.(*database.Routed); ok {
+		log.Printf(" Routing reads across %d replica(s)", len(cfg.Database.ReplicaURLs))
+		srv.Go(routed.Monitor)
+	}
+	if cfg.Jobs.Enabled {
+		srv.Go(scheduleJobs(db, st, mail, cfg.Jobs).Run)
+	}
+	if err := srv.Run(ctx); err != nil {
+		log.Printf("Server error: %v", err)
+	}
+	db.Close()
+	log.Println(" Database connection closed")
+}
+
+func runMigrations(db database.DB) {
+	m, err := migrations.New(db)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	for _, mig := range applied {
+		log.Printf(" Applied migration %04d_%s", mig.Version, mig.Name)
+	}
+}
+
+// scheduleJobs sets up the background jobs; those with an empty schedule
+// are left out.
+func scheduleJobs(db database.DB, st *store.Store, mail mailer.Mailer, cfg config.JobsConfig) *scheduler.Scheduler {
+	jobs := services.NewCampaignJobs(st, services.NewCampaignService(st), mail, cfg)
+	sched := scheduler.New(db, st.JobRuns, cfg.RunRetention)
+	for _, job := range []struct {
+		name, spec string
+		run        scheduler.Func
+	}{
+		{"close_expired_campaigns", cfg.CloseExpiredSchedule, jobs.CloseExpired},
+		{"expire_applications", cfg.ExpireApplicationsSchedule, jobs.ExpireApplications},
+		{"deadline_reminders", cfg.RemindersSchedule, jobs.SendDeadlineReminders},
+	} {
+		if job.spec == "" {
+			continue
+		}
+		if err := sched.Add(job.name, job.spec, job.run); err != nil {
+			log.Fatalf("Invalid job schedule: %v", err)
+		}
+		log.Printf(" Scheduled job %s (%s)", job.name, job.spec)
+	}
+	return sched
 }
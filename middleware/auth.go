@@ -1,103 +1,154 @@
 package middleware
 
 import (
+	"context"
 	"errors"
-	"net/http"
-	"os"
+	"slices"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+
+	"InfluenceIQ/apperr"
+	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
 )
 
-// AuthMiddleware validates JWT and sets user context
-func AuthMiddleware() gin.HandlerFunc {
+// Authenticator verifies access tokens.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*services.Claims, error)
+}
+
+// KeyAuthenticator verifies brands' API keys.
+type KeyAuthenticator interface {
+	AuthenticateKey(ctx context.Context, key string) (*models.APIKey, *models.User, error)
+}
+
+// AuthMiddleware validates JWT and sets user context. When keys is not nil
+// it also accepts API keys in place of a JWT; routes that do so must check
+// the key's scopes with ScopeRequired.
+func AuthMiddleware(auth Authenticator, keys KeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing"})
+			_ = c.Error(apperr.Unauthorized("Authorization header missing"))
 			c.Abort()
 			return
 		}
 
 		parts := strings.Fields(authHeader)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid auth header format"})
+			_ = c.Error(apperr.Unauthorized("Invalid auth header format"))
 			c.Abort()
 			return
 		}
 
-		tokenStr := parts[1]
-
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			secret := os.Getenv("JWT_SECRET")
-			if secret == "" {
-				return nil, errors.New("missing JWT_SECRET environment variable")
-			}
-			return []byte(secret), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+		if services.IsAPIKey(parts[1]) {
+			authenticateKey(c, keys, parts[1])
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		claims, err := auth.Authenticate(c.Request.Context(), parts[1])
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
+			err = apperr.Unauthorized("Token expired").Wrap(err)
+		case errors.Is(err, services.ErrTokenRevoked):
+			err = apperr.Unauthorized("Token has been revoked").Wrap(err)
+		case errors.Is(err, services.ErrInvalidToken):
+			err = apperr.Unauthorized("Invalid token").Wrap(err)
+		}
+		if err != nil {
+			_ = c.Error(err)
 			c.Abort()
 			return
 		}
 
-		// Safe extraction helpers
-		getInt := func(key string) int {
-			if val, ok := claims[key]; ok {
-				switch v := val.(type) {
-				case float64:
-					return int(v)
-				case int:
-					return v
-				}
-			}
-			return 0
+		// Store for next handlers
+		c.Set("jti", claims.ID)
+		c.Set("sid", claims.SessionID)
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
+		c.Set("user_id", claims.UserID)
+		c.Set("role", claims.Role)
+
+		c.Next()
+	}
+}
+
+func authenticateKey(c *gin.Context, keys KeyAuthenticator, raw string) {
+	if keys == nil {
+		_ = c.Error(apperr.Unauthorized("API keys can't be used here; sign in instead"))
+		c.Abort()
+		return
+	}
+
+	key, user, err := keys.AuthenticateKey(c.Request.Context(), raw)
+	if errors.Is(err, services.ErrInvalidAPIKey) {
+		err = apperr.Unauthorized("Invalid or revoked API key").Wrap(err)
+	}
+	if err != nil {
+		_ = c.Error(err)
+		c.Abort()
+		return
+	}
+
+	c.Set("api_key_id", key.ID)
+	c.Set("api_key_scopes", key.Scopes)
+	c.Set("user_id", user.ID)
+	c.Set("role", user.Role)
+
+	c.Next()
+}
+
+// ScopeRequired lets API keys through only if they hold one of scopes.
+// Requests signed in with a JWT are not limited by scopes. Use it after
+// AuthMiddleware.
+func ScopeRequired(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isKey := c.Get("api_key_id"); !isKey {
+			c.Next()
+			return
 		}
 
-		getString := func(key string) string {
-			if val, ok := claims[key]; ok {
-				if s, ok := val.(string); ok {
-					return s
-				}
+		granted := c.GetStringSlice("api_key_scopes")
+		for _, scope := range scopes {
+			if slices.Contains(granted, scope) {
+				c.Next()
+				return
 			}
-			return ""
 		}
 
-		userID := getInt("user_id")
-		employeeID := getInt("employee_id")
-		role := getString("role")
-		exp := int64(getInt("exp"))
+		_ = c.Error(apperr.Forbidden("API key lacks the " + strings.Join(scopes, " or ") + " scope"))
+		c.Abort()
+	}
+}
+
+// UserLookup loads the signed-in user for checks the token can't answer.
+type UserLookup interface {
+	GetByID(ctx context.Context, id int) (*models.User, error)
+}
 
-		if userID == 0 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
+// VerifiedRequired only lets users with a verified email address through.
+// It reads the user rather than trusting the token so that verifying takes
+// effect immediately. Use it after AuthMiddleware.
+func VerifiedRequired(users UserLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := users.GetByID(c.Request.Context(), c.GetInt("user_id"))
+		if errors.Is(err, store.ErrNotFound) {
+			err = apperr.Unauthorized("User no longer exists")
+		}
+		if err != nil {
+			_ = c.Error(err)
 			c.Abort()
 			return
 		}
 
-		if exp != 0 && time.Now().Unix() > exp {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
+		if !user.EmailVerified() {
+			_ = c.Error(apperr.Forbidden("Verify your email address first"))
 			c.Abort()
 			return
 		}
 
-		// Store for next handlers
-		c.Set("user_id", userID)
-		c.Set("employee_id", employeeID)
-		c.Set("role", role)
-
 		c.Next()
 	}
 }
@@ -107,14 +158,14 @@ func RoleRequired(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		roleVal, exists := c.Get("role")
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "No role found in context"})
+			_ = c.Error(apperr.Unauthorized("No role found in context"))
 			c.Abort()
 			return
 		}
 
 		role, ok := roleVal.(string)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid role type"})
+			_ = c.Error(apperr.Unauthorized("Invalid role type"))
 			c.Abort()
 			return
 		}
@@ -126,7 +177,7 @@ func RoleRequired(allowedRoles ...string) gin.HandlerFunc {
 			}
 		}
 
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+		_ = c.Error(apperr.Forbidden("Insufficient permissions"))
 		c.Abort()
 	}
 }
@@ -135,8 +186,9 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Content-Length, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Content-Length, X-Requested-With, If-Match")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(200)
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store/memory"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	tokens, err := services.NewTokenService(st, config.JWTConfig{Issuer: "test", TTL: time.Minute, RefreshTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := services.NewAPIKeyService(st)
+
+	newUser := func(name, role string) *models.User {
+		u := &models.User{Username: name, Email: name + "@example.com", Role: role}
+		if err := st.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	brand, influencer := newUser("acme", models.RoleBrand), newUser("ada", models.RoleInfluencer)
+
+	login := func(user *models.User) string {
+		pair, err := tokens.Issue(ctx, user, services.Client{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pair.AccessToken
+	}
+	brandToken, influencerToken, revokedToken := login(brand), login(influencer), login(brand)
+	revoked, err := tokens.Authenticate(ctx, revokedToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := tokens.Sessions(ctx, brand.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range sessions {
+		if s.FamilyID != revoked.SessionID {
+			continue
+		}
+		if err := tokens.RevokeSession(ctx, brand.ID, s.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newKey := func(scopes ...string) string {
+		key, err := keys.Create(ctx, brand.ID, "CI", scopes, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key.Key
+	}
+	readKey, writeKey := newKey(models.ScopeCampaignsRead), newKey(models.ScopeCampaignsWrite)
+	revokedKey, err := keys.Create(ctx, brand.ID, "old", []string{models.ScopeCampaignsWrite}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Revoke(ctx, brand.ID, revokedKey.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(ErrorHandler())
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	r.GET("/jwt", AuthMiddleware(tokens, nil), ok)
+	r.POST("/campaigns", AuthMiddleware(tokens, keys), RoleRequired(models.RoleBrand),
+		ScopeRequired(models.ScopeCampaignsWrite), ok)
+
+	tests := []struct {
+		name   string
+		path   string
+		method string
+		auth   string
+		want   int
+	}{
+		{"no header", "/jwt", http.MethodGet, "", http.StatusUnauthorized},
+		{"not bearer", "/jwt", http.MethodGet, "Basic " + brandToken, http.StatusUnauthorized},
+		{"valid token", "/jwt", http.MethodGet, "Bearer " + brandToken, http.StatusNoContent},
+		{"revoked session", "/jwt", http.MethodGet, "Bearer " + revokedToken, http.StatusUnauthorized},
+		{"key where only tokens are accepted", "/jwt", http.MethodGet, "Bearer " + writeKey, http.StatusUnauthorized},
+		{"role allowed", "/campaigns", http.MethodPost, "Bearer " + brandToken, http.StatusNoContent},
+		{"role refused", "/campaigns", http.MethodPost, "Bearer " + influencerToken, http.StatusForbidden},
+		{"key with scope", "/campaigns", http.MethodPost, "Bearer " + writeKey, http.StatusNoContent},
+		{"key without scope", "/campaigns", http.MethodPost, "Bearer " + readKey, http.StatusForbidden},
+		{"revoked key", "/campaigns", http.MethodPost, "Bearer " + revokedKey.Key, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d; body: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
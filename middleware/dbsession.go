@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"InfluenceIQ/database"
+)
+
+// DBSession gives each request its own read-your-writes session, so that
+// reads following a write in the same request see it even with replicas.
+// Handlers must derive their contexts from c.Request.Context().
+func DBSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(database.WithSession(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"InfluenceIQ/apperr"
+)
+
+// ErrorHandler renders the last error a handler attached with c.Error as
+// the apperr envelope. It must be registered before any handler that can
+// fail so that it runs last.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+		e := apperr.From(c.Errors.Last().Err)
+		if e.Status() >= http.StatusInternalServerError {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, e)
+		}
+		if c.Writer.Written() {
+			return
+		}
+		c.JSON(e.Status(), gin.H{"success": false, "error": e})
+	}
+}
+
+// Recovery turns a panic into an internal_error response.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		_ = c.Error(apperr.Internal(fmt.Errorf("panic: %v", recovered)))
+		c.Abort()
+	})
+}
+
+// NotFound answers requests that match no route.
+func NotFound(c *gin.Context) {
+	_ = c.Error(apperr.NotFound("route not found"))
+}
@@ -0,0 +1,281 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"InfluenceIQ/database"
+)
+
+// Each supported dialect has its own copy of every migration, with the same
+// version numbers and names.
+//
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// lockID is the Postgres advisory lock key held while migrating, so that
+// several instances booting with auto-migrate don't race each other.
+const lockID = 7_240_531_001
+
+// Migration is a single versioned schema change with its rollback.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied to the database.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies the embedded migrations to a database.
+type Migrator struct {
+	db         database.DB
+	migrations []Migration
+}
+
+// New loads the embedded migrations for db's dialect and returns a Migrator.
+func New(db database.DB) (*Migrator, error) {
+	migrations, err := load(files, string(db.Dialect()))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// load reads "<version>_<name>.up.sql" / ".down.sql" pairs from dir.
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		name := e.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		versionStr, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>", name)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", name, err)
+		}
+
+		body, err := fs.ReadFile(fsys, dir+"/"+name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration %d: conflicting names %q and %q", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s: missing up or down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, mig := range m.migrations {
+		ran, err := m.run(ctx, mig, true)
+		if err != nil {
+			return applied, fmt.Errorf("migration %04d_%s up: %w", mig.Version, mig.Name, err)
+		}
+		if ran {
+			applied = append(applied, mig)
+		}
+	}
+	return applied, nil
+}
+
+// Down rolls back the most recently applied migration. It returns nil when
+// nothing has been applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	done, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if _, ok := done[mig.Version]; !ok {
+			continue
+		}
+		ran, err := m.run(ctx, mig, false)
+		if err != nil {
+			return nil, fmt.Errorf("migration %04d_%s down: %w", mig.Version, mig.Name, err)
+		}
+		if !ran {
+			// Someone else rolled it back first.
+			return nil, nil
+		}
+		return &mig, nil
+	}
+	return nil, nil
+}
+
+// Redo rolls back the latest migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	mig, err := m.Down(ctx)
+	if err != nil || mig == nil {
+		return mig, err
+	}
+	if _, err := m.Up(ctx); err != nil {
+		return nil, err
+	}
+	return mig, nil
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	done, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		at, ok := done[mig.Version]
+		statuses = append(statuses, Status{Migration: mig, Applied: ok, AppliedAt: at})
+	}
+	return statuses, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	ddl := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    BIGINT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`
+	if m.db.Dialect() == database.SQLite {
+		ddl = `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version    INTEGER PRIMARY KEY,
+				name       TEXT NOT NULL,
+				applied_at DATETIME NOT NULL
+			)
+		`
+	}
+	if _, err := m.db.Exec(ctx, ddl); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int]time.Time, error) {
+	rows, err := m.db.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		done[version] = at
+	}
+	return done, rows.Err()
+}
+
+// run applies (up) or reverts (down) one migration together with its
+// schema_migrations bookkeeping in a single transaction. It reports false
+// without touching the schema when the migration is already in the wanted
+// state, which happens when another instance got there first.
+func (m *Migrator) run(ctx context.Context, mig Migration, up bool) (bool, error) {
+	tx, err := m.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if m.db.Dialect() == database.Postgres {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, lockID); err != nil {
+			return false, fmt.Errorf("acquire migration lock: %w", err)
+		}
+	}
+
+	var applied bool
+	if err := tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)`, mig.Version,
+	).Scan(&applied); err != nil {
+		return false, err
+	}
+	if applied == up {
+		return false, nil
+	}
+
+	if up {
+		if _, err := tx.Exec(ctx, mig.Up); err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(ctx,
+			`INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, NOW())`,
+			mig.Version, mig.Name)
+	} else {
+		if _, err := tx.Exec(ctx, mig.Down); err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
@@ -0,0 +1,149 @@
+package migrations
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/database"
+)
+
+// schema lists the SQL of every table and index, so that two schemas can
+// be compared.
+func schema(t *testing.T, db database.DB) string {
+	t.Helper()
+	rows, err := db.Query(context.Background(),
+		`SELECT sql FROM sqlite_master WHERE sql IS NOT NULL AND name NOT IN ('schema_migrations', 'sqlite_sequence') ORDER BY type, name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var all []string
+	for rows.Next() {
+		var sql string
+		if err := rows.Scan(&sql); err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, sql)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join(all, ";\n")
+}
+
+func TestUpDownUpSQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.OpenSQLite(ctx, config.DatabaseConfig{URL: filepath.Join(t.TempDir(), "test.db"), MaxConns: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	m, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(m.migrations) {
+		t.Fatalf("applied %d migrations, want %d", len(applied), len(m.migrations))
+	}
+	want := schema(t, db)
+	if again, err := m.Up(ctx); err != nil || len(again) != 0 {
+		t.Fatalf("second Up applied %d migrations (%v), want none", len(again), err)
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig, err := m.Down(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mig == nil || mig.Version != m.migrations[i].Version {
+			t.Fatalf("Down rolled back %v, want version %d", mig, m.migrations[i].Version)
+		}
+	}
+	if mig, err := m.Down(ctx); mig != nil || err != nil {
+		t.Fatalf("Down with nothing applied = %v, %v", mig, err)
+	}
+	if got := schema(t, db); got != "" {
+		t.Errorf("schema left after rolling everything back:\n%s", got)
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := schema(t, db); got != want {
+		t.Errorf("schema after up, down and up again differs:\n%s\nwant:\n%s", got, want)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if !s.Applied {
+			t.Errorf("migration %04d_%s not applied", s.Version, s.Name)
+		}
+	}
+}
+
+func TestDialectsMatch(t *testing.T) {
+	postgres, err := load(files, string(database.Postgres))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := load(files, string(database.SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("%d postgres migrations, %d sqlite migrations", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Version != sqlite[i].Version || postgres[i].Name != sqlite[i].Name {
+			t.Errorf("postgres has %04d_%s where sqlite has %04d_%s",
+				postgres[i].Version, postgres[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := func(body string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(body)} }
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{"pairs", fstest.MapFS{
+			"d/0002_b.up.sql": file("B"), "d/0002_b.down.sql": file("-B"),
+			"d/0001_a.up.sql": file("A"), "d/0001_a.down.sql": file("-A"),
+			"d/README.md": file("ignored"),
+		}, ""},
+		{"missing down", fstest.MapFS{"d/0001_a.up.sql": file("A")}, "missing up or down"},
+		{"conflicting names", fstest.MapFS{"d/0001_a.up.sql": file("A"), "d/0001_b.down.sql": file("-B")}, "conflicting names"},
+		{"no version", fstest.MapFS{"d/init.up.sql": file("A")}, "expected <version>_<name>"},
+		{"bad version", fstest.MapFS{"d/one_a.up.sql": file("A")}, "invalid version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(tt.fsys, "d")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("load = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrations) != 2 || migrations[0].Name != "a" || migrations[0].Down != "-A" || migrations[1].Up != "B" {
+				t.Errorf("loaded %+v", migrations)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS users (
+    user_id    SERIAL PRIMARY KEY,
+    username   VARCHAR(100) NOT NULL,
+    email      VARCHAR(255) NOT NULL,
+    password   TEXT NOT NULL,
+    full_name  TEXT NOT NULL DEFAULT '',
+    role       VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT users_username_key UNIQUE (username),
+    CONSTRAINT users_email_key UNIQUE (email)
+);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS profiles (
+    id              SERIAL PRIMARY KEY,
+    user_id         INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    display_name    TEXT NOT NULL,
+    avatar_url      TEXT NOT NULL DEFAULT '',
+    bio             TEXT NOT NULL DEFAULT '',
+    account_type    VARCHAR(20) NOT NULL,
+    category        TEXT NOT NULL DEFAULT '',
+    follower_count  INTEGER NOT NULL DEFAULT 0,
+    engagement_rate DOUBLE PRECISION NOT NULL DEFAULT 0,
+    company_name    TEXT NOT NULL DEFAULT '',
+    industry        TEXT NOT NULL DEFAULT '',
+    website         TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT profiles_user_id_key UNIQUE (user_id),
+    CONSTRAINT profiles_account_type_check CHECK (account_type IN ('influencer', 'brand')),
+    CONSTRAINT profiles_follower_count_check CHECK (follower_count >= 0),
+    CONSTRAINT profiles_engagement_rate_check CHECK (engagement_rate >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS profiles_account_type_category_idx ON profiles (account_type, category);
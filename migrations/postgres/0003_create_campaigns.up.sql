@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS campaigns (
+    id          SERIAL PRIMARY KEY,
+    brand_id    INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    title       TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    category    TEXT NOT NULL DEFAULT '',
+    budget      NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    deadline    TIMESTAMPTZ NOT NULL,
+    status      VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT campaigns_budget_check CHECK (budget >= 0),
+    CONSTRAINT campaigns_status_check CHECK (status IN ('active', 'closed', 'draft'))
+);
+
+CREATE INDEX IF NOT EXISTS campaigns_brand_id_idx ON campaigns (brand_id);
+CREATE INDEX IF NOT EXISTS campaigns_created_at_idx ON campaigns (created_at DESC);
+CREATE INDEX IF NOT EXISTS campaigns_status_deadline_idx ON campaigns (status, deadline);
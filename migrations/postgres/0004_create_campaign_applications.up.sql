@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS campaign_applications (
+    id            SERIAL PRIMARY KEY,
+    campaign_id   INTEGER NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    influencer_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    status        VARCHAR(20) NOT NULL DEFAULT 'pending',
+    message       TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT campaign_applications_status_check CHECK (status IN ('pending', 'accepted', 'rejected'))
+);
+
+CREATE INDEX IF NOT EXISTS campaign_applications_campaign_id_idx ON campaign_applications (campaign_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS campaign_applications_influencer_id_idx ON campaign_applications (influencer_id, created_at DESC);
@@ -0,0 +1,7 @@
+ALTER TABLE campaigns
+    DROP CONSTRAINT IF EXISTS campaigns_counts_check,
+    DROP COLUMN IF EXISTS accepted_count,
+    DROP COLUMN IF EXISTS application_count;
+
+ALTER TABLE campaign_applications
+    DROP CONSTRAINT IF EXISTS campaign_applications_campaign_influencer_key;
@@ -0,0 +1,20 @@
+-- Keep the oldest application when an influencer applied more than once.
+DELETE FROM campaign_applications a
+USING campaign_applications b
+WHERE a.campaign_id = b.campaign_id
+  AND a.influencer_id = b.influencer_id
+  AND a.id > b.id;
+
+ALTER TABLE campaign_applications
+    ADD CONSTRAINT campaign_applications_campaign_influencer_key UNIQUE (campaign_id, influencer_id);
+
+ALTER TABLE campaigns
+    ADD COLUMN application_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN accepted_count    INTEGER NOT NULL DEFAULT 0;
+
+UPDATE campaigns c SET
+    application_count = (SELECT COUNT(*) FROM campaign_applications a WHERE a.campaign_id = c.id),
+    accepted_count    = (SELECT COUNT(*) FROM campaign_applications a WHERE a.campaign_id = c.id AND a.status = 'accepted');
+
+ALTER TABLE campaigns
+    ADD CONSTRAINT campaigns_counts_check CHECK (application_count >= 0 AND accepted_count >= 0);
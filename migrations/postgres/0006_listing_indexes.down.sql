@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS campaign_applications_influencer_created_idx;
+DROP INDEX IF EXISTS campaign_applications_campaign_created_idx;
+CREATE INDEX IF NOT EXISTS campaign_applications_influencer_id_idx ON campaign_applications (influencer_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS campaign_applications_campaign_id_idx ON campaign_applications (campaign_id, created_at DESC);
+
+DROP INDEX IF EXISTS campaigns_category_idx;
+DROP INDEX IF EXISTS campaigns_budget_id_idx;
+DROP INDEX IF EXISTS campaigns_deadline_id_idx;
+DROP INDEX IF EXISTS campaigns_created_at_id_idx;
+CREATE INDEX IF NOT EXISTS campaigns_created_at_idx ON campaigns (created_at DESC);
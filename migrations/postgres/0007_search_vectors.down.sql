@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS profiles_search_idx;
+ALTER TABLE profiles DROP COLUMN IF EXISTS search_vector;
+
+DROP INDEX IF EXISTS campaigns_search_idx;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS search_vector;
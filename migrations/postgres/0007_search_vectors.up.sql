@@ -0,0 +1,18 @@
+-- Weighted full-text documents, kept current by Postgres itself.
+ALTER TABLE campaigns
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', title), 'A') ||
+        setweight(to_tsvector('english', description), 'B') ||
+        setweight(to_tsvector('english', category), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS campaigns_search_idx ON campaigns USING GIN (search_vector);
+
+ALTER TABLE profiles
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', display_name), 'A') ||
+        setweight(to_tsvector('english', category), 'B') ||
+        setweight(to_tsvector('english', bio), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS profiles_search_idx ON profiles USING GIN (search_vector);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS users_email_lower_idx;
+DROP TABLE IF EXISTS user_tokens;
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,11 @@
+-- Users with a profile become a brand or an influencer; anything else
+-- that isn't a known role falls back to viewer.
+UPDATE users SET role = p.account_type
+FROM profiles p
+WHERE p.user_id = users.user_id AND users.role = 'viewer';
+
+UPDATE users SET role = 'viewer'
+WHERE role NOT IN ('viewer', 'influencer', 'brand', 'admin');
+
+ALTER TABLE users ADD CONSTRAINT users_role_check
+    CHECK (role IN ('viewer', 'influencer', 'brand', 'admin'));
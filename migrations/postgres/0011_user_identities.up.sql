@@ -0,0 +1,29 @@
+-- Accounts at OpenID Connect providers linked to users, at most one per
+-- provider and user.
+CREATE TABLE IF NOT EXISTS user_identities (
+    id            SERIAL PRIMARY KEY,
+    user_id       INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    provider      VARCHAR(64) NOT NULL,
+    subject       VARCHAR(255) NOT NULL,
+    email         VARCHAR(255) NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ,
+    CONSTRAINT user_identities_provider_subject_key UNIQUE (provider, subject),
+    CONSTRAINT user_identities_user_id_provider_key UNIQUE (user_id, provider)
+);
+
+-- Logins started at a provider and not finished yet. Only a hash of the
+-- state parameter is stored; user_id is set when linking a provider.
+CREATE TABLE IF NOT EXISTS oauth_states (
+    id            SERIAL PRIMARY KEY,
+    state_hash    VARCHAR(64) NOT NULL,
+    provider      VARCHAR(64) NOT NULL,
+    nonce         VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    user_id       INTEGER REFERENCES users (user_id) ON DELETE CASCADE,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT oauth_states_state_hash_key UNIQUE (state_hash)
+);
+
+CREATE INDEX IF NOT EXISTS oauth_states_expires_at_idx ON oauth_states (expires_at);
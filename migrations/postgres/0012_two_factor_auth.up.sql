@@ -0,0 +1,42 @@
+-- Authenticator app enrollments. enabled_at stays NULL until the user has
+-- entered a first code.
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id        INTEGER PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+    secret         VARCHAR(64) NOT NULL,
+    enabled_at     TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One-time recovery codes, stored as hashes.
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS mfa_recovery_codes_user_id_idx ON mfa_recovery_codes (user_id);
+
+-- Logins waiting for their second factor.
+CREATE TABLE IF NOT EXISTS mfa_challenges (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    attempts   INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT mfa_challenges_token_hash_key UNIQUE (token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS mfa_challenges_expires_at_idx ON mfa_challenges (expires_at);
+
+-- Groups of users who must use two-factor authentication.
+CREATE TABLE IF NOT EXISTS mfa_policies (
+    scope      VARCHAR(32) NOT NULL,
+    value      VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (scope, value),
+    CONSTRAINT mfa_policies_scope_check CHECK (scope IN ('role'))
+);
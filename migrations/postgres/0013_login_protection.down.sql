@@ -0,0 +1,7 @@
+DELETE FROM user_tokens WHERE purpose = 'unlock_account';
+ALTER TABLE user_tokens DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('verify_email', 'reset_password'));
+
+DROP TABLE IF EXISTS security_events;
+DROP TABLE IF EXISTS login_attempts;
@@ -0,0 +1,30 @@
+-- Failed login counts per bucket (an account, an unknown login name or a
+-- client IP) and the blocks placed on them, shared by every instance when
+-- the database login limiter is used.
+CREATE TABLE IF NOT EXISTS login_attempts (
+    bucket        VARCHAR(320) PRIMARY KEY,
+    failures      INTEGER NOT NULL DEFAULT 0,
+    window_start  TIMESTAMPTZ NOT NULL,
+    blocked_until TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS login_attempts_window_start_idx ON login_attempts (window_start);
+
+-- Security audit log: lockouts, blocked IPs and suspicious logins.
+CREATE TABLE IF NOT EXISTS security_events (
+    id         SERIAL PRIMARY KEY,
+    type       VARCHAR(32) NOT NULL,
+    user_id    INTEGER REFERENCES users (user_id) ON DELETE SET NULL,
+    ip         VARCHAR(64) NOT NULL DEFAULT '',
+    login      VARCHAR(255) NOT NULL DEFAULT '',
+    detail     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS security_events_created_at_idx ON security_events (created_at, id);
+CREATE INDEX IF NOT EXISTS security_events_user_id_idx ON security_events (user_id);
+
+-- Locked accounts are mailed a link to unlock them.
+ALTER TABLE user_tokens DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('verify_email', 'reset_password', 'unlock_account'));
@@ -0,0 +1,9 @@
+DELETE FROM mfa_policies WHERE scope = 'organization';
+ALTER TABLE mfa_policies DROP CONSTRAINT IF EXISTS mfa_policies_scope_check;
+ALTER TABLE mfa_policies ADD CONSTRAINT mfa_policies_scope_check CHECK (scope IN ('role'));
+
+DROP INDEX IF EXISTS campaigns_organization_id_idx;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organization_invitations;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS campaign_transitions;
+
+ALTER TABLE campaigns DROP CONSTRAINT IF EXISTS campaigns_status_check;
+UPDATE campaigns SET status = CASE
+    WHEN status IN ('published', 'paused') THEN 'active'
+    WHEN status IN ('completed', 'cancelled') THEN 'closed'
+    ELSE status
+END;
+ALTER TABLE campaigns ALTER COLUMN status SET DEFAULT 'active';
+ALTER TABLE campaigns ADD CONSTRAINT campaigns_status_check
+    CHECK (status IN ('active', 'closed', 'draft'));
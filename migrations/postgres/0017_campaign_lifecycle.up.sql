@@ -0,0 +1,21 @@
+-- Campaigns follow an explicit lifecycle: draft -> published <-> paused ->
+-- closed -> completed, or cancelled from any state before completion.
+-- What used to be "active" is now "published".
+ALTER TABLE campaigns DROP CONSTRAINT IF EXISTS campaigns_status_check;
+UPDATE campaigns SET status = 'published' WHERE status = 'active';
+ALTER TABLE campaigns ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE campaigns ADD CONSTRAINT campaigns_status_check
+    CHECK (status IN ('draft', 'published', 'paused', 'closed', 'completed', 'cancelled'));
+
+-- Every status change, and who made it. actor_id is NULL once the user is
+-- deleted.
+CREATE TABLE IF NOT EXISTS campaign_transitions (
+    id          SERIAL PRIMARY KEY,
+    campaign_id INTEGER NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status   VARCHAR(20) NOT NULL,
+    actor_id    INTEGER REFERENCES users (user_id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS campaign_transitions_campaign_id_idx ON campaign_transitions (campaign_id, created_at);
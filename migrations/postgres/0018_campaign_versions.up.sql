@@ -0,0 +1,3 @@
+-- Counts edits and status changes, so that clients can update a campaign
+-- only if nobody changed it since they read it (ETag / If-Match).
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
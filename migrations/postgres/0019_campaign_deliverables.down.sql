@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS deliverable_submissions;
+DROP TABLE IF EXISTS campaign_deliverables;
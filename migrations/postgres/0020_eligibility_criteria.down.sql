@@ -0,0 +1,10 @@
+ALTER TABLE profiles DROP COLUMN IF EXISTS verified_at;
+ALTER TABLE profiles DROP COLUMN IF EXISTS country;
+ALTER TABLE profiles DROP COLUMN IF EXISTS platforms;
+
+ALTER TABLE campaigns DROP COLUMN IF EXISTS verified_only;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS eligible_countries;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS eligible_platforms;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS eligible_categories;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS min_engagement_rate;
+ALTER TABLE campaigns DROP COLUMN IF EXISTS min_followers;
@@ -0,0 +1,16 @@
+-- Who may apply to a campaign. The lists are comma-separated and empty
+-- when they don't restrict anything.
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS min_followers INTEGER NOT NULL DEFAULT 0
+    CONSTRAINT campaigns_min_followers_check CHECK (min_followers >= 0);
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS min_engagement_rate DOUBLE PRECISION NOT NULL DEFAULT 0
+    CONSTRAINT campaigns_min_engagement_rate_check CHECK (min_engagement_rate >= 0);
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS eligible_categories TEXT NOT NULL DEFAULT '';
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS eligible_platforms TEXT NOT NULL DEFAULT '';
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS eligible_countries TEXT NOT NULL DEFAULT '';
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS verified_only BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- What campaigns check applicants against: where influencers post and are
+-- based, and whether an admin verified their accounts.
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS platforms TEXT NOT NULL DEFAULT '';
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS country VARCHAR(2) NOT NULL DEFAULT '';
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS verified_at TIMESTAMPTZ;
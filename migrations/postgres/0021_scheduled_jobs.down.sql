@@ -0,0 +1,9 @@
+ALTER TABLE campaigns DROP COLUMN IF EXISTS reminded_deadline;
+
+DROP INDEX IF EXISTS campaign_applications_status_created_idx;
+ALTER TABLE campaign_applications DROP CONSTRAINT IF EXISTS campaign_applications_status_check;
+UPDATE campaign_applications SET status = 'rejected' WHERE status = 'expired';
+ALTER TABLE campaign_applications ADD CONSTRAINT campaign_applications_status_check
+    CHECK (status IN ('pending', 'accepted', 'rejected'));
+
+DROP TABLE IF EXISTS job_runs;
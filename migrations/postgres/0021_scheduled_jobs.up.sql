@@ -0,0 +1,29 @@
+-- Every run of a scheduled job. A job runs at most once per scheduled
+-- time, whichever instance gets to it first.
+CREATE TABLE IF NOT EXISTS job_runs (
+    id           SERIAL PRIMARY KEY,
+    job          VARCHAR(100) NOT NULL,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    instance     VARCHAR(255) NOT NULL DEFAULT '',
+    status       VARCHAR(20) NOT NULL DEFAULT 'running',
+    result       TEXT NOT NULL DEFAULT '',
+    error        TEXT NOT NULL DEFAULT '',
+    duration_ms  BIGINT NOT NULL DEFAULT 0,
+    started_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at  TIMESTAMPTZ,
+    CONSTRAINT job_runs_job_scheduled_at_key UNIQUE (job, scheduled_at),
+    CONSTRAINT job_runs_status_check CHECK (status IN ('running', 'succeeded', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS job_runs_started_at_id_idx ON job_runs (started_at, id);
+CREATE INDEX IF NOT EXISTS job_runs_duration_ms_id_idx ON job_runs (duration_ms, id);
+
+-- Pending applications nobody decided on expire after a while.
+ALTER TABLE campaign_applications DROP CONSTRAINT IF EXISTS campaign_applications_status_check;
+ALTER TABLE campaign_applications ADD CONSTRAINT campaign_applications_status_check
+    CHECK (status IN ('pending', 'accepted', 'rejected', 'expired'));
+CREATE INDEX IF NOT EXISTS campaign_applications_status_created_idx ON campaign_applications (status, created_at);
+
+-- The deadline the brand was last reminded of, so that each deadline gets
+-- one reminder even when it is moved.
+ALTER TABLE campaigns ADD COLUMN IF NOT EXISTS reminded_deadline TIMESTAMPTZ;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS campaign_applications (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    campaign_id   INTEGER NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    influencer_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    status        VARCHAR(20) NOT NULL DEFAULT 'pending',
+    message       TEXT NOT NULL DEFAULT '',
+    created_at    DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at    DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT campaign_applications_status_check CHECK (status IN ('pending', 'accepted', 'rejected'))
+);
+
+CREATE INDEX IF NOT EXISTS campaign_applications_campaign_id_idx ON campaign_applications (campaign_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS campaign_applications_influencer_id_idx ON campaign_applications (influencer_id, created_at DESC);
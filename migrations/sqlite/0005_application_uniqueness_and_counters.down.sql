@@ -0,0 +1,4 @@
+ALTER TABLE campaigns DROP COLUMN accepted_count;
+ALTER TABLE campaigns DROP COLUMN application_count;
+
+DROP INDEX IF EXISTS campaign_applications_campaign_influencer_key;
@@ -0,0 +1,15 @@
+-- Keep the oldest application when an influencer applied more than once.
+DELETE FROM campaign_applications
+WHERE id NOT IN (
+    SELECT MIN(id) FROM campaign_applications GROUP BY campaign_id, influencer_id
+);
+
+CREATE UNIQUE INDEX campaign_applications_campaign_influencer_key
+    ON campaign_applications (campaign_id, influencer_id);
+
+ALTER TABLE campaigns ADD COLUMN application_count INTEGER NOT NULL DEFAULT 0 CHECK (application_count >= 0);
+ALTER TABLE campaigns ADD COLUMN accepted_count INTEGER NOT NULL DEFAULT 0 CHECK (accepted_count >= 0);
+
+UPDATE campaigns SET
+    application_count = (SELECT COUNT(*) FROM campaign_applications a WHERE a.campaign_id = campaigns.id),
+    accepted_count    = (SELECT COUNT(*) FROM campaign_applications a WHERE a.campaign_id = campaigns.id AND a.status = 'accepted');
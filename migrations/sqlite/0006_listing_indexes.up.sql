@@ -0,0 +1,12 @@
+-- Keyset pagination orders by (<sort field>, id); give each whitelisted
+-- sort field a matching index.
+DROP INDEX IF EXISTS campaigns_created_at_idx;
+CREATE INDEX IF NOT EXISTS campaigns_created_at_id_idx ON campaigns (created_at, id);
+CREATE INDEX IF NOT EXISTS campaigns_deadline_id_idx ON campaigns (deadline, id);
+CREATE INDEX IF NOT EXISTS campaigns_budget_id_idx ON campaigns (budget, id);
+CREATE INDEX IF NOT EXISTS campaigns_category_idx ON campaigns (category);
+
+DROP INDEX IF EXISTS campaign_applications_campaign_id_idx;
+DROP INDEX IF EXISTS campaign_applications_influencer_id_idx;
+CREATE INDEX IF NOT EXISTS campaign_applications_campaign_created_idx ON campaign_applications (campaign_id, created_at, id);
+CREATE INDEX IF NOT EXISTS campaign_applications_influencer_created_idx ON campaign_applications (influencer_id, created_at, id);
@@ -0,0 +1,3 @@
+-- SQLite has no tsvector; search falls back to LIKE plus an in-app matcher,
+-- so there is nothing to store. Kept so versions match across dialects.
+SELECT 1;
@@ -0,0 +1,24 @@
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME;
+
+-- Accounts created before verification existed keep working.
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- Single-use tokens mailed to users, e.g. to verify an email address or
+-- reset a password. Only a hash of each token is stored.
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    purpose    VARCHAR(32) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at    DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT user_tokens_token_hash_key UNIQUE (token_hash),
+    CONSTRAINT user_tokens_purpose_check CHECK (purpose IN ('verify_email', 'reset_password'))
+);
+
+CREATE INDEX IF NOT EXISTS user_tokens_user_id_idx ON user_tokens (user_id, purpose);
+CREATE INDEX IF NOT EXISTS user_tokens_expires_at_idx ON user_tokens (expires_at);
+
+-- Emails are looked up ignoring case.
+CREATE INDEX IF NOT EXISTS users_email_lower_idx ON users (lower(email));
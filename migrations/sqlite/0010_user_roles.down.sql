@@ -0,0 +1,2 @@
+-- Nothing to undo: the up migration only updated data.
+SELECT 1;
@@ -0,0 +1,10 @@
+-- Users with a profile become a brand or an influencer; anything else
+-- that isn't a known role falls back to viewer.
+UPDATE users SET role = (SELECT p.account_type FROM profiles p WHERE p.user_id = users.user_id)
+WHERE role = 'viewer' AND EXISTS (SELECT 1 FROM profiles p WHERE p.user_id = users.user_id);
+
+UPDATE users SET role = 'viewer'
+WHERE role NOT IN ('viewer', 'influencer', 'brand', 'admin');
+
+-- SQLite can't add a CHECK constraint to an existing table; the Postgres
+-- users_role_check has no equivalent here.
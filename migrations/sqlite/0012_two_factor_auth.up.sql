@@ -0,0 +1,42 @@
+-- Authenticator app enrollments. enabled_at stays NULL until the user has
+-- entered a first code.
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id        INTEGER PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+    secret         VARCHAR(64) NOT NULL,
+    enabled_at     DATETIME,
+    last_used_step INTEGER NOT NULL DEFAULT 0,
+    created_at     DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+-- One-time recovery codes, stored as hashes.
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS mfa_recovery_codes_user_id_idx ON mfa_recovery_codes (user_id);
+
+-- Logins waiting for their second factor.
+CREATE TABLE IF NOT EXISTS mfa_challenges (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    attempts   INTEGER NOT NULL DEFAULT 0,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT mfa_challenges_token_hash_key UNIQUE (token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS mfa_challenges_expires_at_idx ON mfa_challenges (expires_at);
+
+-- Groups of users who must use two-factor authentication.
+CREATE TABLE IF NOT EXISTS mfa_policies (
+    scope      VARCHAR(32) NOT NULL,
+    value      VARCHAR(64) NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    PRIMARY KEY (scope, value),
+    CONSTRAINT mfa_policies_scope_check CHECK (scope IN ('role'))
+);
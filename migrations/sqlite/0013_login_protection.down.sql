@@ -0,0 +1,23 @@
+DELETE FROM user_tokens WHERE purpose = 'unlock_account';
+
+CREATE TABLE user_tokens_old (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    purpose    VARCHAR(32) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at    DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT user_tokens_token_hash_key UNIQUE (token_hash),
+    CONSTRAINT user_tokens_purpose_check CHECK (purpose IN ('verify_email', 'reset_password'))
+);
+
+INSERT INTO user_tokens_old SELECT id, user_id, purpose, token_hash, expires_at, used_at, created_at FROM user_tokens;
+DROP TABLE user_tokens;
+ALTER TABLE user_tokens_old RENAME TO user_tokens;
+
+CREATE INDEX IF NOT EXISTS user_tokens_user_id_idx ON user_tokens (user_id, purpose);
+CREATE INDEX IF NOT EXISTS user_tokens_expires_at_idx ON user_tokens (expires_at);
+
+DROP TABLE IF EXISTS security_events;
+DROP TABLE IF EXISTS login_attempts;
@@ -0,0 +1,46 @@
+-- Failed login counts per bucket (an account, an unknown login name or a
+-- client IP) and the blocks placed on them, shared by every instance when
+-- the database login limiter is used.
+CREATE TABLE IF NOT EXISTS login_attempts (
+    bucket        VARCHAR(320) PRIMARY KEY,
+    failures      INTEGER NOT NULL DEFAULT 0,
+    window_start  DATETIME NOT NULL,
+    blocked_until DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS login_attempts_window_start_idx ON login_attempts (window_start);
+
+-- Security audit log: lockouts, blocked IPs and suspicious logins.
+CREATE TABLE IF NOT EXISTS security_events (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    type       VARCHAR(32) NOT NULL,
+    user_id    INTEGER REFERENCES users (user_id) ON DELETE SET NULL,
+    ip         VARCHAR(64) NOT NULL DEFAULT '',
+    login      VARCHAR(255) NOT NULL DEFAULT '',
+    detail     TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS security_events_created_at_idx ON security_events (created_at, id);
+CREATE INDEX IF NOT EXISTS security_events_user_id_idx ON security_events (user_id);
+
+-- Locked accounts are mailed a link to unlock them. SQLite can't alter a
+-- CHECK constraint, so user_tokens is rebuilt.
+CREATE TABLE user_tokens_new (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    purpose    VARCHAR(32) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at    DATETIME,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT user_tokens_token_hash_key UNIQUE (token_hash),
+    CONSTRAINT user_tokens_purpose_check CHECK (purpose IN ('verify_email', 'reset_password', 'unlock_account'))
+);
+
+INSERT INTO user_tokens_new SELECT id, user_id, purpose, token_hash, expires_at, used_at, created_at FROM user_tokens;
+DROP TABLE user_tokens;
+ALTER TABLE user_tokens_new RENAME TO user_tokens;
+
+CREATE INDEX IF NOT EXISTS user_tokens_user_id_idx ON user_tokens (user_id, purpose);
+CREATE INDEX IF NOT EXISTS user_tokens_expires_at_idx ON user_tokens (expires_at);
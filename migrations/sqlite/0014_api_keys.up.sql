@@ -0,0 +1,18 @@
+-- Keys that let brands' own systems call the API. Only a hash of each key
+-- is stored; prefix is its first characters, shown so keys can be told
+-- apart. scopes is a space-separated list.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id      INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    name         VARCHAR(100) NOT NULL,
+    prefix       VARCHAR(16) NOT NULL,
+    key_hash     VARCHAR(64) NOT NULL,
+    scopes       TEXT NOT NULL,
+    expires_at   DATETIME,
+    last_used_at DATETIME,
+    revoked_at   DATETIME,
+    created_at   DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT api_keys_key_hash_key UNIQUE (key_hash)
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
@@ -0,0 +1,20 @@
+DELETE FROM mfa_policies WHERE scope = 'organization';
+
+CREATE TABLE mfa_policies_rebuilt (
+    scope      VARCHAR(32) NOT NULL,
+    value      VARCHAR(64) NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    PRIMARY KEY (scope, value),
+    CONSTRAINT mfa_policies_scope_check CHECK (scope IN ('role'))
+);
+
+INSERT INTO mfa_policies_rebuilt (scope, value, created_at) SELECT scope, value, created_at FROM mfa_policies;
+
+DROP TABLE mfa_policies;
+ALTER TABLE mfa_policies_rebuilt RENAME TO mfa_policies;
+
+DROP INDEX IF EXISTS campaigns_organization_id_idx;
+ALTER TABLE campaigns DROP COLUMN organization_id;
+DROP TABLE IF EXISTS organization_invitations;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,58 @@
+-- Brand teams. Members share the organization's campaigns; a user belongs
+-- to at most one organization.
+CREATE TABLE IF NOT EXISTS organizations (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       VARCHAR(100) NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id INTEGER NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id         INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    role            VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+    created_at      DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    PRIMARY KEY (organization_id, user_id),
+    CONSTRAINT organization_members_user_id_key UNIQUE (user_id)
+);
+
+-- Emailed invitations. Only a hash of the token is stored, and accepted
+-- invitations are deleted.
+CREATE TABLE IF NOT EXISTS organization_invitations (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    organization_id INTEGER NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    email           VARCHAR(255) NOT NULL,
+    role            VARCHAR(16) NOT NULL CHECK (role IN ('admin', 'member')),
+    token_hash      VARCHAR(64) NOT NULL,
+    invited_by      INTEGER REFERENCES users (user_id) ON DELETE SET NULL,
+    expires_at      DATETIME NOT NULL,
+    created_at      DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT organization_invitations_token_hash_key UNIQUE (token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS organization_invitations_organization_id_idx ON organization_invitations (organization_id);
+CREATE INDEX IF NOT EXISTS organization_invitations_expires_at_idx ON organization_invitations (expires_at);
+
+-- Campaigns of an organization; deleting it hands them back to the brands
+-- who created them.
+ALTER TABLE campaigns ADD COLUMN organization_id INTEGER REFERENCES organizations (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS campaigns_organization_id_idx ON campaigns (organization_id);
+
+-- Two-factor authentication can also be required of every member of an
+-- organization; value holds the organization's ID.
+--
+-- SQLite can't change a CHECK constraint in place, so mfa_policies is
+-- rebuilt.
+CREATE TABLE mfa_policies_rebuilt (
+    scope      VARCHAR(32) NOT NULL,
+    value      VARCHAR(64) NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    PRIMARY KEY (scope, value),
+    CONSTRAINT mfa_policies_scope_check CHECK (scope IN ('role', 'organization'))
+);
+
+INSERT INTO mfa_policies_rebuilt (scope, value, created_at) SELECT scope, value, created_at FROM mfa_policies;
+
+DROP TABLE mfa_policies;
+ALTER TABLE mfa_policies_rebuilt RENAME TO mfa_policies;
@@ -0,0 +1,18 @@
+-- One row per login, tied to the login's refresh token family. Access
+-- tokens name the family in their sid claim and stop working once the
+-- session is revoked.
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id      INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    family_id    VARCHAR(64) NOT NULL,
+    user_agent   VARCHAR(512) NOT NULL DEFAULT '',
+    ip           VARCHAR(64) NOT NULL DEFAULT '',
+    created_at   DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    last_seen_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    expires_at   DATETIME NOT NULL,
+    revoked_at   DATETIME,
+    CONSTRAINT user_sessions_family_id_key UNIQUE (family_id)
+);
+
+CREATE INDEX IF NOT EXISTS user_sessions_user_id_idx ON user_sessions (user_id);
+CREATE INDEX IF NOT EXISTS user_sessions_expires_at_idx ON user_sessions (expires_at);
@@ -0,0 +1,48 @@
+DROP TABLE IF EXISTS campaign_transitions;
+
+-- See the up migration for why campaigns is rebuilt.
+CREATE TEMP TABLE campaign_applications_saved AS SELECT * FROM campaign_applications;
+
+CREATE TABLE campaigns_rebuilt (
+    id                INTEGER PRIMARY KEY AUTOINCREMENT,
+    brand_id          INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    title             TEXT NOT NULL,
+    description       TEXT NOT NULL DEFAULT '',
+    category          TEXT NOT NULL DEFAULT '',
+    budget            REAL NOT NULL DEFAULT 0,
+    deadline          DATETIME NOT NULL,
+    status            VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at        DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at        DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    application_count INTEGER NOT NULL DEFAULT 0 CHECK (application_count >= 0),
+    accepted_count    INTEGER NOT NULL DEFAULT 0 CHECK (accepted_count >= 0),
+    organization_id   INTEGER REFERENCES organizations (id) ON DELETE SET NULL,
+    CONSTRAINT campaigns_budget_check CHECK (budget >= 0),
+    CONSTRAINT campaigns_status_check CHECK (status IN ('active', 'closed', 'draft'))
+);
+
+INSERT INTO campaigns_rebuilt (
+    id, brand_id, title, description, category, budget, deadline, status,
+    created_at, updated_at, application_count, accepted_count, organization_id
+)
+SELECT id, brand_id, title, description, category, budget, deadline, CASE
+        WHEN status IN ('published', 'paused') THEN 'active'
+        WHEN status IN ('completed', 'cancelled') THEN 'closed'
+        ELSE status
+    END,
+    created_at, updated_at, application_count, accepted_count, organization_id
+FROM campaigns;
+
+DROP TABLE campaigns;
+ALTER TABLE campaigns_rebuilt RENAME TO campaigns;
+
+INSERT INTO campaign_applications SELECT * FROM campaign_applications_saved;
+DROP TABLE campaign_applications_saved;
+
+CREATE INDEX IF NOT EXISTS campaigns_brand_id_idx ON campaigns (brand_id);
+CREATE INDEX IF NOT EXISTS campaigns_status_deadline_idx ON campaigns (status, deadline);
+CREATE INDEX IF NOT EXISTS campaigns_created_at_id_idx ON campaigns (created_at, id);
+CREATE INDEX IF NOT EXISTS campaigns_deadline_id_idx ON campaigns (deadline, id);
+CREATE INDEX IF NOT EXISTS campaigns_budget_id_idx ON campaigns (budget, id);
+CREATE INDEX IF NOT EXISTS campaigns_category_idx ON campaigns (category);
+CREATE INDEX IF NOT EXISTS campaigns_organization_id_idx ON campaigns (organization_id);
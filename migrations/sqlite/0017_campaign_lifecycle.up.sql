@@ -0,0 +1,61 @@
+-- Campaigns follow an explicit lifecycle: draft -> published <-> paused ->
+-- closed -> completed, or cancelled from any state before completion.
+-- What used to be "active" is now "published".
+--
+-- SQLite can't change a CHECK constraint in place, so campaigns is
+-- rebuilt. Dropping the old table cascades to campaign_applications, whose
+-- rows are set aside first and put back afterwards.
+CREATE TEMP TABLE campaign_applications_saved AS SELECT * FROM campaign_applications;
+
+CREATE TABLE campaigns_rebuilt (
+    id                INTEGER PRIMARY KEY AUTOINCREMENT,
+    brand_id          INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    title             TEXT NOT NULL,
+    description       TEXT NOT NULL DEFAULT '',
+    category          TEXT NOT NULL DEFAULT '',
+    budget            REAL NOT NULL DEFAULT 0,
+    deadline          DATETIME NOT NULL,
+    status            VARCHAR(20) NOT NULL DEFAULT 'draft',
+    created_at        DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at        DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    application_count INTEGER NOT NULL DEFAULT 0 CHECK (application_count >= 0),
+    accepted_count    INTEGER NOT NULL DEFAULT 0 CHECK (accepted_count >= 0),
+    organization_id   INTEGER REFERENCES organizations (id) ON DELETE SET NULL,
+    CONSTRAINT campaigns_budget_check CHECK (budget >= 0),
+    CONSTRAINT campaigns_status_check CHECK (status IN ('draft', 'published', 'paused', 'closed', 'completed', 'cancelled'))
+);
+
+INSERT INTO campaigns_rebuilt (
+    id, brand_id, title, description, category, budget, deadline, status,
+    created_at, updated_at, application_count, accepted_count, organization_id
+)
+SELECT id, brand_id, title, description, category, budget, deadline, CASE status WHEN 'active' THEN 'published' ELSE status END,
+    created_at, updated_at, application_count, accepted_count, organization_id
+FROM campaigns;
+
+DROP TABLE campaigns;
+ALTER TABLE campaigns_rebuilt RENAME TO campaigns;
+
+INSERT INTO campaign_applications SELECT * FROM campaign_applications_saved;
+DROP TABLE campaign_applications_saved;
+
+CREATE INDEX IF NOT EXISTS campaigns_brand_id_idx ON campaigns (brand_id);
+CREATE INDEX IF NOT EXISTS campaigns_status_deadline_idx ON campaigns (status, deadline);
+CREATE INDEX IF NOT EXISTS campaigns_created_at_id_idx ON campaigns (created_at, id);
+CREATE INDEX IF NOT EXISTS campaigns_deadline_id_idx ON campaigns (deadline, id);
+CREATE INDEX IF NOT EXISTS campaigns_budget_id_idx ON campaigns (budget, id);
+CREATE INDEX IF NOT EXISTS campaigns_category_idx ON campaigns (category);
+CREATE INDEX IF NOT EXISTS campaigns_organization_id_idx ON campaigns (organization_id);
+
+-- Every status change, and who made it. actor_id is NULL once the user is
+-- deleted.
+CREATE TABLE IF NOT EXISTS campaign_transitions (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    campaign_id INTEGER NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status   VARCHAR(20) NOT NULL,
+    actor_id    INTEGER REFERENCES users (user_id) ON DELETE SET NULL,
+    created_at  DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS campaign_transitions_campaign_id_idx ON campaign_transitions (campaign_id, created_at);
@@ -0,0 +1,39 @@
+-- What a campaign pays for: so many posts, stories, reels or videos on a
+-- platform, optionally by a due date.
+CREATE TABLE IF NOT EXISTS campaign_deliverables (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    campaign_id  INTEGER NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    type         VARCHAR(20) NOT NULL,
+    platform     VARCHAR(20) NOT NULL,
+    quantity     INTEGER NOT NULL DEFAULT 1,
+    due_at       DATETIME,
+    requirements TEXT NOT NULL DEFAULT '',
+    created_at   DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at   DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT campaign_deliverables_type_check CHECK (type IN ('post', 'story', 'reel', 'video')),
+    CONSTRAINT campaign_deliverables_quantity_check CHECK (quantity >= 1)
+);
+
+CREATE INDEX IF NOT EXISTS campaign_deliverables_campaign_id_idx ON campaign_deliverables (campaign_id, id);
+
+-- Drafts and live links accepted influencers submit against a deliverable,
+-- and the brand's review of each. reviewed_by is NULL once the reviewer is
+-- deleted.
+CREATE TABLE IF NOT EXISTS deliverable_submissions (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    deliverable_id INTEGER NOT NULL REFERENCES campaign_deliverables (id) ON DELETE CASCADE,
+    application_id INTEGER NOT NULL REFERENCES campaign_applications (id) ON DELETE CASCADE,
+    kind           VARCHAR(10) NOT NULL,
+    url            VARCHAR(2048) NOT NULL,
+    note           TEXT NOT NULL DEFAULT '',
+    status         VARCHAR(20) NOT NULL DEFAULT 'pending',
+    comment        TEXT NOT NULL DEFAULT '',
+    reviewed_by    INTEGER REFERENCES users (user_id) ON DELETE SET NULL,
+    reviewed_at    DATETIME,
+    created_at     DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT deliverable_submissions_kind_check CHECK (kind IN ('draft', 'live')),
+    CONSTRAINT deliverable_submissions_status_check CHECK (status IN ('pending', 'approved', 'revision_requested'))
+);
+
+CREATE INDEX IF NOT EXISTS deliverable_submissions_application_id_idx ON deliverable_submissions (application_id, created_at, id);
+CREATE INDEX IF NOT EXISTS deliverable_submissions_deliverable_id_idx ON deliverable_submissions (deliverable_id, created_at, id);
@@ -0,0 +1,10 @@
+ALTER TABLE profiles DROP COLUMN verified_at;
+ALTER TABLE profiles DROP COLUMN country;
+ALTER TABLE profiles DROP COLUMN platforms;
+
+ALTER TABLE campaigns DROP COLUMN verified_only;
+ALTER TABLE campaigns DROP COLUMN eligible_countries;
+ALTER TABLE campaigns DROP COLUMN eligible_platforms;
+ALTER TABLE campaigns DROP COLUMN eligible_categories;
+ALTER TABLE campaigns DROP COLUMN min_engagement_rate;
+ALTER TABLE campaigns DROP COLUMN min_followers;
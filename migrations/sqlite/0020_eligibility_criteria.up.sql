@@ -0,0 +1,16 @@
+-- Who may apply to a campaign. The lists are comma-separated and empty
+-- when they don't restrict anything.
+ALTER TABLE campaigns ADD COLUMN min_followers INTEGER NOT NULL DEFAULT 0
+    CONSTRAINT campaigns_min_followers_check CHECK (min_followers >= 0);
+ALTER TABLE campaigns ADD COLUMN min_engagement_rate DOUBLE PRECISION NOT NULL DEFAULT 0
+    CONSTRAINT campaigns_min_engagement_rate_check CHECK (min_engagement_rate >= 0);
+ALTER TABLE campaigns ADD COLUMN eligible_categories TEXT NOT NULL DEFAULT '';
+ALTER TABLE campaigns ADD COLUMN eligible_platforms TEXT NOT NULL DEFAULT '';
+ALTER TABLE campaigns ADD COLUMN eligible_countries TEXT NOT NULL DEFAULT '';
+ALTER TABLE campaigns ADD COLUMN verified_only BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- What campaigns check applicants against: where influencers post and are
+-- based, and whether an admin verified their accounts.
+ALTER TABLE profiles ADD COLUMN platforms TEXT NOT NULL DEFAULT '';
+ALTER TABLE profiles ADD COLUMN country VARCHAR(2) NOT NULL DEFAULT '';
+ALTER TABLE profiles ADD COLUMN verified_at DATETIME;
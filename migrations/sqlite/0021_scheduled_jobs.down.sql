@@ -0,0 +1,32 @@
+ALTER TABLE campaigns DROP COLUMN reminded_deadline;
+
+-- Expired applications become rejected ones; see the up migration for the rebuild.
+CREATE TEMP TABLE deliverable_submissions_saved AS SELECT * FROM deliverable_submissions;
+
+CREATE TABLE campaign_applications_rebuilt (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    campaign_id   INTEGER NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    influencer_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    status        VARCHAR(20) NOT NULL DEFAULT 'pending',
+    message       TEXT NOT NULL DEFAULT '',
+    created_at    DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at    DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT campaign_applications_status_check CHECK (status IN ('pending', 'accepted', 'rejected'))
+);
+
+INSERT INTO campaign_applications_rebuilt (id, campaign_id, influencer_id, status, message, created_at, updated_at)
+SELECT id, campaign_id, influencer_id, CASE status WHEN 'expired' THEN 'rejected' ELSE status END, message, created_at, updated_at
+FROM campaign_applications;
+
+DROP TABLE campaign_applications;
+ALTER TABLE campaign_applications_rebuilt RENAME TO campaign_applications;
+
+INSERT INTO deliverable_submissions SELECT * FROM deliverable_submissions_saved;
+DROP TABLE deliverable_submissions_saved;
+
+CREATE UNIQUE INDEX IF NOT EXISTS campaign_applications_campaign_influencer_key
+    ON campaign_applications (campaign_id, influencer_id);
+CREATE INDEX IF NOT EXISTS campaign_applications_campaign_created_idx ON campaign_applications (campaign_id, created_at, id);
+CREATE INDEX IF NOT EXISTS campaign_applications_influencer_created_idx ON campaign_applications (influencer_id, created_at, id);
+
+DROP TABLE IF EXISTS job_runs;
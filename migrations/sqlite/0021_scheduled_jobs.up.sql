@@ -0,0 +1,57 @@
+-- Every run of a scheduled job. A job runs at most once per scheduled
+-- time, whichever instance gets to it first.
+CREATE TABLE IF NOT EXISTS job_runs (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    job          VARCHAR(100) NOT NULL,
+    scheduled_at DATETIME NOT NULL,
+    instance     VARCHAR(255) NOT NULL DEFAULT '',
+    status       VARCHAR(20) NOT NULL DEFAULT 'running',
+    result       TEXT NOT NULL DEFAULT '',
+    error        TEXT NOT NULL DEFAULT '',
+    duration_ms  BIGINT NOT NULL DEFAULT 0,
+    started_at   DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    finished_at  DATETIME,
+    CONSTRAINT job_runs_job_scheduled_at_key UNIQUE (job, scheduled_at),
+    CONSTRAINT job_runs_status_check CHECK (status IN ('running', 'succeeded', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS job_runs_started_at_id_idx ON job_runs (started_at, id);
+CREATE INDEX IF NOT EXISTS job_runs_duration_ms_id_idx ON job_runs (duration_ms, id);
+
+-- Pending applications nobody decided on expire after a while.
+--
+-- SQLite can't change a CHECK constraint in place, so campaign_applications
+-- is rebuilt. Dropping the old table cascades to deliverable_submissions,
+-- whose rows are set aside first and put back afterwards.
+CREATE TEMP TABLE deliverable_submissions_saved AS SELECT * FROM deliverable_submissions;
+
+CREATE TABLE campaign_applications_rebuilt (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    campaign_id   INTEGER NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    influencer_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    status        VARCHAR(20) NOT NULL DEFAULT 'pending',
+    message       TEXT NOT NULL DEFAULT '',
+    created_at    DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    updated_at    DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+    CONSTRAINT campaign_applications_status_check CHECK (status IN ('pending', 'accepted', 'rejected', 'expired'))
+);
+
+INSERT INTO campaign_applications_rebuilt (id, campaign_id, influencer_id, status, message, created_at, updated_at)
+SELECT id, campaign_id, influencer_id, status, message, created_at, updated_at
+FROM campaign_applications;
+
+DROP TABLE campaign_applications;
+ALTER TABLE campaign_applications_rebuilt RENAME TO campaign_applications;
+
+INSERT INTO deliverable_submissions SELECT * FROM deliverable_submissions_saved;
+DROP TABLE deliverable_submissions_saved;
+
+CREATE UNIQUE INDEX IF NOT EXISTS campaign_applications_campaign_influencer_key
+    ON campaign_applications (campaign_id, influencer_id);
+CREATE INDEX IF NOT EXISTS campaign_applications_campaign_created_idx ON campaign_applications (campaign_id, created_at, id);
+CREATE INDEX IF NOT EXISTS campaign_applications_influencer_created_idx ON campaign_applications (influencer_id, created_at, id);
+CREATE INDEX IF NOT EXISTS campaign_applications_status_created_idx ON campaign_applications (status, created_at);
+
+-- The deadline the brand was last reminded of, so that each deadline gets
+-- one reminder even when it is moved.
+ALTER TABLE campaigns ADD COLUMN reminded_deadline DATETIME;
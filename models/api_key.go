@@ -0,0 +1,49 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Scopes an API key can be granted. Keys only reach the routes that accept
+// one of their scopes; signed-in users are limited by their role alone.
+const (
+	ScopeCampaignsRead      = "campaigns:read"
+	ScopeCampaignsWrite     = "campaigns:write"
+	ScopeApplicationsRead   = "applications:read"
+	ScopeApplicationsManage = "applications:manage"
+)
+
+// APIScopes lists every scope, in the order they are shown.
+var APIScopes = []string{
+	ScopeCampaignsRead,
+	ScopeCampaignsWrite,
+	ScopeApplicationsRead,
+	ScopeApplicationsManage,
+}
+
+// APIKey lets a brand's own systems call the API on the brand's behalf.
+// Only a hash of the key is stored; Prefix is its first characters, kept so
+// that users can tell their keys apart.
+type APIKey struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Active reports whether the key is neither revoked nor expired at t.
+func (k *APIKey) Active(t time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || t.Before(*k.ExpiresAt))
+}
+
+// HasScope reports whether the key was granted scope.
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
@@ -1,71 +1,72 @@
 package models
 
 import (
-	"InfluenceIQ/config"
-	"context"
+	"slices"
 	"time"
 )
 
-// Campaign represents a brand campaign record in PostgreSQL
+// Campaign statuses. A campaign starts as a draft, is published to take
+// applications, may be paused and resumed, and is closed once selection is
+// over. Closed campaigns end up completed; any campaign that isn't
+// completed can be cancelled instead.
+const (
+	CampaignDraft     = "draft"
+	CampaignPublished = "published"
+	CampaignPaused    = "paused"
+	CampaignClosed    = "closed"
+	CampaignCompleted = "completed"
+	CampaignCancelled = "cancelled"
+)
+
+// Campaign represents a brand campaign record in PostgreSQL. BrandID is the
+// brand who created it; when the campaign belongs to an organization, every
+// member manages it. Version goes up with every edit and status change.
+// Only influencers who meet Eligibility may apply.
 type Campaign struct {
-	ID          int       `json:"id"`
-	BrandID     int       `json:"brand_id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Category    string    `json:"category,omitempty"`
-	Budget      float64   `json:"budget"`
-	Deadline    time.Time `json:"deadline"`
-	Status      string    `json:"status"` // active, closed, draft
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID               int         `json:"id"`
+	BrandID          int         `json:"brand_id"`
+	OrganizationID   *int        `json:"organization_id,omitempty"`
+	Title            string      `json:"title"`
+	Description      string      `json:"description"`
+	Category         string      `json:"category,omitempty"`
+	Budget           float64     `json:"budget"`
+	Deadline         time.Time   `json:"deadline"`
+	Eligibility      Eligibility `json:"eligibility"`
+	Status           string      `json:"status"`
+	ApplicationCount int         `json:"application_count"`
+	AcceptedCount    int         `json:"accepted_count"`
+	Version          int         `json:"version"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
 }
 
-func CreateCampaign(ctx context.Context, c *Campaign) error {
-	query := `
-		INSERT INTO campaigns (
-			brand_id, title, description, category, budget, deadline, status, created_at, updated_at
-		)
-		VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, 'active'), NOW(), NOW())
-		RETURNING id, created_at, updated_at
-	`
-	return config.DB.QueryRow(ctx, query,
-		c.BrandID, c.Title, c.Description, c.Category, c.Budget, c.Deadline, c.Status,
-	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+// ListedCampaignStatuses are the statuses in which everyone sees a
+// campaign. Drafts, completed and cancelled campaigns are only shown to the
+// brands who manage them.
+var ListedCampaignStatuses = []string{CampaignPublished, CampaignPaused, CampaignClosed}
+
+// Listed reports whether everyone may see the campaign.
+func (c *Campaign) Listed() bool {
+	return slices.Contains(ListedCampaignStatuses, c.Status)
 }
 
-func GetCampaignByID(ctx context.Context, id int) (*Campaign, error) {
-	var c Campaign
-	query := `
-		SELECT id, brand_id, title, description, category, budget, deadline, status, created_at, updated_at
-		FROM campaigns
-		WHERE id = $1
-	`
-	err := config.DB.QueryRow(ctx, query, id).Scan(
-		&c.ID, &c.BrandID, &c.Title, &c.Description, &c.Category,
-		&c.Budget, &c.Deadline, &c.Status, &c.CreatedAt, &c.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return &c, nil
+// AcceptsApplications reports whether influencers may apply.
+func (c *Campaign) AcceptsApplications() bool {
+	return c.Status == CampaignPublished
 }
 
-func UpdateCampaign(ctx context.Context, c *Campaign) error {
-	query := `
-		UPDATE campaigns
-		SET title = $1, description = $2, category = $3, budget = $4,
-		    deadline = $5, status = $6, updated_at = NOW()
-		WHERE id = $7 AND brand_id = $8
-	`
-	_, err := config.DB.Exec(ctx, query,
-		c.Title, c.Description, c.Category, c.Budget, c.Deadline, c.Status, c.ID, c.BrandID,
-	)
-	return err
+// Finished reports whether the campaign reached a final status.
+func (c *Campaign) Finished() bool {
+	return c.Status == CampaignCompleted || c.Status == CampaignCancelled
 }
 
-func DeleteCampaign(ctx context.Context, id int, brandID int) error {
-	_, err := config.DB.Exec(ctx, `
-		DELETE FROM campaigns WHERE id = $1 AND brand_id = $2
-	`, id, brandID)
-	return err
+// CampaignTransition records a status change. ActorID is nil for changes a
+// scheduled job made, and once the user who made it has been deleted.
+type CampaignTransition struct {
+	ID         int       `json:"id"`
+	CampaignID int       `json:"campaign_id"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	ActorID    *int      `json:"actor_id"`
+	CreatedAt  time.Time `json:"created_at"`
 }
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Deliverable types and the platforms they go on.
+var (
+	DeliverableTypes     = []string{"post", "story", "reel", "video"}
+	DeliverablePlatforms = []string{"instagram", "tiktok", "youtube", "facebook", "x", "linkedin", "twitch", "blog", "other"}
+)
+
+// Deliverable is something a campaign pays every accepted influencer for,
+// e.g. three Instagram reels.
+type Deliverable struct {
+	ID           int        `json:"id"`
+	CampaignID   int        `json:"campaign_id"`
+	Type         string     `json:"type"`
+	Platform     string     `json:"platform"`
+	Quantity     int        `json:"quantity"`
+	DueAt        *time.Time `json:"due_at"`
+	Requirements string     `json:"requirements"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Submission kinds: a draft for the brand to check before posting, or the
+// link to the published content.
+const (
+	SubmissionDraft = "draft"
+	SubmissionLive  = "live"
+)
+
+// Submission statuses.
+const (
+	SubmissionPending  = "pending"
+	SubmissionApproved = "approved"
+	SubmissionRevision = "revision_requested"
+)
+
+// Delivery statuses describe how far an application got with a
+// deliverable, or with all of them.
+const (
+	// DeliveryPending means nothing was submitted or approved yet.
+	DeliveryPending = "pending"
+	// DeliverySubmitted means a submission awaits the brand's review.
+	DeliverySubmitted = "submitted"
+	// DeliveryRevision means the brand asked for changes.
+	DeliveryRevision = "revision_requested"
+	// DeliveryInProgress means some, but not all, work was approved.
+	DeliveryInProgress = "in_progress"
+	// DeliveryCompleted means every live link the deliverable asks for
+	// was approved.
+	DeliveryCompleted = "completed"
+)
+
+// DeliverableSubmission is a draft or live link an influencer submitted for
+// a deliverable of the campaign they were accepted to. ReviewedBy is nil
+// until the brand reviewed it, and once the reviewer has been deleted.
+type DeliverableSubmission struct {
+	ID            int        `json:"id"`
+	DeliverableID int        `json:"deliverable_id"`
+	ApplicationID int        `json:"application_id"`
+	Kind          string     `json:"kind"`
+	URL           string     `json:"url"`
+	Note          string     `json:"note,omitempty"`
+	Status        string     `json:"status"`
+	Comment       string     `json:"comment,omitempty"`
+	ReviewedBy    *int       `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Eligibility are the rules a campaign sets for who may apply. Zero values
+// don't restrict anything; an applicant must match one of the listed
+// categories, platforms and countries.
+type Eligibility struct {
+	MinFollowers      int      `json:"min_followers,omitempty" binding:"gte=0"`
+	MinEngagementRate float64  `json:"min_engagement_rate,omitempty" binding:"gte=0"`
+	Categories        []string `json:"categories,omitempty" binding:"max=20,dive,required,max=50,excludesall=0x2C"`
+	Platforms         []string `json:"platforms,omitempty" binding:"dive,oneof=instagram tiktok youtube facebook x linkedin twitch blog other"`
+	Countries         []string `json:"countries,omitempty" binding:"max=250,dive,iso3166_1_alpha2"`
+	VerifiedOnly      bool     `json:"verified_only,omitempty"`
+}
+
+// Ineligibility explains why a profile fails one of a campaign's rules.
+// Criterion is the rule's JSON name.
+type Ineligibility struct {
+	Criterion string `json:"criterion"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+}
+
+// Check returns the rules p fails, or nil if it may apply.
+func (e *Eligibility) Check(p *Profile) []Ineligibility {
+	var reasons []Ineligibility
+	fails := func(criterion, code, format string, args ...any) {
+		reasons = append(reasons, Ineligibility{Criterion: criterion, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if p.FollowerCount < e.MinFollowers {
+		fails("min_followers", "below_minimum", "at least %d followers required, profile has %d", e.MinFollowers, p.FollowerCount)
+	}
+	if p.EngagementRate < e.MinEngagementRate {
+		fails("min_engagement_rate", "below_minimum", "an engagement rate of at least %g required, profile has %g", e.MinEngagementRate, p.EngagementRate)
+	}
+
+	switch {
+	case len(e.Categories) == 0:
+	case p.Category == "":
+		fails("categories", "missing", "profile has no category; open to: %s", strings.Join(e.Categories, ", "))
+	case !slices.ContainsFunc(e.Categories, func(c string) bool { return strings.EqualFold(c, p.Category) }):
+		fails("categories", "not_allowed", "category %s isn't one of: %s", p.Category, strings.Join(e.Categories, ", "))
+	}
+
+	switch {
+	case len(e.Platforms) == 0:
+	case len(p.Platforms) == 0:
+		fails("platforms", "missing", "profile lists no platforms; open to: %s", strings.Join(e.Platforms, ", "))
+	case !slices.ContainsFunc(p.Platforms, func(platform string) bool { return slices.Contains(e.Platforms, platform) }):
+		fails("platforms", "not_allowed", "profile is on none of: %s", strings.Join(e.Platforms, ", "))
+	}
+
+	switch {
+	case len(e.Countries) == 0:
+	case p.Country == "":
+		fails("countries", "missing", "profile has no country; open to: %s", strings.Join(e.Countries, ", "))
+	case !slices.Contains(e.Countries, p.Country):
+		fails("countries", "not_allowed", "country %s isn't one of: %s", p.Country, strings.Join(e.Countries, ", "))
+	}
+
+	if e.VerifiedOnly && !p.Verified() {
+		fails("verified_only", "unverified", "only verified profiles may apply")
+	}
+	return reasons
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEligibilityCheck(t *testing.T) {
+	verified := time.Now()
+	profile := Profile{
+		FollowerCount:  5000,
+		EngagementRate: 3.5,
+		Category:       "Fashion",
+		Platforms:      []string{"instagram", "tiktok"},
+		Country:        "US",
+		VerifiedAt:     &verified,
+	}
+
+	tests := []struct {
+		name    string
+		rules   Eligibility
+		profile func(p *Profile)
+		// want lists the failed criteria as "criterion/code".
+		want []string
+	}{
+		{"no rules", Eligibility{}, nil, nil},
+		{"every rule met", Eligibility{
+			MinFollowers:      5000,
+			MinEngagementRate: 3.5,
+			Categories:        []string{"beauty", "fashion"},
+			Platforms:         []string{"youtube", "tiktok"},
+			Countries:         []string{"CA", "US"},
+			VerifiedOnly:      true,
+		}, nil, nil},
+		{"too few followers", Eligibility{MinFollowers: 5001}, nil, []string{"min_followers/below_minimum"}},
+		{"low engagement", Eligibility{MinEngagementRate: 4}, nil, []string{"min_engagement_rate/below_minimum"}},
+		{"other category", Eligibility{Categories: []string{"food"}}, nil, []string{"categories/not_allowed"}},
+		{"no category", Eligibility{Categories: []string{"food"}}, func(p *Profile) { p.Category = "" }, []string{"categories/missing"}},
+		{"other platforms", Eligibility{Platforms: []string{"youtube"}}, nil, []string{"platforms/not_allowed"}},
+		{"no platforms", Eligibility{Platforms: []string{"youtube"}}, func(p *Profile) { p.Platforms = nil }, []string{"platforms/missing"}},
+		{"other country", Eligibility{Countries: []string{"GB"}}, nil, []string{"countries/not_allowed"}},
+		{"no country", Eligibility{Countries: []string{"GB"}}, func(p *Profile) { p.Country = "" }, []string{"countries/missing"}},
+		{"country codes match exactly", Eligibility{Countries: []string{"us"}}, nil, []string{"countries/not_allowed"}},
+		{"unverified", Eligibility{VerifiedOnly: true}, func(p *Profile) { p.VerifiedAt = nil }, []string{"verified_only/unverified"}},
+		{"every rule failed", Eligibility{
+			MinFollowers:      10000,
+			MinEngagementRate: 5,
+			Categories:        []string{"food"},
+			Platforms:         []string{"youtube"},
+			Countries:         []string{"GB"},
+			VerifiedOnly:      true,
+		}, func(p *Profile) { p.VerifiedAt = nil }, []string{
+			"min_followers/below_minimum",
+			"min_engagement_rate/below_minimum",
+			"categories/not_allowed",
+			"platforms/not_allowed",
+			"countries/not_allowed",
+			"verified_only/unverified",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := profile
+			if tt.profile != nil {
+				tt.profile(&p)
+			}
+			var got []string
+			for _, r := range tt.rules.Check(&p) {
+				if r.Message == "" {
+					t.Errorf("%s/%s has no message", r.Criterion, r.Code)
+				}
+				got = append(got, r.Criterion+"/"+r.Code)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Identity links a user to their account at an OpenID Connect provider,
+// identified by the provider's subject. A user has at most one identity
+// per provider.
+type Identity struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"-"`
+	Email       string     `json:"email,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// OAuthState is a login started at an OpenID Connect provider and not
+// finished yet. It is found by a hash of the state parameter and holds
+// what is needed to finish the login: the PKCE code verifier and the
+// nonce expected in the ID token. UserID is set when a signed-in user is
+// linking the provider rather than logging in.
+type OAuthState struct {
+	ID           int       `json:"id"`
+	StateHash    string    `json:"-"`
+	Provider     string    `json:"provider"`
+	Nonce        string    `json:"-"`
+	CodeVerifier string    `json:"-"`
+	UserID       *int      `json:"user_id,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Job run statuses.
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// JobRun records one run of a scheduled job. ScheduledAt is the time of
+// the schedule the run is for; each job runs at most once per scheduled
+// time across all instances, and Instance names the one that did. Result
+// summarises what a successful run did and Error why a run failed. A run
+// stays running with no FinishedAt if its instance stopped mid-way.
+type JobRun struct {
+	ID          int        `json:"id"`
+	Job         string     `json:"job"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	Instance    string     `json:"instance"`
+	Status      string     `json:"status"`
+	Result      string     `json:"result,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	DurationMS  int64      `json:"duration_ms"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// TOTP is a user's authenticator app enrollment (RFC 6238). It is pending
+// until the user proves the app works by entering a code, which sets
+// EnabledAt. LastUsedStep is the time step of the last accepted code, so
+// that no code works twice.
+type TOTP struct {
+	UserID       int        `json:"user_id"`
+	Secret       string     `json:"-"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Enabled reports whether the enrollment has been confirmed.
+func (t *TOTP) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+// MFAChallenge stands between the password and the second factor of a
+// login. Only a hash of its token is stored, and it dies after a few
+// wrong codes.
+type MFAChallenge struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	TokenHash string    `json:"-"`
+	Attempts  int       `json:"attempts"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Scopes of an MFAPolicy.
+const (
+	MFAScopeRole         = "role"
+	MFAScopeOrganization = "organization"
+)
+
+// MFAPolicy requires two-factor authentication of every user in a scope,
+// e.g. scope "role" and value "brand", or scope "organization" and the
+// organization's ID as value.
+type MFAPolicy struct {
+	Scope     string    `json:"scope"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
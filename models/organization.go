@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Roles within an organization. Every member can manage the organization's
+// campaigns; admins also manage members and invitations, and owners manage
+// roles and the organization itself.
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// Organization is a brand team whose members share its campaigns. A user
+// belongs to at most one organization.
+type Organization struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OrganizationMember is a user's membership. Username and Email are read
+// from the user.
+type OrganizationMember struct {
+	OrganizationID int       `json:"organization_id"`
+	UserID         int       `json:"user_id"`
+	Username       string    `json:"username"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CanManageMembers reports whether the member may invite and remove members.
+func (m *OrganizationMember) CanManageMembers() bool {
+	return m.Role == OrgRoleOwner || m.Role == OrgRoleAdmin
+}
+
+// OrganizationInvitation is an emailed invitation to join an organization.
+// Only a hash of its token is stored, and it is deleted once accepted.
+type OrganizationInvitation struct {
+	ID             int       `json:"id"`
+	OrganizationID int       `json:"organization_id"`
+	Email          string    `json:"email"`
+	Role           string    `json:"role"`
+	TokenHash      string    `json:"-"`
+	InvitedBy      *int      `json:"invited_by,omitempty"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
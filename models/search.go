@@ -0,0 +1,31 @@
+package models
+
+// CampaignHit is a campaign matched by a search.
+type CampaignHit struct {
+	Campaign
+	Rank float64 `json:"rank"`
+	// Snippet is HTML-escaped text with matches wrapped in <mark> tags.
+	Snippet string `json:"snippet"`
+}
+
+// ProfileHit is a profile matched by a search.
+type ProfileHit struct {
+	Profile
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// SearchFacets counts matching campaigns per category, and matching
+// profiles per category and per account type.
+type SearchFacets struct {
+	CampaignCategory map[string]int `json:"campaign_category"`
+	ProfileCategory  map[string]int `json:"profile_category"`
+	AccountType      map[string]int `json:"account_type"`
+}
+
+// SearchResults holds the ranked hits of one search.
+type SearchResults struct {
+	Campaigns []CampaignHit `json:"campaigns"`
+	Profiles  []ProfileHit  `json:"profiles"`
+	Facets    SearchFacets  `json:"facets"`
+}
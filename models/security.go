@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// LoginAttempts counts the failed logins for one bucket, e.g. an account or
+// a client IP, since WindowStart. A bucket may be blocked until a time,
+// after which its attempts are accepted again.
+type LoginAttempts struct {
+	Bucket       string     `json:"bucket"`
+	Failures     int        `json:"failures"`
+	WindowStart  time.Time  `json:"window_start"`
+	BlockedUntil *time.Time `json:"blocked_until,omitempty"`
+}
+
+// Blocked reports whether the bucket is blocked at t.
+func (a *LoginAttempts) Blocked(t time.Time) bool {
+	return a.BlockedUntil != nil && a.BlockedUntil.After(t)
+}
+
+// Types of a SecurityEvent.
+const (
+	SecurityEventAccountLocked      = "account_locked"
+	SecurityEventAccountUnlocked    = "account_unlocked"
+	SecurityEventIPBlocked          = "ip_blocked"
+	SecurityEventLoginAfterFailures = "login_after_failures"
+)
+
+// SecurityEvent is an entry in the security audit log. UserID is nil when
+// the event concerns no known account, and Login holds the email address or
+// username that was tried.
+type SecurityEvent struct {
+	ID        int       `json:"id"`
+	Type      string    `json:"type"`
+	UserID    *int      `json:"user_id"`
+	IP        string    `json:"ip"`
+	Login     string    `json:"login,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
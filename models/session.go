@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Session is one login on one device. It lives as long as the login's
+// refresh token family, whose ID access tokens carry in their sid claim,
+// and revoking it logs that device out at once.
+type Session struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	FamilyID   string     `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the session is neither revoked nor expired at t.
+func (s *Session) Active(t time.Time) bool {
+	return s.RevokedAt == nil && t.Before(s.ExpiresAt)
+}
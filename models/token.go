@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// RefreshToken is a stored refresh token; only a hash of the token itself
+// is kept. Every token descending from one login shares a FamilyID. Each
+// token can be used once, and using it yields its replacement.
+type RefreshToken struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	FamilyID  string     `json:"family_id"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Purposes of a UserToken.
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+	TokenPurposeUnlockAccount = "unlock_account"
+)
+
+// UserToken is a single-use token mailed to a user, e.g. to verify their
+// email address. Like refresh tokens, only a hash is stored.
+type UserToken struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Purpose   string     `json:"purpose"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
@@ -1,5 +1,20 @@
 package models
 
+import (
+	"strings"
+	"time"
+)
+
+// User roles. Brands and influencers get their role from the account type
+// they pick at signup or when creating their profile; until then a user is
+// a viewer. Admins are appointed.
+const (
+	RoleViewer     = "viewer"
+	RoleInfluencer = "influencer"
+	RoleBrand      = "brand"
+	RoleAdmin      = "admin"
+)
+
 type User struct {
 	ID           int    `json:"id"`
 	Username     string `json:"username"`
@@ -7,11 +22,28 @@ type User struct {
 	FullName     string `json:"full_name,omitempty"`
 	PasswordHash string `json:"-"`
 	Role         string `json:"role,omitempty"`
+	// EmailVerifiedAt is nil until the user follows the link sent to Email.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+}
+
+// EmailVerified reports whether the user has confirmed their address.
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// NormalizeEmail is the form email addresses are stored in. Lookups ignore
+// case as well, for addresses stored before.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 type SignupInput struct {
-	Username string `json:"username" binding:"required,min=3,max=100"`
+	// Username can't contain "@", so that it can't pass for someone's
+	// email address when logging in.
+	Username string `json:"username" binding:"required,min=3,max=100,excludes=@"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
 	FullName string `json:"full_name"`
+	// AccountType may be left empty and chosen later with the profile.
+	AccountType string `json:"account_type" binding:"omitempty,oneof=influencer brand"`
 }
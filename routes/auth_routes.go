@@ -1,51 +1,177 @@
 package routes
 
 import (
+	"InfluenceIQ/config"
 	"InfluenceIQ/controllers"
 	"InfluenceIQ/middleware"
+	"InfluenceIQ/models"
+	"InfluenceIQ/services"
+	"InfluenceIQ/store"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterAuthRoutes(r *gin.RouterGroup) {
+func RegisterAuthRoutes(r *gin.RouterGroup, s *store.Store, tokens *services.TokenService, accounts *services.AccountService, guard *services.LoginGuard, cfg *config.Config) {
+	mfa := services.NewMFAService(s, tokens, guard, cfg.MFA)
+	authCtrl := controllers.NewAuthController(s.Users, tokens, accounts, mfa, guard)
+	mfaCtrl := controllers.NewMFAController(s.Users, mfa)
+	oidcCtrl := controllers.NewOIDCController(services.NewOIDCService(s, cfg), mfa)
+	profileCtrl := controllers.NewProfileController(s, tokens)
+	orgs := services.NewOrganizationService(s, accounts, cfg.Account)
+	orgCtrl := controllers.NewOrganizationController(s.Users, orgs)
+	campaignCtrl := controllers.NewCampaignController(s.Campaigns, s.Profiles, orgs, services.NewCampaignService(s))
+	appCtrl := controllers.NewApplicationController(s, orgs)
+	deliverableCtrl := controllers.NewDeliverableController(s, orgs, services.NewDeliverableService(s))
+	searchCtrl := controllers.NewSearchController(s.Search)
+	aiCtrl := controllers.NewAIController(services.NewGeminiClient(cfg.AI))
+	adminCtrl := controllers.NewAdminController(s)
+	apiKeys := services.NewAPIKeyService(s)
+	apiKeyCtrl := controllers.NewAPIKeyController(apiKeys)
+	sessionCtrl := controllers.NewSessionController(tokens)
+	// requireAuth only accepts signed-in users. Routes brands' integrations
+	// may call use requireAuthOrKey and a scope.
+	requireAuth := middleware.AuthMiddleware(tokens, nil)
+	requireAuthOrKey := middleware.AuthMiddleware(tokens, apiKeys)
+	scope := middleware.ScopeRequired
+	requireVerified := middleware.VerifiedRequired(s.Users)
+	brandOnly := middleware.RoleRequired(models.RoleBrand)
+	influencerOnly := middleware.RoleRequired(models.RoleInfluencer)
+	adminOnly := middleware.RoleRequired(models.RoleAdmin)
+
 	auth := r.Group("/auth")
 	{
-		auth.POST("/signup", controllers.Signup)
-		auth.POST("/login", controllers.Login)
+		auth.POST("/signup", authCtrl.Signup)
+		auth.POST("/login", authCtrl.Login)
+		auth.POST("/refresh", authCtrl.Refresh)
+		auth.POST("/logout", requireAuth, authCtrl.Logout)
+		auth.GET("/sessions", requireAuth, sessionCtrl.List)
+		auth.DELETE("/sessions", requireAuth, sessionCtrl.RevokeAll)
+		auth.DELETE("/sessions/:id", requireAuth, sessionCtrl.Revoke)
+		auth.POST("/verify-email", authCtrl.VerifyEmail)
+		auth.POST("/verify-email/resend", requireAuth, authCtrl.ResendVerification)
+		auth.POST("/forgot-password", authCtrl.ForgotPassword)
+		auth.POST("/reset-password", authCtrl.ResetPassword)
+		auth.POST("/unlock", authCtrl.Unlock)
+
+		// Login with OpenID Connect providers, and linking them to an account
+		auth.GET("/oidc/providers", oidcCtrl.Providers)
+		auth.POST("/oidc/:provider/start", oidcCtrl.Start)
+		auth.POST("/oidc/:provider/callback", oidcCtrl.Callback)
+		auth.GET("/identities", requireAuth, oidcCtrl.Identities)
+		auth.POST("/identities/:provider", requireAuth, oidcCtrl.StartLink)
+		auth.DELETE("/identities/:provider", requireAuth, oidcCtrl.Unlink)
+
+		// Two-factor authentication: finishing logins, and managing it
+		auth.POST("/mfa/verify", mfaCtrl.Verify)
+		auth.POST("/mfa/setup", mfaCtrl.Setup)
+		auth.GET("/mfa", requireAuth, mfaCtrl.Status)
+		auth.POST("/mfa/totp", requireAuth, mfaCtrl.Enroll)
+		auth.POST("/mfa/totp/confirm", requireAuth, mfaCtrl.Confirm)
+		auth.DELETE("/mfa/totp", requireAuth, mfaCtrl.Disable)
+		auth.POST("/mfa/recovery-codes", requireAuth, mfaCtrl.RegenerateRecoveryCodes)
+
+		// API keys for brands' server-to-server integrations
+		auth.POST("/api-keys", requireAuth, brandOnly, requireVerified, apiKeyCtrl.Create)
+		auth.GET("/api-keys", requireAuth, brandOnly, apiKeyCtrl.List)
+		auth.DELETE("/api-keys/:id", requireAuth, brandOnly, apiKeyCtrl.Revoke)
 	}
 
 	// Protected Profile Routes
-	r.GET("/profile/me", middleware.AuthMiddleware(), controllers.GetMyProfileHandler)
-	r.PUT("/profile/update", middleware.AuthMiddleware(), controllers.UpdateMyProfileHandler)
-	r.POST("/profile/create", middleware.AuthMiddleware(), controllers.CreateProfileHandler)
-	r.DELETE("/profile/delete", middleware.AuthMiddleware(), controllers.DeleteMyProfileHandler)
+	r.GET("/profile/me", requireAuth, profileCtrl.GetMyProfileHandler)
+	r.PUT("/profile/update", requireAuth, profileCtrl.UpdateMyProfileHandler)
+	r.POST("/profile/create", requireAuth, profileCtrl.CreateProfileHandler)
+	r.DELETE("/profile/delete", requireAuth, profileCtrl.DeleteMyProfileHandler)
 
 	// Protected Campaign Routes
 	campaign := r.Group("/campaign")
-	campaign.Use(middleware.AuthMiddleware())
+	campaign.Use(requireAuthOrKey)
 	{
-		campaign.POST("/", controllers.CreateCampaign)
-		campaign.GET("/", controllers.GetAllCampaigns)
-		campaign.GET("/me", controllers.GetMyCampaigns)
-		campaign.GET("/:id", controllers.GetCampaignByID)
-		campaign.DELETE("/:id", controllers.DeleteCampaign)
+		campaign.POST("/", brandOnly, requireVerified, scope(models.ScopeCampaignsWrite), campaignCtrl.CreateCampaign)
+		campaign.GET("/", scope(models.ScopeCampaignsRead), campaignCtrl.GetAllCampaigns)
+		campaign.GET("/me", brandOnly, scope(models.ScopeCampaignsRead), campaignCtrl.GetMyCampaigns)
+		campaign.GET("/:id", scope(models.ScopeCampaignsRead), campaignCtrl.GetCampaignByID)
+		campaign.PATCH("/:id", brandOnly, scope(models.ScopeCampaignsWrite), campaignCtrl.UpdateCampaign)
+		campaign.DELETE("/:id", brandOnly, scope(models.ScopeCampaignsWrite), campaignCtrl.DeleteCampaign)
+
+		// Lifecycle: draft -> published <-> paused -> closed -> completed,
+		// or cancelled before completion
+		campaign.POST("/:id/publish", brandOnly, scope(models.ScopeCampaignsWrite), campaignCtrl.Publish)
+		campaign.POST("/:id/pause", brandOnly, scope(models.ScopeCampaignsWrite), campaignCtrl.Pause)
+		campaign.POST("/:id/resume", brandOnly, scope(models.ScopeCampaignsWrite), campaignCtrl.Resume)
+		campaign.POST("/:id/close", brandOnly, scope(models.ScopeCampaignsWrite), campaignCtrl.Close)
+		campaign.POST("/:id/complete", brandOnly, scope(models.ScopeCampaignsWrite), campaignCtrl.Complete)
+		campaign.POST("/:id/cancel", brandOnly, scope(models.ScopeCampaignsWrite), campaignCtrl.Cancel)
+		campaign.GET("/:id/transitions", brandOnly, scope(models.ScopeCampaignsRead), campaignCtrl.Transitions)
+
+		// What the campaign pays accepted influencers for, and how far they got
+		campaign.POST("/:id/deliverables", brandOnly, scope(models.ScopeCampaignsWrite), deliverableCtrl.Create)
+		campaign.GET("/:id/deliverables", scope(models.ScopeCampaignsRead), deliverableCtrl.List)
+		campaign.GET("/:id/deliverables/progress", brandOnly,
+			scope(models.ScopeApplicationsRead, models.ScopeApplicationsManage), deliverableCtrl.CampaignProgress)
+		campaign.PUT("/:id/deliverables/:deliverable_id", brandOnly, scope(models.ScopeCampaignsWrite), deliverableCtrl.Update)
+		campaign.DELETE("/:id/deliverables/:deliverable_id", brandOnly, scope(models.ScopeCampaignsWrite), deliverableCtrl.Delete)
 	}
 
 	// Protected Applications
 	app := r.Group("/application")
-	app.Use(middleware.AuthMiddleware())
 	{
-		app.POST("/apply/:id", controllers.ApplyToCampaign)
-		app.GET("/my", controllers.GetMyApplications)
-		app.GET("/campaign/:id", controllers.GetApplicationsForCampaign)
-		app.PUT("/:id/status", controllers.UpdateApplicationStatus)
+		app.POST("/apply/:id", requireAuth, influencerOnly, requireVerified, appCtrl.ApplyToCampaign)
+		app.GET("/my", requireAuth, influencerOnly, appCtrl.GetMyApplications)
+		app.GET("/campaign/:id", requireAuthOrKey, brandOnly,
+			scope(models.ScopeApplicationsRead, models.ScopeApplicationsManage), appCtrl.GetApplicationsForCampaign)
+		app.PUT("/:id/status", requireAuthOrKey, brandOnly, scope(models.ScopeApplicationsManage), appCtrl.UpdateApplicationStatus)
+
+		// Deliverables: accepted influencers submit, brands review
+		app.GET("/:id/deliverables", requireAuthOrKey,
+			scope(models.ScopeApplicationsRead, models.ScopeApplicationsManage), deliverableCtrl.ApplicationProgress)
+		app.POST("/:id/submissions", requireAuth, influencerOnly, deliverableCtrl.Submit)
 	}
 
-	//  Public AI Endpoints (NO AUTH)
+	submission := r.Group("/submission")
+	submission.Use(requireAuthOrKey, brandOnly, scope(models.ScopeApplicationsManage))
+	{
+		submission.POST("/:id/approve", deliverableCtrl.Approve)
+		submission.POST("/:id/request-revision", deliverableCtrl.RequestRevision)
+	}
+
+	// Brand organizations: members share the organization's campaigns
+	org := r.Group("/organization")
+	org.Use(requireAuth, brandOnly)
+	{
+		org.POST("", requireVerified, orgCtrl.Create)
+		org.GET("", orgCtrl.Get)
+		org.PUT("", orgCtrl.Rename)
+		org.DELETE("", orgCtrl.Delete)
+		org.PUT("/members/:user_id", orgCtrl.SetMemberRole)
+		org.DELETE("/members/:user_id", orgCtrl.RemoveMember)
+		org.POST("/invitations", orgCtrl.Invite)
+		org.GET("/invitations", orgCtrl.ListInvitations)
+		org.DELETE("/invitations/:id", orgCtrl.RevokeInvitation)
+		org.POST("/invitations/accept", requireVerified, orgCtrl.Accept)
+	}
+
+	// Protected Search
+	r.GET("/search", requireAuth, searchCtrl.Search)
+
+	// AI Endpoints: campaign planning for brands, captions for both
 	ai := r.Group("/ai")
+	ai.Use(requireAuth)
+	{
+		ai.POST("/campaign-idea", brandOnly, aiCtrl.GenerateCampaignIdea)
+		ai.POST("/recommend", brandOnly, aiCtrl.RecommendInfluencers)
+		ai.POST("/captions", middleware.RoleRequired(models.RoleBrand, models.RoleInfluencer), aiCtrl.GenerateCaptions)
+	}
+
+	// Admin
+	admin := r.Group("/admin")
+	admin.Use(requireAuth, adminOnly)
 	{
-		ai.POST("/campaign-idea", controllers.GenerateCampaignIdea)
-		ai.POST("/recommend", controllers.RecommendInfluencers)
-		ai.POST("/captions", controllers.GenerateCaptions)
+		admin.PUT("/users/:id/role", adminCtrl.SetUserRole)
+		admin.DELETE("/users/:id/mfa", adminCtrl.ResetUserMFA)
+		admin.PUT("/users/:id/verification", adminCtrl.SetProfileVerification)
+		admin.GET("/mfa-policy", adminCtrl.GetMFAPolicy)
+		admin.PUT("/mfa-policy", adminCtrl.SetMFAPolicy)
+		admin.GET("/security-events", adminCtrl.ListSecurityEvents)
+		admin.GET("/job-runs", adminCtrl.ListJobRuns)
 	}
 }
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"InfluenceIQ/controllers"
+	"InfluenceIQ/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterWellKnownRoutes serves the /.well-known documents other services
+// use to verify InfluenceIQ tokens.
+func RegisterWellKnownRoutes(r gin.IRoutes, tokens *services.TokenService) {
+	wellKnownCtrl := controllers.NewWellKnownController(tokens)
+
+	r.GET("/.well-known/jwks.json", wellKnownCtrl.JWKS)
+}
@@ -0,0 +1,210 @@
+package scheduler
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule says when a job runs. It is either a cron expression of five
+// fields, minute hour day-of-month month day-of-week, evaluated in UTC, or
+// "@every <duration>" for runs at multiples of the duration since the zero
+// time, so that every instance computes the same times.
+type Schedule struct {
+	spec  string
+	every time.Duration
+
+	minute, hour, dom, month, dow uint64
+	// When both day fields are restricted a day matching either runs the
+	// job, as in cron.
+	domStar, dowStar bool
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field bounds, in the order of a cron expression
+var fieldNames = [5]string{"minute", "hour", "day of month", "month", "day of week"}
+var fieldBounds = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// Parse reads a cron expression such as "*/5 * * * *" or "0 9 * * 1-5",
+// one of @hourly, @daily, @weekly, @monthly and @yearly, or "@every 10m".
+// Fields take *, numbers, ranges (a-b), steps (*/n, a-b/n) and lists of
+// those separated by commas. Sunday is 0 or 7.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("schedule %q: @every takes a duration of at least 1s", spec)
+		}
+		return &Schedule{spec: spec, every: d}, nil
+	}
+	expr := spec
+	if d, ok := descriptors[spec]; ok {
+		expr = d
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q: want 5 fields (minute hour day-of-month month day-of-week)", spec)
+	}
+	var sets [5]uint64
+	for i, f := range fields {
+		set, err := parseField(f, fieldBounds[i][0], fieldBounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %s: %w", spec, fieldNames[i], err)
+		}
+		sets[i] = set
+	}
+	// Sunday may be written as 7.
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+	s := &Schedule{
+		spec:    spec,
+		minute:  sets[0],
+		hour:    sets[1],
+		dom:     sets[2],
+		month:   sets[3],
+		dow:     sets[4],
+		domStar: fields[2] == "*" || fields[2] == "?",
+		dowStar: fields[4] == "*" || fields[4] == "?",
+	}
+	if s.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("schedule %q never fires", spec)
+	}
+	return s, nil
+}
+
+// parseField returns the set of values a field matches as a bit mask.
+func parseField(field string, lo, hi int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+
+		from, to := lo, hi
+		switch {
+		case rng == "*" || rng == "?":
+		default:
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("invalid value %q", a)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("invalid value %q", b)
+				}
+			} else if hasStep {
+				// "a/n" means from a to the end.
+				to = hi
+			}
+		}
+		if from < lo || to > hi {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, lo, hi)
+		}
+		if from > to {
+			return 0, fmt.Errorf("range %q ends before it starts", part)
+		}
+		for v := from; v <= to; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first time after t that the schedule fires. Parse
+// rejects schedules that never do, e.g. on February 30th.
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Truncate(s.every).Add(s.every)
+	}
+
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	// Every valid expression fires within a leap cycle.
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !has(s.hour, t.Hour()):
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<v) != 0
+}
+
+// Every returns how often the schedule fires at most, which bounds how long
+// a run should take; for cron expressions it's the shortest gap between
+// minutes or hours they name.
+func (s *Schedule) Every() time.Duration {
+	if s.every > 0 {
+		return s.every
+	}
+	if bits.OnesCount64(s.minute) > 1 {
+		return time.Duration(minGap(s.minute, 60)) * time.Minute
+	}
+	if bits.OnesCount64(s.hour) > 1 {
+		return time.Duration(minGap(s.hour, 24)) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// minGap is the smallest distance between two values of set, wrapping
+// around at n.
+func minGap(set uint64, n int) int {
+	gap, first, prev := n, -1, -1
+	for v := 0; v < n; v++ {
+		if !has(set, v) {
+			continue
+		}
+		if prev >= 0 {
+			gap = min(gap, v-prev)
+		} else {
+			first = v
+		}
+		prev = v
+	}
+	return min(gap, first+n-prev)
+}
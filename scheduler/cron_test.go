@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		err  string
+	}{
+		{"", "want 5 fields"},
+		{"* * * *", "want 5 fields"},
+		{"* * * * * *", "want 5 fields"},
+		{"@often", "want 5 fields"},
+		{"60 * * * *", "minute: \"60\" is outside 0-59"},
+		{"* 24 * * *", "hour: \"24\" is outside 0-23"},
+		{"* * 0 * *", "day of month: \"0\" is outside 1-31"},
+		{"* * * 13 *", "month: \"13\" is outside 1-12"},
+		{"* * * * 8", "day of week: \"8\" is outside 0-7"},
+		{"5-1 * * * *", "range \"5-1\" ends before it starts"},
+		{"*/0 * * * *", "invalid step \"0\""},
+		{"*/x * * * *", "invalid step \"x\""},
+		{"a * * * *", "invalid value \"a\""},
+		{"1-b * * * *", "invalid value \"b\""},
+		{"0 0 30 2 *", "never fires"},
+		{"0 0 31 4,6,9,11 *", "never fires"},
+		{"@every", "want 5 fields"},
+		{"@every 10", "@every takes a duration"},
+		{"@every 500ms", "@every takes a duration"},
+		{"@every -1m", "@every takes a duration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := Parse(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Parse(%q) error = %v, want one containing %q", tt.spec, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	// A Wednesday.
+	from := time.Date(2025, time.January, 15, 10, 30, 45, 0, time.UTC)
+	date := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		spec string
+		want []time.Time
+	}{
+		{"* * * * *", []time.Time{date(1, 15, 10, 31), date(1, 15, 10, 32)}},
+		{"*/5 * * * *", []time.Time{date(1, 15, 10, 35), date(1, 15, 10, 40)}},
+		{"15 * * * *", []time.Time{date(1, 15, 11, 15), date(1, 15, 12, 15)}},
+		{"0 9 * * *", []time.Time{date(1, 16, 9, 0), date(1, 17, 9, 0)}},
+		{"0 9 * * 1-5", []time.Time{date(1, 16, 9, 0), date(1, 17, 9, 0), date(1, 20, 9, 0)}},
+		{"0 0 * * 0", []time.Time{date(1, 19, 0, 0), date(1, 26, 0, 0)}},
+		{"0 0 * * 7", []time.Time{date(1, 19, 0, 0), date(1, 26, 0, 0)}},
+		{"10-20/5 8,17 * * *", []time.Time{date(1, 15, 17, 10), date(1, 15, 17, 15), date(1, 15, 17, 20), date(1, 16, 8, 10)}},
+		{"30/15 * * * *", []time.Time{date(1, 15, 10, 45), date(1, 15, 11, 30)}},
+		{"0 0 31 * *", []time.Time{date(1, 31, 0, 0), date(3, 31, 0, 0), date(5, 31, 0, 0)}},
+		// Both day fields restricted: either matches, as in cron.
+		{"0 12 1 * 5", []time.Time{date(1, 17, 12, 0), date(1, 24, 12, 0), date(1, 31, 12, 0), date(2, 1, 12, 0)}},
+		{"@hourly", []time.Time{date(1, 15, 11, 0), date(1, 15, 12, 0)}},
+		{"@daily", []time.Time{date(1, 16, 0, 0)}},
+		{"@weekly", []time.Time{date(1, 19, 0, 0)}},
+		{"@monthly", []time.Time{date(2, 1, 0, 0), date(3, 1, 0, 0)}},
+		{"@yearly", []time.Time{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{"0 0 29 2 *", []time.Time{time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)}},
+		{"@every 1h", []time.Time{date(1, 15, 11, 0), date(1, 15, 12, 0)}},
+		{"@every 90s", []time.Time{
+			time.Date(2025, 1, 15, 10, 31, 30, 0, time.UTC),
+			time.Date(2025, 1, 15, 10, 33, 0, 0, time.UTC),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			at := from
+			for _, want := range tt.want {
+				got := s.Next(at)
+				if !got.Equal(want) {
+					t.Fatalf("Next(%s) = %s, want %s", at, got, want)
+				}
+				at = got
+			}
+		})
+	}
+}
+
+func TestNextInOtherZones(t *testing.T) {
+	s, err := Parse("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 09:00 UTC is 04:00 in New York in winter.
+	ny := time.FixedZone("EST", -5*60*60)
+	from := time.Date(2025, 1, 15, 3, 0, 0, 0, ny)
+	want := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	if got := s.Next(from); !got.Equal(want) {
+		t.Errorf("Next(%s) = %s, want %s", from, got, want)
+	}
+}
+
+func TestEvery(t *testing.T) {
+	tests := []struct {
+		spec string
+		want time.Duration
+	}{
+		{"* * * * *", time.Minute},
+		{"*/5 * * * *", 5 * time.Minute},
+		{"0,50 * * * *", 10 * time.Minute},
+		{"15 * * * *", time.Hour},
+		{"0 */6 * * *", 6 * time.Hour},
+		{"0 9,17 * * *", 8 * time.Hour},
+		{"0 9 * * *", 24 * time.Hour},
+		{"@weekly", 24 * time.Hour},
+		{"@every 90s", 90 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Every(); got != tt.want {
+				t.Errorf("Every() = %s, want %s", got, tt.want)
+			}
+			if s.String() != tt.spec {
+				t.Errorf("String() = %q, want %q", s.String(), tt.spec)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Package scheduler runs jobs in process on cron-like schedules. Every
+// instance schedules every job, but each scheduled time runs once: the
+// instance that takes the job's database lock first records the run in
+// job_runs, and the others skip it.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+// Func does a job's work and returns a short summary of what it did for
+// the run's record.
+type Func func(ctx context.Context) (string, error)
+
+type job struct {
+	name     string
+	schedule *Schedule
+	run      Func
+}
+
+// Scheduler runs the jobs added to it and records their runs.
+type Scheduler struct {
+	db   database.DB
+	runs store.JobRunRepository
+	// retention is how long runs are kept; zero keeps them forever.
+	retention time.Duration
+	instance  string
+	jobs      []job
+}
+
+// New returns a Scheduler that elects the instance running each job
+// through db and keeps runs for retention.
+func New(db database.DB, runs store.JobRunRepository, retention time.Duration) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		db:        db,
+		runs:      runs,
+		retention: retention,
+		instance:  fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+}
+
+// Add registers run as the job name on the schedule spec; see Parse. Names
+// must be unique, as they identify the job's runs and lock.
+func (s *Scheduler) Add(name, spec string, run Func) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	for _, j := range s.jobs {
+		if j.name == name {
+			return fmt.Errorf("job %s is already scheduled", name)
+		}
+	}
+	s.jobs = append(s.jobs, job{name: name, schedule: schedule, run: run})
+	return nil
+}
+
+// Run runs each job on its schedule until ctx is done, then waits for the
+// runs in progress, whose context is cancelled too. Run it as a server
+// worker.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}()
+	}
+	wg.Wait()
+}
+
+// loop waits for each of the job's scheduled times and runs it. Times
+// that pass while a run takes longer than the schedule allows are skipped.
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	next := j.schedule.Next(time.Now())
+	for !next.IsZero() {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.runAt(ctx, j, next)
+		next = j.schedule.Next(time.Now())
+	}
+}
+
+// runAt runs the job for the scheduled time unless another instance holds
+// its lock or already ran it.
+func (s *Scheduler) runAt(ctx context.Context, j job, scheduledAt time.Time) {
+	release, ok, err := database.TryLock(ctx, s.db, "job:"+j.name)
+	if err != nil {
+		log.Printf("Locking job %s failed: %v", j.name, err)
+		return
+	}
+	if !ok {
+		return
+	}
+	defer release()
+
+	run := &models.JobRun{
+		Job:         j.name,
+		ScheduledAt: scheduledAt.UTC(),
+		Instance:    s.instance,
+		Status:      models.JobRunning,
+	}
+	if err := s.runs.Start(ctx, run); err != nil {
+		// A conflict means another instance ran this scheduled time after
+		// letting go of the lock.
+		if !errors.Is(err, store.ErrConflict) {
+			log.Printf("Recording a run of job %s failed: %v", j.name, err)
+		}
+		return
+	}
+
+	started := time.Now()
+	result, err := call(ctx, j)
+	run.DurationMS = time.Since(started).Milliseconds()
+	run.Result = result
+	run.Status = models.JobSucceeded
+	if err != nil {
+		run.Status = models.JobFailed
+		run.Error = err.Error()
+		log.Printf("Job %s failed: %v", j.name, err)
+	}
+
+	// Record the outcome even if the run was cut short by shutdown.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.runs.Finish(ctx, run); err != nil {
+		log.Printf("Recording the outcome of job %s failed: %v", j.name, err)
+	}
+	if s.retention > 0 {
+		if _, err := s.runs.Purge(ctx, time.Now().Add(-s.retention)); err != nil {
+			log.Printf("Purging job runs failed: %v", err)
+		}
+	}
+}
+
+// call runs the job with a timeout of its shortest interval, so that a run
+// ends before the next one is due, and turns a panic into an error.
+func call(ctx context.Context, j job) (result string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, j.schedule.Every())
+	defer cancel()
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return j.run(ctx)
+}
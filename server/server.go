@@ -0,0 +1,99 @@
+// Package server runs the HTTP API and its background workers, and shuts
+// them down in order when the process is asked to stop.
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+
+	"InfluenceIQ/config"
+)
+
+// Worker is a background task. It must return promptly once ctx is done.
+type Worker func(ctx context.Context)
+
+// Server owns the HTTP listener and the background workers.
+type Server struct {
+	cfg     config.HTTPConfig
+	http    *http.Server
+	workers []Worker
+}
+
+func New(cfg config.HTTPConfig, handler http.Handler) *Server {
+	return &Server{
+		cfg: cfg,
+		http: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+	}
+}
+
+// Go registers a worker to run alongside the HTTP server. Workers start
+// when Run is called and are stopped after the HTTP server has drained.
+func (s *Server) Go(w Worker) {
+	s.workers = append(s.workers, w)
+}
+
+// Run serves until ctx is cancelled (typically by SIGINT/SIGTERM) or the
+// listener fails. On the way out it stops accepting connections, waits up to
+// ShutdownTimeout for in-flight requests, then cancels and waits for the
+// workers. Closing shared resources such as the database is left to the
+// caller, after Run returns.
+func (s *Server) Run(ctx context.Context) error {
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var wg sync.WaitGroup
+	for _, w := range s.workers {
+		wg.Add(1)
+		go func(w Worker) {
+			defer wg.Done()
+			w(workerCtx)
+		}(w)
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		var err error
+		if s.cfg.TLSCertFile != "" {
+			log.Printf(" Server running on https://%s", s.cfg.Addr)
+			err = s.http.ListenAndServeTLS(s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+		} else {
+			log.Printf(" Server running on http://%s", s.cfg.Addr)
+			err = s.http.ListenAndServe()
+		}
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		listenErr <- err
+	}()
+
+	var err error
+	select {
+	case err = <-listenErr:
+		// The listener failed (e.g. port in use); nothing to drain.
+	case <-ctx.Done():
+		log.Println(" Shutting down, draining in-flight requests...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+		err = s.http.Shutdown(shutdownCtx)
+		cancel()
+		if err != nil {
+			log.Printf(" HTTP shutdown incomplete: %v", err)
+			s.http.Close()
+		}
+		<-listenErr
+	}
+
+	stopWorkers()
+	wg.Wait()
+	log.Println(" Background workers stopped")
+	return err
+}
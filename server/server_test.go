@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"InfluenceIQ/config"
+)
+
+// freeAddr returns a local address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+// waitListening polls addr until the server accepts connections.
+func waitListening(t *testing.T, addr string) {
+	t.Helper()
+	for range 100 {
+		if c, err := net.Dial("tcp", addr); err == nil {
+			c.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("nothing listening on %s", addr)
+}
+
+func TestRunDrains(t *testing.T) {
+	tests := []struct {
+		name            string
+		shutdownTimeout time.Duration
+		// hold is how long the in-flight request takes after shutdown
+		// begins.
+		hold    time.Duration
+		wantErr error
+		// wantServed is whether the in-flight request gets its response.
+		wantServed bool
+	}{
+		{"in-flight request finishes", time.Second, 50 * time.Millisecond, nil, true},
+		{"drain times out", 50 * time.Millisecond, time.Second, context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			var finished atomic.Bool
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				select {
+				case <-time.After(tt.hold):
+				case <-r.Context().Done():
+					return
+				}
+				finished.Store(true)
+				io.WriteString(w, "done")
+			})
+
+			addr := freeAddr(t)
+			s := New(config.HTTPConfig{Addr: addr, ShutdownTimeout: tt.shutdownTimeout}, handler)
+			var workerStoppedEarly atomic.Bool
+			workerDone := make(chan struct{})
+			s.Go(func(ctx context.Context) {
+				<-ctx.Done()
+				// Workers are only stopped once the HTTP server has drained.
+				workerStoppedEarly.Store(tt.wantServed && !finished.Load())
+				close(workerDone)
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			runErr := make(chan error, 1)
+			go func() { runErr <- s.Run(ctx) }()
+			waitListening(t, addr)
+
+			served := make(chan bool, 1)
+			go func() {
+				resp, err := http.Get("http://" + addr + "/")
+				if err != nil {
+					served <- false
+					return
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				served <- string(body) == "done"
+			}()
+			<-started
+			cancel()
+
+			if err := <-runErr; !errors.Is(err, tt.wantErr) {
+				t.Errorf("Run = %v, want %v", err, tt.wantErr)
+			}
+			select {
+			case <-workerDone:
+			default:
+				t.Error("Run returned before its workers stopped")
+			}
+			if workerStoppedEarly.Load() {
+				t.Error("worker stopped while a request was still in flight")
+			}
+			if got := <-served; got != tt.wantServed {
+				t.Errorf("request served = %v, want %v", got, tt.wantServed)
+			}
+			if _, err := net.Dial("tcp", addr); err == nil {
+				t.Error("still accepting connections after Run returned")
+			}
+		})
+	}
+}
+
+func TestRunListenFails(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	s := New(config.HTTPConfig{Addr: l.Addr().String(), ShutdownTimeout: time.Second}, http.NotFoundHandler())
+	stopped := make(chan struct{})
+	s.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(context.Background()) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Run = nil, want the listen error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run didn't return when the address was in use")
+	}
+	select {
+	case <-stopped:
+	default:
+		t.Error("workers not stopped")
+	}
+}
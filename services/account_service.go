@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/mailer"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+// ErrInvalidUserToken is returned for unknown, used or expired email
+// verification, password reset and unlock tokens.
+var ErrInvalidUserToken = errors.New("invalid or expired token")
+
+// AccountService runs the flows that prove control of an email address:
+// verifying it after signup, resetting a forgotten password and unlocking
+// a locked account.
+type AccountService struct {
+	store  *store.Store
+	mailer mailer.Mailer
+	cfg    config.AccountConfig
+}
+
+func NewAccountService(s *store.Store, m mailer.Mailer, cfg config.AccountConfig) *AccountService {
+	return &AccountService{store: s, mailer: m, cfg: cfg}
+}
+
+// SendVerification mails user a link to verify their email address. Links
+// sent earlier stop working.
+func (s *AccountService) SendVerification(ctx context.Context, user *models.User) error {
+	raw, err := s.newToken(ctx, user.ID, models.TokenPurposeVerifyEmail, s.cfg.VerificationTTL)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your InfluenceIQ email address",
+		Text: fmt.Sprintf("Hi %s,\n\n"+
+			"Confirm your email address by opening this link:\n\n%s\n\n"+
+			"The link expires in %s. If you didn't sign up for InfluenceIQ, you can ignore this email.\n",
+			user.Username, s.link("/verify-email", raw), humanDuration(s.cfg.VerificationTTL)),
+	})
+}
+
+// VerifyEmail consumes a verification token and marks its user verified.
+func (s *AccountService) VerifyEmail(ctx context.Context, raw string) error {
+	return s.store.WithTx(ctx, func(tx *store.Store) error {
+		t, err := tx.Tokens.ConsumeUserToken(ctx, models.TokenPurposeVerifyEmail, hashToken(raw))
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrInvalidUserToken
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Users.SetEmailVerified(ctx, t.UserID)
+	})
+}
+
+// ForgotPassword mails a password reset link to the account with the given
+// email address. Unknown addresses are silently ignored so that the
+// endpoint doesn't reveal which addresses have accounts.
+func (s *AccountService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.store.Users.GetByEmail(ctx, email)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	raw, err := s.newToken(ctx, user.ID, models.TokenPurposeResetPassword, s.cfg.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+	// A delivery failure is only logged: reporting it would tell the
+	// caller that the address has an account.
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your InfluenceIQ password",
+		Text: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password of your InfluenceIQ account. Choose a new one here:\n\n%s\n\n"+
+			"The link expires in %s. If it wasn't you, ignore this email; your password stays the same.\n",
+			user.Username, s.link("/reset-password", raw), humanDuration(s.cfg.PasswordResetTTL)),
+	})
+	if err != nil {
+		log.Printf("Sending password reset email to user %d failed: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword consumes a reset token and sets the new password. Every
+// session of the user is logged out, and since the link arrived by email
+// the address counts as verified.
+func (s *AccountService) ResetPassword(ctx context.Context, raw, password string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	return s.store.WithTx(ctx, func(tx *store.Store) error {
+		t, err := tx.Tokens.ConsumeUserToken(ctx, models.TokenPurposeResetPassword, hashToken(raw))
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrInvalidUserToken
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Users.UpdatePassword(ctx, t.UserID, string(hashed)); err != nil {
+			return err
+		}
+		if err := tx.Users.SetEmailVerified(ctx, t.UserID); err != nil {
+			return err
+		}
+		if err := tx.Tokens.DeleteUserTokens(ctx, t.UserID, models.TokenPurposeResetPassword); err != nil {
+			return err
+		}
+		return tx.Tokens.RevokeUserRefresh(ctx, t.UserID)
+	})
+}
+
+// SendUnlock mails user a link that lifts the lockout their failed logins
+// caused. The link works until the lockout would end anyway.
+func (s *AccountService) SendUnlock(ctx context.Context, user *models.User, failures int, until time.Time) error {
+	ttl := time.Until(until)
+	raw, err := s.newToken(ctx, user.ID, models.TokenPurposeUnlockAccount, ttl)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your InfluenceIQ account was locked",
+		Text: fmt.Sprintf("Hi %s,\n\n"+
+			"Your InfluenceIQ account was locked after %d failed login attempts. If they were yours, unlock it here:\n\n%s\n\n"+
+			"Otherwise someone may be guessing your password. The account unlocks by itself in %s; "+
+			"consider choosing a stronger password and turning on two-factor authentication.\n",
+			user.Username, failures, s.link("/unlock-account", raw), humanDuration(ttl)),
+	})
+}
+
+// ConsumeUnlock consumes an unlock token and returns its user's ID.
+func (s *AccountService) ConsumeUnlock(ctx context.Context, raw string) (int, error) {
+	t, err := s.store.Tokens.ConsumeUserToken(ctx, models.TokenPurposeUnlockAccount, hashToken(raw))
+	if errors.Is(err, store.ErrNotFound) {
+		return 0, ErrInvalidUserToken
+	}
+	if err != nil {
+		return 0, err
+	}
+	return t.UserID, nil
+}
+
+// SendInvitation mails the link that lets inv's invitee join org. raw is
+// the invitation token.
+func (s *AccountService) SendInvitation(ctx context.Context, inv *models.OrganizationInvitation, raw string, org *models.Organization, inviter *models.User) error {
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      inv.Email,
+		Subject: fmt.Sprintf("Join %s on InfluenceIQ", org.Name),
+		Text: fmt.Sprintf("Hi,\n\n"+
+			"%s invited you to join %s on InfluenceIQ with the %s role. Accept the invitation here:\n\n%s\n\n"+
+			"You need a brand account with this email address to join. The link expires in %s; "+
+			"if you weren't expecting it, you can ignore this email.\n",
+			inviter.Username, org.Name, inv.Role,
+			s.link("/join-organization", raw), humanDuration(s.cfg.InvitationTTL)),
+	})
+}
+
+// newToken replaces the user's outstanding tokens for purpose with a new
+// one and returns it.
+func (s *AccountService) newToken(ctx context.Context, userID int, purpose string, ttl time.Duration) (string, error) {
+	raw := rand.Text() + rand.Text()
+	err := s.store.WithTx(ctx, func(tx *store.Store) error {
+		if err := tx.Tokens.DeleteUserTokens(ctx, userID, purpose); err != nil {
+			return err
+		}
+		return tx.Tokens.CreateUserToken(ctx, &models.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: hashToken(raw),
+			ExpiresAt: time.Now().Add(ttl),
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// link points at path on the frontend with the token as a query parameter.
+func (s *AccountService) link(path, token string) string {
+	return strings.TrimRight(s.cfg.LinkBaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// humanDuration formats d for an email, e.g. "48 hours" or "30 minutes".
+func humanDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return plural(int(d/time.Hour), "hour")
+	case d >= time.Minute:
+		return plural(int(d.Round(time.Minute)/time.Minute), "minute")
+	}
+	return d.String()
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
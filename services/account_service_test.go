@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/mailer"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/memory"
+)
+
+// recordingMailer keeps the messages it is asked to send.
+type recordingMailer struct {
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// last returns the token in the link of the last message sent to to.
+func (m *recordingMailer) last(t *testing.T, to string) string {
+	t.Helper()
+	for i := len(m.sent) - 1; i >= 0; i-- {
+		if m.sent[i].To != to {
+			continue
+		}
+		_, query, ok := strings.Cut(m.sent[i].Text, "?token=")
+		if !ok {
+			t.Fatalf("no link in %q", m.sent[i].Text)
+		}
+		raw, err := url.QueryUnescape(strings.Fields(query)[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	t.Fatalf("nothing sent to %s", to)
+	return ""
+}
+
+func newAccountTest(t *testing.T) (*AccountService, *store.Store, *recordingMailer) {
+	t.Helper()
+	st := memory.New()
+	m := &recordingMailer{}
+	s := NewAccountService(st, m, config.AccountConfig{
+		LinkBaseURL:      "https://app.example.com/",
+		VerificationTTL:  time.Hour,
+		PasswordResetTTL: time.Hour,
+		InvitationTTL:    time.Hour,
+	})
+	return s, st, m
+}
+
+func TestVerifyEmail(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		// resend sends another link before verifying with the first.
+		resend bool
+		want   error
+	}{
+		{"mailed token", "", false, nil},
+		{"unknown token", "nope", false, ErrInvalidUserToken},
+		{"superseded token", "", true, ErrInvalidUserToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, st, m := newAccountTest(t)
+			user := newTestUser(t, st, "ada", "ada@example.com")
+			if err := s.SendVerification(ctx, user); err != nil {
+				t.Fatal(err)
+			}
+			mailed := m.last(t, user.Email)
+			if tt.resend {
+				if err := s.SendVerification(ctx, user); err != nil {
+					t.Fatal(err)
+				}
+			}
+			token := tt.token
+			if token == "" {
+				token = mailed
+			}
+
+			err := s.VerifyEmail(ctx, token)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("VerifyEmail = %v, want %v", err, tt.want)
+			}
+			got, err := st.Users.GetByID(ctx, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.EmailVerified() != (tt.want == nil) {
+				t.Errorf("verified = %v, want %v", got.EmailVerified(), tt.want == nil)
+			}
+			if tt.want == nil {
+				if err := s.VerifyEmail(ctx, mailed); !errors.Is(err, ErrInvalidUserToken) {
+					t.Errorf("second VerifyEmail = %v, want %v", err, ErrInvalidUserToken)
+				}
+			}
+		})
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+		// wantTo is who receives the reset link, or empty for nobody.
+		wantTo string
+	}{
+		{"known address", "ada@example.com", "ada@example.com"},
+		{"address in another case", "Ada@Example.com", "ada@example.com"},
+		{"unknown address", "bob@example.com", ""},
+		{"username that looks like the address", "grace@example.com", "grace@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, st, m := newAccountTest(t)
+			newTestUser(t, st, "ada", "ada@example.com")
+			// A legacy username that equals someone else's address must
+			// not receive their reset link.
+			newTestUser(t, st, "grace@example.com", "mallory@example.com")
+			newTestUser(t, st, "grace", "grace@example.com")
+
+			if err := s.ForgotPassword(ctx, tt.email); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantTo == "" {
+				if len(m.sent) != 0 {
+					t.Errorf("sent %d messages, want none", len(m.sent))
+				}
+				return
+			}
+			if len(m.sent) != 1 || m.sent[0].To != tt.wantTo {
+				t.Fatalf("sent %+v, want one message to %s", m.sent, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	s, st, m := newAccountTest(t)
+	user := newTestUser(t, st, "ada", "ada@example.com")
+	tokens, err := NewTokenService(st, config.JWTConfig{Issuer: "test", TTL: time.Minute, RefreshTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := tokens.Issue(ctx, user, Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.ForgotPassword(ctx, user.Email); err != nil {
+		t.Fatal(err)
+	}
+	raw := m.last(t, user.Email)
+	if err := s.ResetPassword(ctx, "nope", "new password"); !errors.Is(err, ErrInvalidUserToken) {
+		t.Fatalf("ResetPassword with unknown token = %v, want %v", err, ErrInvalidUserToken)
+	}
+	if err := s.ResetPassword(ctx, raw, "new password"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := st.Users.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(got.PasswordHash), []byte("new password")) != nil {
+		t.Error("password not changed")
+	}
+	if !got.EmailVerified() {
+		t.Error("email not verified by the reset link")
+	}
+	if _, err := tokens.Refresh(ctx, pair.RefreshToken, Client{}); err == nil {
+		t.Error("refresh token still works after the reset")
+	}
+	if err := s.ResetPassword(ctx, raw, "another password"); !errors.Is(err, ErrInvalidUserToken) {
+		t.Errorf("reusing the token = %v, want %v", err, ErrInvalidUserToken)
+	}
+}
@@ -7,12 +7,26 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"os"
+
+	"InfluenceIQ/config"
 )
 
-var geminiKey = os.Getenv("GEMINI_API_KEY")
+const geminiEndpoint = "https://generativelanguage.googleapis.com/v1/models/%s:generateContent"
+
+// GeminiClient calls the Gemini generateContent API.
+type GeminiClient struct {
+	apiKey string
+	model  string
+	client *http.Client
+}
 
-const geminiEndpoint = "https://generativelanguage.googleapis.com/v1/models/gemini-2.5-flash:generateContent"
+func NewGeminiClient(cfg config.AIConfig) *GeminiClient {
+	return &GeminiClient{
+		apiKey: cfg.GeminiAPIKey,
+		model:  cfg.GeminiModel,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
 
 type GeminiRequest struct {
 	Input string `json:"input"` // single string, not a list
@@ -28,12 +42,12 @@ type GeminiResponse struct {
 	} `json:"candidates"`
 }
 
-func CallGemini(prompt string) (string, error) {
-	if geminiKey == "" {
+func (g *GeminiClient) CallGemini(prompt string) (string, error) {
+	if g.apiKey == "" {
 		return "", errors.New("GEMINI_API_KEY not set")
 	}
 
-	url := geminiEndpoint + "?key=" + geminiKey
+	url := fmt.Sprintf(geminiEndpoint, g.model) + "?key=" + g.apiKey
 
 	payload := map[string]interface{}{
 		"contents": []map[string]interface{}{
@@ -50,8 +64,7 @@ func CallGemini(prompt string) (string, error) {
 	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := g.client.Do(req)
 	if err != nil {
 		return "", err
 	}
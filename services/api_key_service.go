@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, revoked and expired API
+	// keys, and for keys whose owner is no longer a brand.
+	ErrInvalidAPIKey = errors.New("invalid or revoked API key")
+	// ErrAPIKeyLimit is returned when a user already has maxAPIKeys active
+	// keys.
+	ErrAPIKeyLimit = errors.New("too many active API keys")
+	// ErrAPIKeyExpiry is returned for expiry times that have already passed.
+	ErrAPIKeyExpiry = errors.New("API key expiry is in the past")
+)
+
+const (
+	// apiKeyPrefix marks API keys so they can't be mistaken for JWTs.
+	apiKeyPrefix = "iiq_"
+	// apiKeyShownChars is how many characters of a key, after apiKeyPrefix,
+	// are kept in the clear to tell keys apart.
+	apiKeyShownChars = 6
+	maxAPIKeys       = 20
+	// apiKeyTouchInterval limits how often using a key writes its
+	// last-used time.
+	apiKeyTouchInterval = time.Minute
+)
+
+// NewAPIKey is a key just created. Key is the only time the key itself is
+// shown.
+type NewAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyService manages the API keys brands use for server-to-server
+// integrations and authenticates requests made with them.
+type APIKeyService struct {
+	store *store.Store
+}
+
+func NewAPIKeyService(s *store.Store) *APIKeyService {
+	return &APIKeyService{store: s}
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than a JWT.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// Create issues user a key with the given scopes. expiresAt is optional.
+func (s *APIKeyService) Create(ctx context.Context, userID int, name string, scopes []string, expiresAt *time.Time) (*NewAPIKey, error) {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, ErrAPIKeyExpiry
+	}
+
+	active, err := s.store.APIKeys.CountActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if active >= maxAPIKeys {
+		return nil, ErrAPIKeyLimit
+	}
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	raw := apiKeyPrefix + rand.Text()
+	key := NewAPIKey{
+		APIKey: models.APIKey{
+			UserID:    userID,
+			Name:      strings.TrimSpace(name),
+			Prefix:    raw[:len(apiKeyPrefix)+apiKeyShownChars],
+			KeyHash:   hashToken(raw),
+			Scopes:    slices.Compact(scopes),
+			ExpiresAt: expiresAt,
+		},
+		Key: raw,
+	}
+	if err := s.store.APIKeys.Create(ctx, &key.APIKey); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List returns the user's keys, revoked and expired ones included.
+func (s *APIKeyService) List(ctx context.Context, userID int) ([]models.APIKey, error) {
+	return s.store.APIKeys.ListByUser(ctx, userID)
+}
+
+// Revoke stops the user's key from working. It returns store.ErrNotFound
+// for keys of other users and keys already revoked.
+func (s *APIKeyService) Revoke(ctx context.Context, userID, id int) error {
+	return s.store.APIKeys.Revoke(ctx, id, userID)
+}
+
+// AuthenticateKey returns the active key raw and the brand it belongs to.
+// The owner is read on every request so that a user who stops being a
+// brand loses access at once.
+func (s *APIKeyService) AuthenticateKey(ctx context.Context, raw string) (*models.APIKey, *models.User, error) {
+	key, err := s.store.APIKeys.GetByHash(ctx, hashToken(raw))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	t := time.Now()
+	if !key.Active(t) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.store.Users.GetByID(ctx, key.UserID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Role != models.RoleBrand {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || t.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		// Failing to record the use shouldn't fail the request.
+		if err := s.store.APIKeys.TouchLastUsed(ctx, key.ID); err != nil {
+			log.Printf("Recording use of API key %d failed: %v", key.ID, err)
+		}
+	}
+	return key, user, nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/memory"
+)
+
+func TestAPIKeyCreate(t *testing.T) {
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	tests := []struct {
+		name      string
+		scopes    []string
+		expiresAt *time.Time
+		// existing is how many active keys the user has already.
+		existing   int
+		want       error
+		wantScopes []string
+	}{
+		{"sorts and dedupes scopes", []string{models.ScopeCampaignsWrite, models.ScopeCampaignsRead, models.ScopeCampaignsWrite},
+			nil, 0, nil, []string{models.ScopeCampaignsRead, models.ScopeCampaignsWrite}},
+		{"expiring", []string{models.ScopeCampaignsRead}, &future, 0, nil, []string{models.ScopeCampaignsRead}},
+		{"expiry in the past", []string{models.ScopeCampaignsRead}, &past, 0, ErrAPIKeyExpiry, nil},
+		{"too many keys", []string{models.ScopeCampaignsRead}, nil, maxAPIKeys, ErrAPIKeyLimit, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := memory.New()
+			s := NewAPIKeyService(st)
+			user := newTestUser(t, st, "acme", "acme@example.com")
+			for range tt.existing {
+				if _, err := s.Create(ctx, user.ID, "old", tt.scopes, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			key, err := s.Create(ctx, user.ID, " CI ", tt.scopes, tt.expiresAt)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Create = %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			if !IsAPIKey(key.Key) || !strings.HasPrefix(key.Key, key.Prefix) || len(key.Prefix) != len(apiKeyPrefix)+apiKeyShownChars {
+				t.Errorf("key %q with prefix %q", key.Key, key.Prefix)
+			}
+			if key.KeyHash != hashToken(key.Key) {
+				t.Error("stored hash doesn't match the key")
+			}
+			if key.Name != "CI" {
+				t.Errorf("name = %q, want %q", key.Name, "CI")
+			}
+			if strings.Join(key.Scopes, " ") != strings.Join(tt.wantScopes, " ") {
+				t.Errorf("scopes = %v, want %v", key.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestAuthenticateKey(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare changes the key or its owner before authenticating and
+		// returns the key to present.
+		prepare func(t *testing.T, st *store.Store, s *APIKeyService, key *NewAPIKey, owner *models.User) string
+		want    error
+	}{
+		{"active key", func(_ *testing.T, _ *store.Store, _ *APIKeyService, key *NewAPIKey, _ *models.User) string {
+			return key.Key
+		}, nil},
+		{"unknown key", func(_ *testing.T, _ *store.Store, _ *APIKeyService, key *NewAPIKey, _ *models.User) string {
+			return key.Key + "x"
+		}, ErrInvalidAPIKey},
+		{"revoked key", func(t *testing.T, _ *store.Store, s *APIKeyService, key *NewAPIKey, owner *models.User) string {
+			if err := s.Revoke(context.Background(), owner.ID, key.ID); err != nil {
+				t.Fatal(err)
+			}
+			return key.Key
+		}, ErrInvalidAPIKey},
+		{"owner no longer a brand", func(t *testing.T, st *store.Store, _ *APIKeyService, key *NewAPIKey, owner *models.User) string {
+			if err := st.Users.SetRole(context.Background(), owner.ID, models.RoleViewer); err != nil {
+				t.Fatal(err)
+			}
+			return key.Key
+		}, ErrInvalidAPIKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := memory.New()
+			s := NewAPIKeyService(st)
+			owner := &models.User{Username: "acme", Email: "acme@example.com", Role: models.RoleBrand}
+			if err := st.Users.Create(ctx, owner); err != nil {
+				t.Fatal(err)
+			}
+			key, err := s.Create(ctx, owner.ID, "CI", []string{models.ScopeCampaignsRead}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, user, err := s.AuthenticateKey(ctx, tt.prepare(t, st, s, key, owner))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("AuthenticateKey = %v, want %v", err, tt.want)
+			}
+			if err == nil && (got.ID != key.ID || user.ID != owner.ID) {
+				t.Errorf("got key %d of user %d, want key %d of user %d", got.ID, user.ID, key.ID, owner.ID)
+			}
+		})
+	}
+
+	t.Run("revoking someone else's key", func(t *testing.T) {
+		ctx := context.Background()
+		st := memory.New()
+		s := NewAPIKeyService(st)
+		owner := newTestUser(t, st, "acme", "acme@example.com")
+		other := newTestUser(t, st, "other", "other@example.com")
+		key, err := s.Create(ctx, owner.ID, "CI", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Revoke(ctx, other.ID, key.ID); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("Revoke = %v, want %v", err, store.ErrNotFound)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/mailer"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+// CampaignJobs are the scheduled jobs that act on campaign deadlines and
+// on applications nobody decided on. Each returns a summary for the job's
+// run record.
+type CampaignJobs struct {
+	store     *store.Store
+	lifecycle *CampaignService
+	mailer    mailer.Mailer
+	cfg       config.JobsConfig
+}
+
+func NewCampaignJobs(s *store.Store, lifecycle *CampaignService, m mailer.Mailer, cfg config.JobsConfig) *CampaignJobs {
+	return &CampaignJobs{store: s, lifecycle: lifecycle, mailer: m, cfg: cfg}
+}
+
+// running lists the published and paused campaigns matching f.
+func (j *CampaignJobs) running(ctx context.Context, f store.CampaignFilter) ([]models.Campaign, error) {
+	f.Statuses = []string{models.CampaignPublished, models.CampaignPaused}
+	opts := store.ListOptions{Limit: store.MaxLimit, Sort: store.Sort{Field: "deadline"}}
+	var campaigns []models.Campaign
+	for {
+		page, err := j.store.Campaigns.List(ctx, f, opts)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, page.Items...)
+		if page.Next == nil {
+			return campaigns, nil
+		}
+		opts.Cursor = page.Next
+	}
+}
+
+// CloseExpired closes the published and paused campaigns whose deadline
+// has passed, which also rejects their pending applications.
+func (j *CampaignJobs) CloseExpired(ctx context.Context) (string, error) {
+	now := time.Now()
+	campaigns, err := j.running(ctx, store.CampaignFilter{DeadlineTo: &now})
+	if err != nil {
+		return "", err
+	}
+
+	closed := 0
+	var errs []error
+	for _, c := range campaigns {
+		_, err := j.lifecycle.Transition(ctx, c.ID, 0, CampaignClose)
+		switch {
+		case err == nil:
+			closed++
+		case errors.Is(err, ErrInvalidTransition), errors.Is(err, store.ErrNotFound):
+			// The brand closed, cancelled or deleted it in the meantime.
+		default:
+			errs = append(errs, fmt.Errorf("closing campaign %d: %w", c.ID, err))
+		}
+	}
+	return "closed " + plural(closed, "campaign"), errors.Join(errs...)
+}
+
+// ExpireApplications expires the applications that have been pending for
+// longer than the configured time to live.
+func (j *CampaignJobs) ExpireApplications(ctx context.Context) (string, error) {
+	n, err := j.store.Applications.ExpirePending(ctx, time.Now().Add(-j.cfg.ApplicationTTL))
+	if err != nil {
+		return "", err
+	}
+	return "expired " + plural(int(n), "application"), nil
+}
+
+// SendDeadlineReminders emails the brands whose running campaigns reach
+// their deadline within the configured lead time. Each deadline is
+// reminded of once, even across runs; a reminder that fails to send isn't
+// retried, so that nobody gets two.
+func (j *CampaignJobs) SendDeadlineReminders(ctx context.Context) (string, error) {
+	now := time.Now()
+	until := now.Add(j.cfg.ReminderLead)
+	campaigns, err := j.running(ctx, store.CampaignFilter{DeadlineFrom: &now, DeadlineTo: &until})
+	if err != nil {
+		return "", err
+	}
+
+	sent := 0
+	var errs []error
+	for _, c := range campaigns {
+		claimed, err := j.store.Campaigns.ClaimDeadlineReminder(ctx, c.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reminding of campaign %d: %w", c.ID, err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if err := j.remind(ctx, &c); err != nil {
+			errs = append(errs, fmt.Errorf("reminding of campaign %d: %w", c.ID, err))
+			continue
+		}
+		sent++
+	}
+	return "sent " + plural(sent, "reminder"), errors.Join(errs...)
+}
+
+// remind emails the brand who created the campaign.
+func (j *CampaignJobs) remind(ctx context.Context, c *models.Campaign) error {
+	brand, err := j.store.Users.GetByID(ctx, c.BrandID)
+	if err != nil {
+		return err
+	}
+	return j.mailer.Send(ctx, mailer.Message{
+		To:      brand.Email,
+		Subject: fmt.Sprintf("Your campaign %q closes soon", c.Title),
+		Text: fmt.Sprintf("Hi %s,\n\n"+
+			"Your campaign %q reaches its deadline on %s. So far it has %s, %d of them accepted.\n\n"+
+			"At the deadline the campaign closes and the applications still pending are rejected. "+
+			"To take applications for longer, move the deadline.\n",
+			brand.Username, c.Title, c.Deadline.UTC().Format("Mon, 2 Jan 2006 15:04 MST"),
+			plural(c.ApplicationCount, "application"), c.AcceptedCount),
+	})
+}
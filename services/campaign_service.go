@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+var (
+	// ErrInvalidTransition is returned for actions the campaign's status
+	// doesn't allow, e.g. pausing a draft.
+	ErrInvalidTransition = errors.New("campaign status doesn't allow this")
+	// ErrBudgetRequired is returned when publishing a campaign without a
+	// budget.
+	ErrBudgetRequired = errors.New("campaign needs a budget")
+	// ErrDeadlineRequired is returned when publishing or resuming a
+	// campaign without a deadline, or whose deadline has passed.
+	ErrDeadlineRequired = errors.New("campaign needs a deadline in the future")
+	// ErrCampaignFinished is returned for changes to completed or cancelled
+	// campaigns and their deliverables.
+	ErrCampaignFinished = errors.New("campaign is finished")
+)
+
+// CampaignAction is a step in a campaign's lifecycle.
+type CampaignAction string
+
+const (
+	CampaignPublish  CampaignAction = "publish"
+	CampaignPause    CampaignAction = "pause"
+	CampaignResume   CampaignAction = "resume"
+	CampaignClose    CampaignAction = "close"
+	CampaignComplete CampaignAction = "complete"
+	CampaignCancel   CampaignAction = "cancel"
+)
+
+// transition describes an action: the statuses it applies to, the status
+// it leads to, what must hold before it and what happens after it.
+type transition struct {
+	from  []string
+	to    string
+	guard func(c *models.Campaign, now time.Time) error
+	// after runs in the same transaction once the status changed.
+	after func(ctx context.Context, tx *store.Store, c *models.Campaign) error
+}
+
+var transitions = map[CampaignAction]transition{
+	CampaignPublish: {
+		from:  []string{models.CampaignDraft},
+		to:    models.CampaignPublished,
+		guard: readyToPublish,
+	},
+	CampaignPause: {
+		from: []string{models.CampaignPublished},
+		to:   models.CampaignPaused,
+	},
+	CampaignResume: {
+		from:  []string{models.CampaignPaused},
+		to:    models.CampaignPublished,
+		guard: deadlineAhead,
+	},
+	CampaignClose: {
+		from:  []string{models.CampaignPublished, models.CampaignPaused},
+		to:    models.CampaignClosed,
+		after: rejectPending,
+	},
+	CampaignComplete: {
+		from: []string{models.CampaignClosed},
+		to:   models.CampaignCompleted,
+	},
+	CampaignCancel: {
+		from:  []string{models.CampaignDraft, models.CampaignPublished, models.CampaignPaused, models.CampaignClosed},
+		to:    models.CampaignCancelled,
+		after: rejectPending,
+	},
+}
+
+func readyToPublish(c *models.Campaign, now time.Time) error {
+	if c.Budget <= 0 {
+		return ErrBudgetRequired
+	}
+	return deadlineAhead(c, now)
+}
+
+func deadlineAhead(c *models.Campaign, now time.Time) error {
+	if !c.Deadline.After(now) {
+		return ErrDeadlineRequired
+	}
+	return nil
+}
+
+// rejectPending turns down the applications nobody decided on, since the
+// campaign no longer selects influencers.
+func rejectPending(ctx context.Context, tx *store.Store, c *models.Campaign) error {
+	_, err := tx.Applications.RejectPending(ctx, c.ID)
+	return err
+}
+
+// CampaignService moves campaigns through their lifecycle and records who
+// did so.
+type CampaignService struct {
+	store *store.Store
+}
+
+func NewCampaignService(s *store.Store) *CampaignService {
+	return &CampaignService{store: s}
+}
+
+// Transition applies action to the campaign on behalf of actorID, who the
+// caller has checked may manage it, and returns the updated campaign. An
+// actorID of 0 records the change as the system's, e.g. a scheduled job's.
+// It returns store.ErrNotFound for unknown campaigns.
+func (s *CampaignService) Transition(ctx context.Context, campaignID, actorID int, action CampaignAction) (*models.Campaign, error) {
+	t, ok := transitions[action]
+	if !ok {
+		return nil, ErrInvalidTransition
+	}
+
+	var campaign *models.Campaign
+	err := s.store.WithTx(ctx, func(tx *store.Store) error {
+		c, err := tx.Campaigns.GetByID(ctx, campaignID)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(t.from, c.Status) {
+			return ErrInvalidTransition
+		}
+		if t.guard != nil {
+			if err := t.guard(c, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		// SetStatus only succeeds from the status read above, so of two
+		// concurrent transitions one fails.
+		if err := tx.Campaigns.SetStatus(ctx, c.ID, c.Status, t.to); err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				return ErrInvalidTransition
+			}
+			return err
+		}
+		var actor *int
+		if actorID != 0 {
+			actor = &actorID
+		}
+		if err := tx.Campaigns.AddTransition(ctx, &models.CampaignTransition{
+			CampaignID: c.ID,
+			From:       c.Status,
+			To:         t.to,
+			ActorID:    actor,
+		}); err != nil {
+			return err
+		}
+		if t.after != nil {
+			if err := t.after(ctx, tx, c); err != nil {
+				return err
+			}
+		}
+
+		campaign, err = tx.Campaigns.GetByID(ctx, c.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// CheckEdit reports whether c, with a brand's edits applied, may be saved in
+// its current status. Finished campaigns don't change any more, and
+// published and paused ones must still meet what publishing required.
+func (s *CampaignService) CheckEdit(c *models.Campaign) error {
+	switch {
+	case c.Finished():
+		return ErrCampaignFinished
+	case c.Status == models.CampaignPublished, c.Status == models.CampaignPaused:
+		return readyToPublish(c, time.Now())
+	}
+	return nil
+}
+
+// Transitions returns the campaign's status history, oldest first.
+func (s *CampaignService) Transitions(ctx context.Context, campaignID int) ([]models.CampaignTransition, error) {
+	return s.store.Campaigns.ListTransitions(ctx, campaignID)
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/memory"
+)
+
+var allCampaignStatuses = []string{
+	models.CampaignDraft, models.CampaignPublished, models.CampaignPaused,
+	models.CampaignClosed, models.CampaignCompleted, models.CampaignCancelled,
+}
+
+func newCampaign(t *testing.T, st *store.Store, c models.Campaign) *models.Campaign {
+	t.Helper()
+	if c.BrandID == 0 {
+		c.BrandID = 1
+	}
+	if c.Title == "" {
+		c.Title, c.Description = "Launch", "Posts about the launch"
+	}
+	if err := st.Campaigns.Create(context.Background(), &c); err != nil {
+		t.Fatal(err)
+	}
+	return &c
+}
+
+func TestCampaignTransitions(t *testing.T) {
+	// The status each action leads to from each status; missing entries
+	// aren't allowed.
+	allowed := map[CampaignAction]map[string]string{
+		CampaignPublish: {models.CampaignDraft: models.CampaignPublished},
+		CampaignPause:   {models.CampaignPublished: models.CampaignPaused},
+		CampaignResume:  {models.CampaignPaused: models.CampaignPublished},
+		CampaignClose: {
+			models.CampaignPublished: models.CampaignClosed,
+			models.CampaignPaused:    models.CampaignClosed,
+		},
+		CampaignComplete: {models.CampaignClosed: models.CampaignCompleted},
+		CampaignCancel: {
+			models.CampaignDraft:     models.CampaignCancelled,
+			models.CampaignPublished: models.CampaignCancelled,
+			models.CampaignPaused:    models.CampaignCancelled,
+			models.CampaignClosed:    models.CampaignCancelled,
+		},
+	}
+
+	ctx := context.Background()
+	for action, to := range allowed {
+		for _, from := range allCampaignStatuses {
+			t.Run(string(action)+" from "+from, func(t *testing.T) {
+				st := memory.New()
+				c := newCampaign(t, st, models.Campaign{
+					Budget:   100,
+					Deadline: time.Now().Add(24 * time.Hour),
+					Status:   from,
+				})
+
+				got, err := NewCampaignService(st).Transition(ctx, c.ID, 7, action)
+				want, ok := to[from]
+				if !ok {
+					if !errors.Is(err, ErrInvalidTransition) {
+						t.Fatalf("err = %v, want ErrInvalidTransition", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.Status != want {
+					t.Errorf("status = %q, want %q", got.Status, want)
+				}
+
+				history, err := st.Campaigns.ListTransitions(ctx, c.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(history) != 1 || history[0].From != from || history[0].To != want ||
+					history[0].ActorID == nil || *history[0].ActorID != 7 {
+					t.Errorf("transitions = %+v, want one from %s to %s by 7", history, from, want)
+				}
+			})
+		}
+	}
+}
+
+func TestCampaignTransitionGuards(t *testing.T) {
+	ctx := context.Background()
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		campaign models.Campaign
+		action   CampaignAction
+		want     error
+	}{
+		{"publish without budget", models.Campaign{Deadline: future, Status: models.CampaignDraft}, CampaignPublish, ErrBudgetRequired},
+		{"publish without deadline", models.Campaign{Budget: 100, Status: models.CampaignDraft}, CampaignPublish, ErrDeadlineRequired},
+		{"publish past deadline", models.Campaign{Budget: 100, Deadline: past, Status: models.CampaignDraft}, CampaignPublish, ErrDeadlineRequired},
+		{"publish", models.Campaign{Budget: 100, Deadline: future, Status: models.CampaignDraft}, CampaignPublish, nil},
+		{"resume past deadline", models.Campaign{Budget: 100, Deadline: past, Status: models.CampaignPaused}, CampaignResume, ErrDeadlineRequired},
+		{"close past deadline", models.Campaign{Budget: 100, Deadline: past, Status: models.CampaignPaused}, CampaignClose, nil},
+		{"unknown action", models.Campaign{Status: models.CampaignDraft}, "archive", ErrInvalidTransition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := memory.New()
+			c := newCampaign(t, st, tt.campaign)
+			_, err := NewCampaignService(st).Transition(ctx, c.ID, 7, tt.action)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				got, err := st.Campaigns.GetByID(ctx, c.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.Status != tt.campaign.Status {
+					t.Errorf("status = %q after a failed transition, want %q", got.Status, tt.campaign.Status)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown campaign", func(t *testing.T) {
+		_, err := NewCampaignService(memory.New()).Transition(ctx, 42, 7, CampaignCancel)
+		if !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("err = %v, want store.ErrNotFound", err)
+		}
+	})
+}
+
+func TestCampaignTransitionRejectsPending(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		action CampaignAction
+		want   string
+	}{
+		{CampaignPause, "pending"},
+		{CampaignClose, "rejected"},
+		{CampaignCancel, "rejected"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.action), func(t *testing.T) {
+			st := memory.New()
+			c := newCampaign(t, st, models.Campaign{
+				Budget:   100,
+				Deadline: time.Now().Add(time.Hour),
+				Status:   models.CampaignPublished,
+			})
+			pending := models.CampaignApplication{CampaignID: c.ID, InfluencerID: 2}
+			accepted := models.CampaignApplication{CampaignID: c.ID, InfluencerID: 3, Status: "accepted"}
+			for _, a := range []*models.CampaignApplication{&pending, &accepted} {
+				if err := st.Applications.Create(ctx, a); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Scheduled jobs act as nobody.
+			if _, err := NewCampaignService(st).Transition(ctx, c.ID, 0, tt.action); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, a := range []struct {
+				id   int
+				want string
+			}{{pending.ID, tt.want}, {accepted.ID, "accepted"}} {
+				got, err := st.Applications.GetByID(ctx, a.id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.Status != a.want {
+					t.Errorf("application %d is %q, want %q", a.id, got.Status, a.want)
+				}
+			}
+
+			history, err := st.Campaigns.ListTransitions(ctx, c.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(history) != 1 || history[0].ActorID != nil {
+				t.Errorf("transitions = %+v, want one without an actor", history)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+var (
+	// ErrCampaignNotRunning is returned for submissions to campaigns that
+	// aren't published, paused or closed.
+	ErrCampaignNotRunning = errors.New("campaign doesn't take submissions")
+	// ErrNotAccepted is returned for submissions of applications that
+	// weren't accepted.
+	ErrNotAccepted = errors.New("application isn't accepted")
+	// ErrDeliverableDone is returned for submissions to a deliverable the
+	// application already completed.
+	ErrDeliverableDone = errors.New("deliverable already completed")
+	// ErrDeliverableInUse is returned when deleting a deliverable that has
+	// submissions.
+	ErrDeliverableInUse = errors.New("deliverable has submissions")
+	// ErrSubmissionReviewed is returned for reviews of submissions that
+	// were already reviewed.
+	ErrSubmissionReviewed = errors.New("submission already reviewed")
+)
+
+// DeliverableProgress is how far one application got with one deliverable.
+type DeliverableProgress struct {
+	models.Deliverable
+	Status string `json:"status"`
+	// Approved counts the approved live links.
+	Approved int `json:"approved"`
+	// Submissions are left out of the campaign's rollup.
+	Submissions []models.DeliverableSubmission `json:"submissions,omitempty"`
+}
+
+// ApplicationDelivery is an application's progress with all of the
+// campaign's deliverables. Status is empty while the campaign has none.
+type ApplicationDelivery struct {
+	ApplicationID int                   `json:"application_id"`
+	InfluencerID  int                   `json:"influencer_id"`
+	Status        string                `json:"status"`
+	Deliverables  []DeliverableProgress `json:"deliverables"`
+}
+
+// CampaignDelivery rolls the progress of every accepted application up to
+// the campaign. Applications lists those that submitted anything; the
+// others are counted as pending.
+type CampaignDelivery struct {
+	CampaignID   int                   `json:"campaign_id"`
+	Status       string                `json:"status"`
+	Accepted     int                   `json:"accepted_applications"`
+	ByStatus     map[string]int        `json:"by_status"`
+	Deliverables []models.Deliverable  `json:"deliverables"`
+	Applications []ApplicationDelivery `json:"applications"`
+}
+
+// DeliverableService manages what campaigns ask of influencers, the work
+// they submit and the brand's reviews. Callers check that users may manage
+// the campaign, or own the application, first.
+type DeliverableService struct {
+	store *store.Store
+}
+
+func NewDeliverableService(s *store.Store) *DeliverableService {
+	return &DeliverableService{store: s}
+}
+
+func (s *DeliverableService) List(ctx context.Context, campaignID int) ([]models.Deliverable, error) {
+	return s.store.Deliverables.ListByCampaign(ctx, campaignID)
+}
+
+// Get returns one of the campaign's deliverables, or store.ErrNotFound.
+func (s *DeliverableService) Get(ctx context.Context, campaignID, id int) (*models.Deliverable, error) {
+	d, err := s.store.Deliverables.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.CampaignID != campaignID {
+		return nil, store.ErrNotFound
+	}
+	return d, nil
+}
+
+func (s *DeliverableService) Create(ctx context.Context, campaign *models.Campaign, d *models.Deliverable) error {
+	if campaign.Finished() {
+		return ErrCampaignFinished
+	}
+	d.CampaignID = campaign.ID
+	return s.store.Deliverables.Create(ctx, d)
+}
+
+// Update replaces the deliverable's fields. Lowering the quantity may
+// complete it for applications that already delivered enough.
+func (s *DeliverableService) Update(ctx context.Context, campaign *models.Campaign, d *models.Deliverable) error {
+	if campaign.Finished() {
+		return ErrCampaignFinished
+	}
+	existing, err := s.Get(ctx, campaign.ID, d.ID)
+	if err != nil {
+		return err
+	}
+	d.CampaignID = campaign.ID
+	d.CreatedAt = existing.CreatedAt
+	return s.store.Deliverables.Update(ctx, d)
+}
+
+// Delete removes a deliverable nobody submitted anything for yet.
+func (s *DeliverableService) Delete(ctx context.Context, campaign *models.Campaign, id int) error {
+	if campaign.Finished() {
+		return ErrCampaignFinished
+	}
+	return s.store.WithTx(ctx, func(tx *store.Store) error {
+		d, err := tx.Deliverables.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if d.CampaignID != campaign.ID {
+			return store.ErrNotFound
+		}
+		n, err := tx.Deliverables.CountSubmissions(ctx, id)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrDeliverableInUse
+		}
+		return tx.Deliverables.Delete(ctx, id)
+	})
+}
+
+// Submit records a draft or live link for one of the deliverables of the
+// campaign app was accepted to. sub.DeliverableID names the deliverable;
+// store.ErrNotFound means it isn't the campaign's.
+func (s *DeliverableService) Submit(ctx context.Context, app *models.CampaignApplication, sub *models.DeliverableSubmission) error {
+	if app.Status != "accepted" {
+		return ErrNotAccepted
+	}
+	return s.store.WithTx(ctx, func(tx *store.Store) error {
+		campaign, err := tx.Campaigns.GetByID(ctx, app.CampaignID)
+		if err != nil {
+			return err
+		}
+		switch campaign.Status {
+		case models.CampaignPublished, models.CampaignPaused, models.CampaignClosed:
+		default:
+			return ErrCampaignNotRunning
+		}
+
+		d, err := tx.Deliverables.GetByID(ctx, sub.DeliverableID)
+		if err != nil {
+			return err
+		}
+		if d.CampaignID != campaign.ID {
+			return store.ErrNotFound
+		}
+		subs, err := tx.Deliverables.ListSubmissions(ctx, store.SubmissionFilter{
+			ApplicationID: app.ID,
+			DeliverableID: d.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if status, _ := deliverableStatus(d, subs); status == models.DeliveryCompleted {
+			return ErrDeliverableDone
+		}
+
+		sub.ApplicationID = app.ID
+		return tx.Deliverables.CreateSubmission(ctx, sub)
+	})
+}
+
+// Submission returns a submission with its deliverable, for the caller to
+// check who may review it.
+func (s *DeliverableService) Submission(ctx context.Context, id int) (*models.DeliverableSubmission, *models.Deliverable, error) {
+	sub, err := s.store.Deliverables.GetSubmission(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := s.store.Deliverables.GetByID(ctx, sub.DeliverableID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sub, d, nil
+}
+
+// Review approves a pending submission or asks for a revision of it, with
+// an optional comment, on behalf of reviewerID.
+func (s *DeliverableService) Review(ctx context.Context, reviewerID, id int, approve bool, comment string) (*models.DeliverableSubmission, error) {
+	sub := models.DeliverableSubmission{
+		ID:         id,
+		Status:     models.SubmissionRevision,
+		Comment:    comment,
+		ReviewedBy: &reviewerID,
+	}
+	if approve {
+		sub.Status = models.SubmissionApproved
+	}
+	if err := s.store.Deliverables.ReviewSubmission(ctx, &sub); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			return nil, ErrSubmissionReviewed
+		}
+		return nil, err
+	}
+	return s.store.Deliverables.GetSubmission(ctx, id)
+}
+
+// ApplicationDelivery reports the application's progress with each of the
+// campaign's deliverables, including what it submitted.
+func (s *DeliverableService) ApplicationDelivery(ctx context.Context, app *models.CampaignApplication) (*ApplicationDelivery, error) {
+	deliverables, err := s.store.Deliverables.ListByCampaign(ctx, app.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := s.store.Deliverables.ListSubmissions(ctx, store.SubmissionFilter{ApplicationID: app.ID})
+	if err != nil {
+		return nil, err
+	}
+	return delivery(app.ID, app.InfluencerID, deliverables, subs), nil
+}
+
+// CampaignDelivery reports the progress of the campaign's accepted
+// applications and rolls it up to the campaign.
+func (s *DeliverableService) CampaignDelivery(ctx context.Context, campaign *models.Campaign) (*CampaignDelivery, error) {
+	deliverables, err := s.store.Deliverables.ListByCampaign(ctx, campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	accepted, err := s.accepted(ctx, campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	subs, err := s.store.Deliverables.ListSubmissions(ctx, store.SubmissionFilter{CampaignID: campaign.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	// Submissions only count for the applications read above, so that one
+	// whose status changed in between isn't counted twice or left out.
+	byApplication := map[int][]models.DeliverableSubmission{}
+	for _, sub := range subs {
+		if _, ok := accepted[sub.ApplicationID]; ok {
+			byApplication[sub.ApplicationID] = append(byApplication[sub.ApplicationID], sub)
+		}
+	}
+	appIDs := slices.Sorted(maps.Keys(byApplication))
+
+	result := CampaignDelivery{
+		CampaignID:   campaign.ID,
+		Accepted:     len(accepted),
+		ByStatus:     map[string]int{},
+		Deliverables: deliverables,
+		Applications: []ApplicationDelivery{},
+	}
+	var statuses []string
+	for _, id := range appIDs {
+		d := delivery(id, accepted[id].InfluencerID, deliverables, byApplication[id])
+		for i := range d.Deliverables {
+			d.Deliverables[i].Submissions = nil
+		}
+		result.Applications = append(result.Applications, *d)
+		statuses = append(statuses, d.Status)
+	}
+	if len(deliverables) > 0 {
+		for range len(accepted) - len(appIDs) {
+			statuses = append(statuses, models.DeliveryPending)
+		}
+	}
+	for _, status := range statuses {
+		result.ByStatus[status]++
+	}
+	result.Status = rollUp(statuses)
+	return &result, nil
+}
+
+// accepted returns the campaign's accepted applications by ID.
+func (s *DeliverableService) accepted(ctx context.Context, campaignID int) (map[int]models.CampaignApplication, error) {
+	apps := map[int]models.CampaignApplication{}
+	f := store.ApplicationFilter{CampaignID: campaignID, Status: "accepted"}
+	opts := store.ListOptions{Limit: store.MaxLimit}
+	for {
+		page, err := s.store.Applications.List(ctx, f, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range page.Items {
+			apps[a.ID] = a
+		}
+		if page.Next == nil {
+			return apps, nil
+		}
+		opts.Cursor = page.Next
+	}
+}
+
+// delivery works out one application's progress from its submissions.
+func delivery(appID, influencerID int, deliverables []models.Deliverable, subs []models.DeliverableSubmission) *ApplicationDelivery {
+	d := ApplicationDelivery{
+		ApplicationID: appID,
+		InfluencerID:  influencerID,
+		Deliverables:  make([]DeliverableProgress, 0, len(deliverables)),
+	}
+	statuses := make([]string, 0, len(deliverables))
+	for _, deliverable := range deliverables {
+		var own []models.DeliverableSubmission
+		for _, sub := range subs {
+			if sub.DeliverableID == deliverable.ID {
+				own = append(own, sub)
+			}
+		}
+		status, approved := deliverableStatus(&deliverable, own)
+		d.Deliverables = append(d.Deliverables, DeliverableProgress{
+			Deliverable: deliverable,
+			Status:      status,
+			Approved:    approved,
+			Submissions: own,
+		})
+		statuses = append(statuses, status)
+	}
+	d.Status = rollUp(statuses)
+	return &d
+}
+
+// deliverableStatus derives one application's status for a deliverable
+// from its submissions for it, oldest first, and counts the approved live
+// links.
+func deliverableStatus(d *models.Deliverable, subs []models.DeliverableSubmission) (string, int) {
+	approved, anyApproved, pending := 0, false, false
+	lastReview := ""
+	for _, sub := range subs {
+		switch sub.Status {
+		case models.SubmissionPending:
+			pending = true
+		case models.SubmissionApproved:
+			anyApproved = true
+			if sub.Kind == models.SubmissionLive {
+				approved++
+			}
+		}
+		if sub.Status != models.SubmissionPending {
+			lastReview = sub.Status
+		}
+	}
+
+	switch {
+	case approved >= d.Quantity:
+		return models.DeliveryCompleted, approved
+	case pending:
+		return models.DeliverySubmitted, approved
+	case lastReview == models.SubmissionRevision:
+		return models.DeliveryRevision, approved
+	case anyApproved:
+		return models.DeliveryInProgress, approved
+	}
+	return models.DeliveryPending, approved
+}
+
+// rollUp combines several delivery statuses into one: completed when all
+// are, otherwise the one that most needs attention. It returns "" for
+// none.
+func rollUp(statuses []string) string {
+	if len(statuses) == 0 {
+		return ""
+	}
+	count := map[string]int{}
+	for _, status := range statuses {
+		count[status]++
+	}
+	switch {
+	case count[models.DeliveryCompleted] == len(statuses):
+		return models.DeliveryCompleted
+	case count[models.DeliveryRevision] > 0:
+		return models.DeliveryRevision
+	case count[models.DeliverySubmitted] > 0:
+		return models.DeliverySubmitted
+	case count[models.DeliveryInProgress] > 0, count[models.DeliveryCompleted] > 0:
+		return models.DeliveryInProgress
+	}
+	return models.DeliveryPending
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"maps"
+	"testing"
+	"time"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store/memory"
+)
+
+func TestCampaignDelivery(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	s := NewDeliverableService(st)
+
+	c := newCampaign(t, st, models.Campaign{
+		Budget:   100,
+		Deadline: time.Now().Add(time.Hour),
+		Status:   models.CampaignPublished,
+	})
+	d := models.Deliverable{CampaignID: c.ID, Type: "reel", Platform: "instagram", Quantity: 1}
+	if err := st.Deliverables.Create(ctx, &d); err != nil {
+		t.Fatal(err)
+	}
+
+	apply := func(influencerID int) *models.CampaignApplication {
+		a := &models.CampaignApplication{CampaignID: c.ID, InfluencerID: influencerID, Status: "accepted"}
+		if err := st.Applications.Create(ctx, a); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	submit := func(a *models.CampaignApplication) *models.DeliverableSubmission {
+		sub := &models.DeliverableSubmission{DeliverableID: d.ID, Kind: models.SubmissionLive, URL: "https://example.com/p"}
+		if err := s.Submit(ctx, a, sub); err != nil {
+			t.Fatal(err)
+		}
+		return sub
+	}
+
+	done := apply(10)
+	if _, err := s.Review(ctx, 1, submit(done).ID, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	waiting := apply(11)
+	submit(waiting)
+	apply(12)
+	// Submissions of applications that are no longer accepted don't count.
+	dropped := apply(13)
+	submit(dropped)
+	if err := st.Applications.UpdateStatus(ctx, dropped.ID, "accepted", "rejected"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.CampaignDelivery(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Accepted != 3 {
+		t.Errorf("Accepted = %d, want 3", got.Accepted)
+	}
+	wantStatus := map[string]int{
+		models.DeliveryCompleted: 1,
+		models.DeliverySubmitted: 1,
+		models.DeliveryPending:   1,
+	}
+	if !maps.Equal(got.ByStatus, wantStatus) {
+		t.Errorf("ByStatus = %v, want %v", got.ByStatus, wantStatus)
+	}
+	if got.Status != models.DeliverySubmitted {
+		t.Errorf("Status = %q, want %q", got.Status, models.DeliverySubmitted)
+	}
+
+	apps := map[int]string{}
+	for _, a := range got.Applications {
+		apps[a.ApplicationID] = a.Status
+		for _, p := range a.Deliverables {
+			if p.Submissions != nil {
+				t.Errorf("application %d lists its submissions in the rollup", a.ApplicationID)
+			}
+		}
+	}
+	wantApps := map[int]string{done.ID: models.DeliveryCompleted, waiting.ID: models.DeliverySubmitted}
+	if !maps.Equal(apps, wantApps) {
+		t.Errorf("applications = %v, want %v", apps, wantApps)
+	}
+}
+
+func TestRollUp(t *testing.T) {
+	const (
+		pending    = models.DeliveryPending
+		submitted  = models.DeliverySubmitted
+		revision   = models.DeliveryRevision
+		inProgress = models.DeliveryInProgress
+		completed  = models.DeliveryCompleted
+	)
+	tests := []struct {
+		statuses []string
+		want     string
+	}{
+		{nil, ""},
+		{[]string{pending, pending}, pending},
+		{[]string{completed, completed}, completed},
+		{[]string{completed, pending}, inProgress},
+		{[]string{inProgress, pending}, inProgress},
+		{[]string{submitted, completed}, submitted},
+		{[]string{revision, submitted}, revision},
+	}
+	for _, tt := range tests {
+		if got := rollUp(tt.statuses); got != tt.want {
+			t.Errorf("rollUp(%q) = %q, want %q", tt.statuses, got, tt.want)
+		}
+	}
+}
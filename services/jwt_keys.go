@@ -0,0 +1,236 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"InfluenceIQ/config"
+)
+
+// minRSABits is the smallest RSA key accepted for RS256.
+const minRSABits = 2048
+
+// signingKey is one key of a KeySet. private is nil for keys that are only
+// used to verify.
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	public  crypto.PublicKey
+	private crypto.Signer
+}
+
+// KeySet holds the key that signs new access tokens and every key whose
+// tokens are still accepted. Keys are identified by their RFC 7638
+// thumbprint, which is sent as the token's kid header.
+type KeySet struct {
+	signing *signingKey
+	keys    map[string]*signingKey
+	order   []string // kids in JWKS order, signing key first
+}
+
+// LoadKeySet reads the keys named in cfg. Without a signing key file it
+// generates a throwaway Ed25519 key, which is only suitable for
+// development: tokens stop verifying on restart and across instances.
+func LoadKeySet(cfg config.JWTConfig) (*KeySet, error) {
+	ks := &KeySet{keys: map[string]*signingKey{}}
+
+	if cfg.SigningKeyFile == "" {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		key, err := newSigningKey(private)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("No JWT signing key configured; using a temporary key (kid %s) that is lost on restart", key.id)
+		ks.add(key)
+	} else {
+		key, err := loadKeyFile(cfg.SigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if key.private == nil {
+			return nil, fmt.Errorf("jwt signing key %s: not a private key", cfg.SigningKeyFile)
+		}
+		ks.add(key)
+	}
+	ks.signing = ks.keys[ks.order[0]]
+
+	for _, path := range cfg.VerificationKeyFiles {
+		key, err := loadKeyFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// Verification keys never sign, even if the file holds a private key.
+		key.private = nil
+		if _, dup := ks.keys[key.id]; !dup {
+			ks.add(key)
+		}
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) add(key *signingKey) {
+	ks.keys[key.id] = key
+	ks.order = append(ks.order, key.id)
+}
+
+// Sign signs claims with the current signing key.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.id
+	return token.SignedString(ks.signing.private)
+}
+
+// Keyfunc returns the verification key named by the token's kid.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method != key.method {
+		return nil, fmt.Errorf("key %s does not use %s", kid, token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// Algorithms lists the signing algorithms of the keys in the set.
+func (ks *KeySet) Algorithms() []string {
+	var algs []string
+	for _, kid := range ks.order {
+		if alg := ks.keys[kid].method.Alg(); !slices.Contains(algs, alg) {
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// JWK is one public key in a JSON Web Key Set (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519, and EC keys of other issuers
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public half of every key in the set.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, kid := range ks.order {
+		key := ks.keys[kid]
+		jwk := publicJWK(key.public)
+		jwk.KeyID, jwk.Use, jwk.Algorithm = key.id, "sig", key.method.Alg()
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func publicJWK(public crypto.PublicKey) JWK {
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{KeyType: "RSA", N: b64(k.N.Bytes()), E: b64(big.NewInt(int64(k.E)).Bytes())}
+	case ed25519.PublicKey:
+		return JWK{KeyType: "OKP", Curve: "Ed25519", X: b64(k)}
+	}
+	panic(fmt.Sprintf("unsupported key type %T", public))
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint of a public key: the
+// SHA-256 of its required members, in lexicographic order, without spaces.
+func thumbprint(public crypto.PublicKey) string {
+	jwk := publicJWK(public)
+	var canonical string
+	switch jwk.KeyType {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, jwk.Curve, jwk.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func newSigningKey(key any) (*signingKey, error) {
+	var k signingKey
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		k.method, k.public, k.private = jwt.SigningMethodRS256, &key.PublicKey, key
+	case *rsa.PublicKey:
+		k.method, k.public = jwt.SigningMethodRS256, key
+	case ed25519.PrivateKey:
+		k.method, k.public, k.private = jwt.SigningMethodEdDSA, key.Public(), key
+	case ed25519.PublicKey:
+		k.method, k.public = jwt.SigningMethodEdDSA, key
+	default:
+		return nil, fmt.Errorf("unsupported key type %T (use RSA or Ed25519)", key)
+	}
+	if rsaKey, ok := k.public.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("RSA key is %d bits; at least %d are required", rsaKey.N.BitLen(), minRSABits)
+	}
+	k.id = thumbprint(k.public)
+	return &k, nil
+}
+
+// loadKeyFile reads a PEM-encoded private or public key.
+func loadKeyFile(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read jwt key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("jwt key %s: no PEM data", path)
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		err = errors.New("unsupported PEM block " + block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jwt key %s: %w", path, err)
+	}
+
+	k, err := newSigningKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("jwt key %s: %w", path, err)
+	}
+	return k, nil
+}
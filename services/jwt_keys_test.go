@@ -0,0 +1,211 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"InfluenceIQ/config"
+)
+
+func TestThumbprint(t *testing.T) {
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	tests := []struct {
+		name string
+		key  crypto.PublicKey
+		want string
+	}{
+		// RFC 7638, section 3.1.
+		{"RSA", &rsa.PublicKey{
+			N: new(big.Int).SetBytes(decode("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")),
+			E: 65537,
+		}, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"},
+		// RFC 8037, appendix A.3.
+		{"Ed25519", ed25519.PublicKey(decode("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")),
+			"kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := thumbprint(tt.key); got != tt.want {
+				t.Errorf("thumbprint = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// writeKey writes key to a PEM file in dir and returns its path.
+func writeKey(t *testing.T, dir, name string, key any) string {
+	t.Helper()
+	var block pem.Block
+	var err error
+	switch key.(type) {
+	case *rsa.PrivateKey, ed25519.PrivateKey:
+		block.Type = "PRIVATE KEY"
+		block.Bytes, err = x509.MarshalPKCS8PrivateKey(key)
+	default:
+		block.Type = "PUBLIC KEY"
+		block.Bytes, err = x509.MarshalPKIXPublicKey(key)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newRSAKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+	ed := newEd25519Key(t)
+	edFile := writeKey(t, dir, "ed.pem", ed)
+	edPublicFile := writeKey(t, dir, "ed.pub.pem", ed.Public())
+	rsaFile := writeKey(t, dir, "rsa.pem", newRSAKey(t, 2048))
+	weakFile := writeKey(t, dir, "weak.pem", newRSAKey(t, 1024))
+
+	tests := []struct {
+		name string
+		cfg  config.JWTConfig
+		// wantErr is part of the error, or empty if loading succeeds.
+		wantErr string
+		// wantAlgs are the algorithms of the set, signing key first.
+		wantAlgs []string
+	}{
+		{"Ed25519", config.JWTConfig{SigningKeyFile: edFile}, "", []string{"EdDSA"}},
+		{"RSA", config.JWTConfig{SigningKeyFile: rsaFile}, "", []string{"RS256"}},
+		{"RSA signing with Ed25519 verification", config.JWTConfig{SigningKeyFile: rsaFile,
+			VerificationKeyFiles: []string{edPublicFile}}, "", []string{"RS256", "EdDSA"}},
+		{"RSA under 2048 bits", config.JWTConfig{SigningKeyFile: weakFile}, "at least 2048", nil},
+		{"weak verification key", config.JWTConfig{SigningKeyFile: edFile,
+			VerificationKeyFiles: []string{weakFile}}, "at least 2048", nil},
+		{"public signing key", config.JWTConfig{SigningKeyFile: edPublicFile}, "not a private key", nil},
+		{"missing file", config.JWTConfig{SigningKeyFile: filepath.Join(dir, "missing.pem")}, "read jwt key", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := LoadKeySet(tt.cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadKeySet = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ks.Algorithms(); strings.Join(got, ",") != strings.Join(tt.wantAlgs, ",") {
+				t.Errorf("algorithms = %v, want %v", got, tt.wantAlgs)
+			}
+		})
+	}
+}
+
+func TestKeySetVerification(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, newKey := newEd25519Key(t), newRSAKey(t, 2048)
+	oldFile, newFile := writeKey(t, dir, "old.pem", oldKey), writeKey(t, dir, "new.pem", newKey)
+
+	before, err := LoadKeySet(config.JWTConfig{SigningKeyFile: oldFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The old key's file still holds its private half; it must only verify.
+	rotated, err := LoadKeySet(config.JWTConfig{SigningKeyFile: newFile, VerificationKeyFiles: []string{oldFile}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := LoadKeySet(config.JWTConfig{SigningKeyFile: newFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}
+	sign := func(ks *KeySet) string {
+		token, err := ks.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	// mismatched claims the old key's kid for a token signed by the new
+	// key, so that a verifier trusting the header would pick the wrong
+	// algorithm.
+	mismatched := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	mismatched.Header["kid"] = thumbprint(oldKey.Public())
+	mismatchedToken, err := mismatched.SignedString(newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		ks    *KeySet
+		token string
+		valid bool
+	}{
+		{"current key", rotated, sign(rotated), true},
+		{"rotated-out key", rotated, sign(before), true},
+		{"retired key", after, sign(before), false},
+		{"kid and alg mismatch", rotated, mismatchedToken, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwt.Parse(tt.token, tt.ks.Keyfunc, jwt.WithValidMethods(tt.ks.Algorithms()))
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("valid = %v (%v), want %v", valid, err, tt.valid)
+			}
+		})
+	}
+
+	t.Run("verification keys never sign", func(t *testing.T) {
+		token, _, err := jwt.NewParser().ParseUnverified(sign(rotated), &jwt.RegisteredClaims{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kid := token.Header["kid"]; kid != thumbprint(&newKey.PublicKey) {
+			t.Errorf("signed with kid %v, want the signing key's", kid)
+		}
+		for kid, key := range rotated.keys {
+			if key.private != nil && kid != rotated.signing.id {
+				t.Errorf("verification key %s holds a private key", kid)
+			}
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/attempts"
+)
+
+// LoginBlockedError is returned while a client IP or an account may not
+// try to log in. Locked is set when the account is locked out rather than
+// briefly delayed.
+type LoginBlockedError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginBlockedError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("account locked for %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("login blocked for %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginGuard protects password logins from guessing. It counts failures
+// per account and per client IP: an account's attempts are delayed more
+// and more and finally locked, and an IP with too many failures across
+// any accounts is blocked. Lockouts and suspicious logins are written to
+// the security audit log.
+//
+// Attempts are checked before the password and counted after it, so a few
+// concurrent guesses can slip past a delay; the lockout still follows.
+type LoginGuard struct {
+	store    *store.Store
+	limiter  store.LoginAttemptRepository
+	accounts *AccountService
+	cfg      config.LoginConfig
+}
+
+func NewLoginGuard(s *store.Store, accounts *AccountService, cfg config.LoginConfig) *LoginGuard {
+	var limiter store.LoginAttemptRepository = attempts.NewCounter()
+	if cfg.LimiterDriver == "database" {
+		limiter = s.Attempts
+	}
+	return &LoginGuard{store: s, limiter: limiter, accounts: accounts, cfg: cfg}
+}
+
+// Check returns a *LoginBlockedError if logins from ip, or to the account
+// behind login, are blocked. user is nil when login matches no account,
+// which is counted and blocked alike so that lockouts don't reveal which
+// accounts exist.
+func (g *LoginGuard) Check(ctx context.Context, ip, login string, user *models.User) error {
+	now := time.Now()
+	for _, bucket := range []string{ipBucket(ip), accountBucket(login, user)} {
+		a, err := g.limiter.Get(ctx, bucket)
+		if err != nil {
+			return err
+		}
+		if a.Blocked(now) {
+			return &LoginBlockedError{
+				RetryAfter: a.BlockedUntil.Sub(now),
+				Locked:     bucket != ipBucket(ip) && a.Failures >= g.cfg.LockoutAfter,
+			}
+		}
+	}
+	return nil
+}
+
+// Failed counts a wrong password for login from ip.
+func (g *LoginGuard) Failed(ctx context.Context, ip, login string, user *models.User) error {
+	now := time.Now()
+	windowStart := now.Add(-g.cfg.Window)
+
+	a, err := g.limiter.Fail(ctx, accountBucket(login, user), windowStart)
+	if err != nil {
+		return err
+	}
+	switch {
+	case a.Failures >= g.cfg.LockoutAfter:
+		until := now.Add(g.cfg.LockoutDuration)
+		if err := g.limiter.Block(ctx, a.Bucket, until); err != nil {
+			return err
+		}
+		g.lockedOut(ctx, ip, login, user, a.Failures, until)
+	case a.Failures >= g.cfg.DelayAfter:
+		delay := min(time.Second<<min(a.Failures-g.cfg.DelayAfter, 30), g.cfg.MaxDelay)
+		if err := g.limiter.Block(ctx, a.Bucket, now.Add(delay)); err != nil {
+			return err
+		}
+	}
+
+	a, err = g.limiter.Fail(ctx, ipBucket(ip), windowStart)
+	if err != nil {
+		return err
+	}
+	if a.Failures >= g.cfg.IPLimit {
+		if err := g.limiter.Block(ctx, a.Bucket, now.Add(g.cfg.LockoutDuration)); err != nil {
+			return err
+		}
+		g.record(ctx, &models.SecurityEvent{
+			Type:   models.SecurityEventIPBlocked,
+			IP:     ip,
+			Login:  login,
+			Detail: fmt.Sprintf("%d failed logins within %s; blocked for %s", a.Failures, g.cfg.Window, g.cfg.LockoutDuration),
+		})
+	}
+	return nil
+}
+
+// Succeeded clears the account's failures after a correct password. A
+// login that follows repeated failures may mean the password was guessed,
+// so it is recorded.
+func (g *LoginGuard) Succeeded(ctx context.Context, ip, login string, user *models.User) error {
+	a, err := g.limiter.Get(ctx, accountBucket(login, user))
+	if err != nil || a.Failures == 0 {
+		return err
+	}
+	if a.Failures >= g.cfg.DelayAfter && a.WindowStart.After(time.Now().Add(-g.cfg.Window)) {
+		g.record(ctx, &models.SecurityEvent{
+			Type:   models.SecurityEventLoginAfterFailures,
+			UserID: &user.ID,
+			IP:     ip,
+			Login:  login,
+			Detail: fmt.Sprintf("logged in after %d failed logins", a.Failures),
+		})
+	}
+	return g.limiter.Reset(ctx, a.Bucket)
+}
+
+// Unlock lifts the lockout of the account an unlock link was mailed to.
+func (g *LoginGuard) Unlock(ctx context.Context, raw, ip string) error {
+	userID, err := g.accounts.ConsumeUnlock(ctx, raw)
+	if err != nil {
+		return err
+	}
+	if err := g.limiter.Reset(ctx, userBucket(userID)); err != nil {
+		return err
+	}
+	g.record(ctx, &models.SecurityEvent{Type: models.SecurityEventAccountUnlocked, UserID: &userID, IP: ip})
+	return nil
+}
+
+// lockedOut records a lockout and mails the account's owner a link to
+// lift it.
+func (g *LoginGuard) lockedOut(ctx context.Context, ip, login string, user *models.User, failures int, until time.Time) {
+	e := models.SecurityEvent{
+		Type:   models.SecurityEventAccountLocked,
+		IP:     ip,
+		Login:  login,
+		Detail: fmt.Sprintf("%d failed logins within %s; locked for %s", failures, g.cfg.Window, g.cfg.LockoutDuration),
+	}
+	if user != nil {
+		e.UserID = &user.ID
+	}
+	g.record(ctx, &e)
+
+	if user == nil {
+		return
+	}
+	if err := g.accounts.SendUnlock(ctx, user, failures, until); err != nil {
+		log.Printf("Sending unlock email to user %d failed: %v", user.ID, err)
+	}
+}
+
+// record writes to the audit log. A failure is only logged, so that it
+// can't be used to get around the limits.
+func (g *LoginGuard) record(ctx context.Context, e *models.SecurityEvent) {
+	if err := g.store.SecurityEvents.Create(ctx, e); err != nil {
+		log.Printf("Recording %s security event failed: %v", e.Type, err)
+	}
+}
+
+// PurgeStale periodically removes attempt counts that have run their
+// course until ctx is done. Run it as a server worker.
+func (g *LoginGuard) PurgeStale(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := g.limiter.Purge(ctx, time.Now().Add(-g.cfg.Window)); err != nil && ctx.Err() == nil {
+				log.Printf("Purging login attempts failed: %v", err)
+			}
+		}
+	}
+}
+
+// Buckets of the limiter. Logins that match no account are counted by a
+// hash of the login so that the table doesn't collect what was typed.
+func ipBucket(ip string) string { return "ip:" + ip }
+
+func userBucket(userID int) string { return "user:" + strconv.Itoa(userID) }
+
+func accountBucket(login string, user *models.User) string {
+	if user != nil {
+		return userBucket(user.ID)
+	}
+	return "login:" + hashToken(strings.ToLower(login))
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/memory"
+)
+
+var testLoginConfig = config.LoginConfig{
+	LimiterDriver:   "memory",
+	Window:          time.Hour,
+	DelayAfter:      2,
+	MaxDelay:        time.Hour,
+	LockoutAfter:    4,
+	LockoutDuration: time.Hour,
+	IPLimit:         6,
+}
+
+func newGuardTest(t *testing.T, cfg config.LoginConfig) (*LoginGuard, *store.Store, *recordingMailer) {
+	t.Helper()
+	st := memory.New()
+	m := &recordingMailer{}
+	accounts := NewAccountService(st, m, config.AccountConfig{LinkBaseURL: "https://app.example.com"})
+	return NewLoginGuard(st, accounts, cfg), st, m
+}
+
+// securityEvents lists the types of the audit log's events.
+func securityEvents(t *testing.T, st *store.Store) map[string]int {
+	t.Helper()
+	page, err := st.SecurityEvents.List(context.Background(), store.SecurityEventFilter{}, store.ListOptions{Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := map[string]int{}
+	for _, e := range page.Items {
+		types[e.Type]++
+	}
+	return types
+}
+
+func TestLoginGuard(t *testing.T) {
+	type attempt struct {
+		ip, login string
+	}
+	ada := attempt{"192.0.2.1", "ada"}
+	tests := []struct {
+		name     string
+		failures []attempt
+		// check is the attempt checked afterwards.
+		check      attempt
+		wantLocked bool
+		// wantBlocked is whether check is blocked at all.
+		wantBlocked bool
+		wantEvent   string
+	}{
+		{"below the delay", []attempt{ada}, ada, false, false, ""},
+		{"delayed", []attempt{ada, ada}, ada, false, true, ""},
+		{"delay is per account", []attempt{ada, ada}, attempt{"192.0.2.1", "bob"}, false, false, ""},
+		{"account locked", []attempt{ada, ada, ada, ada}, ada, true, true, models.SecurityEventAccountLocked},
+		{"lock follows the account to other IPs", []attempt{ada, ada, ada, ada},
+			attempt{"198.51.100.7", "ada"}, true, true, models.SecurityEventAccountLocked},
+		{"unknown login locked", []attempt{{"192.0.2.1", "nobody"}, {"192.0.2.2", "nobody"},
+			{"192.0.2.3", "nobody"}, {"192.0.2.4", "nobody"}}, attempt{"192.0.2.5", "NOBODY"},
+			true, true, models.SecurityEventAccountLocked},
+		{"IP blocked across accounts", []attempt{{"203.0.113.9", "a"}, {"203.0.113.9", "b"}, {"203.0.113.9", "c"},
+			{"203.0.113.9", "d"}, {"203.0.113.9", "e"}, {"203.0.113.9", "f"}},
+			attempt{"203.0.113.9", "ada"}, false, true, models.SecurityEventIPBlocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			g, st, m := newGuardTest(t, testLoginConfig)
+			user := newTestUser(t, st, "ada", "ada@example.com")
+			lookup := func(login string) *models.User {
+				if login == user.Username {
+					return user
+				}
+				return nil
+			}
+
+			for _, a := range tt.failures {
+				if err := g.Failed(ctx, a.ip, a.login, lookup(a.login)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := g.Check(ctx, tt.check.ip, tt.check.login, lookup(tt.check.login))
+
+			var blocked *LoginBlockedError
+			if isBlocked := errors.As(err, &blocked); isBlocked != tt.wantBlocked {
+				t.Fatalf("Check = %v, want blocked %v", err, tt.wantBlocked)
+			}
+			if blocked != nil && blocked.Locked != tt.wantLocked {
+				t.Errorf("locked = %v, want %v", blocked.Locked, tt.wantLocked)
+			}
+			events := securityEvents(t, st)
+			if tt.wantEvent != "" && events[tt.wantEvent] != 1 {
+				t.Errorf("audit log = %v, want one %s", events, tt.wantEvent)
+			}
+			if tt.wantEvent == "" && len(events) != 0 {
+				t.Errorf("audit log = %v, want nothing", events)
+			}
+			// Only the owner of a known account is mailed an unlock link.
+			wantMails := 0
+			if tt.wantLocked && tt.check.login == user.Username {
+				wantMails = 1
+			}
+			if len(m.sent) != wantMails {
+				t.Errorf("sent %d emails, want %d", len(m.sent), wantMails)
+			}
+		})
+	}
+}
+
+func TestLoginGuardUnlock(t *testing.T) {
+	lock := func(t *testing.T, g *LoginGuard, user *models.User) {
+		t.Helper()
+		for range testLoginConfig.LockoutAfter {
+			if err := g.Failed(context.Background(), "192.0.2.1", user.Username, user); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	t.Run("lockout ends", func(t *testing.T) {
+		cfg := testLoginConfig
+		cfg.LockoutDuration = 10 * time.Millisecond
+		g, st, _ := newGuardTest(t, cfg)
+		user := newTestUser(t, st, "ada", "ada@example.com")
+		lock(t, g, user)
+
+		time.Sleep(cfg.LockoutDuration)
+		if err := g.Check(context.Background(), "192.0.2.1", user.Username, user); err != nil {
+			t.Errorf("Check after the lockout = %v, want nil", err)
+		}
+	})
+
+	t.Run("unlock link", func(t *testing.T) {
+		ctx := context.Background()
+		g, st, m := newGuardTest(t, testLoginConfig)
+		user := newTestUser(t, st, "ada", "ada@example.com")
+		lock(t, g, user)
+
+		raw := m.last(t, user.Email)
+		if err := g.Unlock(ctx, "nope", "192.0.2.1"); !errors.Is(err, ErrInvalidUserToken) {
+			t.Fatalf("Unlock with unknown token = %v, want %v", err, ErrInvalidUserToken)
+		}
+		if err := g.Unlock(ctx, raw, "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Check(ctx, "192.0.2.1", user.Username, user); err != nil {
+			t.Errorf("Check after unlocking = %v, want nil", err)
+		}
+		if err := g.Unlock(ctx, raw, "192.0.2.1"); !errors.Is(err, ErrInvalidUserToken) {
+			t.Errorf("reusing the unlock link = %v, want %v", err, ErrInvalidUserToken)
+		}
+		if events := securityEvents(t, st); events[models.SecurityEventAccountUnlocked] != 1 {
+			t.Errorf("audit log = %v, want one %s", events, models.SecurityEventAccountUnlocked)
+		}
+	})
+
+	t.Run("login after failures", func(t *testing.T) {
+		ctx := context.Background()
+		g, st, _ := newGuardTest(t, testLoginConfig)
+		user := newTestUser(t, st, "ada", "ada@example.com")
+		for range testLoginConfig.DelayAfter {
+			if err := g.Failed(ctx, "192.0.2.1", user.Username, user); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := g.Succeeded(ctx, "192.0.2.1", user.Username, user); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Check(ctx, "192.0.2.1", user.Username, user); err != nil {
+			t.Errorf("Check after a login = %v, want nil", err)
+		}
+		if events := securityEvents(t, st); events[models.SecurityEventLoginAfterFailures] != 1 {
+			t.Errorf("audit log = %v, want one %s", events, models.SecurityEventLoginAfterFailures)
+		}
+	})
+}
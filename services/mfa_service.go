@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+var (
+	// ErrInvalidMFAToken is returned for unknown, expired or exhausted MFA
+	// challenge tokens.
+	ErrInvalidMFAToken = errors.New("invalid or expired MFA token")
+	// ErrInvalidMFACode is returned for wrong, reused or expired one-time
+	// codes and unknown or used recovery codes.
+	ErrInvalidMFACode    = errors.New("invalid one-time code")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not set up")
+	// ErrMFARequired is returned when a policy forbids turning 2FA off.
+	ErrMFARequired = errors.New("two-factor authentication is required for this account")
+)
+
+const (
+	// maxChallengeAttempts is how many wrong codes end a login.
+	maxChallengeAttempts = 5
+	recoveryCodeCount    = 10
+)
+
+// LoginResult is the outcome of a successful first login factor: either
+// tokens, or a Challenge to answer with a second factor.
+type LoginResult struct {
+	Tokens    *TokenPair
+	Challenge *Challenge
+}
+
+// Challenge asks the client for a one-time code. When EnrollmentRequired
+// is set a policy requires 2FA that the user hasn't set up yet, and the
+// token also allows enrolling an authenticator app.
+type Challenge struct {
+	Token              string    `json:"mfa_token"`
+	ExpiresAt          time.Time `json:"expires_at"`
+	EnrollmentRequired bool      `json:"enrollment_required"`
+}
+
+// Enrollment is what an authenticator app needs: the provisioning URI to
+// show as a QR code, or the secret to type in.
+type Enrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"provisioning_uri"`
+}
+
+// MFALogin is the outcome of answering a challenge.
+type MFALogin struct {
+	User   *models.User
+	Tokens *TokenPair
+	// RecoveryCodes are set when the login completed an enrollment.
+	RecoveryCodes []string
+	// RecoveryCodesLeft is set when a recovery code was used.
+	RecoveryCodesLeft *int
+}
+
+// MFAStatus describes a user's two-factor setup.
+type MFAStatus struct {
+	Enabled           bool `json:"enabled"`
+	Pending           bool `json:"pending"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// MFAService adds TOTP two-factor authentication to logins. Wrong codes
+// count against the account's failed logins in guard, like wrong
+// passwords, so that getting a new challenge doesn't allow more guesses.
+type MFAService struct {
+	store        *store.Store
+	tokens       *TokenService
+	guard        *LoginGuard
+	issuer       string
+	challengeTTL time.Duration
+}
+
+func NewMFAService(s *store.Store, tokens *TokenService, guard *LoginGuard, cfg config.MFAConfig) *MFAService {
+	return &MFAService{store: s, tokens: tokens, guard: guard, issuer: cfg.Issuer, challengeTTL: cfg.ChallengeTTL}
+}
+
+// Login continues a login whose first factor (a password or an identity
+// provider) succeeded. Users with 2FA enabled, or required by a policy,
+// get a challenge instead of tokens. client is recorded on the session the
+// login starts.
+func (s *MFAService) Login(ctx context.Context, user *models.User, client Client) (*LoginResult, error) {
+	required, err := s.required(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.store.MFA.GetTOTP(ctx, user.ID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	enabled := t != nil && t.Enabled()
+
+	if !enabled && !required {
+		pair, err := s.tokens.Issue(ctx, user, client)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{Tokens: pair}, nil
+	}
+
+	raw := rand.Text() + rand.Text()
+	ch := models.MFAChallenge{UserID: user.ID, TokenHash: hashToken(raw), ExpiresAt: time.Now().Add(s.challengeTTL)}
+	if err := s.store.MFA.CreateChallenge(ctx, &ch); err != nil {
+		return nil, err
+	}
+	return &LoginResult{Challenge: &Challenge{Token: raw, ExpiresAt: ch.ExpiresAt, EnrollmentRequired: !enabled}}, nil
+}
+
+// Verify answers a challenge with a one-time code or a recovery code and
+// issues tokens. If the user was enrolling, the code confirms the
+// enrollment and the result carries the new recovery codes. While the
+// account's logins are blocked it returns a *LoginBlockedError without
+// checking the code; only a correct code clears the account's failures.
+func (s *MFAService) Verify(ctx context.Context, mfaToken, code string, client Client) (*MFALogin, error) {
+	ch, err := s.store.MFA.GetChallenge(ctx, hashToken(mfaToken))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrInvalidMFAToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.store.Users.GetByID(ctx, ch.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.guard.Check(ctx, client.IP, user.Username, user); err != nil {
+		return nil, err
+	}
+
+	var login MFALogin
+	err = s.store.WithTx(ctx, func(tx *store.Store) error {
+		t, err := tx.MFA.GetTOTP(ctx, ch.UserID)
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrMFANotEnrolled
+		}
+		if err != nil {
+			return err
+		}
+
+		if t.Enabled() {
+			recovery, err := s.check(ctx, tx, t, code, true)
+			if err != nil {
+				return err
+			}
+			if recovery {
+				left, err := tx.MFA.CountRecoveryCodes(ctx, t.UserID)
+				if err != nil {
+					return err
+				}
+				login.RecoveryCodesLeft = &left
+			}
+		} else if login.RecoveryCodes, err = s.confirm(ctx, tx, t, code); err != nil {
+			return err
+		}
+
+		if err := tx.MFA.DeleteChallenge(ctx, ch.ID); errors.Is(err, store.ErrNotFound) {
+			return ErrInvalidMFAToken
+		} else if err != nil {
+			return err
+		}
+		login.User, err = tx.Users.GetByID(ctx, ch.UserID)
+		return err
+	})
+	if errors.Is(err, ErrInvalidMFACode) {
+		if attempts, ferr := s.store.MFA.FailChallenge(ctx, ch.ID); ferr == nil && attempts >= maxChallengeAttempts {
+			_ = s.store.MFA.DeleteChallenge(ctx, ch.ID)
+		}
+		if ferr := s.guard.Failed(ctx, client.IP, user.Username, user); ferr != nil {
+			return nil, ferr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.guard.Succeeded(ctx, client.IP, user.Username, login.User); err != nil {
+		return nil, err
+	}
+
+	if login.Tokens, err = s.tokens.Issue(ctx, login.User, client); err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
+
+// Status describes the user's two-factor setup.
+func (s *MFAService) Status(ctx context.Context, user *models.User) (*MFAStatus, error) {
+	var status MFAStatus
+	var err error
+	if status.Required, err = s.required(ctx, user); err != nil {
+		return nil, err
+	}
+	t, err := s.store.MFA.GetTOTP(ctx, user.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		return &status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	status.Enabled, status.Pending = t.Enabled(), !t.Enabled()
+	if status.RecoveryCodesLeft, err = s.store.MFA.CountRecoveryCodes(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Enroll starts setting up an authenticator app for the user, replacing
+// an unconfirmed earlier attempt.
+func (s *MFAService) Enroll(ctx context.Context, user *models.User) (*Enrollment, error) {
+	t, err := s.store.MFA.GetTOTP(ctx, user.ID)
+	switch {
+	case err == nil && t.Enabled():
+		return nil, ErrMFAAlreadyEnabled
+	case err != nil && !errors.Is(err, store.ErrNotFound):
+		return nil, err
+	}
+
+	t = &models.TOTP{UserID: user.ID, Secret: newTOTPSecret()}
+	if err := s.store.MFA.SaveTOTP(ctx, t); err != nil {
+		return nil, err
+	}
+	return &Enrollment{Secret: t.Secret, URI: provisioningURI(s.issuer, user.Email, t.Secret)}, nil
+}
+
+// EnrollForChallenge is Enroll for a user who can't log in until they set
+// up 2FA, authorised by their challenge token.
+func (s *MFAService) EnrollForChallenge(ctx context.Context, mfaToken string) (*Enrollment, error) {
+	ch, err := s.store.MFA.GetChallenge(ctx, hashToken(mfaToken))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrInvalidMFAToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.store.Users.GetByID(ctx, ch.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Enroll(ctx, user)
+}
+
+// Confirm enables the user's pending enrollment with a code from the app
+// and returns their recovery codes.
+func (s *MFAService) Confirm(ctx context.Context, userID int, code string) ([]string, error) {
+	var codes []string
+	err := s.store.WithTx(ctx, func(tx *store.Store) error {
+		t, err := tx.MFA.GetTOTP(ctx, userID)
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrMFANotEnrolled
+		}
+		if err != nil {
+			return err
+		}
+		if t.Enabled() {
+			return ErrMFAAlreadyEnabled
+		}
+		codes, err = s.confirm(ctx, tx, t, code)
+		return err
+	})
+	return codes, err
+}
+
+// Disable turns 2FA off after checking a code, unless a policy requires
+// it. An unconfirmed enrollment is simply dropped.
+func (s *MFAService) Disable(ctx context.Context, user *models.User, code string) error {
+	required, err := s.required(ctx, user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequired
+	}
+
+	return s.store.WithTx(ctx, func(tx *store.Store) error {
+		t, err := tx.MFA.GetTOTP(ctx, user.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrMFANotEnrolled
+		}
+		if err != nil {
+			return err
+		}
+		if t.Enabled() {
+			if _, err := s.check(ctx, tx, t, code, true); err != nil {
+				return err
+			}
+		}
+		return tx.MFA.DeleteTOTP(ctx, user.ID)
+	})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after
+// checking a one-time code.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	var codes []string
+	err := s.store.WithTx(ctx, func(tx *store.Store) error {
+		t, err := tx.MFA.GetTOTP(ctx, userID)
+		if errors.Is(err, store.ErrNotFound) || err == nil && !t.Enabled() {
+			return ErrMFANotEnrolled
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := s.check(ctx, tx, t, code, false); err != nil {
+			return err
+		}
+		codes, err = s.newRecoveryCodes(ctx, tx, userID)
+		return err
+	})
+	return codes, err
+}
+
+// required reports whether a policy covers the user's role or the
+// organization they belong to.
+func (s *MFAService) required(ctx context.Context, user *models.User) (bool, error) {
+	ok, err := s.store.MFA.Required(ctx, models.MFAScopeRole, user.Role)
+	if ok || err != nil {
+		return ok, err
+	}
+	m, err := s.store.Organizations.GetMembership(ctx, user.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return s.store.MFA.Required(ctx, models.MFAScopeOrganization, strconv.Itoa(m.OrganizationID))
+}
+
+// confirm enables a pending enrollment with a code from the app.
+func (s *MFAService) confirm(ctx context.Context, tx *store.Store, t *models.TOTP, code string) ([]string, error) {
+	if _, err := s.check(ctx, tx, t, code, false); err != nil {
+		return nil, err
+	}
+	if err := tx.MFA.EnableTOTP(ctx, t.UserID); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(ctx, tx, t.UserID)
+}
+
+// check accepts a one-time code from the app or, if allowRecovery, an
+// unused recovery code, and reports which it was.
+func (s *MFAService) check(ctx context.Context, tx *store.Store, t *models.TOTP, code string, allowRecovery bool) (recovery bool, err error) {
+	code = normalizeCode(code)
+
+	if step, ok := totpMatch(t.Secret, code, time.Now()); ok {
+		err := tx.MFA.UseTOTPStep(ctx, t.UserID, step)
+		if errors.Is(err, store.ErrConflict) {
+			return false, ErrInvalidMFACode
+		}
+		return false, err
+	}
+	if !allowRecovery || len(code) <= totpDigits {
+		return false, ErrInvalidMFACode
+	}
+
+	err = tx.MFA.UseRecoveryCode(ctx, t.UserID, hashToken(code))
+	if errors.Is(err, store.ErrNotFound) {
+		return false, ErrInvalidMFACode
+	}
+	return err == nil, err
+}
+
+// newRecoveryCodes replaces the user's recovery codes and returns them in
+// the form shown to users, e.g. "k3j9x-p2mq7".
+func (s *MFAService) newRecoveryCodes(ctx context.Context, tx *store.Store, userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := strings.ToLower(rand.Text()[:10])
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashToken(raw)
+	}
+	if err := tx.MFA.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeCode drops the spaces and dashes users type or paste with
+// codes.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store/memory"
+)
+
+func TestMFARequired(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+
+	newUser := func(name, role string) *models.User {
+		u := &models.User{Username: name, Email: name + "@example.com", Role: role}
+		if err := st.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	brand := newUser("brand", "brand")
+	member := newUser("member", "brand")
+	outsider := newUser("outsider", "brand")
+	admin := newUser("admin", "admin")
+
+	org := &models.Organization{Name: "Acme"}
+	if err := st.Organizations.Create(ctx, org); err != nil {
+		t.Fatal(err)
+	}
+	other := &models.Organization{Name: "Other"}
+	if err := st.Organizations.Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []models.OrganizationMember{
+		{OrganizationID: org.ID, UserID: brand.ID, Role: models.OrgRoleOwner},
+		{OrganizationID: org.ID, UserID: member.ID, Role: models.OrgRoleMember},
+		{OrganizationID: other.ID, UserID: outsider.ID, Role: models.OrgRoleOwner},
+	} {
+		if err := st.Organizations.AddMember(ctx, &m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := st.MFA.ReplacePolicies(ctx, models.MFAScopeRole, []string{"admin"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.MFA.ReplacePolicies(ctx, models.MFAScopeOrganization, []string{strconv.Itoa(org.ID)}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewMFAService(st, nil, nil, config.MFAConfig{})
+	tests := []struct {
+		name string
+		user *models.User
+		want bool
+	}{
+		{"organization owner", brand, true},
+		{"organization member", member, true},
+		{"member of another organization", outsider, false},
+		{"role policy without organization", admin, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := s.Status(ctx, tt.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.Required != tt.want {
+				t.Errorf("Required = %v, want %v", status.Required, tt.want)
+			}
+		})
+	}
+
+	t.Run("organization deleted", func(t *testing.T) {
+		if err := st.Organizations.Delete(ctx, org.ID); err != nil {
+			t.Fatal(err)
+		}
+		policies, err := st.MFA.ListPolicies(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range policies {
+			if p.Scope == models.MFAScopeOrganization {
+				t.Errorf("policy %s=%s outlived its organization", p.Scope, p.Value)
+			}
+		}
+	})
+}
+
+func TestMFAVerify(t *testing.T) {
+	const (
+		right    = "right"
+		recovery = "recovery"
+	)
+	tests := []struct {
+		name string
+		// codes answer one challenge in turn: "right" for a valid code
+		// the enrollment hasn't used, "recovery" for the first recovery
+		// code, anything else as it is.
+		codes []string
+		want  error
+	}{
+		{"valid code", []string{right}, nil},
+		{"wrong code", []string{"000000"}, ErrInvalidMFACode},
+		{"recovery code", []string{recovery}, nil},
+		{"too many wrong codes end the challenge", []string{"000000", "000000", "000000", "000000", "000000", right},
+			ErrInvalidMFAToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := memory.New()
+			tokens, err := NewTokenService(st, config.JWTConfig{Issuer: "test", TTL: time.Minute, RefreshTTL: time.Hour})
+			if err != nil {
+				t.Fatal(err)
+			}
+			accounts := NewAccountService(st, &recordingMailer{}, config.AccountConfig{})
+			guard := NewLoginGuard(st, accounts, config.LoginConfig{
+				Window:          time.Hour,
+				DelayAfter:      100,
+				LockoutAfter:    100,
+				LockoutDuration: time.Hour,
+				IPLimit:         100,
+			})
+			s := NewMFAService(st, tokens, guard, config.MFAConfig{Issuer: "test", ChallengeTTL: time.Minute})
+			user := newTestUser(t, st, "ada", "ada@example.com")
+
+			enrollment, err := s.Enroll(ctx, user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key, err := totpEncoding.DecodeString(enrollment.Secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+			step := time.Now().Unix() / int64(totpPeriod/time.Second)
+			codes, err := s.Confirm(ctx, user.ID, totpCode(key, step))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			login, err := s.Login(ctx, user, Client{IP: "192.0.2.1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if login.Challenge == nil {
+				t.Fatal("login with 2FA enabled issued tokens")
+			}
+			var got *MFALogin
+			for _, code := range tt.codes {
+				switch code {
+				case right:
+					// The current step's code confirmed the enrollment
+					// and can't be replayed; the next one is accepted.
+					code = totpCode(key, step+1)
+				case recovery:
+					code = codes[0]
+				}
+				got, err = s.Verify(ctx, login.Challenge.Token, code, Client{IP: "192.0.2.1"})
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			if got.User.ID != user.ID || got.Tokens == nil {
+				t.Errorf("Verify = user %d with tokens %v, want user %d with tokens", got.User.ID, got.Tokens != nil, user.ID)
+			}
+
+			if tt.codes[len(tt.codes)-1] != recovery {
+				return
+			}
+			if got.RecoveryCodesLeft == nil || *got.RecoveryCodesLeft != recoveryCodeCount-1 {
+				t.Errorf("recovery codes left = %v, want %d", got.RecoveryCodesLeft, recoveryCodeCount-1)
+			}
+			again, err := s.Login(ctx, user, Client{IP: "192.0.2.1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Verify(ctx, again.Challenge.Token, codes[0], Client{IP: "192.0.2.1"}); !errors.Is(err, ErrInvalidMFACode) {
+				t.Errorf("reusing a recovery code = %v, want %v", err, ErrInvalidMFACode)
+			}
+		})
+	}
+}
+
+func TestMFAVerifyCountsFailedLogins(t *testing.T) {
+	const (
+		ok      = "ok"
+		invalid = "invalid"
+		locked  = "locked"
+	)
+	tests := []struct {
+		name string
+		// codes are answered to a new challenge each: "ok" for the right
+		// code, anything else as it is.
+		codes []string
+		want  []string
+	}{
+		{"wrong codes lock the account", []string{"000000", "111111", "222222", ok},
+			[]string{invalid, invalid, invalid, locked}},
+		{"right code clears the failures", []string{"000000", "111111", ok, "222222", "333333"},
+			[]string{invalid, invalid, "", invalid, invalid}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := memory.New()
+			tokens, err := NewTokenService(st, config.JWTConfig{Issuer: "test", TTL: time.Minute, RefreshTTL: time.Hour})
+			if err != nil {
+				t.Fatal(err)
+			}
+			accounts := NewAccountService(st, &recordingMailer{}, config.AccountConfig{})
+			guard := NewLoginGuard(st, accounts, config.LoginConfig{
+				Window:          time.Hour,
+				DelayAfter:      100,
+				LockoutAfter:    3,
+				LockoutDuration: time.Hour,
+				IPLimit:         100,
+			})
+			s := NewMFAService(st, tokens, guard, config.MFAConfig{ChallengeTTL: time.Minute})
+
+			user := newTestUser(t, st, "ada", "ada@example.com")
+			secret := newTOTPSecret()
+			if err := st.MFA.SaveTOTP(ctx, &models.TOTP{UserID: user.ID, Secret: secret}); err != nil {
+				t.Fatal(err)
+			}
+			if err := st.MFA.EnableTOTP(ctx, user.ID); err != nil {
+				t.Fatal(err)
+			}
+			key, err := totpEncoding.DecodeString(secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+			right := totpCode(key, time.Now().Unix()/int64(totpPeriod/time.Second))
+
+			for i, code := range tt.codes {
+				if code == ok {
+					code = right
+				} else if code == right {
+					code = "999999"
+				}
+				login, err := s.Login(ctx, user, Client{IP: "192.0.2.1"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = s.Verify(ctx, login.Challenge.Token, code, Client{IP: "192.0.2.1"})
+
+				var blocked *LoginBlockedError
+				got := ""
+				switch {
+				case errors.As(err, &blocked) && blocked.Locked:
+					got = locked
+				case errors.Is(err, ErrInvalidMFACode):
+					got = invalid
+				case err != nil:
+					t.Fatalf("code %d: %v", i, err)
+				}
+				if got != tt.want[i] {
+					t.Fatalf("code %d: got %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
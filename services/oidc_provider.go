@@ -0,0 +1,372 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"InfluenceIQ/config"
+)
+
+var (
+	// ErrProviderUnavailable wraps failures to reach an identity provider
+	// or to make sense of its answers.
+	ErrProviderUnavailable = errors.New("identity provider unavailable")
+	// ErrProviderRejected is returned when the provider refuses to
+	// exchange the authorization code, e.g. because it was already used.
+	ErrProviderRejected = errors.New("identity provider rejected the login")
+	// ErrInvalidIDToken wraps the reason an ID token failed verification.
+	ErrInvalidIDToken = errors.New("invalid ID token")
+)
+
+const (
+	// jwksRefreshInterval limits how often an unknown kid makes us fetch
+	// the provider's keys again.
+	jwksRefreshInterval = time.Minute
+	// maxProviderResponse caps the size of documents read from providers.
+	maxProviderResponse = 1 << 20
+)
+
+// idTokenAlgorithms are the signing algorithms accepted in ID tokens.
+var idTokenAlgorithms = []string{"RS256", "ES256", "EdDSA"}
+
+// oidcMetadata is the part of a provider's discovery document we use.
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// idTokenClaims are the ID token claims we read.
+type idTokenClaims struct {
+	Nonce             string `json:"nonce"`
+	AuthorizedParty   string `json:"azp"`
+	Email             string `json:"email"`
+	EmailVerified     any    `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+// emailVerified reports whether the provider vouches for the email
+// address. Some providers send the flag as a string.
+func (c *idTokenClaims) emailVerified() bool {
+	return c.EmailVerified == true || c.EmailVerified == "true"
+}
+
+// oidcProvider talks to one OpenID Connect provider. The discovery
+// document is fetched on first use and the signing keys whenever a token
+// names a key we haven't seen.
+type oidcProvider struct {
+	name        string
+	issuer      string
+	clientID    string
+	secret      string
+	redirectURL string
+	scopes      []string
+	client      *http.Client
+
+	mu          sync.Mutex
+	meta        *oidcMetadata
+	keys        map[string]JWK
+	keysFetched time.Time
+}
+
+func newOIDCProvider(name string, cfg config.OIDCProvider, linkBaseURL string) *oidcProvider {
+	p := &oidcProvider{
+		name:        name,
+		issuer:      cfg.Issuer,
+		clientID:    cfg.ClientID,
+		secret:      cfg.ClientSecret,
+		redirectURL: cfg.RedirectURL,
+		scopes:      []string{"openid", "email", "profile"},
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+	if p.redirectURL == "" {
+		p.redirectURL = strings.TrimRight(linkBaseURL, "/") + "/oauth/callback/" + name
+	}
+	if len(cfg.Scopes) > 0 {
+		p.scopes = append([]string{"openid"}, slices.DeleteFunc(slices.Clone(cfg.Scopes), func(s string) bool { return s == "openid" })...)
+	}
+	return p
+}
+
+// metadata returns the provider's discovery document, fetching it the
+// first time.
+func (p *oidcProvider) metadata(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta oidcMetadata
+	if err := p.getJSON(ctx, strings.TrimRight(p.issuer, "/")+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, err
+	}
+	// OpenID Connect Discovery 1.0, section 4.3: the document must be
+	// about the issuer we asked for.
+	if meta.Issuer != p.issuer {
+		return nil, fmt.Errorf("%w: %s: discovery document is for issuer %q", ErrProviderUnavailable, p.name, meta.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: %s: incomplete discovery document", ErrProviderUnavailable, p.name)
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// authURL is where the user logs in at the provider.
+func (p *oidcProvider) authURL(meta *oidcMetadata, state, nonce, challenge string) string {
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {strings.Join(p.scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode()
+}
+
+// exchange trades an authorization code for the provider's ID token.
+func (p *oidcProvider) exchange(ctx context.Context, code, verifier string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"code_verifier": {verifier},
+	}
+	if p.secret == "" {
+		form.Set("client_id", p.clientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.secret != "" {
+		// RFC 6749, section 2.3.1: both parts are form-encoded first.
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.secret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", ErrProviderUnavailable, p.name, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxProviderResponse))
+	if err == nil {
+		err = json.Unmarshal(data, &body)
+	}
+	switch {
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && body.Error != "":
+		return "", fmt.Errorf("%w: %s: %s %s", ErrProviderRejected, p.name, body.Error, body.ErrorDescription)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("%w: %s: token endpoint returned %s", ErrProviderUnavailable, p.name, resp.Status)
+	case err != nil:
+		return "", fmt.Errorf("%w: %s: token response: %w", ErrProviderUnavailable, p.name, err)
+	case body.IDToken == "":
+		return "", fmt.Errorf("%w: %s: token response has no id_token", ErrProviderUnavailable, p.name)
+	}
+	return body.IDToken, nil
+}
+
+// verify checks the ID token's signature, issuer, audience, lifetime and
+// nonce (OpenID Connect Core 1.0, section 3.1.3.7) and returns its claims.
+func (p *oidcProvider) verify(ctx context.Context, raw, nonce string) (*idTokenClaims, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims idTokenClaims
+	_, err = jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (any, error) { return p.key(ctx, t) },
+		jwt.WithValidMethods(idTokenAlgorithms),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if errors.Is(err, ErrProviderUnavailable) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	switch {
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	case subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	case (len(claims.Audience) > 1 || claims.AuthorizedParty != "") && claims.AuthorizedParty != p.clientID:
+		return nil, fmt.Errorf("%w: issued to %q", ErrInvalidIDToken, claims.AuthorizedParty)
+	}
+	return &claims, nil
+}
+
+// key returns the provider key that signed t, refetching the key set when
+// t names a key we don't know.
+func (p *oidcProvider) key(ctx context.Context, t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	jwk, ok := p.find(kid)
+	if !ok && time.Since(p.keysFetched) >= jwksRefreshInterval {
+		var set JWKS
+		if err := p.getJSON(ctx, p.meta.JWKSURI, &set); err != nil {
+			return nil, err
+		}
+		p.keys = map[string]JWK{}
+		for _, k := range set.Keys {
+			if k.Use == "" || k.Use == "sig" {
+				p.keys[k.KeyID] = k
+			}
+		}
+		p.keysFetched = time.Now()
+		jwk, ok = p.find(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if jwk.Algorithm != "" && jwk.Algorithm != t.Method.Alg() {
+		return nil, fmt.Errorf("key %q is for %s, not %s", kid, jwk.Algorithm, t.Method.Alg())
+	}
+
+	key, err := jwk.publicKey()
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", kid, err)
+	}
+	if !keyMatchesMethod(key, t.Method) {
+		return nil, fmt.Errorf("key %q can't verify %s", kid, t.Method.Alg())
+	}
+	return key, nil
+}
+
+// find looks a key up by kid. Tokens without a kid are accepted only when
+// the provider has a single key. Callers must hold mu.
+func (p *oidcProvider) find(kid string) (JWK, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, true
+		}
+	}
+	k, ok := p.keys[kid]
+	return k, ok && kid != ""
+}
+
+func (p *oidcProvider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrProviderUnavailable, p.name, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrProviderUnavailable, p.name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s: GET %s returned %s", ErrProviderUnavailable, p.name, url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxProviderResponse))
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: GET %s: %w", ErrProviderUnavailable, p.name, url, err)
+	}
+	return nil
+}
+
+// publicKey decodes an RSA, P-256 or Ed25519 public key.
+func (k JWK) publicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding.DecodeString
+	switch {
+	case k.KeyType == "RSA":
+		n, errN := b64(k.N)
+		e, errE := b64(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("malformed RSA key")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key is %d bits; at least %d are required", key.N.BitLen(), minRSABits)
+		}
+		return key, nil
+
+	case k.KeyType == "EC" && k.Curve == "P-256":
+		x, errX := b64(k.X)
+		y, errY := b64(k.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("malformed EC key")
+		}
+		// ecdh rejects points that are not on the curve.
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+
+	case k.KeyType == "OKP" && k.Curve == "Ed25519":
+		x, err := b64(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("malformed Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s %s", k.KeyType, k.Curve)
+}
+
+func keyMatchesMethod(key crypto.PublicKey, method jwt.SigningMethod) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return method == jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		return method == jwt.SigningMethodES256
+	case ed25519.PublicKey:
+		return method == jwt.SigningMethodEdDSA
+	}
+	return false
+}
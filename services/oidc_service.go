@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	// ErrInvalidOAuthState is returned for unknown, used or expired login
+	// states, and for states started with another provider.
+	ErrInvalidOAuthState = errors.New("invalid or expired login state")
+	// ErrIdentityLinked is returned when the provider account is linked to
+	// another user, or the user already has another account at the
+	// provider.
+	ErrIdentityLinked = errors.New("identity is already linked")
+	// ErrEmailUnverified is returned when the provider doesn't vouch for an
+	// email address, so no account can be created or matched by it.
+	ErrEmailUnverified = errors.New("identity provider has not verified the email address")
+	// ErrAccountNotLinkable is returned when an account with the email
+	// address exists but hasn't verified it, so it can't be proven to
+	// belong to the same person.
+	ErrAccountNotLinkable = errors.New("an unverified account uses this email address")
+	// ErrLastLoginMethod is returned when unlinking would leave an account
+	// without any way to log in.
+	ErrLastLoginMethod = errors.New("identity is the account's only login method")
+)
+
+// OIDCLogin is the outcome of finishing a login at a provider. Like a
+// correct password, it is the first factor of a login.
+type OIDCLogin struct {
+	User *models.User
+	// Created is set when the login created the account, Linked when it
+	// linked the provider to an existing one.
+	Created bool
+	Linked  bool
+}
+
+// OIDCService logs users in through OpenID Connect providers using the
+// authorization code flow with PKCE. Provider accounts are matched to
+// users by their linked identity or, on first login, by a verified email
+// address.
+type OIDCService struct {
+	store     *store.Store
+	providers map[string]*oidcProvider
+	stateTTL  time.Duration
+}
+
+func NewOIDCService(s *store.Store, cfg *config.Config) *OIDCService {
+	providers := map[string]*oidcProvider{}
+	for name, p := range cfg.OIDC.Providers {
+		providers[name] = newOIDCProvider(name, p, cfg.Account.LinkBaseURL)
+	}
+	return &OIDCService{store: s, providers: providers, stateTTL: cfg.OIDC.StateTTL}
+}
+
+// Providers lists the names of the configured providers.
+func (s *OIDCService) Providers() []string {
+	return slices.Sorted(maps.Keys(s.providers))
+}
+
+// Start begins a login at the named provider and returns the URL to send
+// the user to, along with the state the frontend should check when the
+// provider redirects back. A non-nil linkUserID links the provider to that
+// user instead of logging in.
+func (s *OIDCService) Start(ctx context.Context, provider string, linkUserID *int) (authURL, state string, err error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, nonce := rand.Text(), rand.Text()
+	verifier := rand.Text() + rand.Text()
+	err = s.store.Tokens.CreateOAuthState(ctx, &models.OAuthState{
+		StateHash:    hashToken(state),
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		UserID:       linkUserID,
+		ExpiresAt:    time.Now().Add(s.stateTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	return p.authURL(meta, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:])), state, nil
+}
+
+// Finish completes a login with the code and state the provider redirected
+// back with, and returns the matched, linked or created user.
+func (s *OIDCService) Finish(ctx context.Context, provider, code, state string) (*OIDCLogin, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	st, err := s.store.Tokens.ConsumeOAuthState(ctx, hashToken(state))
+	if errors.Is(err, store.ErrNotFound) || err == nil && st.Provider != provider {
+		return nil, ErrInvalidOAuthState
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := p.exchange(ctx, code, st.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := p.verify(ctx, raw, st.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	var login OIDCLogin
+	err = s.store.WithTx(ctx, func(tx *store.Store) error {
+		return s.resolve(ctx, tx, provider, claims, st.UserID, &login)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
+
+// resolve finds the user behind the provider account, linking or creating
+// one as needed.
+func (s *OIDCService) resolve(ctx context.Context, tx *store.Store, provider string, claims *idTokenClaims, linkUserID *int, login *OIDCLogin) error {
+	identity, err := tx.Identities.GetBySubject(ctx, provider, claims.Subject)
+	switch {
+	case err == nil:
+		if linkUserID != nil && *linkUserID != identity.UserID {
+			return ErrIdentityLinked
+		}
+		if err := tx.Identities.TouchLogin(ctx, identity.ID); err != nil {
+			return err
+		}
+		login.User, err = tx.Users.GetByID(ctx, identity.UserID)
+		return err
+	case !errors.Is(err, store.ErrNotFound):
+		return err
+	}
+
+	if linkUserID != nil {
+		if login.User, err = tx.Users.GetByID(ctx, *linkUserID); err != nil {
+			return err
+		}
+		return s.link(ctx, tx, login, provider, claims)
+	}
+
+	if claims.Email == "" || !claims.emailVerified() {
+		return ErrEmailUnverified
+	}
+	user, err := tx.Users.GetByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		// Only an address the account has proven control of is enough to
+		// hand it to whoever controls it at the provider.
+		if !user.EmailVerified() {
+			return ErrAccountNotLinkable
+		}
+		login.User = user
+		return s.link(ctx, tx, login, provider, claims)
+	case err != nil && !errors.Is(err, store.ErrNotFound):
+		return err
+	}
+
+	if login.User, err = s.createUser(ctx, tx, claims); err != nil {
+		return err
+	}
+	login.Created = true
+	return s.link(ctx, tx, login, provider, claims)
+}
+
+func (s *OIDCService) link(ctx context.Context, tx *store.Store, login *OIDCLogin, provider string, claims *idTokenClaims) error {
+	identity := models.Identity{UserID: login.User.ID, Provider: provider, Subject: claims.Subject, Email: claims.Email}
+	if err := tx.Identities.Create(ctx, &identity); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			return ErrIdentityLinked
+		}
+		return err
+	}
+	login.Linked = !login.Created
+	return tx.Identities.TouchLogin(ctx, identity.ID)
+}
+
+// createUser signs up the provider account. It has no password until the
+// user sets one through a password reset.
+func (s *OIDCService) createUser(ctx context.Context, tx *store.Store, claims *idTokenClaims) (*models.User, error) {
+	username, err := freeUsername(ctx, tx.Users, claims)
+	if err != nil {
+		return nil, err
+	}
+	user := models.User{
+		Username: username,
+		Email:    models.NormalizeEmail(claims.Email),
+		FullName: strings.TrimSpace(claims.Name),
+		Role:     models.RoleViewer,
+	}
+	if err := tx.Users.Create(ctx, &user); err != nil {
+		return nil, err
+	}
+	if err := tx.Users.SetEmailVerified(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return tx.Users.GetByID(ctx, user.ID)
+}
+
+// freeUsername derives an unused username from the provider's preferred
+// username or the email address.
+func freeUsername(ctx context.Context, users store.UserRepository, claims *idTokenClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, base)
+	base = base[:min(len(base), 30)]
+	if len(base) < 3 {
+		base = "user"
+	}
+
+	candidate := base
+	for range 5 {
+		taken, err := users.Exists(ctx, claims.Email, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = base + "-" + strings.ToLower(rand.Text()[:5])
+	}
+	return "", store.ErrConflict
+}
+
+// Identities lists the providers linked to the user.
+func (s *OIDCService) Identities(ctx context.Context, userID int) ([]models.Identity, error) {
+	return s.store.Identities.ListByUser(ctx, userID)
+}
+
+// Unlink removes the user's identity at the provider, unless the account
+// has no password and no other identity to log in with.
+func (s *OIDCService) Unlink(ctx context.Context, userID int, provider string) error {
+	return s.store.WithTx(ctx, func(tx *store.Store) error {
+		user, err := tx.Users.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		identities, err := tx.Identities.ListByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if user.PasswordHash == "" && len(identities) == 1 && identities[0].Provider == provider {
+			return ErrLastLoginMethod
+		}
+		return tx.Identities.Delete(ctx, userID, provider)
+	})
+}
@@ -0,0 +1,448 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/memory"
+)
+
+// mockOIDC is an OpenID Connect provider like cmd/mockoidc: it approves
+// every authorization request as the user named by login_hint and checks
+// PKCE and the client credentials. tamper, when set, edits each ID token
+// before it is signed.
+type mockOIDC struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	tamper func(claims jwt.MapClaims, header map[string]any)
+
+	mu     sync.Mutex
+	grants map[string]url.Values // authorization request by code
+}
+
+const (
+	mockClientID     = "influenceiq"
+	mockClientSecret = "mock-secret"
+	mockRedirectURL  = "http://app.test/oauth/callback"
+)
+
+var (
+	mockKeyOnce sync.Once
+	mockKey     *rsa.PrivateKey
+)
+
+func newMockOIDC(t *testing.T) *mockOIDC {
+	t.Helper()
+	mockKeyOnce.Do(func() {
+		var err error
+		if mockKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			panic(err)
+		}
+	})
+
+	p := &mockOIDC{key: mockKey, grants: map[string]url.Values{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeMockJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		b64 := base64.RawURLEncoding.EncodeToString
+		writeMockJSON(w, http.StatusOK, JWKS{Keys: []JWK{{
+			KeyType:   "RSA",
+			KeyID:     "mock-1",
+			Use:       "sig",
+			Algorithm: "RS256",
+			N:         b64(p.key.N.Bytes()),
+			E:         b64(big.NewInt(int64(p.key.E)).Bytes()),
+		}}})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *mockOIDC) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != mockClientID || q.Get("redirect_uri") != mockRedirectURL ||
+		q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "bad authorization request", http.StatusBadRequest)
+		return
+	}
+	code := rand.Text()
+	p.mu.Lock()
+	p.grants[code] = q
+	p.mu.Unlock()
+	http.Redirect(w, r, mockRedirectURL+"?"+url.Values{"code": {code}, "state": {q.Get("state")}}.Encode(), http.StatusFound)
+}
+
+func (p *mockOIDC) token(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, _ := r.BasicAuth()
+	if err := r.ParseForm(); err != nil || clientID != mockClientID || secret != mockClientSecret {
+		writeMockJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	q, ok := p.grants[code]
+	delete(p.grants, code)
+	p.mu.Unlock()
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || q.Get("code_challenge") != base64.RawURLEncoding.EncodeToString(challenge[:]) ||
+		r.PostForm.Get("redirect_uri") != mockRedirectURL {
+		writeMockJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	email := q.Get("login_hint")
+	subject := sha256.Sum256([]byte(email))
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":                p.URL,
+		"sub":                hex.EncodeToString(subject[:12]),
+		"aud":                mockClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"nonce":              q.Get("nonce"),
+		"email":              email,
+		"email_verified":     q.Get("email_verified") != "false",
+		"preferred_username": strings.Split(email, "@")[0],
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = "mock-1"
+	if p.tamper != nil {
+		p.tamper(claims, idToken.Header)
+	}
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		writeMockJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeMockJSON(w, http.StatusOK, map[string]string{"token_type": "Bearer", "id_token": signed})
+}
+
+func writeMockJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newOIDCTest returns a service with the providers "mock" and "other",
+// both at p, on an empty memory store.
+func newOIDCTest(p *mockOIDC) (*OIDCService, *store.Store) {
+	st := memory.New()
+	provider := config.OIDCProvider{
+		Issuer:       p.URL,
+		ClientID:     mockClientID,
+		ClientSecret: mockClientSecret,
+		RedirectURL:  mockRedirectURL,
+	}
+	cfg := &config.Config{OIDC: config.OIDCConfig{
+		Providers: map[string]config.OIDCProvider{"mock": provider, "other": provider},
+		StateTTL:  time.Minute,
+	}}
+	return NewOIDCService(st, cfg), st
+}
+
+// authorize starts a login at provider and lets the mock approve it as
+// email, returning the code and state it redirects back with.
+func authorize(t *testing.T, s *OIDCService, provider, email string, verified bool, linkUserID *int) (code, state string) {
+	t.Helper()
+	ctx := context.Background()
+	authURL, state, err := s.Start(ctx, provider, linkUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	q.Set("login_hint", email)
+	if !verified {
+		q.Set("email_verified", "false")
+	}
+	u.RawQuery = q.Encode()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusFound || err != nil {
+		t.Fatalf("authorize: %s, Location %q", resp.Status, resp.Header.Get("Location"))
+	}
+	if got := back.Query().Get("state"); got != state {
+		t.Fatalf("provider sent back state %q, want %q", got, state)
+	}
+	return back.Query().Get("code"), state
+}
+
+func TestOIDCLogin(t *testing.T) {
+	ctx := context.Background()
+	p := newMockOIDC(t)
+
+	tests := []struct {
+		name string
+		// existing is an account registered with the email beforehand.
+		existing *models.User
+		// email is what the provider asserts, creator@example.com if empty.
+		email       string
+		verified    bool
+		wantErr     error
+		wantCreated bool
+		wantLinked  bool
+	}{
+		{name: "new account", verified: true, wantCreated: true},
+		{name: "email not verified by provider", verified: false, wantErr: ErrEmailUnverified},
+		{
+			name:       "verified account linked by email",
+			existing:   &models.User{Username: "creator", Email: "creator@example.com", Role: models.RoleViewer},
+			verified:   true,
+			wantLinked: true,
+		},
+		{
+			name:       "email matched ignoring case",
+			existing:   &models.User{Username: "creator", Email: "creator@example.com", Role: models.RoleViewer},
+			email:      "Creator@Example.COM",
+			verified:   true,
+			wantLinked: true,
+		},
+		{
+			name:       "account with a mixed-case email",
+			existing:   &models.User{Username: "creator", Email: "Creator@example.com", Role: models.RoleViewer},
+			verified:   true,
+			wantLinked: true,
+		},
+		{
+			name:     "unverified account not linked",
+			existing: &models.User{Username: "creator", Email: "creator@example.com", Role: models.RoleViewer},
+			verified: true,
+			wantErr:  ErrAccountNotLinkable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, st := newOIDCTest(p)
+			if tt.existing != nil {
+				user := *tt.existing
+				if err := st.Users.Create(ctx, &user); err != nil {
+					t.Fatal(err)
+				}
+				if tt.wantLinked {
+					if err := st.Users.SetEmailVerified(ctx, user.ID); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			email := tt.email
+			if email == "" {
+				email = "creator@example.com"
+			}
+			code, state := authorize(t, s, "mock", email, tt.verified, nil)
+			login, err := s.Finish(ctx, "mock", code, state)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Finish error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if login.Created != tt.wantCreated || login.Linked != tt.wantLinked {
+				t.Errorf("Created, Linked = %v, %v, want %v, %v", login.Created, login.Linked, tt.wantCreated, tt.wantLinked)
+			}
+			if !strings.EqualFold(login.User.Email, "creator@example.com") || !login.User.EmailVerified() {
+				t.Errorf("user %q verified=%v, want creator@example.com verified", login.User.Email, login.User.EmailVerified())
+			}
+
+			// The next login finds the account by its identity.
+			code, state = authorize(t, s, "mock", email, tt.verified, nil)
+			again, err := s.Finish(ctx, "mock", code, state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.User.ID != login.User.ID || again.Created || again.Linked {
+				t.Errorf("second login: user %d created=%v linked=%v, want user %d found", again.User.ID, again.Created, again.Linked, login.User.ID)
+			}
+		})
+	}
+}
+
+func TestOIDCRejectsIDToken(t *testing.T) {
+	ctx := context.Background()
+	p := newMockOIDC(t)
+	s, _ := newOIDCTest(p)
+
+	tests := []struct {
+		name   string
+		tamper func(claims jwt.MapClaims, header map[string]any)
+	}{
+		{"wrong nonce", func(c jwt.MapClaims, _ map[string]any) { c["nonce"] = "replayed" }},
+		{"wrong issuer", func(c jwt.MapClaims, _ map[string]any) { c["iss"] = "https://evil.example" }},
+		{"wrong audience", func(c jwt.MapClaims, _ map[string]any) { c["aud"] = "another-client" }},
+		{"expired", func(c jwt.MapClaims, _ map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"no expiry", func(c jwt.MapClaims, _ map[string]any) { delete(c, "exp") }},
+		{"no subject", func(c jwt.MapClaims, _ map[string]any) { delete(c, "sub") }},
+		{"authorized party is another client", func(c jwt.MapClaims, _ map[string]any) {
+			c["aud"] = []string{mockClientID, "another-client"}
+			c["azp"] = "another-client"
+		}},
+		{"unknown key", func(_ jwt.MapClaims, h map[string]any) { h["kid"] = "rotated-away" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.tamper = tt.tamper
+			defer func() { p.tamper = nil }()
+
+			code, state := authorize(t, s, "mock", "creator@example.com", true, nil)
+			if _, err := s.Finish(ctx, "mock", code, state); !errors.Is(err, ErrInvalidIDToken) {
+				t.Errorf("Finish error = %v, want %v", err, ErrInvalidIDToken)
+			}
+		})
+	}
+}
+
+func TestOIDCState(t *testing.T) {
+	ctx := context.Background()
+	p := newMockOIDC(t)
+	s, _ := newOIDCTest(p)
+
+	tests := []struct {
+		name string
+		// finish completes a login started by authorize.
+		finish  func(t *testing.T) error
+		wantErr error
+	}{
+		{
+			name: "unknown state",
+			finish: func(t *testing.T) error {
+				code, _ := authorize(t, s, "mock", "creator@example.com", true, nil)
+				_, err := s.Finish(ctx, "mock", code, "forged")
+				return err
+			},
+			wantErr: ErrInvalidOAuthState,
+		},
+		{
+			name: "state used twice",
+			finish: func(t *testing.T) error {
+				code, state := authorize(t, s, "mock", "creator@example.com", true, nil)
+				if _, err := s.Finish(ctx, "mock", code, state); err != nil {
+					t.Fatal(err)
+				}
+				_, err := s.Finish(ctx, "mock", code, state)
+				return err
+			},
+			wantErr: ErrInvalidOAuthState,
+		},
+		{
+			name: "state of another provider",
+			finish: func(t *testing.T) error {
+				code, state := authorize(t, s, "other", "creator@example.com", true, nil)
+				_, err := s.Finish(ctx, "mock", code, state)
+				return err
+			},
+			wantErr: ErrInvalidOAuthState,
+		},
+		{
+			name: "unknown provider",
+			finish: func(t *testing.T) error {
+				_, err := s.Finish(ctx, "nope", "code", "state")
+				return err
+			},
+			wantErr: ErrUnknownProvider,
+		},
+		{
+			name: "code of another login",
+			finish: func(t *testing.T) error {
+				// The provider checks the code verifier against the
+				// challenge the code was issued for.
+				code, _ := authorize(t, s, "mock", "creator@example.com", true, nil)
+				_, state := authorize(t, s, "mock", "creator@example.com", true, nil)
+				_, err := s.Finish(ctx, "mock", code, state)
+				return err
+			},
+			wantErr: ErrProviderRejected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.finish(t); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Finish error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOIDCLinkAndUnlink(t *testing.T) {
+	ctx := context.Background()
+	p := newMockOIDC(t)
+	s, st := newOIDCTest(p)
+
+	newUser := func(name string) *models.User {
+		u := &models.User{Username: name, Email: name + "@example.com", PasswordHash: "hash", Role: models.RoleViewer}
+		if err := st.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	alice, bob := newUser("alice"), newUser("bob")
+
+	// Linking doesn't need the provider's email to match the account's.
+	code, state := authorize(t, s, "mock", "alice.social@example.net", false, &alice.ID)
+	login, err := s.Finish(ctx, "mock", code, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if login.User.ID != alice.ID || !login.Linked {
+		t.Fatalf("link: user %d linked=%v, want user %d linked", login.User.ID, login.Linked, alice.ID)
+	}
+
+	code, state = authorize(t, s, "mock", "alice.social@example.net", false, &bob.ID)
+	if _, err := s.Finish(ctx, "mock", code, state); !errors.Is(err, ErrIdentityLinked) {
+		t.Errorf("linking another user's identity: error = %v, want %v", err, ErrIdentityLinked)
+	}
+
+	// Without a password the identity is alice's only way to log in.
+	if err := st.Users.UpdatePassword(ctx, alice.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Unlink(ctx, alice.ID, "mock"); !errors.Is(err, ErrLastLoginMethod) {
+		t.Errorf("Unlink without password: error = %v, want %v", err, ErrLastLoginMethod)
+	}
+	if err := st.Users.UpdatePassword(ctx, alice.ID, "hash"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Unlink(ctx, alice.ID, "mock"); err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := s.Identities(ctx, alice.ID); err != nil || len(ids) != 0 {
+		t.Errorf("Identities after Unlink = %v, %v, want none", ids, err)
+	}
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+var (
+	ErrNoOrganization = errors.New("user belongs to no organization")
+	ErrInOrganization = errors.New("user already belongs to an organization")
+	// ErrOrgPermission is returned when the user's role in the organization
+	// doesn't allow a change.
+	ErrOrgPermission = errors.New("organization role doesn't allow this")
+	// ErrLastOwner is returned for changes that would leave an organization
+	// without an owner.
+	ErrLastOwner = errors.New("organization needs an owner")
+	// ErrAlreadyMember is returned when inviting a member of the
+	// organization.
+	ErrAlreadyMember = errors.New("user is already a member")
+	// ErrInvalidInvitation is returned for unknown and expired invitations,
+	// and for invitations to another email address.
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+)
+
+// OrganizationDetails is an organization as its members see it.
+type OrganizationDetails struct {
+	models.Organization
+	// Role is the requesting user's role.
+	Role    string                      `json:"role"`
+	Members []models.OrganizationMember `json:"members"`
+}
+
+// OrganizationService manages brand organizations and decides who may
+// manage which campaigns.
+type OrganizationService struct {
+	store    *store.Store
+	accounts *AccountService
+	cfg      config.AccountConfig
+}
+
+func NewOrganizationService(s *store.Store, accounts *AccountService, cfg config.AccountConfig) *OrganizationService {
+	return &OrganizationService{store: s, accounts: accounts, cfg: cfg}
+}
+
+// membership returns the user's membership or ErrNoOrganization.
+func membership(ctx context.Context, s *store.Store, userID int) (*models.OrganizationMember, error) {
+	m, err := s.Organizations.GetMembership(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNoOrganization
+	}
+	return m, err
+}
+
+// OrganizationID returns the ID of the user's organization, or nil.
+func (s *OrganizationService) OrganizationID(ctx context.Context, userID int) (*int, error) {
+	m, err := membership(ctx, s.store, userID)
+	if errors.Is(err, ErrNoOrganization) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m.OrganizationID, nil
+}
+
+// CanManageCampaign reports whether the user may change c and its
+// applications: every member of the organization that owns it, or the
+// brand who created it if no organization does.
+func (s *OrganizationService) CanManageCampaign(ctx context.Context, userID int, c *models.Campaign) (bool, error) {
+	if c.OrganizationID == nil {
+		return c.BrandID == userID, nil
+	}
+	orgID, err := s.OrganizationID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return orgID != nil && *orgID == *c.OrganizationID, nil
+}
+
+// Create starts an organization owned by the user, who brings along the
+// campaigns they created outside any organization.
+func (s *OrganizationService) Create(ctx context.Context, userID int, name string) (*OrganizationDetails, error) {
+	err := s.store.WithTx(ctx, func(tx *store.Store) error {
+		org := models.Organization{Name: strings.TrimSpace(name)}
+		if err := tx.Organizations.Create(ctx, &org); err != nil {
+			return err
+		}
+		return join(ctx, tx, org.ID, userID, models.OrgRoleOwner)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID)
+}
+
+// join adds the user to the organization and moves their campaigns there.
+func join(ctx context.Context, tx *store.Store, orgID, userID int, role string) error {
+	err := tx.Organizations.AddMember(ctx, &models.OrganizationMember{
+		OrganizationID: orgID,
+		UserID:         userID,
+		Role:           role,
+	})
+	if errors.Is(err, store.ErrConflict) {
+		return ErrInOrganization
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Campaigns.AdoptCampaigns(ctx, userID, orgID)
+}
+
+// Get returns the user's organization.
+func (s *OrganizationService) Get(ctx context.Context, userID int) (*OrganizationDetails, error) {
+	m, err := membership(ctx, s.store, userID)
+	if err != nil {
+		return nil, err
+	}
+	org, err := s.store.Organizations.GetByID(ctx, m.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.store.Organizations.ListMembers(ctx, m.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	return &OrganizationDetails{Organization: *org, Role: m.Role, Members: members}, nil
+}
+
+// Rename lets owners and admins rename their organization.
+func (s *OrganizationService) Rename(ctx context.Context, userID int, name string) (*OrganizationDetails, error) {
+	m, err := membership(ctx, s.store, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !m.CanManageMembers() {
+		return nil, ErrOrgPermission
+	}
+	if err := s.store.Organizations.Rename(ctx, m.OrganizationID, strings.TrimSpace(name)); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID)
+}
+
+// Delete lets an owner dissolve their organization. Its campaigns go back
+// to the brands who created them.
+func (s *OrganizationService) Delete(ctx context.Context, userID int) error {
+	m, err := membership(ctx, s.store, userID)
+	if err != nil {
+		return err
+	}
+	if m.Role != models.OrgRoleOwner {
+		return ErrOrgPermission
+	}
+	// The organization's 2FA policy goes with it.
+	return s.store.WithTx(ctx, func(tx *store.Store) error {
+		return tx.Organizations.Delete(ctx, m.OrganizationID)
+	})
+}
+
+// SetMemberRole lets an owner change a member's role, as long as an owner
+// remains.
+func (s *OrganizationService) SetMemberRole(ctx context.Context, userID, memberID int, role string) error {
+	return s.store.WithTx(ctx, func(tx *store.Store) error {
+		m, err := membership(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if m.Role != models.OrgRoleOwner {
+			return ErrOrgPermission
+		}
+
+		target, err := tx.Organizations.GetMembership(ctx, memberID)
+		if err == nil && target.OrganizationID != m.OrganizationID {
+			err = store.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if target.Role == models.OrgRoleOwner && role != models.OrgRoleOwner {
+			if err := ensureOwnerRemains(ctx, tx, m.OrganizationID); err != nil {
+				return err
+			}
+		}
+		return tx.Organizations.SetMemberRole(ctx, m.OrganizationID, memberID, role)
+	})
+}
+
+// RemoveMember takes a member out of the user's organization, or lets the
+// user leave when memberID is their own ID. Owners remove anyone, admins
+// only members. The organization keeps the campaigns.
+func (s *OrganizationService) RemoveMember(ctx context.Context, userID, memberID int) error {
+	return s.store.WithTx(ctx, func(tx *store.Store) error {
+		m, err := membership(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		target := m
+		if memberID != userID {
+			target, err = tx.Organizations.GetMembership(ctx, memberID)
+			if err == nil && target.OrganizationID != m.OrganizationID {
+				err = store.ErrNotFound
+			}
+			if err != nil {
+				return err
+			}
+			allowed := m.Role == models.OrgRoleOwner ||
+				m.Role == models.OrgRoleAdmin && target.Role == models.OrgRoleMember
+			if !allowed {
+				return ErrOrgPermission
+			}
+		}
+		if target.Role == models.OrgRoleOwner {
+			if err := ensureOwnerRemains(ctx, tx, m.OrganizationID); err != nil {
+				return err
+			}
+		}
+		return tx.Organizations.RemoveMember(ctx, m.OrganizationID, memberID)
+	})
+}
+
+// ensureOwnerRemains returns ErrLastOwner unless the organization has
+// another owner besides the one about to go.
+func ensureOwnerRemains(ctx context.Context, tx *store.Store, orgID int) error {
+	owners, err := tx.Organizations.CountOwners(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if owners < 2 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// Invite emails email an invitation to the inviter's organization. Owners
+// and admins invite members; only owners invite admins. Inviting the same
+// address again replaces the earlier invitation.
+func (s *OrganizationService) Invite(ctx context.Context, inviter *models.User, email, role string) (*models.OrganizationInvitation, error) {
+	m, err := membership(ctx, s.store, inviter.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !m.CanManageMembers() || role == models.OrgRoleAdmin && m.Role != models.OrgRoleOwner {
+		return nil, ErrOrgPermission
+	}
+
+	email = models.NormalizeEmail(email)
+	if invitee, err := s.store.Users.GetByEmail(ctx, email); err == nil {
+		theirs, err := s.store.Organizations.GetMembership(ctx, invitee.ID)
+		if err == nil && theirs.OrganizationID == m.OrganizationID {
+			return nil, ErrAlreadyMember
+		}
+	}
+
+	org, err := s.store.Organizations.GetByID(ctx, m.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := rand.Text() + rand.Text()
+	inv := models.OrganizationInvitation{
+		OrganizationID: m.OrganizationID,
+		Email:          email,
+		Role:           role,
+		TokenHash:      hashToken(raw),
+		InvitedBy:      &inviter.ID,
+		ExpiresAt:      time.Now().Add(s.cfg.InvitationTTL),
+	}
+	if err := s.store.Organizations.CreateInvitation(ctx, &inv); err != nil {
+		return nil, err
+	}
+
+	// The invitation stands even if the email doesn't go out; inviting
+	// again sends a new one.
+	if err := s.accounts.SendInvitation(ctx, &inv, raw, org, inviter); err != nil {
+		log.Printf("Sending invitation %d to organization %d failed: %v", inv.ID, org.ID, err)
+	}
+	return &inv, nil
+}
+
+// ListInvitations returns the pending invitations of the user's
+// organization.
+func (s *OrganizationService) ListInvitations(ctx context.Context, userID int) ([]models.OrganizationInvitation, error) {
+	m, err := membership(ctx, s.store, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !m.CanManageMembers() {
+		return nil, ErrOrgPermission
+	}
+	return s.store.Organizations.ListInvitations(ctx, m.OrganizationID)
+}
+
+// RevokeInvitation withdraws an invitation of the user's organization.
+func (s *OrganizationService) RevokeInvitation(ctx context.Context, userID, id int) error {
+	m, err := membership(ctx, s.store, userID)
+	if err != nil {
+		return err
+	}
+	if !m.CanManageMembers() {
+		return ErrOrgPermission
+	}
+	return s.store.Organizations.DeleteInvitation(ctx, m.OrganizationID, id)
+}
+
+// Accept adds user to the organization that invited their email address.
+// The invitation only works once.
+func (s *OrganizationService) Accept(ctx context.Context, user *models.User, raw string) (*OrganizationDetails, error) {
+	err := s.store.WithTx(ctx, func(tx *store.Store) error {
+		inv, err := tx.Organizations.ConsumeInvitation(ctx, hashToken(raw))
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrInvalidInvitation
+		}
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(inv.Email, user.Email) {
+			return ErrInvalidInvitation
+		}
+		return join(ctx, tx, inv.OrganizationID, user.ID, inv.Role)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, user.ID)
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/memory"
+)
+
+// orgFixture is an organization with one member of each role, and a brand
+// of another organization.
+type orgFixture struct {
+	s                           *OrganizationService
+	st                          *store.Store
+	mail                        *recordingMailer
+	owner, admin, member, other *models.User
+	orgID                       int
+}
+
+func newOrgFixture(t *testing.T) *orgFixture {
+	t.Helper()
+	ctx := context.Background()
+	st := memory.New()
+	m := &recordingMailer{}
+	cfg := config.AccountConfig{LinkBaseURL: "https://app.example.com", InvitationTTL: time.Hour}
+	f := &orgFixture{s: NewOrganizationService(st, NewAccountService(st, m, cfg), cfg), st: st, mail: m}
+
+	newBrand := func(name string) *models.User {
+		u := &models.User{Username: name, Email: name + "@example.com", Role: models.RoleBrand}
+		if err := st.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	f.owner, f.admin, f.member, f.other = newBrand("owner"), newBrand("admin"), newBrand("member"), newBrand("other")
+
+	org, err := f.s.Create(ctx, f.owner.ID, " Acme ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.orgID = org.ID
+	if org.Name != "Acme" || org.Role != models.OrgRoleOwner {
+		t.Fatalf("created %+v", org)
+	}
+	for _, m := range []models.OrganizationMember{
+		{OrganizationID: org.ID, UserID: f.admin.ID, Role: models.OrgRoleAdmin},
+		{OrganizationID: org.ID, UserID: f.member.ID, Role: models.OrgRoleMember},
+	} {
+		if err := st.Organizations.AddMember(ctx, &m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := f.s.Create(ctx, f.other.ID, "Other"); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestOrganizationRoles(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(f *orgFixture) error
+		want error
+	}{
+		{"owner promotes member", func(f *orgFixture) error {
+			return f.s.SetMemberRole(context.Background(), f.owner.ID, f.member.ID, models.OrgRoleAdmin)
+		}, nil},
+		{"admin can't change roles", func(f *orgFixture) error {
+			return f.s.SetMemberRole(context.Background(), f.admin.ID, f.member.ID, models.OrgRoleAdmin)
+		}, ErrOrgPermission},
+		{"last owner can't step down", func(f *orgFixture) error {
+			return f.s.SetMemberRole(context.Background(), f.owner.ID, f.owner.ID, models.OrgRoleAdmin)
+		}, ErrLastOwner},
+		{"owner steps down for another", func(f *orgFixture) error {
+			ctx := context.Background()
+			if err := f.s.SetMemberRole(ctx, f.owner.ID, f.admin.ID, models.OrgRoleOwner); err != nil {
+				return err
+			}
+			return f.s.SetMemberRole(ctx, f.owner.ID, f.owner.ID, models.OrgRoleMember)
+		}, nil},
+		{"role in another organization", func(f *orgFixture) error {
+			return f.s.SetMemberRole(context.Background(), f.owner.ID, f.other.ID, models.OrgRoleMember)
+		}, store.ErrNotFound},
+		{"admin removes member", func(f *orgFixture) error {
+			return f.s.RemoveMember(context.Background(), f.admin.ID, f.member.ID)
+		}, nil},
+		{"admin can't remove admin", func(f *orgFixture) error {
+			return f.s.RemoveMember(context.Background(), f.admin.ID, f.owner.ID)
+		}, ErrOrgPermission},
+		{"member can't remove others", func(f *orgFixture) error {
+			return f.s.RemoveMember(context.Background(), f.member.ID, f.admin.ID)
+		}, ErrOrgPermission},
+		{"member leaves", func(f *orgFixture) error {
+			return f.s.RemoveMember(context.Background(), f.member.ID, f.member.ID)
+		}, nil},
+		{"last owner can't leave", func(f *orgFixture) error {
+			return f.s.RemoveMember(context.Background(), f.owner.ID, f.owner.ID)
+		}, ErrLastOwner},
+		{"admin renames", func(f *orgFixture) error {
+			_, err := f.s.Rename(context.Background(), f.admin.ID, "Acme Inc.")
+			return err
+		}, nil},
+		{"member can't rename", func(f *orgFixture) error {
+			_, err := f.s.Rename(context.Background(), f.member.ID, "Acme Inc.")
+			return err
+		}, ErrOrgPermission},
+		{"admin can't delete", func(f *orgFixture) error {
+			return f.s.Delete(context.Background(), f.admin.ID)
+		}, ErrOrgPermission},
+		{"owner deletes", func(f *orgFixture) error {
+			return f.s.Delete(context.Background(), f.owner.ID)
+		}, nil},
+		{"second organization", func(f *orgFixture) error {
+			_, err := f.s.Create(context.Background(), f.member.ID, "Side project")
+			return err
+		}, ErrInOrganization},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOrgFixture(t)
+			if err := tt.run(f); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanManageCampaign(t *testing.T) {
+	f := newOrgFixture(t)
+	own := &models.Campaign{BrandID: f.other.ID}
+	shared := &models.Campaign{BrandID: f.owner.ID, OrganizationID: &f.orgID}
+	tests := []struct {
+		name     string
+		user     *models.User
+		campaign *models.Campaign
+		want     bool
+	}{
+		{"creator without organization", f.other, own, true},
+		{"someone else's campaign", f.owner, own, false},
+		{"member of the owning organization", f.member, shared, true},
+		{"brand of another organization", f.other, shared, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.s.CanManageCampaign(context.Background(), tt.user.ID, tt.campaign)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("CanManageCampaign = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvitations(t *testing.T) {
+	tests := []struct {
+		name    string
+		inviter func(f *orgFixture) *models.User
+		email   string
+		role    string
+		wantErr error
+		// acceptor accepts the invitation when the invite succeeds.
+		acceptor func(t *testing.T, f *orgFixture) *models.User
+		wantJoin error
+	}{
+		{"owner invites admin", func(f *orgFixture) *models.User { return f.owner }, "New@Example.com", models.OrgRoleAdmin, nil,
+			func(t *testing.T, f *orgFixture) *models.User { return newTestUser(t, f.st, "new", "new@example.com") }, nil},
+		{"admin invites member", func(f *orgFixture) *models.User { return f.admin }, "new@example.com", models.OrgRoleMember, nil,
+			func(t *testing.T, f *orgFixture) *models.User { return newTestUser(t, f.st, "new", "new@example.com") }, nil},
+		{"admin can't invite admin", func(f *orgFixture) *models.User { return f.admin }, "new@example.com", models.OrgRoleAdmin,
+			ErrOrgPermission, nil, nil},
+		{"member can't invite", func(f *orgFixture) *models.User { return f.member }, "new@example.com", models.OrgRoleMember,
+			ErrOrgPermission, nil, nil},
+		{"already a member", func(f *orgFixture) *models.User { return f.owner }, "MEMBER@example.com", models.OrgRoleMember,
+			ErrAlreadyMember, nil, nil},
+		{"accepted by another address", func(f *orgFixture) *models.User { return f.owner }, "new@example.com", models.OrgRoleMember, nil,
+			func(t *testing.T, f *orgFixture) *models.User {
+				return newTestUser(t, f.st, "mallory", "mallory@example.com")
+			}, ErrInvalidInvitation},
+		{"accepted from another organization", func(f *orgFixture) *models.User { return f.owner }, "other@example.com", models.OrgRoleMember, nil,
+			func(_ *testing.T, f *orgFixture) *models.User { return f.other }, ErrInOrganization},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newOrgFixture(t)
+			inv, err := f.s.Invite(ctx, tt.inviter(f), tt.email, tt.role)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Invite = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(f.mail.sent) != 0 {
+					t.Errorf("sent %d emails for a refused invitation", len(f.mail.sent))
+				}
+				return
+			}
+			if inv.Email != models.NormalizeEmail(tt.email) {
+				t.Errorf("invitation to %q, want %q", inv.Email, models.NormalizeEmail(tt.email))
+			}
+
+			user := tt.acceptor(t, f)
+			raw := f.mail.last(t, inv.Email)
+			org, err := f.s.Accept(ctx, user, raw)
+			if !errors.Is(err, tt.wantJoin) {
+				t.Fatalf("Accept = %v, want %v", err, tt.wantJoin)
+			}
+			if err != nil {
+				return
+			}
+			if org.ID != f.orgID || org.Role != tt.role {
+				t.Errorf("joined organization %d as %q, want %d as %q", org.ID, org.Role, f.orgID, tt.role)
+			}
+			if _, err := f.s.Accept(ctx, user, raw); !errors.Is(err, ErrInvalidInvitation) {
+				t.Errorf("accepting twice = %v, want %v", err, ErrInvalidInvitation)
+			}
+		})
+	}
+}
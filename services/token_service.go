@@ -0,0 +1,415 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked
+	// refresh tokens.
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when a refresh token that was
+	// already exchanged is presented again. Its whole family is revoked.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrInvalidToken wraps every reason an access token fails
+	// verification, including jwt.ErrTokenExpired.
+	ErrInvalidToken = errors.New("invalid access token")
+	// ErrTokenRevoked is returned for access tokens on the denylist and for
+	// those whose session was revoked.
+	ErrTokenRevoked = errors.New("access token has been revoked")
+	// ErrSessionNotFound is returned for sessions that don't exist, belong
+	// to someone else or have already ended.
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// Claims are the claims of an access token. Subject holds the user ID as
+// a string for verifiers that only look at registered claims. SessionID
+// is the token family the access token was issued with.
+type Claims struct {
+	UserID    int    `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// Client describes where a login comes from. It is recorded on the
+// session.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// Session lengths are capped to their columns.
+const (
+	maxUserAgentLength = 512
+	maxIPLength        = 64
+)
+
+// sessionTouchInterval is how often a session's last-seen time is updated
+// while its access tokens are in use.
+const sessionTouchInterval = time.Minute
+
+// TokenPair is what a client receives on login and on every refresh.
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// TokenService issues short-lived access tokens together with rotating
+// refresh tokens, verifies access tokens and revokes both.
+type TokenService struct {
+	store      *store.Store
+	keys       *KeySet
+	parser     *jwt.Parser
+	issuer     string
+	ttl        time.Duration
+	refreshTTL time.Duration
+}
+
+func NewTokenService(s *store.Store, cfg config.JWTConfig) (*TokenService, error) {
+	keys, err := LoadKeySet(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenService{
+		store: s,
+		keys:  keys,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(keys.Algorithms()),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+		),
+		issuer:     cfg.Issuer,
+		ttl:        cfg.TTL,
+		refreshTTL: cfg.RefreshTTL,
+	}, nil
+}
+
+// JWKS returns the public keys that access tokens may be signed with.
+func (s *TokenService) JWKS() JWKS {
+	return s.keys.JWKS()
+}
+
+// accessToken signs an access token for user in session sid and returns
+// it with its expiry. Each token gets a random ID (the jti claim) so that
+// it can be revoked individually.
+func (s *TokenService) accessToken(user *models.User, sid string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	token, err := s.keys.Sign(Claims{
+		UserID:    user.ID,
+		Role:      user.Role,
+		SessionID: sid,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        rand.Text(),
+			Issuer:    s.issuer,
+			Subject:   strconv.Itoa(user.ID),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+	return token, expiresAt, err
+}
+
+// Authenticate verifies an access token's signature and claims and checks
+// it against the denylist and its session.
+func (s *TokenService) Authenticate(ctx context.Context, token string) (*Claims, error) {
+	var claims Claims
+	if _, err := s.parser.ParseWithClaims(token, &claims, s.keys.Keyfunc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if claims.UserID == 0 || claims.ID == "" || claims.SessionID == "" {
+		return nil, fmt.Errorf("%w: missing user_id, jti or sid", ErrInvalidToken)
+	}
+
+	denied, err := s.store.Tokens.IsDenied(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if denied {
+		return nil, ErrTokenRevoked
+	}
+
+	session, err := s.store.Sessions.GetByFamily(ctx, claims.SessionID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrTokenRevoked
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !session.Active(now) || session.UserID != claims.UserID {
+		return nil, ErrTokenRevoked
+	}
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := s.store.Sessions.Touch(ctx, session.ID); err != nil {
+			log.Printf("Updating last use of session %d failed: %v", session.ID, err)
+		}
+	}
+	return &claims, nil
+}
+
+// Issue starts a new session for user, e.g. after a login, and returns
+// the first token pair of its family.
+func (s *TokenService) Issue(ctx context.Context, user *models.User, client Client) (*TokenPair, error) {
+	var pair *TokenPair
+	err := s.store.WithTx(ctx, func(tx *store.Store) error {
+		session := models.Session{
+			UserID:    user.ID,
+			FamilyID:  rand.Text(),
+			UserAgent: truncate(client.UserAgent, maxUserAgentLength),
+			IP:        truncate(client.IP, maxIPLength),
+			ExpiresAt: time.Now().Add(s.refreshTTL),
+		}
+		if err := tx.Sessions.Create(ctx, &session); err != nil {
+			return err
+		}
+		var err error
+		pair, err = s.issue(ctx, tx.Tokens, user, session.FamilyID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+func (s *TokenService) issue(ctx context.Context, tokens store.TokenRepository, user *models.User, familyID string) (*TokenPair, error) {
+	access, expiresAt, err := s.accessToken(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := rand.Text() + rand.Text()
+	refresh := models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+	if err := tokens.CreateRefresh(ctx, &refresh); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     raw,
+		RefreshExpiresAt: refresh.ExpiresAt,
+	}, nil
+}
+
+// Reissue replaces an access token whose claims are out of date, e.g.
+// after the user's role changed: the old token is denied, its session sid
+// ends and the user gets a new session.
+func (s *TokenService) Reissue(ctx context.Context, user *models.User, jti string, expiresAt time.Time, sid string, client Client) (*TokenPair, error) {
+	if err := s.store.Tokens.Deny(ctx, jti, expiresAt); err != nil {
+		return nil, err
+	}
+	if err := s.store.Tokens.RevokeFamily(ctx, sid); err != nil {
+		return nil, err
+	}
+	return s.Issue(ctx, user, client)
+}
+
+// Refresh exchanges a refresh token for a new pair in the same family. The
+// old refresh token stops working. Presenting it again means a copy has
+// leaked, so the whole family is revoked and the holder of the current
+// token has to log in again too. The family's session is extended and
+// records the client's current IP.
+func (s *TokenService) Refresh(ctx context.Context, raw string, client Client) (*TokenPair, error) {
+	var pair *TokenPair
+	var reusedFamily string
+	err := s.store.WithTx(ctx, func(tx *store.Store) error {
+		t, err := tx.Tokens.GetRefreshByHash(ctx, hashToken(raw))
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case t.RevokedAt != nil, !time.Now().Before(t.ExpiresAt):
+			return ErrInvalidRefreshToken
+		case t.UsedAt != nil:
+			reusedFamily = t.FamilyID
+			return ErrRefreshTokenReused
+		}
+
+		// UseRefresh only succeeds once, so of two concurrent refreshes
+		// with the same token one is treated as reuse.
+		if err := tx.Tokens.UseRefresh(ctx, t.ID); err != nil {
+			if errors.Is(err, store.ErrConflict) {
+				reusedFamily = t.FamilyID
+				return ErrRefreshTokenReused
+			}
+			return err
+		}
+
+		// Reload the user so that the new access token carries the
+		// current role.
+		user, err := tx.Users.GetByID(ctx, t.UserID)
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+
+		pair, err = s.issue(ctx, tx.Tokens, user, t.FamilyID)
+		if err != nil {
+			return err
+		}
+		return s.extendSession(ctx, tx, user.ID, t.FamilyID, client, pair.RefreshExpiresAt)
+	})
+
+	if errors.Is(err, ErrRefreshTokenReused) {
+		// The transaction rolled back, so revoke outside of it.
+		if rerr := s.store.Tokens.RevokeFamily(ctx, reusedFamily); rerr != nil {
+			return nil, rerr
+		}
+		log.Printf("Refresh token reuse detected; revoked token family %s", reusedFamily)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// extendSession moves the session of a refreshed family forward. Families
+// started before sessions existed get one now.
+func (s *TokenService) extendSession(ctx context.Context, tx *store.Store, userID int, familyID string, client Client, expiresAt time.Time) error {
+	ip := truncate(client.IP, maxIPLength)
+	session, err := tx.Sessions.GetByFamily(ctx, familyID)
+	if errors.Is(err, store.ErrNotFound) {
+		return tx.Sessions.Create(ctx, &models.Session{
+			UserID:    userID,
+			FamilyID:  familyID,
+			UserAgent: truncate(client.UserAgent, maxUserAgentLength),
+			IP:        ip,
+			ExpiresAt: expiresAt,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if session.RevokedAt != nil {
+		return ErrInvalidRefreshToken
+	}
+	return tx.Sessions.Extend(ctx, session.ID, ip, expiresAt)
+}
+
+// Logout denies the access token jti and ends its session sid. If a
+// refresh token of the same user is given, its family is revoked as well.
+// Unknown refresh tokens are ignored so that logging out twice succeeds.
+func (s *TokenService) Logout(ctx context.Context, userID int, jti string, expiresAt time.Time, sid, refreshToken string) error {
+	if err := s.store.Tokens.Deny(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+	if err := s.store.Tokens.RevokeFamily(ctx, sid); err != nil {
+		return err
+	}
+	if refreshToken == "" {
+		return nil
+	}
+
+	t, err := s.store.Tokens.GetRefreshByHash(ctx, hashToken(refreshToken))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if t.UserID != userID {
+		return nil
+	}
+	return s.store.Tokens.RevokeFamily(ctx, t.FamilyID)
+}
+
+// Sessions lists the user's active sessions, most recently used first.
+func (s *TokenService) Sessions(ctx context.Context, userID int) ([]models.Session, error) {
+	return s.store.Sessions.ListActive(ctx, userID)
+}
+
+// RevokeSession ends one of the user's sessions: its refresh tokens stop
+// working and its access tokens are rejected from the next request on.
+func (s *TokenService) RevokeSession(ctx context.Context, userID, id int) error {
+	session, err := s.store.Sessions.GetByID(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID || !session.Active(time.Now()) {
+		return ErrSessionNotFound
+	}
+	return s.store.Tokens.RevokeFamily(ctx, session.FamilyID)
+}
+
+// RevokeSessions ends every session of the user, including the current
+// one.
+func (s *TokenService) RevokeSessions(ctx context.Context, userID int) error {
+	return s.store.Tokens.RevokeUserRefresh(ctx, userID)
+}
+
+// purgeInterval is how often PurgeExpired runs.
+const purgeInterval = time.Hour
+
+// PurgeExpired periodically deletes expired refresh tokens and denylist
+// entries until ctx is done. Run it as a server worker.
+func (s *TokenService) PurgeExpired(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.store.Tokens.DeleteExpired(ctx, time.Now())
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Purging expired tokens failed: %v", err)
+			} else if n > 0 {
+				log.Printf(" Purged %d expired token(s)", n)
+			}
+		}
+	}
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// hashToken is how refresh tokens are stored. They are random, so a fast
+// unsalted hash is enough.
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/memory"
+)
+
+func newTestUser(t *testing.T, st *store.Store, username, email string) *models.User {
+	t.Helper()
+	u := &models.User{Username: username, Email: email, Role: models.RoleViewer}
+	if err := st.Users.Create(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func newTokenTest(t *testing.T) (*TokenService, *store.Store, *models.User) {
+	t.Helper()
+	st := memory.New()
+	s, err := NewTokenService(st, config.JWTConfig{Issuer: "test", TTL: time.Minute, RefreshTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, st, newTestUser(t, st, "ada", "ada@example.com")
+}
+
+func TestRefresh(t *testing.T) {
+	tests := []struct {
+		name string
+		// refresh starts from a fresh pair and returns the newest pair of
+		// its family and the error of the exchange under test.
+		refresh func(ctx context.Context, s *TokenService, first *TokenPair) (*TokenPair, error)
+		want    error
+		// familyRevoked is whether the family's newest refresh token
+		// stops working.
+		familyRevoked bool
+	}{
+		{"rotates", func(ctx context.Context, s *TokenService, first *TokenPair) (*TokenPair, error) {
+			next, err := s.Refresh(ctx, first.RefreshToken, Client{})
+			return next, err
+		}, nil, false},
+		{"unknown token", func(ctx context.Context, s *TokenService, first *TokenPair) (*TokenPair, error) {
+			_, err := s.Refresh(ctx, "nope", Client{})
+			return first, err
+		}, ErrInvalidRefreshToken, false},
+		{"reuse revokes the family", func(ctx context.Context, s *TokenService, first *TokenPair) (*TokenPair, error) {
+			next, err := s.Refresh(ctx, first.RefreshToken, Client{})
+			if err != nil {
+				return nil, err
+			}
+			_, err = s.Refresh(ctx, first.RefreshToken, Client{})
+			return next, err
+		}, ErrRefreshTokenReused, true},
+		{"logout revokes the family", func(ctx context.Context, s *TokenService, first *TokenPair) (*TokenPair, error) {
+			claims, err := s.Authenticate(ctx, first.AccessToken)
+			if err != nil {
+				return nil, err
+			}
+			err = s.Logout(ctx, claims.UserID, claims.ID, claims.ExpiresAt.Time, claims.SessionID, first.RefreshToken)
+			if err != nil {
+				return nil, err
+			}
+			_, err = s.Refresh(ctx, first.RefreshToken, Client{})
+			return first, err
+		}, ErrInvalidRefreshToken, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, _, user := newTokenTest(t)
+			first, err := s.Issue(ctx, user, Client{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			latest, err := tt.refresh(ctx, s, first)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Refresh = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && latest.RefreshToken == first.RefreshToken {
+				t.Error("refresh token not rotated")
+			}
+			if tt.want == nil || tt.familyRevoked {
+				_, err = s.Refresh(ctx, latest.RefreshToken, Client{})
+				if revoked := err != nil; revoked != tt.familyRevoked {
+					t.Errorf("newest refresh token revoked = %v (%v), want %v", revoked, err, tt.familyRevoked)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	s, st, user := newTokenTest(t)
+	expiring, err := NewTokenService(st, config.JWTConfig{Issuer: "test", TTL: -time.Minute, RefreshTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewTokenService(st, config.JWTConfig{Issuer: "other", TTL: time.Minute, RefreshTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issue := func(s *TokenService) *TokenPair {
+		pair, err := s.Issue(ctx, user, Client{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pair
+	}
+	valid, expired, foreign := issue(s), issue(expiring), issue(other)
+
+	loggedOut := issue(s)
+	claims, err := s.Authenticate(ctx, loggedOut.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Logout(ctx, user.ID, claims.ID, claims.ExpiresAt.Time, claims.SessionID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"valid", valid.AccessToken, nil},
+		{"garbage", "not.a.token", ErrInvalidToken},
+		{"expired", expired.AccessToken, ErrInvalidToken},
+		{"signed by another service", foreign.AccessToken, ErrInvalidToken},
+		{"denied after logout", loggedOut.AccessToken, ErrTokenRevoked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := s.Authenticate(ctx, tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Authenticate = %v, want %v", err, tt.want)
+			}
+			if err == nil && claims.UserID != user.ID {
+				t.Errorf("user = %d, want %d", claims.UserID, user.ID)
+			}
+		})
+	}
+}
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	s, st, ada := newTokenTest(t)
+	bob := newTestUser(t, st, "bob", "bob@example.com")
+
+	issue := func(user *models.User, agent string) *TokenPair {
+		pair, err := s.Issue(ctx, user, Client{UserAgent: agent})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pair
+	}
+	phone, laptop := issue(ada, "phone"), issue(ada, "laptop")
+	issue(bob, "bob")
+
+	sessions, err := s.Sessions(ctx, ada.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("listed %d sessions, want ada's 2", len(sessions))
+	}
+	byAgent := map[string]models.Session{}
+	for _, session := range sessions {
+		if session.UserID != ada.ID {
+			t.Errorf("listed session %d of user %d", session.ID, session.UserID)
+		}
+		byAgent[session.UserAgent] = session
+	}
+
+	if err := s.RevokeSession(ctx, bob.ID, byAgent["phone"].ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("revoking someone else's session = %v, want %v", err, ErrSessionNotFound)
+	}
+	if _, err := s.Authenticate(ctx, phone.AccessToken); err != nil {
+		t.Fatalf("session ended by someone else: %v", err)
+	}
+
+	if err := s.RevokeSession(ctx, ada.ID, byAgent["phone"].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(ctx, phone.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("access token of the revoked session = %v, want %v", err, ErrTokenRevoked)
+	}
+	if _, err := s.Refresh(ctx, phone.RefreshToken, Client{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh token of the revoked session = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+	if _, err := s.Authenticate(ctx, laptop.AccessToken); err != nil {
+		t.Errorf("other session ended too: %v", err)
+	}
+	if err := s.RevokeSession(ctx, ada.ID, byAgent["phone"].ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("revoking twice = %v, want %v", err, ErrSessionNotFound)
+	}
+
+	if err := s.RevokeSessions(ctx, ada.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(ctx, laptop.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("access token after revoking every session = %v, want %v", err, ErrTokenRevoked)
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors, in base32.
+var rfc6238Secret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238, appendix B, truncated to six digits.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		step := tt.unix / int64(totpPeriod/time.Second)
+		if got := totpCode([]byte("12345678901234567890"), step); got != tt.code {
+			t.Errorf("totpCode(step %d) = %q, want %q", step, got, tt.code)
+		}
+	}
+}
+
+func TestTOTPMatch(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / int64(totpPeriod/time.Second)
+	key := []byte("12345678901234567890")
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		step   int64
+		ok     bool
+	}{
+		{"current step", rfc6238Secret, totpCode(key, step), step, true},
+		{"previous step", rfc6238Secret, totpCode(key, step-1), step - 1, true},
+		{"next step", rfc6238Secret, totpCode(key, step+1), step + 1, true},
+		{"two steps behind", rfc6238Secret, totpCode(key, step-2), 0, false},
+		{"two steps ahead", rfc6238Secret, totpCode(key, step+2), 0, false},
+		{"wrong code", rfc6238Secret, "000000", 0, false},
+		{"short code", rfc6238Secret, "05047", 0, false},
+		{"long code", rfc6238Secret, "0504710", 0, false},
+		{"bad secret", "not base32!", totpCode(key, step), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := totpMatch(tt.secret, tt.code, now)
+			if ok != tt.ok || got != tt.step {
+				t.Errorf("totpMatch() = %d, %v, want %d, %v", got, ok, tt.step, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNewTOTPSecret(t *testing.T) {
+	a, b := newTOTPSecret(), newTOTPSecret()
+	if a == b {
+		t.Fatal("newTOTPSecret returned the same secret twice")
+	}
+	key, err := totpEncoding.DecodeString(a)
+	if err != nil {
+		t.Fatalf("secret %q isn't base32: %v", a, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret has %d bytes, want 20", len(key))
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := provisioningURI("Influence IQ", "jane@example.com", rfc6238Secret)
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Influence IQ:jane@example.com" {
+		t.Errorf("uri = %q, want otpauth://totp/Influence%%20IQ:jane@example.com", uri)
+	}
+	want := map[string]string{
+		"secret":    rfc6238Secret,
+		"issuer":    "Influence IQ",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	for k, v := range want {
+		if got := u.Query().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Package attempts counts failed logins in the memory of one process. It
+// is the login limiter of a single instance, and the in-memory store's.
+package attempts
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"InfluenceIQ/models"
+)
+
+// Counter is a store.LoginAttemptRepository kept in a map.
+type Counter struct {
+	mu      sync.Mutex
+	records map[string]models.LoginAttempts
+}
+
+func NewCounter() *Counter {
+	return &Counter{records: map[string]models.LoginAttempts{}}
+}
+
+func (c *Counter) Get(ctx context.Context, bucket string) (*models.LoginAttempts, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	a, ok := c.records[bucket]
+	if !ok {
+		a = models.LoginAttempts{Bucket: bucket}
+	}
+	return &a, nil
+}
+
+func (c *Counter) Fail(ctx context.Context, bucket string, windowStart time.Time) (*models.LoginAttempts, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().UTC()
+	a, ok := c.records[bucket]
+	if !ok {
+		a = models.LoginAttempts{Bucket: bucket, WindowStart: now}
+	}
+	if a.WindowStart.Before(windowStart) {
+		a.Failures, a.WindowStart = 0, now
+	}
+	a.Failures++
+	c.records[bucket] = a
+	return &a, nil
+}
+
+func (c *Counter) Block(ctx context.Context, bucket string, until time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	a, ok := c.records[bucket]
+	if !ok {
+		a = models.LoginAttempts{Bucket: bucket, WindowStart: time.Now().UTC()}
+	}
+	a.BlockedUntil = &until
+	c.records[bucket] = a
+	return nil
+}
+
+func (c *Counter) Reset(ctx context.Context, bucket string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.records, bucket)
+	return nil
+}
+
+func (c *Counter) Purge(ctx context.Context, windowStart time.Time) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var n int64
+	now := time.Now()
+	for bucket, a := range c.records {
+		if a.WindowStart.Before(windowStart) && !a.Blocked(now) {
+			delete(c.records, bucket)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,301 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"InfluenceIQ/models"
+)
+
+// Page size bounds for listings.
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// ErrInvalidCursor is returned when a cursor can't be decoded or doesn't
+// belong to the listing it is used with.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Sort orders a listing by one whitelisted field. Ties are broken by ID in
+// the same direction so that every row has a unique position.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// String formats s the way it is written in a query string: "budget" or
+// "-budget" for descending.
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// ListOptions selects one page of a listing.
+type ListOptions struct {
+	Limit int
+	Sort  Sort
+	// Cursor continues from a previous page; nil starts at the beginning.
+	Cursor *Cursor
+}
+
+// Cursor marks a position in a listing by the sort value and ID of the row
+// on the edge of a page. Clients only ever see it encoded.
+type Cursor struct {
+	Sort Sort
+	// Backward asks for the page before the position instead of after it.
+	Backward bool
+	Value    any
+	ID       int
+}
+
+type cursorJSON struct {
+	Sort     string `json:"s"`
+	Backward bool   `json:"b,omitempty"`
+	Kind     string `json:"k"`
+	Value    string `json:"v"`
+	ID       int    `json:"id"`
+}
+
+// Encode returns the opaque string form of c.
+func (c *Cursor) Encode() string {
+	raw := cursorJSON{Sort: c.Sort.String(), Backward: c.Backward, ID: c.ID}
+	switch v := c.Value.(type) {
+	case time.Time:
+		raw.Kind, raw.Value = "t", v.UTC().Format(time.RFC3339Nano)
+	case float64:
+		raw.Kind, raw.Value = "f", strconv.FormatFloat(v, 'g', -1, 64)
+	case int:
+		raw.Kind, raw.Value = "i", strconv.Itoa(v)
+	default:
+		raw.Kind, raw.Value = "s", fmt.Sprint(v)
+	}
+	data, _ := json.Marshal(raw)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var raw cursorJSON
+	if err := json.Unmarshal(data, &raw); err != nil || raw.Sort == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	c := &Cursor{Backward: raw.Backward, ID: raw.ID}
+	c.Sort.Field, c.Sort.Desc = strings.TrimPrefix(raw.Sort, "-"), strings.HasPrefix(raw.Sort, "-")
+	switch raw.Kind {
+	case "t":
+		c.Value, err = time.Parse(time.RFC3339Nano, raw.Value)
+	case "f":
+		c.Value, err = strconv.ParseFloat(raw.Value, 64)
+	case "i":
+		c.Value, err = strconv.Atoi(raw.Value)
+	case "s":
+		c.Value = raw.Value
+	default:
+		err = ErrInvalidCursor
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Page is one slice of a listing with cursors to its neighbours. Next and
+// Prev are nil at either end.
+type Page[T any] struct {
+	Items []T
+	Next  *Cursor
+	Prev  *Cursor
+}
+
+// SortSpec whitelists the fields a listing of T can be sorted by. Field
+// names double as column names in the SQL backends.
+type SortSpec[T any] struct {
+	Default Sort
+	Fields  map[string]func(*T) any
+	ID      func(*T) int
+}
+
+// ParseSort reads "field" or "-field". An empty string gives the default.
+func (s SortSpec[T]) ParseSort(param string) (Sort, error) {
+	if param == "" {
+		return s.Default, nil
+	}
+	sort := Sort{Field: strings.TrimPrefix(param, "-"), Desc: strings.HasPrefix(param, "-")}
+	if _, ok := s.Fields[sort.Field]; !ok {
+		return Sort{}, fmt.Errorf("unknown sort field %q", sort.Field)
+	}
+	return sort, nil
+}
+
+// FieldNames lists the sortable fields alphabetically.
+func (s SortSpec[T]) FieldNames() []string {
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Normalize fills in the default sort and limit, caps the limit and
+// rejects sort fields outside the whitelist and cursors issued for a
+// different sort. Repositories call it before building a query.
+func (s SortSpec[T]) Normalize(opts ListOptions) (ListOptions, error) {
+	if opts.Sort.Field == "" {
+		opts.Sort = s.Default
+	}
+	if _, ok := s.Fields[opts.Sort.Field]; !ok {
+		return opts, fmt.Errorf("unknown sort field %q", opts.Sort.Field)
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+	opts.Limit = min(opts.Limit, MaxLimit)
+
+	if c := opts.Cursor; c != nil {
+		var zero T
+		if c.Sort != opts.Sort || fmt.Sprintf("%T", s.Fields[opts.Sort.Field](&zero)) != fmt.Sprintf("%T", c.Value) {
+			return opts, ErrInvalidCursor
+		}
+	}
+	return opts, nil
+}
+
+// Key returns the sort value and ID of item under sort.
+func (s SortSpec[T]) Key(item *T, sort Sort) (any, int) {
+	return s.Fields[sort.Field](item), s.ID(item)
+}
+
+// Page builds a page from rows fetched in scan order: the listing's order
+// when paging forward and the reverse when paging backward, with up to
+// opts.Limit+1 rows so that a further page can be detected.
+func (s SortSpec[T]) Page(rows []T, opts ListOptions) Page[T] {
+	backward := opts.Cursor != nil && opts.Cursor.Backward
+	more := len(rows) > opts.Limit
+	if more {
+		rows = rows[:opts.Limit]
+	}
+	if backward {
+		slices.Reverse(rows)
+	}
+
+	page := Page[T]{Items: rows}
+	if len(rows) == 0 {
+		return page
+	}
+	edge := func(item *T, backward bool) *Cursor {
+		value, id := s.Key(item, opts.Sort)
+		return &Cursor{Sort: opts.Sort, Backward: backward, Value: value, ID: id}
+	}
+	first, last := &rows[0], &rows[len(rows)-1]
+	if backward {
+		page.Next = edge(last, false)
+		if more {
+			page.Prev = edge(first, true)
+		}
+	} else {
+		if more {
+			page.Next = edge(last, false)
+		}
+		if opts.Cursor != nil {
+			page.Prev = edge(first, true)
+		}
+	}
+	return page
+}
+
+// CampaignFilter narrows a campaign listing. Zero values match everything.
+// Personal limits the listing to campaigns that belong to no organization.
+type CampaignFilter struct {
+	BrandID        int
+	OrganizationID int
+	Personal       bool
+	Category       string
+	// Statuses keeps the campaigns in any of the statuses.
+	Statuses     []string
+	MinBudget    *float64
+	MaxBudget    *float64
+	DeadlineFrom *time.Time
+	DeadlineTo   *time.Time
+	// EligibleFor keeps the campaigns whose eligibility rules the profile
+	// meets.
+	EligibleFor *models.Profile
+}
+
+// ApplicationFilter narrows an application listing. Zero values match
+// everything.
+type ApplicationFilter struct {
+	CampaignID   int
+	InfluencerID int
+	Status       string
+}
+
+// SecurityEventFilter narrows a security event listing. Zero values match
+// everything.
+type SecurityEventFilter struct {
+	Type   string
+	UserID int
+	IP     string
+}
+
+// JobRunFilter narrows a job run listing. Zero values match everything.
+type JobRunFilter struct {
+	Job    string
+	Status string
+}
+
+// CampaignSorts, ApplicationSorts, SecurityEventSorts and JobRunSorts are
+// the sortable fields of each listing.
+var (
+	CampaignSorts = SortSpec[models.Campaign]{
+		Default: Sort{Field: "created_at", Desc: true},
+		Fields: map[string]func(*models.Campaign) any{
+			"created_at": func(c *models.Campaign) any { return c.CreatedAt },
+			"deadline":   func(c *models.Campaign) any { return c.Deadline },
+			"budget":     func(c *models.Campaign) any { return c.Budget },
+			"title":      func(c *models.Campaign) any { return c.Title },
+		},
+		ID: func(c *models.Campaign) int { return c.ID },
+	}
+
+	ApplicationSorts = SortSpec[models.CampaignApplication]{
+		Default: Sort{Field: "created_at", Desc: true},
+		Fields: map[string]func(*models.CampaignApplication) any{
+			"created_at": func(a *models.CampaignApplication) any { return a.CreatedAt },
+			"updated_at": func(a *models.CampaignApplication) any { return a.UpdatedAt },
+			"status":     func(a *models.CampaignApplication) any { return a.Status },
+		},
+		ID: func(a *models.CampaignApplication) int { return a.ID },
+	}
+
+	SecurityEventSorts = SortSpec[models.SecurityEvent]{
+		Default: Sort{Field: "created_at", Desc: true},
+		Fields: map[string]func(*models.SecurityEvent) any{
+			"created_at": func(e *models.SecurityEvent) any { return e.CreatedAt },
+		},
+		ID: func(e *models.SecurityEvent) int { return e.ID },
+	}
+
+	JobRunSorts = SortSpec[models.JobRun]{
+		Default: Sort{Field: "started_at", Desc: true},
+		Fields: map[string]func(*models.JobRun) any{
+			"started_at":  func(r *models.JobRun) any { return r.StartedAt },
+			"duration_ms": func(r *models.JobRun) any { return int(r.DurationMS) },
+		},
+		ID: func(r *models.JobRun) int { return r.ID },
+	}
+)
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 30, 0, 123456789, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{"time", Cursor{Sort: Sort{Field: "created_at", Desc: true}, Value: at, ID: 42}},
+		{"float", Cursor{Sort: Sort{Field: "budget"}, Value: 1234.5, ID: 7}},
+		{"whole float", Cursor{Sort: Sort{Field: "budget"}, Value: 100.0, ID: 7}},
+		{"int", Cursor{Sort: Sort{Field: "duration_ms", Desc: true}, Value: 1500, ID: 3}},
+		{"string", Cursor{Sort: Sort{Field: "title"}, Value: `Summer "sale", 50%`, ID: 9}},
+		{"empty string", Cursor{Sort: Sort{Field: "status"}, Value: "", ID: 1}},
+		{"backward", Cursor{Sort: Sort{Field: "created_at", Desc: true}, Backward: true, Value: at, ID: 42}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := tt.cursor.Encode()
+			if _, err := base64.RawURLEncoding.DecodeString(encoded); err != nil {
+				t.Fatalf("Encode() = %q isn't URL-safe base64: %v", encoded, err)
+			}
+
+			got, err := DecodeCursor(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Sort != tt.cursor.Sort || got.Backward != tt.cursor.Backward || got.ID != tt.cursor.ID {
+				t.Errorf("DecodeCursor() = %+v, want %+v", got, tt.cursor)
+			}
+			if want, ok := tt.cursor.Value.(time.Time); ok {
+				if v, ok := got.Value.(time.Time); !ok || !v.Equal(want) {
+					t.Errorf("value = %v, want %v", got.Value, want)
+				}
+			} else if got.Value != tt.cursor.Value {
+				t.Errorf("value = %#v, want %#v", got.Value, tt.cursor.Value)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorErrors(t *testing.T) {
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"budget","k":"f","v":"1","id":1}`))},
+		{"not JSON", encode("budget:1")},
+		{"no sort", encode(`{"k":"i","v":"1","id":1}`)},
+		{"unknown kind", encode(`{"s":"budget","k":"x","v":"1","id":1}`)},
+		{"bad time", encode(`{"s":"created_at","k":"t","v":"yesterday","id":1}`)},
+		{"bad float", encode(`{"s":"budget","k":"f","v":"lots","id":1}`)},
+		{"bad int", encode(`{"s":"duration_ms","k":"i","v":"1.5","id":1}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q) = %+v, %v, want ErrInvalidCursor", tt.cursor, c, err)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	byBudget := Sort{Field: "budget"}
+	tests := []struct {
+		name string
+		opts ListOptions
+		want ListOptions
+		err  bool
+	}{
+		{"defaults", ListOptions{}, ListOptions{Limit: DefaultLimit, Sort: CampaignSorts.Default}, false},
+		{"caps the limit", ListOptions{Limit: 1000, Sort: byBudget}, ListOptions{Limit: MaxLimit, Sort: byBudget}, false},
+		{"keeps the limit", ListOptions{Limit: 5, Sort: byBudget}, ListOptions{Limit: 5, Sort: byBudget}, false},
+		{"unknown field", ListOptions{Sort: Sort{Field: "password"}}, ListOptions{}, true},
+		{"matching cursor", ListOptions{Sort: byBudget, Cursor: &Cursor{Sort: byBudget, Value: 10.0, ID: 1}},
+			ListOptions{Limit: DefaultLimit, Sort: byBudget, Cursor: &Cursor{Sort: byBudget, Value: 10.0, ID: 1}}, false},
+		{"cursor of another sort", ListOptions{Sort: byBudget, Cursor: &Cursor{Sort: Sort{Field: "budget", Desc: true}, Value: 10.0, ID: 1}},
+			ListOptions{}, true},
+		{"cursor of another type", ListOptions{Sort: byBudget, Cursor: &Cursor{Sort: byBudget, Value: "10", ID: 1}},
+			ListOptions{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CampaignSorts.Normalize(tt.opts)
+			if tt.err {
+				if err == nil {
+					t.Errorf("Normalize() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Limit != tt.want.Limit || got.Sort != tt.want.Sort || (got.Cursor == nil) != (tt.want.Cursor == nil) {
+				t.Errorf("Normalize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		param string
+		want  Sort
+		err   bool
+	}{
+		{"", Sort{Field: "created_at", Desc: true}, false},
+		{"budget", Sort{Field: "budget"}, false},
+		{"-deadline", Sort{Field: "deadline", Desc: true}, false},
+		{"owner", Sort{}, true},
+		{"--budget", Sort{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			got, err := CampaignSorts.ParseSort(tt.param)
+			if (err != nil) != tt.err || got != tt.want {
+				t.Errorf("ParseSort(%q) = %+v, %v, want %+v, error %v", tt.param, got, err, tt.want, tt.err)
+			}
+			if err == nil && got.String() != tt.param && tt.param != "" {
+				t.Errorf("String() = %q, want %q", got.String(), tt.param)
+			}
+		})
+	}
+}
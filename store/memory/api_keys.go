@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type APIKeyRepo struct {
+	*db
+}
+
+func (r *APIKeyRepo) Create(ctx context.Context, k *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.apiKeys {
+		if existing.KeyHash == k.KeyHash {
+			return store.ErrConflict
+		}
+	}
+
+	k.ID = r.id("api_keys")
+	k.CreatedAt = now()
+	stored := *k
+	stored.Scopes = slices.Clone(k.Scopes)
+	r.apiKeys[k.ID] = stored
+	return nil
+}
+
+func (r *APIKeyRepo) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, k := range r.apiKeys {
+		if k.KeyHash == hash {
+			return &k, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (r *APIKeyRepo) ListByUser(ctx context.Context, userID int) ([]models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := []models.APIKey{}
+	for _, k := range r.apiKeys {
+		if k.UserID == userID {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortFunc(keys, func(a, b models.APIKey) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return b.ID - a.ID
+	})
+	return keys, nil
+}
+
+func (r *APIKeyRepo) CountActive(ctx context.Context, userID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	t := now()
+	for _, k := range r.apiKeys {
+		if k.UserID == userID && k.Active(t) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *APIKeyRepo) TouchLastUsed(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k, ok := r.apiKeys[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	usedAt := now()
+	k.LastUsedAt = &usedAt
+	r.apiKeys[id] = k
+	return nil
+}
+
+func (r *APIKeyRepo) Revoke(ctx context.Context, id, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k, ok := r.apiKeys[id]
+	if !ok || k.UserID != userID || k.RevokedAt != nil {
+		return store.ErrNotFound
+	}
+	revokedAt := now()
+	k.RevokedAt = &revokedAt
+	r.apiKeys[id] = k
+	return nil
+}
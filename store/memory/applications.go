@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type ApplicationRepo struct {
+	*db
+}
+
+func (r *ApplicationRepo) Create(ctx context.Context, a *models.CampaignApplication) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.applications {
+		if existing.CampaignID == a.CampaignID && existing.InfluencerID == a.InfluencerID {
+			return store.ErrConflict
+		}
+	}
+
+	a.ID = r.id("campaign_applications")
+	if a.Status == "" {
+		a.Status = "pending"
+	}
+	a.CreatedAt = now()
+	a.UpdatedAt = a.CreatedAt
+	r.applications[a.ID] = *a
+	return nil
+}
+
+func (r *ApplicationRepo) GetByID(ctx context.Context, id int) (*models.CampaignApplication, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, ok := r.applications[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &a, nil
+}
+
+func (r *ApplicationRepo) GetByCampaignAndInfluencer(ctx context.Context, campaignID, influencerID int) (*models.CampaignApplication, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, a := range r.applications {
+		if a.CampaignID == campaignID && a.InfluencerID == influencerID {
+			return &a, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (r *ApplicationRepo) List(ctx context.Context, f store.ApplicationFilter, opts store.ListOptions) (store.Page[models.CampaignApplication], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var apps []models.CampaignApplication
+	for _, a := range r.applications {
+		switch {
+		case f.CampaignID != 0 && a.CampaignID != f.CampaignID,
+			f.InfluencerID != 0 && a.InfluencerID != f.InfluencerID,
+			f.Status != "" && a.Status != f.Status:
+			continue
+		}
+		apps = append(apps, a)
+	}
+	return paginate(apps, store.ApplicationSorts, opts)
+}
+
+func (r *ApplicationRepo) UpdateStatus(ctx context.Context, id int, from, to string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.applications[id]
+	if !ok || a.Status != from {
+		return store.ErrConflict
+	}
+	a.Status = to
+	a.UpdatedAt = now()
+	r.applications[id] = a
+	return nil
+}
+
+func (r *ApplicationRepo) RejectPending(ctx context.Context, campaignID int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, a := range r.applications {
+		if a.CampaignID == campaignID && a.Status == "pending" {
+			a.Status = "rejected"
+			a.UpdatedAt = now()
+			r.applications[id] = a
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *ApplicationRepo) ExpirePending(ctx context.Context, t time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, a := range r.applications {
+		if a.Status == "pending" && a.CreatedAt.Before(t) {
+			a.Status = "expired"
+			a.UpdatedAt = now()
+			r.applications[id] = a
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type CampaignRepo struct {
+	*db
+}
+
+func (r *CampaignRepo) Create(ctx context.Context, c *models.Campaign) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c.ID = r.id("campaigns")
+	if c.Status == "" {
+		c.Status = models.CampaignDraft
+	}
+	c.Version = 1
+	c.CreatedAt = now()
+	c.UpdatedAt = c.CreatedAt
+	r.campaigns[c.ID] = *c
+	return nil
+}
+
+func (r *CampaignRepo) GetByID(ctx context.Context, id int) (*models.Campaign, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.campaigns[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &c, nil
+}
+
+// GetForUpdate needs no lock of its own: transactions run one at a time.
+func (r *CampaignRepo) GetForUpdate(ctx context.Context, id int) (*models.Campaign, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *CampaignRepo) List(ctx context.Context, f store.CampaignFilter, opts store.ListOptions) (store.Page[models.Campaign], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var campaigns []models.Campaign
+	for _, c := range r.campaigns {
+		switch {
+		case f.BrandID != 0 && c.BrandID != f.BrandID,
+			f.OrganizationID != 0 && (c.OrganizationID == nil || *c.OrganizationID != f.OrganizationID),
+			f.Personal && c.OrganizationID != nil,
+			f.Category != "" && c.Category != f.Category,
+			len(f.Statuses) > 0 && !slices.Contains(f.Statuses, c.Status),
+			f.MinBudget != nil && c.Budget < *f.MinBudget,
+			f.MaxBudget != nil && c.Budget > *f.MaxBudget,
+			f.DeadlineFrom != nil && c.Deadline.Before(*f.DeadlineFrom),
+			f.DeadlineTo != nil && c.Deadline.After(*f.DeadlineTo),
+			f.EligibleFor != nil && c.Eligibility.Check(f.EligibleFor) != nil:
+			continue
+		}
+		campaigns = append(campaigns, c)
+	}
+	return paginate(campaigns, store.CampaignSorts, opts)
+}
+
+func (r *CampaignRepo) Update(ctx context.Context, c *models.Campaign) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.campaigns[c.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if existing.Version != c.Version {
+		return store.ErrConflict
+	}
+	existing.Title = c.Title
+	existing.Description = c.Description
+	existing.Category = c.Category
+	existing.Budget = c.Budget
+	existing.Deadline = c.Deadline
+	existing.Eligibility = c.Eligibility
+	existing.Version++
+	existing.UpdatedAt = now()
+	r.campaigns[c.ID] = existing
+	c.Version = existing.Version
+	c.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+func (r *CampaignRepo) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.campaigns[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(r.campaigns, id)
+	delete(r.reminded, id)
+	// Mirror ON DELETE CASCADE.
+	for appID, a := range r.applications {
+		if a.CampaignID == id {
+			delete(r.applications, appID)
+		}
+	}
+	for dID, d := range r.deliverables {
+		if d.CampaignID == id {
+			delete(r.deliverables, dID)
+		}
+	}
+	for sID, s := range r.submissions {
+		if _, ok := r.deliverables[s.DeliverableID]; !ok {
+			delete(r.submissions, sID)
+		}
+	}
+	for tID, t := range r.transitions {
+		if t.CampaignID == id {
+			delete(r.transitions, tID)
+		}
+	}
+	return nil
+}
+
+func (r *CampaignRepo) AdoptCampaigns(ctx context.Context, brandID, orgID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, c := range r.campaigns {
+		if c.BrandID == brandID && c.OrganizationID == nil {
+			c.OrganizationID = &orgID
+			r.campaigns[id] = c
+		}
+	}
+	return nil
+}
+
+func (r *CampaignRepo) IncrementCounters(ctx context.Context, id int, applications, accepted int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.campaigns[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	c.ApplicationCount += applications
+	c.AcceptedCount += accepted
+	r.campaigns[id] = c
+	return nil
+}
+
+func (r *CampaignRepo) SetStatus(ctx context.Context, id int, from, to string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.campaigns[id]
+	if !ok || c.Status != from {
+		return store.ErrConflict
+	}
+	c.Status = to
+	c.Version++
+	c.UpdatedAt = now()
+	r.campaigns[id] = c
+	return nil
+}
+
+func (r *CampaignRepo) AddTransition(ctx context.Context, t *models.CampaignTransition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.campaigns[t.CampaignID]; !ok {
+		return store.ErrNotFound
+	}
+	t.ID = r.id("campaign_transitions")
+	t.CreatedAt = now()
+	r.transitions[t.ID] = *t
+	return nil
+}
+
+func (r *CampaignRepo) ListTransitions(ctx context.Context, campaignID int) ([]models.CampaignTransition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	transitions := []models.CampaignTransition{}
+	for _, t := range r.transitions {
+		if t.CampaignID == campaignID {
+			transitions = append(transitions, t)
+		}
+	}
+	slices.SortFunc(transitions, func(a, b models.CampaignTransition) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return transitions, nil
+}
+
+func (r *CampaignRepo) ClaimDeadlineReminder(ctx context.Context, id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.campaigns[id]
+	if !ok {
+		return false, nil
+	}
+	if reminded, ok := r.reminded[id]; ok && reminded.Equal(c.Deadline) {
+		return false, nil
+	}
+	r.reminded[id] = c.Deadline
+	return true, nil
+}
@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type DeliverableRepo struct {
+	*db
+}
+
+func (r *DeliverableRepo) Create(ctx context.Context, d *models.Deliverable) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.campaigns[d.CampaignID]; !ok {
+		return store.ErrNotFound
+	}
+	d.ID = r.id("campaign_deliverables")
+	d.CreatedAt = now()
+	d.UpdatedAt = d.CreatedAt
+	r.deliverables[d.ID] = *d
+	return nil
+}
+
+func (r *DeliverableRepo) GetByID(ctx context.Context, id int) (*models.Deliverable, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.deliverables[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &d, nil
+}
+
+func (r *DeliverableRepo) ListByCampaign(ctx context.Context, campaignID int) ([]models.Deliverable, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliverables := []models.Deliverable{}
+	for _, d := range r.deliverables {
+		if d.CampaignID == campaignID {
+			deliverables = append(deliverables, d)
+		}
+	}
+	slices.SortFunc(deliverables, func(a, b models.Deliverable) int { return cmp.Compare(a.ID, b.ID) })
+	return deliverables, nil
+}
+
+func (r *DeliverableRepo) Update(ctx context.Context, d *models.Deliverable) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.deliverables[d.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	existing.Type = d.Type
+	existing.Platform = d.Platform
+	existing.Quantity = d.Quantity
+	existing.DueAt = d.DueAt
+	existing.Requirements = d.Requirements
+	existing.UpdatedAt = now()
+	r.deliverables[d.ID] = existing
+	d.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+func (r *DeliverableRepo) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.deliverables[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(r.deliverables, id)
+	// Mirror ON DELETE CASCADE.
+	for sID, s := range r.submissions {
+		if s.DeliverableID == id {
+			delete(r.submissions, sID)
+		}
+	}
+	return nil
+}
+
+func (r *DeliverableRepo) CreateSubmission(ctx context.Context, s *models.DeliverableSubmission) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.deliverables[s.DeliverableID]; !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := r.applications[s.ApplicationID]; !ok {
+		return store.ErrNotFound
+	}
+	s.ID = r.id("deliverable_submissions")
+	s.Status = models.SubmissionPending
+	s.CreatedAt = now()
+	r.submissions[s.ID] = *s
+	return nil
+}
+
+func (r *DeliverableRepo) GetSubmission(ctx context.Context, id int) (*models.DeliverableSubmission, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.submissions[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &s, nil
+}
+
+func (r *DeliverableRepo) ListSubmissions(ctx context.Context, f store.SubmissionFilter) ([]models.DeliverableSubmission, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	submissions := []models.DeliverableSubmission{}
+	for _, s := range r.submissions {
+		a := r.applications[s.ApplicationID]
+		switch {
+		case f.ApplicationID != 0 && s.ApplicationID != f.ApplicationID,
+			f.CampaignID != 0 && (a.CampaignID != f.CampaignID || a.Status != "accepted"),
+			f.DeliverableID != 0 && s.DeliverableID != f.DeliverableID:
+			continue
+		}
+		submissions = append(submissions, s)
+	}
+	slices.SortFunc(submissions, func(a, b models.DeliverableSubmission) int { return cmp.Compare(a.ID, b.ID) })
+	return submissions, nil
+}
+
+func (r *DeliverableRepo) CountSubmissions(ctx context.Context, deliverableID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for _, s := range r.submissions {
+		if s.DeliverableID == deliverableID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *DeliverableRepo) ReviewSubmission(ctx context.Context, s *models.DeliverableSubmission) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.submissions[s.ID]
+	if !ok || existing.Status != models.SubmissionPending {
+		return store.ErrConflict
+	}
+	reviewedAt := now()
+	existing.Status = s.Status
+	existing.Comment = s.Comment
+	existing.ReviewedBy = s.ReviewedBy
+	existing.ReviewedAt = &reviewedAt
+	r.submissions[s.ID] = existing
+	*s = existing
+	return nil
+}
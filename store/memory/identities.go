@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type IdentityRepo struct {
+	*db
+}
+
+func (r *IdentityRepo) Create(ctx context.Context, i *models.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.identities {
+		if existing.Provider == i.Provider && (existing.Subject == i.Subject || existing.UserID == i.UserID) {
+			return store.ErrConflict
+		}
+	}
+
+	i.ID = r.id("user_identities")
+	i.CreatedAt = now()
+	r.identities[i.ID] = *i
+	return nil
+}
+
+func (r *IdentityRepo) GetBySubject(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			return &i, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (r *IdentityRepo) ListByUser(ctx context.Context, userID int) ([]models.Identity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	identities := []models.Identity{}
+	for _, i := range r.identities {
+		if i.UserID == userID {
+			identities = append(identities, i)
+		}
+	}
+	slices.SortFunc(identities, func(a, b models.Identity) int { return cmp.Compare(a.Provider, b.Provider) })
+	return identities, nil
+}
+
+func (r *IdentityRepo) TouchLogin(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, ok := r.identities[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	loginAt := now()
+	i.LastLoginAt = &loginAt
+	r.identities[id] = i
+	return nil
+}
+
+func (r *IdentityRepo) Delete(ctx context.Context, userID int, provider string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, i := range r.identities {
+		if i.UserID == userID && i.Provider == provider {
+			delete(r.identities, id)
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
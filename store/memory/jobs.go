@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type JobRunRepo struct {
+	*db
+}
+
+func (r *JobRunRepo) Start(ctx context.Context, run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.jobRuns {
+		if existing.Job == run.Job && existing.ScheduledAt.Equal(run.ScheduledAt) {
+			return store.ErrConflict
+		}
+	}
+	run.ID = r.id("job_runs")
+	run.StartedAt = now()
+	r.jobRuns[run.ID] = *run
+	return nil
+}
+
+func (r *JobRunRepo) Finish(ctx context.Context, run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.jobRuns[run.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	t := now()
+	existing.Status = run.Status
+	existing.Result = run.Result
+	existing.Error = run.Error
+	existing.DurationMS = run.DurationMS
+	existing.FinishedAt = &t
+	r.jobRuns[run.ID] = existing
+	run.FinishedAt = &t
+	return nil
+}
+
+func (r *JobRunRepo) List(ctx context.Context, f store.JobRunFilter, opts store.ListOptions) (store.Page[models.JobRun], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var runs []models.JobRun
+	for _, run := range r.jobRuns {
+		switch {
+		case f.Job != "" && run.Job != f.Job,
+			f.Status != "" && run.Status != f.Status:
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return paginate(runs, store.JobRunSorts, opts)
+}
+
+func (r *JobRunRepo) Purge(ctx context.Context, t time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, run := range r.jobRuns {
+		if run.StartedAt.Before(t) {
+			delete(r.jobRuns, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"InfluenceIQ/store"
+)
+
+// paginate mirrors the SQL backends' keyset pagination over items that
+// already match the listing's filter.
+func paginate[T any](items []T, spec store.SortSpec[T], opts store.ListOptions) (store.Page[T], error) {
+	opts, err := spec.Normalize(opts)
+	if err != nil {
+		return store.Page[T]{}, err
+	}
+
+	desc := opts.Sort.Desc
+	if opts.Cursor != nil && opts.Cursor.Backward {
+		desc = !desc
+	}
+	// compareKey orders item against the position (value, id) in scan order.
+	compareKey := func(item *T, value any, id int) int {
+		v, itemID := spec.Key(item, opts.Sort)
+		c := compareValues(v, value)
+		if c == 0 {
+			c = cmp.Compare(itemID, id)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	}
+
+	slices.SortFunc(items, func(a, b T) int {
+		v, id := spec.Key(&b, opts.Sort)
+		return compareKey(&a, v, id)
+	})
+	if c := opts.Cursor; c != nil {
+		items = slices.DeleteFunc(items, func(item T) bool {
+			return compareKey(&item, c.Value, c.ID) <= 0
+		})
+	}
+	if len(items) > opts.Limit+1 {
+		items = items[:opts.Limit+1]
+	}
+	return spec.Page(items, opts), nil
+}
+
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case float64:
+		return cmp.Compare(a, b.(float64))
+	case int:
+		return cmp.Compare(a, b.(int))
+	case string:
+		return cmp.Compare(a, b.(string))
+	}
+	return 0
+}
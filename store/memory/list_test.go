@@ -0,0 +1,100 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+func TestPaginateWalk(t *testing.T) {
+	ctx := context.Background()
+	st := New()
+	// Ties on budget are broken by ID in the sort's direction.
+	for _, budget := range []float64{300, 100, 200, 100, 300, 50, 100} {
+		c := models.Campaign{BrandID: 1, Title: "Launch", Description: "Posts", Budget: budget}
+		if err := st.Campaigns.Create(ctx, &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		sort  store.Sort
+		limit int
+		pages [][]int
+	}{
+		{store.Sort{Field: "budget"}, 3, [][]int{{6, 2, 4}, {7, 3, 1}, {5}}},
+		{store.Sort{Field: "budget", Desc: true}, 3, [][]int{{5, 1, 3}, {7, 4, 2}, {6}}},
+		{store.Sort{Field: "budget", Desc: true}, 7, [][]int{{5, 1, 3, 7, 4, 2, 6}}},
+		{store.Sort{Field: "budget"}, 2, [][]int{{6, 2}, {4, 7}, {3, 1}, {5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort.String()+"/"+strconv.Itoa(tt.limit), func(t *testing.T) {
+			ids := func(page store.Page[models.Campaign]) []int {
+				var ids []int
+				for _, c := range page.Items {
+					ids = append(ids, c.ID)
+				}
+				return ids
+			}
+			// Cursors go through their encoded form, as they would between
+			// requests.
+			follow := func(c *store.Cursor) *store.Cursor {
+				decoded, err := store.DecodeCursor(c.Encode())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return decoded
+			}
+
+			opts := store.ListOptions{Limit: tt.limit, Sort: tt.sort}
+			var pages []store.Page[models.Campaign]
+			for {
+				page, err := st.Campaigns.List(ctx, store.CampaignFilter{}, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				pages = append(pages, page)
+				if page.Next == nil {
+					break
+				}
+				if len(pages) > len(tt.pages) {
+					t.Fatalf("more than %d pages", len(tt.pages))
+				}
+				opts.Cursor = follow(page.Next)
+			}
+			if len(pages) != len(tt.pages) {
+				t.Fatalf("%d pages, want %d", len(pages), len(tt.pages))
+			}
+			for i, page := range pages {
+				if got := ids(page); !slices.Equal(got, tt.pages[i]) {
+					t.Errorf("page %d = %v, want %v", i, got, tt.pages[i])
+				}
+				if (page.Prev == nil) != (i == 0) {
+					t.Errorf("page %d has prev %v", i, page.Prev)
+				}
+			}
+
+			// And back again from the last page.
+			for i := len(pages) - 1; i > 0; i-- {
+				opts.Cursor = follow(pages[i].Prev)
+				page, err := st.Campaigns.List(ctx, store.CampaignFilter{}, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := ids(page); !slices.Equal(got, tt.pages[i-1]) {
+					t.Errorf("back to page %d = %v, want %v", i-1, got, tt.pages[i-1])
+				}
+				if (page.Prev == nil) != (i-1 == 0) {
+					t.Errorf("back to page %d has prev %v", i-1, page.Prev)
+				}
+				if page.Next == nil {
+					t.Errorf("back to page %d has no next", i-1)
+				}
+			}
+		})
+	}
+}
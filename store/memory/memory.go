@@ -0,0 +1,184 @@
+// Package memory is an in-process Store implementation for tests and demos.
+// All repositories share one lock so cross-table reads stay consistent.
+package memory
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+	"InfluenceIQ/store/attempts"
+)
+
+// tables holds every record. It is a value so that transactions can take a
+// snapshot with clone and roll back by assigning it back.
+type tables struct {
+	nextID map[string]int
+
+	users          map[int]models.User
+	refreshTokens  map[int]models.RefreshToken
+	sessions       map[int]models.Session
+	deniedTokens   map[string]time.Time // access token ID to expiry
+	userTokens     map[int]models.UserToken
+	oauthStates    map[int]models.OAuthState
+	identities     map[int]models.Identity
+	totp           map[int]models.TOTP // keyed by user ID
+	recoveryCodes  map[int]recoveryCode
+	challenges     map[int]models.MFAChallenge
+	mfaPolicies    map[policyKey]models.MFAPolicy
+	securityEvents map[int]models.SecurityEvent
+	jobRuns        map[int]models.JobRun
+	apiKeys        map[int]models.APIKey
+	organizations  map[int]models.Organization
+	members        map[int]models.OrganizationMember // keyed by user ID
+	invitations    map[int]models.OrganizationInvitation
+	profiles       map[int]models.Profile // keyed by user ID
+	campaigns      map[int]models.Campaign
+	reminded       map[int]time.Time // deadline reminded of, keyed by campaign ID
+	applications   map[int]models.CampaignApplication
+	transitions    map[int]models.CampaignTransition
+	deliverables   map[int]models.Deliverable
+	submissions    map[int]models.DeliverableSubmission
+}
+
+func (t tables) clone() tables {
+	return tables{
+		nextID:         maps.Clone(t.nextID),
+		users:          maps.Clone(t.users),
+		refreshTokens:  maps.Clone(t.refreshTokens),
+		sessions:       maps.Clone(t.sessions),
+		deniedTokens:   maps.Clone(t.deniedTokens),
+		userTokens:     maps.Clone(t.userTokens),
+		oauthStates:    maps.Clone(t.oauthStates),
+		identities:     maps.Clone(t.identities),
+		totp:           maps.Clone(t.totp),
+		recoveryCodes:  maps.Clone(t.recoveryCodes),
+		challenges:     maps.Clone(t.challenges),
+		mfaPolicies:    maps.Clone(t.mfaPolicies),
+		securityEvents: maps.Clone(t.securityEvents),
+		jobRuns:        maps.Clone(t.jobRuns),
+		apiKeys:        maps.Clone(t.apiKeys),
+		organizations:  maps.Clone(t.organizations),
+		members:        maps.Clone(t.members),
+		invitations:    maps.Clone(t.invitations),
+		profiles:       maps.Clone(t.profiles),
+		campaigns:      maps.Clone(t.campaigns),
+		reminded:       maps.Clone(t.reminded),
+		applications:   maps.Clone(t.applications),
+		transitions:    maps.Clone(t.transitions),
+		deliverables:   maps.Clone(t.deliverables),
+		submissions:    maps.Clone(t.submissions),
+	}
+}
+
+type db struct {
+	mu sync.RWMutex
+	// txMu serialises transactions with each other. Writes made outside a
+	// transaction while one is running are lost if it rolls back, which is
+	// acceptable for a test store.
+	txMu sync.Mutex
+
+	tables
+	// attempts keeps the login attempt counts, which transactions don't
+	// roll back.
+	attempts *attempts.Counter
+}
+
+// New returns an empty in-memory Store.
+func New() *store.Store {
+	d := &db{tables: tables{
+		nextID:         map[string]int{},
+		users:          map[int]models.User{},
+		refreshTokens:  map[int]models.RefreshToken{},
+		sessions:       map[int]models.Session{},
+		deniedTokens:   map[string]time.Time{},
+		userTokens:     map[int]models.UserToken{},
+		oauthStates:    map[int]models.OAuthState{},
+		identities:     map[int]models.Identity{},
+		totp:           map[int]models.TOTP{},
+		recoveryCodes:  map[int]recoveryCode{},
+		challenges:     map[int]models.MFAChallenge{},
+		mfaPolicies:    map[policyKey]models.MFAPolicy{},
+		securityEvents: map[int]models.SecurityEvent{},
+		jobRuns:        map[int]models.JobRun{},
+		apiKeys:        map[int]models.APIKey{},
+		organizations:  map[int]models.Organization{},
+		members:        map[int]models.OrganizationMember{},
+		invitations:    map[int]models.OrganizationInvitation{},
+		profiles:       map[int]models.Profile{},
+		campaigns:      map[int]models.Campaign{},
+		reminded:       map[int]time.Time{},
+		applications:   map[int]models.CampaignApplication{},
+		transitions:    map[int]models.CampaignTransition{},
+		deliverables:   map[int]models.Deliverable{},
+		submissions:    map[int]models.DeliverableSubmission{},
+	}, attempts: attempts.NewCounter()}
+	s := d.store()
+	s.Transactor = txRunner{d}
+	return s
+}
+
+func (d *db) store() *store.Store {
+	return &store.Store{
+		Users:          &UserRepo{d},
+		Tokens:         &TokenRepo{d},
+		Sessions:       &SessionRepo{d},
+		Identities:     &IdentityRepo{d},
+		MFA:            &MFARepo{d},
+		Attempts:       d.attempts,
+		SecurityEvents: &SecurityEventRepo{d},
+		JobRuns:        &JobRunRepo{d},
+		APIKeys:        &APIKeyRepo{d},
+		Organizations:  &OrganizationRepo{d},
+		Profiles:       &ProfileRepo{d},
+		Campaigns:      &CampaignRepo{d},
+		Applications:   &ApplicationRepo{d},
+		Deliverables:   &DeliverableRepo{d},
+		Search:         &SearchRepo{d},
+	}
+}
+
+type txRunner struct {
+	d *db
+}
+
+func (r txRunner) WithTx(ctx context.Context, fn func(tx *store.Store) error) error {
+	r.d.txMu.Lock()
+	defer r.d.txMu.Unlock()
+
+	r.d.mu.RLock()
+	snapshot := r.d.tables.clone()
+	r.d.mu.RUnlock()
+
+	s := r.d.store()
+	s.Transactor = inTx{s}
+	if err := fn(s); err != nil {
+		r.d.mu.Lock()
+		r.d.tables = snapshot
+		r.d.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// inTx is the Transactor of a Store that is already inside a transaction.
+type inTx struct {
+	s *store.Store
+}
+
+func (t inTx) WithTx(ctx context.Context, fn func(tx *store.Store) error) error {
+	return fn(t.s)
+}
+
+// id hands out the next serial value for table. Callers must hold mu.
+func (d *db) id(table string) int {
+	d.nextID[table]++
+	return d.nextID[table]
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
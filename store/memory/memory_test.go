@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+func TestUserLookup(t *testing.T) {
+	ctx := context.Background()
+	st := New()
+	ada := &models.User{Username: "ada", Email: "ada@example.com"}
+	if err := st.Users.Create(ctx, ada); err != nil {
+		t.Fatal(err)
+	}
+	if ada.Role != models.RoleViewer {
+		t.Errorf("role = %q, want viewer", ada.Role)
+	}
+
+	tests := []struct {
+		login string
+		found bool
+	}{
+		{"ada", true},
+		{"ada@example.com", true},
+		{"grace", false},
+	}
+	for _, tt := range tests {
+		got, err := st.Users.GetByLogin(ctx, tt.login)
+		switch {
+		case !tt.found && !errors.Is(err, store.ErrNotFound):
+			t.Errorf("GetByLogin(%q) = %v, %v, want %v", tt.login, got, err, store.ErrNotFound)
+		case tt.found && (err != nil || got.ID != ada.ID):
+			t.Errorf("GetByLogin(%q) = %v, %v, want user %d", tt.login, got, err, ada.ID)
+		}
+	}
+
+	for _, taken := range [][2]string{{"ada@example.com", "other"}, {"other@example.com", "ada"}} {
+		exists, err := st.Users.Exists(ctx, taken[0], taken[1])
+		if err != nil || !exists {
+			t.Errorf("Exists(%q, %q) = %v, %v, want true", taken[0], taken[1], exists, err)
+		}
+		if err := st.Users.Create(ctx, &models.User{Email: taken[0], Username: taken[1]}); err == nil {
+			t.Errorf("created a second user with %q or %q", taken[0], taken[1])
+		}
+	}
+	if _, err := st.Users.GetByID(ctx, 99); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetByID of an unknown user = %v, want %v", err, store.ErrNotFound)
+	}
+}
+
+func TestCampaignDelete(t *testing.T) {
+	ctx := context.Background()
+	st := New()
+	c := &models.Campaign{BrandID: 1, Title: "Launch", Deadline: time.Now().Add(time.Hour)}
+	if err := st.Campaigns.Create(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	app := &models.CampaignApplication{CampaignID: c.ID, InfluencerID: 100}
+	if err := st.Applications.Create(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.Campaigns.Delete(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Campaigns.GetByID(ctx, c.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetByID after delete = %v, want %v", err, store.ErrNotFound)
+	}
+	if _, err := st.Applications.GetByID(ctx, app.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("application outlived its campaign: %v", err)
+	}
+}
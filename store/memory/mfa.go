@@ -0,0 +1,221 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type recoveryCode struct {
+	userID int
+	hash   string
+	used   bool
+}
+
+type policyKey struct {
+	scope, value string
+}
+
+type MFARepo struct {
+	*db
+}
+
+func (r *MFARepo) GetTOTP(ctx context.Context, userID int) (*models.TOTP, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.totp[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &t, nil
+}
+
+func (r *MFARepo) SaveTOTP(ctx context.Context, t *models.TOTP) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t.EnabledAt, t.LastUsedStep = nil, 0
+	t.CreatedAt = now()
+	r.totp[t.UserID] = *t
+	return nil
+}
+
+func (r *MFARepo) EnableTOTP(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.totp[userID]
+	if !ok || t.EnabledAt != nil {
+		return store.ErrNotFound
+	}
+	enabledAt := now()
+	t.EnabledAt = &enabledAt
+	r.totp[userID] = t
+	return nil
+}
+
+func (r *MFARepo) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.totp[userID]
+	if !ok || t.LastUsedStep >= step {
+		return store.ErrConflict
+	}
+	t.LastUsedStep = step
+	r.totp[userID] = t
+	return nil
+}
+
+func (r *MFARepo) DeleteTOTP(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deleteRecoveryCodes(userID)
+	if _, ok := r.totp[userID]; !ok {
+		return store.ErrNotFound
+	}
+	delete(r.totp, userID)
+	return nil
+}
+
+func (r *MFARepo) ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deleteRecoveryCodes(userID)
+	for _, hash := range hashes {
+		r.recoveryCodes[r.id("mfa_recovery_codes")] = recoveryCode{userID: userID, hash: hash}
+	}
+	return nil
+}
+
+// deleteRecoveryCodes removes the user's codes. Callers must hold mu.
+func (r *MFARepo) deleteRecoveryCodes(userID int) {
+	for id, c := range r.recoveryCodes {
+		if c.userID == userID {
+			delete(r.recoveryCodes, id)
+		}
+	}
+}
+
+func (r *MFARepo) UseRecoveryCode(ctx context.Context, userID int, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, c := range r.recoveryCodes {
+		if c.userID == userID && c.hash == hash && !c.used {
+			c.used = true
+			r.recoveryCodes[id] = c
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (r *MFARepo) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for _, c := range r.recoveryCodes {
+		if c.userID == userID && !c.used {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *MFARepo) CreateChallenge(ctx context.Context, ch *models.MFAChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.challenges {
+		if existing.TokenHash == ch.TokenHash {
+			return store.ErrConflict
+		}
+	}
+
+	ch.ID = r.id("mfa_challenges")
+	ch.CreatedAt = now()
+	r.challenges[ch.ID] = *ch
+	return nil
+}
+
+func (r *MFARepo) GetChallenge(ctx context.Context, hash string) (*models.MFAChallenge, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, ch := range r.challenges {
+		if ch.TokenHash == hash && now().Before(ch.ExpiresAt) {
+			return &ch, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (r *MFARepo) FailChallenge(ctx context.Context, id int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ch, ok := r.challenges[id]
+	if !ok {
+		return 0, store.ErrNotFound
+	}
+	ch.Attempts++
+	r.challenges[id] = ch
+	return ch.Attempts, nil
+}
+
+func (r *MFARepo) DeleteChallenge(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.challenges[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(r.challenges, id)
+	return nil
+}
+
+func (r *MFARepo) ListPolicies(ctx context.Context) ([]models.MFAPolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policies := slices.Collect(maps.Values(r.mfaPolicies))
+	slices.SortFunc(policies, func(a, b models.MFAPolicy) int {
+		return cmp.Or(cmp.Compare(a.Scope, b.Scope), cmp.Compare(a.Value, b.Value))
+	})
+	if policies == nil {
+		policies = []models.MFAPolicy{}
+	}
+	return policies, nil
+}
+
+func (r *MFARepo) ReplacePolicies(ctx context.Context, scope string, values []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key := range r.mfaPolicies {
+		if key.scope == scope {
+			delete(r.mfaPolicies, key)
+		}
+	}
+	for _, value := range values {
+		r.mfaPolicies[policyKey{scope, value}] = models.MFAPolicy{Scope: scope, Value: value, CreatedAt: now()}
+	}
+	return nil
+}
+
+func (r *MFARepo) Required(ctx context.Context, scope, value string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.mfaPolicies[policyKey{scope, value}]
+	return ok, nil
+}
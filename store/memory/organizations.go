@@ -0,0 +1,234 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type OrganizationRepo struct {
+	*db
+}
+
+func (r *OrganizationRepo) Create(ctx context.Context, o *models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o.ID = r.id("organizations")
+	o.CreatedAt = now()
+	o.UpdatedAt = o.CreatedAt
+	r.organizations[o.ID] = *o
+	return nil
+}
+
+func (r *OrganizationRepo) GetByID(ctx context.Context, id int) (*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	o, ok := r.organizations[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &o, nil
+}
+
+func (r *OrganizationRepo) Rename(ctx context.Context, id int, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o, ok := r.organizations[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	o.Name = name
+	o.UpdatedAt = now()
+	r.organizations[id] = o
+	return nil
+}
+
+func (r *OrganizationRepo) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.organizations[id]; !ok {
+		return store.ErrNotFound
+	}
+	delete(r.organizations, id)
+	// Mirror ON DELETE CASCADE and SET NULL.
+	for userID, m := range r.members {
+		if m.OrganizationID == id {
+			delete(r.members, userID)
+		}
+	}
+	for invID, inv := range r.invitations {
+		if inv.OrganizationID == id {
+			delete(r.invitations, invID)
+		}
+	}
+	for campaignID, c := range r.campaigns {
+		if c.OrganizationID != nil && *c.OrganizationID == id {
+			c.OrganizationID = nil
+			r.campaigns[campaignID] = c
+		}
+	}
+	delete(r.mfaPolicies, policyKey{models.MFAScopeOrganization, strconv.Itoa(id)})
+	return nil
+}
+
+func (r *OrganizationRepo) AddMember(ctx context.Context, m *models.OrganizationMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.members[m.UserID]; ok {
+		return store.ErrConflict
+	}
+	m.CreatedAt = now()
+	r.members[m.UserID] = *m
+	return nil
+}
+
+// member fills in the user's details. Callers must hold mu.
+func (r *OrganizationRepo) member(m models.OrganizationMember) models.OrganizationMember {
+	u := r.users[m.UserID]
+	m.Username = u.Username
+	m.Email = u.Email
+	return m
+}
+
+func (r *OrganizationRepo) GetMembership(ctx context.Context, userID int) (*models.OrganizationMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.members[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	m = r.member(m)
+	return &m, nil
+}
+
+func (r *OrganizationRepo) ListMembers(ctx context.Context, orgID int) ([]models.OrganizationMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := []models.OrganizationMember{}
+	for _, m := range r.members {
+		if m.OrganizationID == orgID {
+			members = append(members, r.member(m))
+		}
+	}
+	slices.SortFunc(members, func(a, b models.OrganizationMember) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.UserID, b.UserID)
+	})
+	return members, nil
+}
+
+func (r *OrganizationRepo) SetMemberRole(ctx context.Context, orgID, userID int, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.members[userID]
+	if !ok || m.OrganizationID != orgID {
+		return store.ErrNotFound
+	}
+	m.Role = role
+	r.members[userID] = m
+	return nil
+}
+
+func (r *OrganizationRepo) RemoveMember(ctx context.Context, orgID, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.members[userID]
+	if !ok || m.OrganizationID != orgID {
+		return store.ErrNotFound
+	}
+	delete(r.members, userID)
+	return nil
+}
+
+func (r *OrganizationRepo) CountOwners(ctx context.Context, orgID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := 0
+	for _, m := range r.members {
+		if m.OrganizationID == orgID && m.Role == models.OrgRoleOwner {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *OrganizationRepo) CreateInvitation(ctx context.Context, inv *models.OrganizationInvitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, existing := range r.invitations {
+		if existing.TokenHash == inv.TokenHash {
+			return store.ErrConflict
+		}
+		if existing.OrganizationID == inv.OrganizationID && existing.Email == inv.Email {
+			delete(r.invitations, id)
+		}
+	}
+
+	inv.ID = r.id("organization_invitations")
+	inv.CreatedAt = now()
+	r.invitations[inv.ID] = *inv
+	return nil
+}
+
+func (r *OrganizationRepo) ListInvitations(ctx context.Context, orgID int) ([]models.OrganizationInvitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invitations := []models.OrganizationInvitation{}
+	t := now()
+	for _, inv := range r.invitations {
+		if inv.OrganizationID == orgID && inv.ExpiresAt.After(t) {
+			invitations = append(invitations, inv)
+		}
+	}
+	slices.SortFunc(invitations, func(a, b models.OrganizationInvitation) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+	return invitations, nil
+}
+
+func (r *OrganizationRepo) ConsumeInvitation(ctx context.Context, hash string) (*models.OrganizationInvitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := now()
+	for id, inv := range r.invitations {
+		if inv.TokenHash == hash && inv.ExpiresAt.After(t) {
+			delete(r.invitations, id)
+			return &inv, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (r *OrganizationRepo) DeleteInvitation(ctx context.Context, orgID, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv, ok := r.invitations[id]
+	if !ok || inv.OrganizationID != orgID {
+		return store.ErrNotFound
+	}
+	delete(r.invitations, id)
+	return nil
+}
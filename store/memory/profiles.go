@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type ProfileRepo struct {
+	*db
+}
+
+func (r *ProfileRepo) Create(ctx context.Context, p *models.Profile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.profiles[p.UserID]; ok {
+		return store.ErrConflict
+	}
+	p.ID = r.id("profiles")
+	p.VerifiedAt = nil
+	p.CreatedAt = now()
+	p.UpdatedAt = p.CreatedAt
+	r.profiles[p.UserID] = *p
+	return nil
+}
+
+func (r *ProfileRepo) GetByUserID(ctx context.Context, userID int) (*models.Profile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.profiles[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &p, nil
+}
+
+func (r *ProfileRepo) Update(ctx context.Context, p *models.Profile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.profiles[p.UserID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	p.ID = existing.ID
+	p.VerifiedAt = existing.VerifiedAt
+	p.CreatedAt = existing.CreatedAt
+	p.UpdatedAt = now()
+	r.profiles[p.UserID] = *p
+	return nil
+}
+
+func (r *ProfileRepo) Delete(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.profiles[userID]; !ok {
+		return store.ErrNotFound
+	}
+	delete(r.profiles, userID)
+	return nil
+}
+
+func (r *ProfileRepo) SetVerified(ctx context.Context, userID int, verified bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.profiles[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	switch {
+	case !verified:
+		p.VerifiedAt = nil
+	case p.VerifiedAt == nil:
+		at := now()
+		p.VerifiedAt = &at
+	}
+	r.profiles[userID] = p
+	return nil
+}
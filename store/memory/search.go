@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type SearchRepo struct {
+	*db
+}
+
+func (r *SearchRepo) Search(ctx context.Context, q store.SearchQuery) (*models.SearchResults, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := store.FallbackSearch(q,
+		slices.Collect(maps.Values(r.campaigns)),
+		slices.Collect(maps.Values(r.profiles)),
+	)
+	return &results, nil
+}
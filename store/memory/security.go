@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"context"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type SecurityEventRepo struct {
+	*db
+}
+
+func (r *SecurityEventRepo) Create(ctx context.Context, e *models.SecurityEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e.ID = r.id("security_events")
+	e.CreatedAt = now()
+	r.securityEvents[e.ID] = *e
+	return nil
+}
+
+func (r *SecurityEventRepo) List(ctx context.Context, f store.SecurityEventFilter, opts store.ListOptions) (store.Page[models.SecurityEvent], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []models.SecurityEvent
+	for _, e := range r.securityEvents {
+		switch {
+		case f.Type != "" && e.Type != f.Type,
+			f.UserID != 0 && (e.UserID == nil || *e.UserID != f.UserID),
+			f.IP != "" && e.IP != f.IP:
+			continue
+		}
+		events = append(events, e)
+	}
+	return paginate(events, store.SecurityEventSorts, opts)
+}
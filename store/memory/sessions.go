@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type SessionRepo struct {
+	*db
+}
+
+func (r *SessionRepo) Create(ctx context.Context, s *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.sessions {
+		if existing.FamilyID == s.FamilyID {
+			return store.ErrConflict
+		}
+	}
+
+	s.ID = r.id("user_sessions")
+	s.CreatedAt = now()
+	s.LastSeenAt = s.CreatedAt
+	r.sessions[s.ID] = *s
+	return nil
+}
+
+func (r *SessionRepo) GetByID(ctx context.Context, id int) (*models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.sessions[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &s, nil
+}
+
+func (r *SessionRepo) GetByFamily(ctx context.Context, familyID string) (*models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, s := range r.sessions {
+		if s.FamilyID == familyID {
+			return &s, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (r *SessionRepo) ListActive(ctx context.Context, userID int) ([]models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sessions := []models.Session{}
+	t := now()
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.Active(t) {
+			sessions = append(sessions, s)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b models.Session) int {
+		if c := b.LastSeenAt.Compare(a.LastSeenAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+	return sessions, nil
+}
+
+func (r *SessionRepo) Touch(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	s.LastSeenAt = now()
+	r.sessions[id] = s
+	return nil
+}
+
+func (r *SessionRepo) Extend(ctx context.Context, id int, ip string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	s.LastSeenAt = now()
+	s.IP = ip
+	s.ExpiresAt = expiresAt
+	r.sessions[id] = s
+	return nil
+}
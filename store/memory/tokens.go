@@ -0,0 +1,238 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type TokenRepo struct {
+	*db
+}
+
+func (r *TokenRepo) CreateRefresh(ctx context.Context, t *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.refreshTokens {
+		if existing.TokenHash == t.TokenHash {
+			return store.ErrConflict
+		}
+	}
+
+	t.ID = r.id("refresh_tokens")
+	t.CreatedAt = now()
+	r.refreshTokens[t.ID] = *t
+	return nil
+}
+
+func (r *TokenRepo) GetRefreshByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, t := range r.refreshTokens {
+		if t.TokenHash == hash {
+			return &t, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (r *TokenRepo) UseRefresh(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.refreshTokens[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if t.UsedAt != nil || t.RevokedAt != nil {
+		return store.ErrConflict
+	}
+	usedAt := now()
+	t.UsedAt = &usedAt
+	r.refreshTokens[id] = t
+	return nil
+}
+
+func (r *TokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	revokedAt := now()
+	for id, t := range r.refreshTokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &revokedAt
+			r.refreshTokens[id] = t
+		}
+	}
+	for id, s := range r.sessions {
+		if s.FamilyID == familyID && s.RevokedAt == nil {
+			s.RevokedAt = &revokedAt
+			r.sessions[id] = s
+		}
+	}
+	return nil
+}
+
+func (r *TokenRepo) RevokeUserRefresh(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	revokedAt := now()
+	for id, t := range r.refreshTokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &revokedAt
+			r.refreshTokens[id] = t
+		}
+	}
+	for id, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			s.RevokedAt = &revokedAt
+			r.sessions[id] = s
+		}
+	}
+	return nil
+}
+
+func (r *TokenRepo) Deny(ctx context.Context, jti string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.deniedTokens[jti]; !ok {
+		r.deniedTokens[jti] = expiresAt
+	}
+	return nil
+}
+
+func (r *TokenRepo) IsDenied(ctx context.Context, jti string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.deniedTokens[jti]
+	return ok, nil
+}
+
+func (r *TokenRepo) CreateUserToken(ctx context.Context, t *models.UserToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.userTokens {
+		if existing.TokenHash == t.TokenHash {
+			return store.ErrConflict
+		}
+	}
+
+	t.ID = r.id("user_tokens")
+	t.CreatedAt = now()
+	r.userTokens[t.ID] = *t
+	return nil
+}
+
+func (r *TokenRepo) ConsumeUserToken(ctx context.Context, purpose, hash string) (*models.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, t := range r.userTokens {
+		if t.Purpose == purpose && t.TokenHash == hash && t.UsedAt == nil && now().Before(t.ExpiresAt) {
+			usedAt := now()
+			t.UsedAt = &usedAt
+			r.userTokens[id] = t
+			return &t, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (r *TokenRepo) DeleteUserTokens(ctx context.Context, userID int, purpose string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, t := range r.userTokens {
+		if t.UserID == userID && t.Purpose == purpose {
+			delete(r.userTokens, id)
+		}
+	}
+	return nil
+}
+
+func (r *TokenRepo) CreateOAuthState(ctx context.Context, s *models.OAuthState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.oauthStates {
+		if existing.StateHash == s.StateHash {
+			return store.ErrConflict
+		}
+	}
+
+	s.ID = r.id("oauth_states")
+	s.CreatedAt = now()
+	r.oauthStates[s.ID] = *s
+	return nil
+}
+
+func (r *TokenRepo) ConsumeOAuthState(ctx context.Context, hash string) (*models.OAuthState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, s := range r.oauthStates {
+		if s.StateHash == hash && now().Before(s.ExpiresAt) {
+			delete(r.oauthStates, id)
+			return &s, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (r *TokenRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, t := range r.refreshTokens {
+		if t.ExpiresAt.Before(before) {
+			delete(r.refreshTokens, id)
+			n++
+		}
+	}
+	for id, s := range r.sessions {
+		if s.ExpiresAt.Before(before) {
+			delete(r.sessions, id)
+			n++
+		}
+	}
+	for jti, expiresAt := range r.deniedTokens {
+		if expiresAt.Before(before) {
+			delete(r.deniedTokens, jti)
+			n++
+		}
+	}
+	for id, t := range r.userTokens {
+		if t.ExpiresAt.Before(before) {
+			delete(r.userTokens, id)
+			n++
+		}
+	}
+	for id, s := range r.oauthStates {
+		if s.ExpiresAt.Before(before) {
+			delete(r.oauthStates, id)
+			n++
+		}
+	}
+	for id, ch := range r.challenges {
+		if ch.ExpiresAt.Before(before) {
+			delete(r.challenges, id)
+			n++
+		}
+	}
+	for id, inv := range r.invitations {
+		if inv.ExpiresAt.Before(before) {
+			delete(r.invitations, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type UserRepo struct {
+	*db
+}
+
+func (r *UserRepo) Create(ctx context.Context, u *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if strings.EqualFold(existing.Email, u.Email) || existing.Username == u.Username {
+			return store.ErrConflict
+		}
+	}
+
+	u.ID = r.id("users")
+	if u.Role == "" {
+		u.Role = "viewer"
+	}
+	r.users[u.ID] = *u
+	return nil
+}
+
+func (r *UserRepo) GetByID(ctx context.Context, id int) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &u, nil
+}
+
+func (r *UserRepo) GetByLogin(ctx context.Context, login string) (*models.User, error) {
+	if u, err := r.GetByEmail(ctx, login); err == nil {
+		return u, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, u := range r.users {
+		if u.Username == login {
+			return &u, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *models.User
+	for _, u := range r.users {
+		if strings.EqualFold(u.Email, email) && (found == nil || u.ID < found.ID) {
+			found = &u
+		}
+	}
+	if found == nil {
+		return nil, store.ErrNotFound
+	}
+	return found, nil
+}
+
+func (r *UserRepo) Exists(ctx context.Context, email, username string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if strings.EqualFold(u.Email, email) || u.Username == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *UserRepo) SetEmailVerified(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if u.EmailVerifiedAt == nil {
+		verifiedAt := now()
+		u.EmailVerifiedAt = &verifiedAt
+		r.users[id] = u
+	}
+	return nil
+}
+
+func (r *UserRepo) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.PasswordHash = passwordHash
+	r.users[id] = u
+	return nil
+}
+
+func (r *UserRepo) SetRole(ctx context.Context, id int, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.Role = role
+	r.users[id] = u
+	return nil
+}
@@ -0,0 +1,220 @@
+package store
+
+import (
+	"cmp"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+
+	"InfluenceIQ/models"
+)
+
+// SearchQuery describes a keyword search over campaigns and profiles.
+type SearchQuery struct {
+	Text string
+	// Campaigns and Profiles select what to search.
+	Campaigns bool
+	Profiles  bool
+	// Category filters both kinds of hit; AccountType filters profiles.
+	// Facet counts ignore both so that clients can offer alternatives.
+	Category    string
+	AccountType string
+	// Limit caps the hits returned per kind.
+	Limit int
+}
+
+// Snippet highlight markers. Backends wrap matches in these and
+// RenderSnippet turns them into <mark> tags after escaping the text.
+const (
+	SnippetStart = "\x02"
+	SnippetStop  = "\x03"
+)
+
+// RenderSnippet HTML-escapes raw and converts its highlight markers.
+func RenderSnippet(raw string) string {
+	s := html.EscapeString(raw)
+	s = strings.ReplaceAll(s, SnippetStart, "<mark>")
+	return strings.ReplaceAll(s, SnippetStop, "</mark>")
+}
+
+// Field weights, matching Postgres' default ts_rank weights for A, B and C.
+const (
+	WeightA = 1.0
+	WeightB = 0.4
+	WeightC = 0.2
+)
+
+// WeightedText is one searchable field of a document.
+type WeightedText struct {
+	Text   string
+	Weight float64
+}
+
+// Matcher is the fallback used where full-text search isn't available. A
+// document matches when every query term is a prefix of one of its words.
+type Matcher struct {
+	terms []string
+}
+
+// NewMatcher splits query into lower-case terms.
+func NewMatcher(query string) Matcher {
+	var terms []string
+	for _, word := range strings.FieldsFunc(query, isWordBreak) {
+		term := strings.ToLower(word)
+		if !slices.Contains(terms, term) {
+			terms = append(terms, term)
+		}
+	}
+	return Matcher{terms: terms}
+}
+
+func isWordBreak(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// Terms returns the query terms.
+func (m Matcher) Terms() []string {
+	return m.terms
+}
+
+// Score ranks a document by the weighted number of words each term
+// matches. It returns 0 if some term matches nothing.
+func (m Matcher) Score(fields ...WeightedText) float64 {
+	if len(m.terms) == 0 {
+		return 0
+	}
+	var total float64
+	for _, term := range m.terms {
+		var score float64
+		for _, f := range fields {
+			for _, word := range strings.FieldsFunc(f.Text, isWordBreak) {
+				if strings.HasPrefix(strings.ToLower(word), term) {
+					score += f.Weight
+				}
+			}
+		}
+		if score == 0 {
+			return 0
+		}
+		total += score
+	}
+	return total / float64(len(m.terms))
+}
+
+func (m Matcher) matches(word string) bool {
+	word = strings.ToLower(strings.TrimFunc(word, isWordBreak))
+	for _, term := range m.terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// snippetWords is roughly how many words a snippet shows.
+const snippetWords = 25
+
+// Snippet returns a rendered excerpt of text around the first match.
+func (m Matcher) Snippet(text string) string {
+	words := strings.Fields(text)
+	first := slices.IndexFunc(words, m.matches)
+
+	start := max(first-snippetWords/3, 0)
+	end := min(start+snippetWords, len(words))
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	for i, word := range words[start:end] {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if m.matches(word) {
+			b.WriteString(SnippetStart + word + SnippetStop)
+		} else {
+			b.WriteString(word)
+		}
+	}
+	if end < len(words) {
+		b.WriteString(" …")
+	}
+	return RenderSnippet(b.String())
+}
+
+// FallbackSearch runs q with a Matcher over candidate rows, which must
+// include every row that could match. Only published campaigns are found.
+func FallbackSearch(q SearchQuery, campaigns []models.Campaign, profiles []models.Profile) models.SearchResults {
+	m := NewMatcher(q.Text)
+	results := models.SearchResults{
+		Campaigns: []models.CampaignHit{},
+		Profiles:  []models.ProfileHit{},
+		Facets: models.SearchFacets{
+			CampaignCategory: map[string]int{},
+			ProfileCategory:  map[string]int{},
+			AccountType:      map[string]int{},
+		},
+	}
+
+	if q.Campaigns {
+		for _, c := range campaigns {
+			if c.Status != models.CampaignPublished {
+				continue
+			}
+			rank := m.Score(
+				WeightedText{c.Title, WeightA},
+				WeightedText{c.Description, WeightB},
+				WeightedText{c.Category, WeightC},
+			)
+			if rank == 0 {
+				continue
+			}
+			if c.Category != "" {
+				results.Facets.CampaignCategory[c.Category]++
+			}
+			if q.Category != "" && c.Category != q.Category {
+				continue
+			}
+			results.Campaigns = append(results.Campaigns, models.CampaignHit{
+				Campaign: c,
+				Rank:     rank,
+				Snippet:  m.Snippet(c.Title + " " + c.Description),
+			})
+		}
+		slices.SortFunc(results.Campaigns, func(a, b models.CampaignHit) int {
+			return cmp.Or(cmp.Compare(b.Rank, a.Rank), cmp.Compare(b.ID, a.ID))
+		})
+		results.Campaigns = results.Campaigns[:min(len(results.Campaigns), q.Limit)]
+	}
+
+	if q.Profiles {
+		for _, p := range profiles {
+			rank := m.Score(
+				WeightedText{p.DisplayName, WeightA},
+				WeightedText{p.Category, WeightB},
+				WeightedText{p.Bio, WeightC},
+			)
+			if rank == 0 {
+				continue
+			}
+			if p.Category != "" {
+				results.Facets.ProfileCategory[p.Category]++
+			}
+			results.Facets.AccountType[p.AccountType]++
+			if (q.Category != "" && p.Category != q.Category) || (q.AccountType != "" && p.AccountType != q.AccountType) {
+				continue
+			}
+			results.Profiles = append(results.Profiles, models.ProfileHit{
+				Profile: p,
+				Rank:    rank,
+				Snippet: m.Snippet(p.DisplayName + " " + p.Bio),
+			})
+		}
+		slices.SortFunc(results.Profiles, func(a, b models.ProfileHit) int {
+			return cmp.Or(cmp.Compare(b.Rank, a.Rank), cmp.Compare(b.ID, a.ID))
+		})
+		results.Profiles = results.Profiles[:min(len(results.Profiles), q.Limit)]
+	}
+	return results
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"InfluenceIQ/models"
+)
+
+func TestFallbackSearch(t *testing.T) {
+	campaigns := []models.Campaign{
+		{ID: 1, Title: "Summer fashion", Description: "Beach looks", Category: "fashion", Status: models.CampaignPublished},
+		{ID: 2, Title: "Summer drinks", Description: "Cold brews", Category: "food", Status: models.CampaignPublished},
+		{ID: 3, Title: "Summer draft", Description: "Not ready", Category: "fashion", Status: models.CampaignDraft},
+		{ID: 4, Title: "Summer sale", Description: "Paused for now", Category: "fashion", Status: models.CampaignPaused},
+		{ID: 5, Title: "Winter coats", Description: "Warm", Category: "fashion", Status: models.CampaignPublished},
+	}
+	profiles := []models.Profile{
+		{ID: 1, DisplayName: "Sunny", Bio: "Summer fashion every day", Category: "fashion", AccountType: "influencer"},
+		{ID: 2, DisplayName: "Summer Co", Bio: "We sell hats", Category: "retail", AccountType: "brand"},
+		{ID: 3, DisplayName: "Frost", Bio: "Winter sports", Category: "sports", AccountType: "influencer"},
+	}
+
+	tests := []struct {
+		name      string
+		query     SearchQuery
+		campaigns []int
+		profiles  []int
+		facets    models.SearchFacets
+	}{
+		{
+			name:      "only published campaigns",
+			query:     SearchQuery{Text: "summer", Campaigns: true, Limit: 10},
+			campaigns: []int{1, 2},
+			facets: models.SearchFacets{
+				CampaignCategory: map[string]int{"fashion": 1, "food": 1},
+				ProfileCategory:  map[string]int{},
+				AccountType:      map[string]int{},
+			},
+		},
+		{
+			name:      "category facets stay apart",
+			query:     SearchQuery{Text: "summer", Campaigns: true, Profiles: true, Limit: 10},
+			campaigns: []int{1, 2},
+			profiles:  []int{1, 2},
+			facets: models.SearchFacets{
+				CampaignCategory: map[string]int{"fashion": 1, "food": 1},
+				ProfileCategory:  map[string]int{"fashion": 1, "retail": 1},
+				AccountType:      map[string]int{"influencer": 1, "brand": 1},
+			},
+		},
+		{
+			name:      "filters leave facets alone",
+			query:     SearchQuery{Text: "summer", Campaigns: true, Profiles: true, Category: "fashion", AccountType: "influencer", Limit: 10},
+			campaigns: []int{1},
+			profiles:  []int{1},
+			facets: models.SearchFacets{
+				CampaignCategory: map[string]int{"fashion": 1, "food": 1},
+				ProfileCategory:  map[string]int{"fashion": 1, "retail": 1},
+				AccountType:      map[string]int{"influencer": 1, "brand": 1},
+			},
+		},
+		{
+			name:     "profiles only",
+			query:    SearchQuery{Text: "winter", Profiles: true, Limit: 10},
+			profiles: []int{3},
+			facets: models.SearchFacets{
+				CampaignCategory: map[string]int{},
+				ProfileCategory:  map[string]int{"sports": 1},
+				AccountType:      map[string]int{"influencer": 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FallbackSearch(tt.query, campaigns, profiles)
+
+			var campaignIDs, profileIDs []int
+			for _, h := range got.Campaigns {
+				campaignIDs = append(campaignIDs, h.ID)
+			}
+			for _, h := range got.Profiles {
+				profileIDs = append(profileIDs, h.ID)
+			}
+			slices.Sort(campaignIDs)
+			slices.Sort(profileIDs)
+			if !slices.Equal(campaignIDs, tt.campaigns) {
+				t.Errorf("campaigns = %v, want %v", campaignIDs, tt.campaigns)
+			}
+			if !slices.Equal(profileIDs, tt.profiles) {
+				t.Errorf("profiles = %v, want %v", profileIDs, tt.profiles)
+			}
+			for name, facet := range map[string][2]map[string]int{
+				"campaign_category": {got.Facets.CampaignCategory, tt.facets.CampaignCategory},
+				"profile_category":  {got.Facets.ProfileCategory, tt.facets.ProfileCategory},
+				"account_type":      {got.Facets.AccountType, tt.facets.AccountType},
+			} {
+				if !maps.Equal(facet[0], facet[1]) {
+					t.Errorf("%s = %v, want %v", name, facet[0], facet[1])
+				}
+			}
+		})
+	}
+}
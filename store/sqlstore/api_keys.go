@@ -0,0 +1,82 @@
+package sqlstore
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+)
+
+type APIKeyRepo struct {
+	db   database.Querier
+	read database.Querier
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*models.APIKey, error) {
+	var k models.APIKey
+	var scopes string
+	if err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.KeyHash, &scopes,
+		&k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt); err != nil {
+		return nil, mapErr(err)
+	}
+	k.Scopes = strings.Fields(scopes)
+	return &k, nil
+}
+
+func (r *APIKeyRepo) Create(ctx context.Context, k *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING id, created_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		k.UserID, k.Name, k.Prefix, k.KeyHash, strings.Join(k.Scopes, " "), k.ExpiresAt,
+	).Scan(&k.ID, &k.CreatedAt))
+}
+
+func (r *APIKeyRepo) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRow(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, hash))
+}
+
+func (r *APIKeyRepo) ListByUser(ctx context.Context, userID int) ([]models.APIKey, error) {
+	rows, err := r.read.Query(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC, id DESC`, userID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	return keys, mapErr(rows.Err())
+}
+
+func (r *APIKeyRepo) CountActive(ctx context.Context, userID int) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)
+	`, userID, time.Now()).Scan(&n)
+	return n, mapErr(err)
+}
+
+func (r *APIKeyRepo) TouchLastUsed(ctx context.Context, id int) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`, id))
+}
+
+func (r *APIKeyRepo) Revoke(ctx context.Context, id, userID int) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID))
+}
@@ -0,0 +1,130 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type ApplicationRepo struct {
+	db   database.Querier
+	read database.Querier
+}
+
+const applicationColumns = `id, campaign_id, influencer_id, status, message, created_at, updated_at`
+
+func scanApplication(row interface{ Scan(...any) error }) (*models.CampaignApplication, error) {
+	var a models.CampaignApplication
+	err := row.Scan(
+		&a.ID, &a.CampaignID, &a.InfluencerID, &a.Status, &a.Message, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &a, nil
+}
+
+func (r *ApplicationRepo) queryApplications(ctx context.Context, query string, args ...any) ([]models.CampaignApplication, error) {
+	rows, err := r.read.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apps []models.CampaignApplication
+	for rows.Next() {
+		a, err := scanApplication(rows)
+		if err != nil {
+			return nil, err
+		}
+		apps = append(apps, *a)
+	}
+	return apps, rows.Err()
+}
+
+func (r *ApplicationRepo) Create(ctx context.Context, a *models.CampaignApplication) error {
+	query := `
+		INSERT INTO campaign_applications (campaign_id, influencer_id, status, message, created_at, updated_at)
+		VALUES ($1, $2, COALESCE(NULLIF($3, ''), 'pending'), $4, NOW(), NOW())
+		RETURNING id, status, created_at, updated_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		a.CampaignID, a.InfluencerID, a.Status, a.Message,
+	).Scan(&a.ID, &a.Status, &a.CreatedAt, &a.UpdatedAt))
+}
+
+func (r *ApplicationRepo) GetByID(ctx context.Context, id int) (*models.CampaignApplication, error) {
+	return scanApplication(r.read.QueryRow(ctx,
+		`SELECT `+applicationColumns+` FROM campaign_applications WHERE id = $1`, id))
+}
+
+func (r *ApplicationRepo) GetByCampaignAndInfluencer(ctx context.Context, campaignID, influencerID int) (*models.CampaignApplication, error) {
+	return scanApplication(r.read.QueryRow(ctx, `
+		SELECT `+applicationColumns+`
+		FROM campaign_applications
+		WHERE campaign_id = $1 AND influencer_id = $2
+	`, campaignID, influencerID))
+}
+
+func (r *ApplicationRepo) List(ctx context.Context, f store.ApplicationFilter, opts store.ListOptions) (store.Page[models.CampaignApplication], error) {
+	opts, err := store.ApplicationSorts.Normalize(opts)
+	if err != nil {
+		return store.Page[models.CampaignApplication]{}, err
+	}
+
+	var w conds
+	if f.CampaignID != 0 {
+		w.add("campaign_id = ?", f.CampaignID)
+	}
+	if f.InfluencerID != 0 {
+		w.add("influencer_id = ?", f.InfluencerID)
+	}
+	if f.Status != "" {
+		w.add("status = ?", f.Status)
+	}
+	order := w.paginate(opts)
+
+	apps, err := r.queryApplications(ctx,
+		`SELECT `+applicationColumns+` FROM campaign_applications `+w.String()+` `+order, w.args...)
+	if err != nil {
+		return store.Page[models.CampaignApplication]{}, err
+	}
+	return store.ApplicationSorts.Page(apps, opts), nil
+}
+
+func (r *ApplicationRepo) UpdateStatus(ctx context.Context, id int, from, to string) error {
+	query := `
+		UPDATE campaign_applications
+		SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status = $3
+	`
+	n, err := r.db.Exec(ctx, query, to, id, from)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (r *ApplicationRepo) RejectPending(ctx context.Context, campaignID int) (int64, error) {
+	n, err := r.db.Exec(ctx, `
+		UPDATE campaign_applications
+		SET status = 'rejected', updated_at = NOW()
+		WHERE campaign_id = $1 AND status = 'pending'
+	`, campaignID)
+	return n, mapErr(err)
+}
+
+func (r *ApplicationRepo) ExpirePending(ctx context.Context, t time.Time) (int64, error) {
+	n, err := r.db.Exec(ctx, `
+		UPDATE campaign_applications
+		SET status = 'expired', updated_at = NOW()
+		WHERE status = 'pending' AND created_at < $1
+	`, t)
+	return n, mapErr(err)
+}
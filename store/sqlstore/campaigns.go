@@ -0,0 +1,291 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type CampaignRepo struct {
+	db   database.Querier
+	read database.Querier
+}
+
+const campaignColumns = `id, brand_id, organization_id, title, description, category, budget, deadline,
+	min_followers, min_engagement_rate, eligible_categories, eligible_platforms, eligible_countries, verified_only,
+	status, application_count, accepted_count, version, created_at, updated_at`
+
+// scanCampaign scans the columns listed above followed by any extra ones.
+func scanCampaign(row interface{ Scan(...any) error }, extra ...any) (*models.Campaign, error) {
+	var c models.Campaign
+	var categories, platforms, countries string
+	dest := []any{
+		&c.ID, &c.BrandID, &c.OrganizationID, &c.Title, &c.Description, &c.Category,
+		&c.Budget, &c.Deadline,
+		&c.Eligibility.MinFollowers, &c.Eligibility.MinEngagementRate, &categories, &platforms, &countries,
+		&c.Eligibility.VerifiedOnly,
+		&c.Status, &c.ApplicationCount, &c.AcceptedCount, &c.Version, &c.CreatedAt, &c.UpdatedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	c.Eligibility.Categories = splitList(categories)
+	c.Eligibility.Platforms = splitList(platforms)
+	c.Eligibility.Countries = splitList(countries)
+	return &c, nil
+}
+
+// joinList stores a list in a comma-separated column; the values can't
+// contain commas.
+func joinList(values []string) string {
+	return strings.Join(values, ",")
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// inList is a condition matching rows whose comma-separated column
+// contains value, ignoring case.
+func inList(column string) string {
+	return `',' || LOWER(` + column + `) || ',' LIKE ? ESCAPE '\'`
+}
+
+func inListArg(value string) string {
+	return "%," + escapeLike(strings.ToLower(value)) + ",%"
+}
+
+func (r *CampaignRepo) queryCampaigns(ctx context.Context, query string, args ...any) ([]models.Campaign, error) {
+	rows, err := r.read.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var campaigns []models.Campaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, *c)
+	}
+	return campaigns, rows.Err()
+}
+
+func (r *CampaignRepo) Create(ctx context.Context, c *models.Campaign) error {
+	query := `
+		INSERT INTO campaigns (
+			brand_id, organization_id, title, description, category, budget, deadline, status,
+			min_followers, min_engagement_rate, eligible_categories, eligible_platforms, eligible_countries,
+			verified_only, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE(NULLIF($8, ''), 'draft'),
+			$9, $10, $11, $12, $13, $14, NOW(), NOW())
+		RETURNING id, status, version, created_at, updated_at
+	`
+	e := c.Eligibility
+	return r.db.QueryRow(ctx, query,
+		c.BrandID, c.OrganizationID, c.Title, c.Description, c.Category, c.Budget, c.Deadline, c.Status,
+		e.MinFollowers, e.MinEngagementRate, joinList(e.Categories), joinList(e.Platforms), joinList(e.Countries),
+		e.VerifiedOnly,
+	).Scan(&c.ID, &c.Status, &c.Version, &c.CreatedAt, &c.UpdatedAt)
+}
+
+func (r *CampaignRepo) GetByID(ctx context.Context, id int) (*models.Campaign, error) {
+	return scanCampaign(r.read.QueryRow(ctx,
+		`SELECT `+campaignColumns+` FROM campaigns WHERE id = $1`, id))
+}
+
+// SQLite transactions take the database's write lock up front, which
+// already keeps the row from changing.
+func (r *CampaignRepo) GetForUpdate(ctx context.Context, id int) (*models.Campaign, error) {
+	query := `SELECT ` + campaignColumns + ` FROM campaigns WHERE id = $1`
+	if r.db.Dialect() == database.Postgres {
+		query += ` FOR UPDATE`
+	}
+	return scanCampaign(r.db.QueryRow(ctx, query, id))
+}
+
+func (r *CampaignRepo) List(ctx context.Context, f store.CampaignFilter, opts store.ListOptions) (store.Page[models.Campaign], error) {
+	opts, err := store.CampaignSorts.Normalize(opts)
+	if err != nil {
+		return store.Page[models.Campaign]{}, err
+	}
+
+	var w conds
+	if f.BrandID != 0 {
+		w.add("brand_id = ?", f.BrandID)
+	}
+	if f.OrganizationID != 0 {
+		w.add("organization_id = ?", f.OrganizationID)
+	}
+	if f.Personal {
+		w.add("organization_id IS NULL")
+	}
+	if f.Category != "" {
+		w.add("category = ?", f.Category)
+	}
+	if len(f.Statuses) > 0 {
+		w.in("status", f.Statuses)
+	}
+	if f.MinBudget != nil {
+		w.add("budget >= ?", *f.MinBudget)
+	}
+	if f.MaxBudget != nil {
+		w.add("budget <= ?", *f.MaxBudget)
+	}
+	if f.DeadlineFrom != nil {
+		w.add("deadline >= ?", *f.DeadlineFrom)
+	}
+	if f.DeadlineTo != nil {
+		w.add("deadline <= ?", *f.DeadlineTo)
+	}
+	if p := f.EligibleFor; p != nil {
+		// Mirrors models.Eligibility.Check.
+		w.add("min_followers <= ?", p.FollowerCount)
+		w.add("min_engagement_rate <= ?", p.EngagementRate)
+		w.add("(eligible_categories = '' OR "+inList("eligible_categories")+")", inListArg(p.Category))
+		platforms := []string{"eligible_platforms = ''"}
+		var args []any
+		for _, platform := range p.Platforms {
+			platforms = append(platforms, inList("eligible_platforms"))
+			args = append(args, inListArg(platform))
+		}
+		w.add("("+strings.Join(platforms, " OR ")+")", args...)
+		w.add("(eligible_countries = '' OR "+inList("eligible_countries")+")", inListArg(p.Country))
+		if !p.Verified() {
+			w.add("NOT verified_only")
+		}
+	}
+	order := w.paginate(opts)
+
+	campaigns, err := r.queryCampaigns(ctx,
+		`SELECT `+campaignColumns+` FROM campaigns `+w.String()+` `+order, w.args...)
+	if err != nil {
+		return store.Page[models.Campaign]{}, err
+	}
+	return store.CampaignSorts.Page(campaigns, opts), nil
+}
+
+func (r *CampaignRepo) Update(ctx context.Context, c *models.Campaign) error {
+	query := `
+		UPDATE campaigns
+		SET title = $1, description = $2, category = $3, budget = $4, deadline = $5,
+		    min_followers = $6, min_engagement_rate = $7, eligible_categories = $8,
+		    eligible_platforms = $9, eligible_countries = $10, verified_only = $11,
+		    version = version + 1, updated_at = NOW()
+		WHERE id = $12 AND version = $13
+		RETURNING version, updated_at
+	`
+	e := c.Eligibility
+	err := mapErr(r.db.QueryRow(ctx, query,
+		c.Title, c.Description, c.Category, c.Budget, c.Deadline,
+		e.MinFollowers, e.MinEngagementRate, joinList(e.Categories),
+		joinList(e.Platforms), joinList(e.Countries), e.VerifiedOnly,
+		c.ID, c.Version,
+	).Scan(&c.Version, &c.UpdatedAt))
+	if !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	// Nothing matched: tell a missing campaign from a newer version.
+	var exists bool
+	if err := r.db.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM campaigns WHERE id = $1)`, c.ID).Scan(&exists); err != nil {
+		return mapErr(err)
+	}
+	if exists {
+		return store.ErrConflict
+	}
+	return store.ErrNotFound
+}
+
+func (r *CampaignRepo) Delete(ctx context.Context, id int) error {
+	n, err := r.db.Exec(ctx, `DELETE FROM campaigns WHERE id = $1`, id)
+	return affected(n, err)
+}
+
+func (r *CampaignRepo) AdoptCampaigns(ctx context.Context, brandID, orgID int) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE campaigns SET organization_id = $1
+		WHERE brand_id = $2 AND organization_id IS NULL
+	`, orgID, brandID)
+	return mapErr(err)
+}
+
+func (r *CampaignRepo) IncrementCounters(ctx context.Context, id int, applications, accepted int) error {
+	n, err := r.db.Exec(ctx, `
+		UPDATE campaigns
+		SET application_count = application_count + $2,
+		    accepted_count = accepted_count + $3
+		WHERE id = $1
+	`, id, applications, accepted)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (r *CampaignRepo) SetStatus(ctx context.Context, id int, from, to string) error {
+	n, err := r.db.Exec(ctx, `
+		UPDATE campaigns SET status = $1, version = version + 1, updated_at = NOW()
+		WHERE id = $2 AND status = $3
+	`, to, id, from)
+	if err != nil {
+		return mapErr(err)
+	}
+	if n == 0 {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (r *CampaignRepo) AddTransition(ctx context.Context, t *models.CampaignTransition) error {
+	return mapErr(r.db.QueryRow(ctx, `
+		INSERT INTO campaign_transitions (campaign_id, from_status, to_status, actor_id, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at
+	`, t.CampaignID, t.From, t.To, t.ActorID).Scan(&t.ID, &t.CreatedAt))
+}
+
+func (r *CampaignRepo) ListTransitions(ctx context.Context, campaignID int) ([]models.CampaignTransition, error) {
+	rows, err := r.read.Query(ctx, `
+		SELECT id, campaign_id, from_status, to_status, actor_id, created_at
+		FROM campaign_transitions WHERE campaign_id = $1
+		ORDER BY created_at, id
+	`, campaignID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+
+	transitions := []models.CampaignTransition{}
+	for rows.Next() {
+		var t models.CampaignTransition
+		if err := rows.Scan(&t.ID, &t.CampaignID, &t.From, &t.To, &t.ActorID, &t.CreatedAt); err != nil {
+			return nil, mapErr(err)
+		}
+		transitions = append(transitions, t)
+	}
+	return transitions, mapErr(rows.Err())
+}
+
+func (r *CampaignRepo) ClaimDeadlineReminder(ctx context.Context, id int) (bool, error) {
+	n, err := r.db.Exec(ctx, `
+		UPDATE campaigns SET reminded_deadline = deadline
+		WHERE id = $1 AND (reminded_deadline IS NULL OR reminded_deadline <> deadline)
+	`, id)
+	return n > 0, mapErr(err)
+}
@@ -0,0 +1,163 @@
+package sqlstore
+
+import (
+	"context"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type DeliverableRepo struct {
+	db   database.Querier
+	read database.Querier
+}
+
+const deliverableColumns = `id, campaign_id, type, platform, quantity, due_at, requirements, created_at, updated_at`
+
+func scanDeliverable(row interface{ Scan(...any) error }) (*models.Deliverable, error) {
+	var d models.Deliverable
+	err := row.Scan(&d.ID, &d.CampaignID, &d.Type, &d.Platform, &d.Quantity, &d.DueAt,
+		&d.Requirements, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &d, nil
+}
+
+func (r *DeliverableRepo) Create(ctx context.Context, d *models.Deliverable) error {
+	query := `
+		INSERT INTO campaign_deliverables (campaign_id, type, platform, quantity, due_at, requirements, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		d.CampaignID, d.Type, d.Platform, d.Quantity, d.DueAt, d.Requirements,
+	).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt))
+}
+
+func (r *DeliverableRepo) GetByID(ctx context.Context, id int) (*models.Deliverable, error) {
+	return scanDeliverable(r.read.QueryRow(ctx,
+		`SELECT `+deliverableColumns+` FROM campaign_deliverables WHERE id = $1`, id))
+}
+
+func (r *DeliverableRepo) ListByCampaign(ctx context.Context, campaignID int) ([]models.Deliverable, error) {
+	rows, err := r.read.Query(ctx, `
+		SELECT `+deliverableColumns+` FROM campaign_deliverables
+		WHERE campaign_id = $1 ORDER BY id
+	`, campaignID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+
+	deliverables := []models.Deliverable{}
+	for rows.Next() {
+		d, err := scanDeliverable(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliverables = append(deliverables, *d)
+	}
+	return deliverables, mapErr(rows.Err())
+}
+
+func (r *DeliverableRepo) Update(ctx context.Context, d *models.Deliverable) error {
+	err := r.db.QueryRow(ctx, `
+		UPDATE campaign_deliverables
+		SET type = $1, platform = $2, quantity = $3, due_at = $4, requirements = $5, updated_at = NOW()
+		WHERE id = $6
+		RETURNING updated_at
+	`, d.Type, d.Platform, d.Quantity, d.DueAt, d.Requirements, d.ID).Scan(&d.UpdatedAt)
+	return mapErr(err)
+}
+
+func (r *DeliverableRepo) Delete(ctx context.Context, id int) error {
+	return affected(r.db.Exec(ctx, `DELETE FROM campaign_deliverables WHERE id = $1`, id))
+}
+
+const submissionColumns = `s.id, s.deliverable_id, s.application_id, s.kind, s.url, s.note, s.status,
+	s.comment, s.reviewed_by, s.reviewed_at, s.created_at`
+
+func scanSubmission(row interface{ Scan(...any) error }) (*models.DeliverableSubmission, error) {
+	var s models.DeliverableSubmission
+	err := row.Scan(&s.ID, &s.DeliverableID, &s.ApplicationID, &s.Kind, &s.URL, &s.Note, &s.Status,
+		&s.Comment, &s.ReviewedBy, &s.ReviewedAt, &s.CreatedAt)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &s, nil
+}
+
+func (r *DeliverableRepo) CreateSubmission(ctx context.Context, s *models.DeliverableSubmission) error {
+	query := `
+		INSERT INTO deliverable_submissions (deliverable_id, application_id, kind, url, note, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, 'pending', NOW())
+		RETURNING id, status, created_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		s.DeliverableID, s.ApplicationID, s.Kind, s.URL, s.Note,
+	).Scan(&s.ID, &s.Status, &s.CreatedAt))
+}
+
+func (r *DeliverableRepo) GetSubmission(ctx context.Context, id int) (*models.DeliverableSubmission, error) {
+	return scanSubmission(r.read.QueryRow(ctx,
+		`SELECT `+submissionColumns+` FROM deliverable_submissions s WHERE s.id = $1`, id))
+}
+
+func (r *DeliverableRepo) ListSubmissions(ctx context.Context, f store.SubmissionFilter) ([]models.DeliverableSubmission, error) {
+	var w conds
+	if f.ApplicationID != 0 {
+		w.add("s.application_id = ?", f.ApplicationID)
+	}
+	if f.CampaignID != 0 {
+		w.add("a.campaign_id = ?", f.CampaignID)
+		w.add("a.status = 'accepted'")
+	}
+	if f.DeliverableID != 0 {
+		w.add("s.deliverable_id = ?", f.DeliverableID)
+	}
+
+	rows, err := r.read.Query(ctx, `
+		SELECT `+submissionColumns+` FROM deliverable_submissions s
+		JOIN campaign_applications a ON a.id = s.application_id
+		`+w.String()+`
+		ORDER BY s.created_at, s.id
+	`, w.args...)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+
+	submissions := []models.DeliverableSubmission{}
+	for rows.Next() {
+		s, err := scanSubmission(rows)
+		if err != nil {
+			return nil, err
+		}
+		submissions = append(submissions, *s)
+	}
+	return submissions, mapErr(rows.Err())
+}
+
+func (r *DeliverableRepo) CountSubmissions(ctx context.Context, deliverableID int) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM deliverable_submissions WHERE deliverable_id = $1`, deliverableID).Scan(&n)
+	return n, mapErr(err)
+}
+
+func (r *DeliverableRepo) ReviewSubmission(ctx context.Context, s *models.DeliverableSubmission) error {
+	n, err := r.db.Exec(ctx, `
+		UPDATE deliverable_submissions
+		SET status = $1, comment = $2, reviewed_by = $3, reviewed_at = NOW()
+		WHERE id = $4 AND status = 'pending'
+	`, s.Status, s.Comment, s.ReviewedBy, s.ID)
+	if err != nil {
+		return mapErr(err)
+	}
+	if n == 0 {
+		return store.ErrConflict
+	}
+	return nil
+}
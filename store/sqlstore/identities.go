@@ -0,0 +1,68 @@
+package sqlstore
+
+import (
+	"context"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+)
+
+type IdentityRepo struct {
+	db database.Querier
+}
+
+const identityColumns = `id, user_id, provider, subject, email, created_at, last_login_at`
+
+func scanIdentity(row interface{ Scan(...any) error }) (*models.Identity, error) {
+	var i models.Identity
+	if err := row.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt, &i.LastLoginAt); err != nil {
+		return nil, mapErr(err)
+	}
+	return &i, nil
+}
+
+func (r *IdentityRepo) Create(ctx context.Context, i *models.Identity) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		i.UserID, i.Provider, i.Subject, i.Email,
+	).Scan(&i.ID, &i.CreatedAt))
+}
+
+func (r *IdentityRepo) GetBySubject(ctx context.Context, provider, subject string) (*models.Identity, error) {
+	return scanIdentity(r.db.QueryRow(ctx,
+		`SELECT `+identityColumns+` FROM user_identities WHERE provider = $1 AND subject = $2`,
+		provider, subject))
+}
+
+func (r *IdentityRepo) ListByUser(ctx context.Context, userID int) ([]models.Identity, error) {
+	rows, err := r.db.Query(ctx,
+		`SELECT `+identityColumns+` FROM user_identities WHERE user_id = $1 ORDER BY provider`, userID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+
+	identities := []models.Identity{}
+	for rows.Next() {
+		i, err := scanIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, *i)
+	}
+	return identities, mapErr(rows.Err())
+}
+
+func (r *IdentityRepo) TouchLogin(ctx context.Context, id int) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE user_identities SET last_login_at = NOW() WHERE id = $1`, id))
+}
+
+func (r *IdentityRepo) Delete(ctx context.Context, userID int, provider string) error {
+	return affected(r.db.Exec(ctx,
+		`DELETE FROM user_identities WHERE user_id = $1 AND provider = $2`, userID, provider))
+}
@@ -0,0 +1,83 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type JobRunRepo struct {
+	db   database.Querier
+	read database.Querier
+}
+
+func (r *JobRunRepo) Start(ctx context.Context, run *models.JobRun) error {
+	query := `
+		INSERT INTO job_runs (job, scheduled_at, instance, status, started_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, started_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		run.Job, run.ScheduledAt, run.Instance, run.Status,
+	).Scan(&run.ID, &run.StartedAt))
+}
+
+func (r *JobRunRepo) Finish(ctx context.Context, run *models.JobRun) error {
+	query := `
+		UPDATE job_runs
+		SET status = $1, result = $2, error = $3, duration_ms = $4, finished_at = NOW()
+		WHERE id = $5
+		RETURNING finished_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		run.Status, run.Result, run.Error, run.DurationMS, run.ID,
+	).Scan(&run.FinishedAt))
+}
+
+func (r *JobRunRepo) List(ctx context.Context, f store.JobRunFilter, opts store.ListOptions) (store.Page[models.JobRun], error) {
+	opts, err := store.JobRunSorts.Normalize(opts)
+	if err != nil {
+		return store.Page[models.JobRun]{}, err
+	}
+
+	var w conds
+	if f.Job != "" {
+		w.add("job = ?", f.Job)
+	}
+	if f.Status != "" {
+		w.add("status = ?", f.Status)
+	}
+	order := w.paginate(opts)
+
+	rows, err := r.read.Query(ctx, `
+		SELECT id, job, scheduled_at, instance, status, result, error, duration_ms, started_at, finished_at
+		FROM job_runs `+w.String()+` `+order, w.args...)
+	if err != nil {
+		return store.Page[models.JobRun]{}, mapErr(err)
+	}
+	defer rows.Close()
+
+	var runs []models.JobRun
+	for rows.Next() {
+		var run models.JobRun
+		if err := rows.Scan(
+			&run.ID, &run.Job, &run.ScheduledAt, &run.Instance, &run.Status, &run.Result, &run.Error,
+			&run.DurationMS, &run.StartedAt, &run.FinishedAt,
+		); err != nil {
+			return store.Page[models.JobRun]{}, mapErr(err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return store.Page[models.JobRun]{}, mapErr(err)
+	}
+	return store.JobRunSorts.Page(runs, opts), nil
+}
+
+func (r *JobRunRepo) Purge(ctx context.Context, t time.Time) (int64, error) {
+	n, err := r.db.Exec(ctx, `DELETE FROM job_runs WHERE started_at < $1`, t)
+	return n, mapErr(err)
+}
@@ -0,0 +1,61 @@
+package sqlstore
+
+import (
+	"fmt"
+	"strings"
+
+	"InfluenceIQ/store"
+)
+
+// conds collects WHERE conditions whose "?" markers are numbered into
+// "$N" placeholders as they are added.
+type conds struct {
+	clauses []string
+	args    []any
+}
+
+func (w *conds) add(clause string, args ...any) {
+	for _, arg := range args {
+		w.args = append(w.args, arg)
+		clause = strings.Replace(clause, "?", fmt.Sprintf("$%d", len(w.args)), 1)
+	}
+	w.clauses = append(w.clauses, clause)
+}
+
+// in adds a condition that column is one of values, which must not be
+// empty.
+func (w *conds) in(column string, values []string) {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	w.add(column+" IN (?"+strings.Repeat(", ?", len(values)-1)+")", args...)
+}
+
+func (w *conds) String() string {
+	if len(w.clauses) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(w.clauses, " AND ")
+}
+
+// paginate adds the keyset condition for opts.Cursor and returns the
+// ORDER BY and LIMIT clauses. Rows come back in scan order, as
+// store.SortSpec.Page expects. opts.Sort.Field must already be whitelisted.
+func (w *conds) paginate(opts store.ListOptions) string {
+	desc := opts.Sort.Desc
+	if opts.Cursor != nil && opts.Cursor.Backward {
+		desc = !desc
+	}
+	op, dir := ">", "ASC"
+	if desc {
+		op, dir = "<", "DESC"
+	}
+
+	col := opts.Sort.Field
+	if opts.Cursor != nil {
+		w.add(fmt.Sprintf("(%s, id) %s (?, ?)", col, op), opts.Cursor.Value, opts.Cursor.ID)
+	}
+	w.args = append(w.args, opts.Limit+1)
+	return fmt.Sprintf("ORDER BY %s %s, id %s LIMIT $%d", col, dir, dir, len(w.args))
+}
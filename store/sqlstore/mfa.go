@@ -0,0 +1,163 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type MFARepo struct {
+	db database.Querier
+}
+
+func (r *MFARepo) GetTOTP(ctx context.Context, userID int) (*models.TOTP, error) {
+	var t models.TOTP
+	err := r.db.QueryRow(ctx, `
+		SELECT user_id, secret, enabled_at, last_used_step, created_at
+		FROM user_totp WHERE user_id = $1
+	`, userID).Scan(&t.UserID, &t.Secret, &t.EnabledAt, &t.LastUsedStep, &t.CreatedAt)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &t, nil
+}
+
+func (r *MFARepo) SaveTOTP(ctx context.Context, t *models.TOTP) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret, created_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = excluded.secret, enabled_at = NULL, last_used_step = 0, created_at = excluded.created_at
+		RETURNING created_at
+	`
+	t.EnabledAt, t.LastUsedStep = nil, 0
+	return mapErr(r.db.QueryRow(ctx, query, t.UserID, t.Secret).Scan(&t.CreatedAt))
+}
+
+func (r *MFARepo) EnableTOTP(ctx context.Context, userID int) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE user_totp SET enabled_at = NOW() WHERE user_id = $1 AND enabled_at IS NULL`, userID))
+}
+
+func (r *MFARepo) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	n, err := r.db.Exec(ctx,
+		`UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return mapErr(err)
+	}
+	if n == 0 {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (r *MFARepo) DeleteTOTP(ctx context.Context, userID int) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return mapErr(err)
+	}
+	return affected(r.db.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID))
+}
+
+func (r *MFARepo) ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return mapErr(err)
+	}
+	for _, hash := range hashes {
+		_, err := r.db.Exec(ctx,
+			`INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, NOW())`, userID, hash)
+		if err != nil {
+			return mapErr(err)
+		}
+	}
+	return nil
+}
+
+func (r *MFARepo) UseRecoveryCode(ctx context.Context, userID int, hash string) error {
+	return affected(r.db.Exec(ctx, `
+		UPDATE mfa_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, hash))
+}
+
+func (r *MFARepo) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID).Scan(&n)
+	return n, mapErr(err)
+}
+
+func (r *MFARepo) CreateChallenge(ctx context.Context, ch *models.MFAChallenge) error {
+	query := `
+		INSERT INTO mfa_challenges (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING id, created_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		ch.UserID, ch.TokenHash, ch.ExpiresAt,
+	).Scan(&ch.ID, &ch.CreatedAt))
+}
+
+func (r *MFARepo) GetChallenge(ctx context.Context, hash string) (*models.MFAChallenge, error) {
+	var ch models.MFAChallenge
+	err := r.db.QueryRow(ctx, `
+		SELECT id, user_id, token_hash, attempts, expires_at, created_at
+		FROM mfa_challenges WHERE token_hash = $1 AND expires_at > $2
+	`, hash, time.Now()).Scan(&ch.ID, &ch.UserID, &ch.TokenHash, &ch.Attempts, &ch.ExpiresAt, &ch.CreatedAt)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &ch, nil
+}
+
+func (r *MFARepo) FailChallenge(ctx context.Context, id int) (int, error) {
+	var attempts int
+	err := r.db.QueryRow(ctx,
+		`UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = $1 RETURNING attempts`, id).Scan(&attempts)
+	return attempts, mapErr(err)
+}
+
+func (r *MFARepo) DeleteChallenge(ctx context.Context, id int) error {
+	return affected(r.db.Exec(ctx, `DELETE FROM mfa_challenges WHERE id = $1`, id))
+}
+
+func (r *MFARepo) ListPolicies(ctx context.Context) ([]models.MFAPolicy, error) {
+	rows, err := r.db.Query(ctx, `SELECT scope, value, created_at FROM mfa_policies ORDER BY scope, value`)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+
+	policies := []models.MFAPolicy{}
+	for rows.Next() {
+		var p models.MFAPolicy
+		if err := rows.Scan(&p.Scope, &p.Value, &p.CreatedAt); err != nil {
+			return nil, mapErr(err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, mapErr(rows.Err())
+}
+
+func (r *MFARepo) ReplacePolicies(ctx context.Context, scope string, values []string) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM mfa_policies WHERE scope = $1`, scope); err != nil {
+		return mapErr(err)
+	}
+	for _, value := range values {
+		_, err := r.db.Exec(ctx,
+			`INSERT INTO mfa_policies (scope, value, created_at) VALUES ($1, $2, NOW())`, scope, value)
+		if err != nil {
+			return mapErr(err)
+		}
+	}
+	return nil
+}
+
+func (r *MFARepo) Required(ctx context.Context, scope, value string) (bool, error) {
+	var required bool
+	err := r.db.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM mfa_policies WHERE scope = $1 AND value = $2)`, scope, value).Scan(&required)
+	return required, mapErr(err)
+}
@@ -0,0 +1,178 @@
+package sqlstore
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+)
+
+type OrganizationRepo struct {
+	db   database.Querier
+	read database.Querier
+}
+
+func (r *OrganizationRepo) Create(ctx context.Context, o *models.Organization) error {
+	query := `
+		INSERT INTO organizations (name, created_at, updated_at)
+		VALUES ($1, NOW(), NOW())
+		RETURNING id, created_at, updated_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query, o.Name).Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt))
+}
+
+func (r *OrganizationRepo) GetByID(ctx context.Context, id int) (*models.Organization, error) {
+	var o models.Organization
+	err := r.db.QueryRow(ctx,
+		`SELECT id, name, created_at, updated_at FROM organizations WHERE id = $1`, id,
+	).Scan(&o.ID, &o.Name, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &o, nil
+}
+
+func (r *OrganizationRepo) Rename(ctx context.Context, id int, name string) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE organizations SET name = $1, updated_at = NOW() WHERE id = $2`, name, id))
+}
+
+func (r *OrganizationRepo) Delete(ctx context.Context, id int) error {
+	if err := affected(r.db.Exec(ctx, `DELETE FROM organizations WHERE id = $1`, id)); err != nil {
+		return err
+	}
+	// Policies name organizations by value, so nothing cascades to them.
+	_, err := r.db.Exec(ctx, `DELETE FROM mfa_policies WHERE scope = $1 AND value = $2`,
+		models.MFAScopeOrganization, strconv.Itoa(id))
+	return mapErr(err)
+}
+
+func (r *OrganizationRepo) AddMember(ctx context.Context, m *models.OrganizationMember) error {
+	query := `
+		INSERT INTO organization_members (organization_id, user_id, role, created_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING created_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query, m.OrganizationID, m.UserID, m.Role).Scan(&m.CreatedAt))
+}
+
+const memberQuery = `
+	SELECT m.organization_id, m.user_id, u.username, u.email, m.role, m.created_at
+	FROM organization_members m
+	JOIN users u ON u.user_id = m.user_id
+`
+
+func scanMember(row interface{ Scan(...any) error }) (*models.OrganizationMember, error) {
+	var m models.OrganizationMember
+	if err := row.Scan(&m.OrganizationID, &m.UserID, &m.Username, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+		return nil, mapErr(err)
+	}
+	return &m, nil
+}
+
+func (r *OrganizationRepo) GetMembership(ctx context.Context, userID int) (*models.OrganizationMember, error) {
+	return scanMember(r.db.QueryRow(ctx, memberQuery+` WHERE m.user_id = $1`, userID))
+}
+
+func (r *OrganizationRepo) ListMembers(ctx context.Context, orgID int) ([]models.OrganizationMember, error) {
+	rows, err := r.read.Query(ctx, memberQuery+` WHERE m.organization_id = $1 ORDER BY m.created_at, m.user_id`, orgID)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+
+	members := []models.OrganizationMember{}
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, *m)
+	}
+	return members, mapErr(rows.Err())
+}
+
+func (r *OrganizationRepo) SetMemberRole(ctx context.Context, orgID, userID int, role string) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE organization_members SET role = $1 WHERE organization_id = $2 AND user_id = $3`,
+		role, orgID, userID))
+}
+
+func (r *OrganizationRepo) RemoveMember(ctx context.Context, orgID, userID int) error {
+	return affected(r.db.Exec(ctx,
+		`DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`, orgID, userID))
+}
+
+func (r *OrganizationRepo) CountOwners(ctx context.Context, orgID int) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM organization_members WHERE organization_id = $1 AND role = $2`,
+		orgID, models.OrgRoleOwner,
+	).Scan(&n)
+	return n, mapErr(err)
+}
+
+func (r *OrganizationRepo) CreateInvitation(ctx context.Context, inv *models.OrganizationInvitation) error {
+	if _, err := r.db.Exec(ctx,
+		`DELETE FROM organization_invitations WHERE organization_id = $1 AND email = $2`,
+		inv.OrganizationID, inv.Email); err != nil {
+		return mapErr(err)
+	}
+
+	query := `
+		INSERT INTO organization_invitations (organization_id, email, role, token_hash, invited_by, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING id, created_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		inv.OrganizationID, inv.Email, inv.Role, inv.TokenHash, inv.InvitedBy, inv.ExpiresAt,
+	).Scan(&inv.ID, &inv.CreatedAt))
+}
+
+const invitationColumns = `id, organization_id, email, role, token_hash, invited_by, expires_at, created_at`
+
+func scanInvitation(row interface{ Scan(...any) error }) (*models.OrganizationInvitation, error) {
+	var inv models.OrganizationInvitation
+	if err := row.Scan(&inv.ID, &inv.OrganizationID, &inv.Email, &inv.Role, &inv.TokenHash,
+		&inv.InvitedBy, &inv.ExpiresAt, &inv.CreatedAt); err != nil {
+		return nil, mapErr(err)
+	}
+	return &inv, nil
+}
+
+func (r *OrganizationRepo) ListInvitations(ctx context.Context, orgID int) ([]models.OrganizationInvitation, error) {
+	rows, err := r.read.Query(ctx, `
+		SELECT `+invitationColumns+` FROM organization_invitations
+		WHERE organization_id = $1 AND expires_at > $2
+		ORDER BY created_at DESC, id DESC
+	`, orgID, time.Now())
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+
+	invitations := []models.OrganizationInvitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *inv)
+	}
+	return invitations, mapErr(rows.Err())
+}
+
+func (r *OrganizationRepo) ConsumeInvitation(ctx context.Context, hash string) (*models.OrganizationInvitation, error) {
+	return scanInvitation(r.db.QueryRow(ctx, `
+		DELETE FROM organization_invitations
+		WHERE token_hash = $1 AND expires_at > $2
+		RETURNING `+invitationColumns,
+		hash, time.Now()))
+}
+
+func (r *OrganizationRepo) DeleteInvitation(ctx context.Context, orgID, id int) error {
+	return affected(r.db.Exec(ctx,
+		`DELETE FROM organization_invitations WHERE id = $1 AND organization_id = $2`, id, orgID))
+}
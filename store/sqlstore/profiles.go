@@ -0,0 +1,86 @@
+package sqlstore
+
+import (
+	"context"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+)
+
+type ProfileRepo struct {
+	db   database.Querier
+	read database.Querier
+}
+
+const profileColumns = `id, user_id, display_name, avatar_url, bio, account_type,
+	category, follower_count, engagement_rate, platforms, country, verified_at,
+	company_name, industry, website, created_at, updated_at`
+
+// scanProfile scans the columns listed above followed by any extra ones.
+func scanProfile(row interface{ Scan(...any) error }, extra ...any) (*models.Profile, error) {
+	var p models.Profile
+	var platforms string
+	dest := []any{
+		&p.ID, &p.UserID, &p.DisplayName, &p.AvatarURL, &p.Bio, &p.AccountType,
+		&p.Category, &p.FollowerCount, &p.EngagementRate, &platforms, &p.Country, &p.VerifiedAt,
+		&p.CompanyName, &p.Industry, &p.Website, &p.CreatedAt, &p.UpdatedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	p.Platforms = splitList(platforms)
+	return &p, nil
+}
+
+func (r *ProfileRepo) Create(ctx context.Context, p *models.Profile) error {
+	query := `
+		INSERT INTO profiles (
+			user_id, display_name, avatar_url, bio, account_type,
+			category, follower_count, engagement_rate, platforms, country,
+			company_name, industry, website, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NOW(), NOW())
+		RETURNING id, verified_at, created_at, updated_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		p.UserID, p.DisplayName, p.AvatarURL, p.Bio, p.AccountType,
+		p.Category, p.FollowerCount, p.EngagementRate, joinList(p.Platforms), p.Country,
+		p.CompanyName, p.Industry, p.Website,
+	).Scan(&p.ID, &p.VerifiedAt, &p.CreatedAt, &p.UpdatedAt))
+}
+
+func (r *ProfileRepo) GetByUserID(ctx context.Context, userID int) (*models.Profile, error) {
+	return scanProfile(r.read.QueryRow(ctx,
+		`SELECT `+profileColumns+` FROM profiles WHERE user_id = $1`, userID))
+}
+
+func (r *ProfileRepo) Update(ctx context.Context, p *models.Profile) error {
+	query := `
+		UPDATE profiles
+		SET display_name = $1, avatar_url = $2, bio = $3, account_type = $4,
+			category = $5, follower_count = $6, engagement_rate = $7, platforms = $8, country = $9,
+			company_name = $10, industry = $11, website = $12, updated_at = NOW()
+		WHERE user_id = $13
+	`
+	n, err := r.db.Exec(ctx, query,
+		p.DisplayName, p.AvatarURL, p.Bio, p.AccountType,
+		p.Category, p.FollowerCount, p.EngagementRate, joinList(p.Platforms), p.Country,
+		p.CompanyName, p.Industry, p.Website, p.UserID,
+	)
+	return affected(n, err)
+}
+
+func (r *ProfileRepo) SetVerified(ctx context.Context, userID int, verified bool) error {
+	query := `UPDATE profiles SET verified_at = NULL WHERE user_id = $1`
+	if verified {
+		query = `UPDATE profiles SET verified_at = COALESCE(verified_at, NOW()) WHERE user_id = $1`
+	}
+	n, err := r.db.Exec(ctx, query, userID)
+	return affected(n, err)
+}
+
+func (r *ProfileRepo) Delete(ctx context.Context, userID int) error {
+	n, err := r.db.Exec(ctx, `DELETE FROM profiles WHERE user_id = $1`, userID)
+	return affected(n, err)
+}
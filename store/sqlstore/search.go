@@ -0,0 +1,207 @@
+package sqlstore
+
+import (
+	"context"
+	"strings"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type SearchRepo struct {
+	db database.Querier
+}
+
+// headlineOptions makes ts_headline mark matches with the store's snippet
+// markers, which RenderSnippet turns into <mark> tags after escaping.
+const headlineOptions = "StartSel=" + store.SnippetStart + ", StopSel=" + store.SnippetStop +
+	`, MaxWords=25, MinWords=10, MaxFragments=2, FragmentDelimiter=" … "`
+
+func (r *SearchRepo) Search(ctx context.Context, q store.SearchQuery) (*models.SearchResults, error) {
+	if r.db.Dialect() != database.Postgres {
+		return r.fallback(ctx, q)
+	}
+
+	results := &models.SearchResults{
+		Campaigns: []models.CampaignHit{},
+		Profiles:  []models.ProfileHit{},
+		Facets: models.SearchFacets{
+			CampaignCategory: map[string]int{},
+			ProfileCategory:  map[string]int{},
+			AccountType:      map[string]int{},
+		},
+	}
+
+	if q.Campaigns {
+		rows, err := r.db.Query(ctx, `
+			SELECT `+campaignColumns+`,
+			       ts_rank_cd(search_vector, query) AS rank,
+			       ts_headline('english', title || ' ' || description, query, $2) AS snippet
+			FROM campaigns, websearch_to_tsquery('english', $1) AS query
+			WHERE search_vector @@ query AND status = $3 AND ($4 = '' OR category = $4)
+			ORDER BY rank DESC, id DESC
+			LIMIT $5
+		`, q.Text, headlineOptions, models.CampaignPublished, q.Category, q.Limit)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var hit models.CampaignHit
+			c, err := scanCampaign(rows, &hit.Rank, &hit.Snippet)
+			if err != nil {
+				return nil, err
+			}
+			hit.Campaign, hit.Snippet = *c, store.RenderSnippet(hit.Snippet)
+			results.Campaigns = append(results.Campaigns, hit)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := r.countFacet(ctx, results.Facets.CampaignCategory, `
+			SELECT category, COUNT(*)
+			FROM campaigns, websearch_to_tsquery('english', $1) AS query
+			WHERE search_vector @@ query AND status = $2 AND category <> ''
+			GROUP BY category
+		`, q.Text, models.CampaignPublished); err != nil {
+			return nil, err
+		}
+	}
+
+	if q.Profiles {
+		rows, err := r.db.Query(ctx, `
+			SELECT `+profileColumns+`,
+			       ts_rank_cd(search_vector, query) AS rank,
+			       ts_headline('english', display_name || ' ' || bio, query, $2) AS snippet
+			FROM profiles, websearch_to_tsquery('english', $1) AS query
+			WHERE search_vector @@ query
+			  AND ($3 = '' OR category = $3)
+			  AND ($4 = '' OR account_type = $4)
+			ORDER BY rank DESC, id DESC
+			LIMIT $5
+		`, q.Text, headlineOptions, q.Category, q.AccountType, q.Limit)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var hit models.ProfileHit
+			p, err := scanProfile(rows, &hit.Rank, &hit.Snippet)
+			if err != nil {
+				return nil, err
+			}
+			hit.Profile, hit.Snippet = *p, store.RenderSnippet(hit.Snippet)
+			results.Profiles = append(results.Profiles, hit)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := r.countFacet(ctx, results.Facets.ProfileCategory, `
+			SELECT category, COUNT(*)
+			FROM profiles, websearch_to_tsquery('english', $1) AS query
+			WHERE search_vector @@ query AND category <> ''
+			GROUP BY category
+		`, q.Text); err != nil {
+			return nil, err
+		}
+		if err := r.countFacet(ctx, results.Facets.AccountType, `
+			SELECT account_type, COUNT(*)
+			FROM profiles, websearch_to_tsquery('english', $1) AS query
+			WHERE search_vector @@ query
+			GROUP BY account_type
+		`, q.Text); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// countFacet adds the (value, count) rows returned by query to counts.
+func (r *SearchRepo) countFacet(ctx context.Context, counts map[string]int, query string, args ...any) error {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var value string
+		var n int
+		if err := rows.Scan(&value, &n); err != nil {
+			return err
+		}
+		counts[value] += n
+	}
+	return rows.Err()
+}
+
+// fallback narrows the rows with LIKE and leaves ranking, snippets and
+// facets to store.FallbackSearch.
+func (r *SearchRepo) fallback(ctx context.Context, q store.SearchQuery) (*models.SearchResults, error) {
+	terms := store.NewMatcher(q.Text).Terms()
+	if len(terms) == 0 {
+		results := store.FallbackSearch(q, nil, nil)
+		return &results, nil
+	}
+
+	// Every term must occur somewhere in the row's searchable text.
+	containsAll := func(document string) conds {
+		var w conds
+		for _, term := range terms {
+			w.add(document+` LIKE ? ESCAPE '\'`, "%"+escapeLike(term)+"%")
+		}
+		return w
+	}
+
+	var campaigns []models.Campaign
+	if q.Campaigns {
+		w := containsAll(`(title || ' ' || description || ' ' || category)`)
+		w.add("status = ?", models.CampaignPublished)
+		rows, err := r.db.Query(ctx, `SELECT `+campaignColumns+` FROM campaigns `+w.String(), w.args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			c, err := scanCampaign(rows)
+			if err != nil {
+				return nil, err
+			}
+			campaigns = append(campaigns, *c)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var profiles []models.Profile
+	if q.Profiles {
+		w := containsAll(`(display_name || ' ' || bio || ' ' || category)`)
+		rows, err := r.db.Query(ctx, `SELECT `+profileColumns+` FROM profiles `+w.String(), w.args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			p, err := scanProfile(rows)
+			if err != nil {
+				return nil, err
+			}
+			profiles = append(profiles, *p)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	results := store.FallbackSearch(q, campaigns, profiles)
+	return &results, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,121 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type LoginAttemptRepo struct {
+	db database.Querier
+}
+
+func (r *LoginAttemptRepo) Get(ctx context.Context, bucket string) (*models.LoginAttempts, error) {
+	a := models.LoginAttempts{Bucket: bucket}
+	err := r.db.QueryRow(ctx, `
+		SELECT failures, window_start, blocked_until FROM login_attempts WHERE bucket = $1
+	`, bucket).Scan(&a.Failures, &a.WindowStart, &a.BlockedUntil)
+	if err = mapErr(err); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *LoginAttemptRepo) Fail(ctx context.Context, bucket string, windowStart time.Time) (*models.LoginAttempts, error) {
+	query := `
+		INSERT INTO login_attempts (bucket, failures, window_start)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (bucket) DO UPDATE SET
+			failures = CASE WHEN login_attempts.window_start < $3 THEN 1 ELSE login_attempts.failures + 1 END,
+			window_start = CASE WHEN login_attempts.window_start < $3 THEN excluded.window_start ELSE login_attempts.window_start END
+		RETURNING failures, window_start, blocked_until
+	`
+	a := models.LoginAttempts{Bucket: bucket}
+	err := r.db.QueryRow(ctx, query, bucket, time.Now(), windowStart).Scan(&a.Failures, &a.WindowStart, &a.BlockedUntil)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &a, nil
+}
+
+func (r *LoginAttemptRepo) Block(ctx context.Context, bucket string, until time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO login_attempts (bucket, failures, window_start, blocked_until)
+		VALUES ($1, 0, $2, $3)
+		ON CONFLICT (bucket) DO UPDATE SET blocked_until = excluded.blocked_until
+	`, bucket, time.Now(), until)
+	return mapErr(err)
+}
+
+func (r *LoginAttemptRepo) Reset(ctx context.Context, bucket string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM login_attempts WHERE bucket = $1`, bucket)
+	return mapErr(err)
+}
+
+func (r *LoginAttemptRepo) Purge(ctx context.Context, windowStart time.Time) (int64, error) {
+	n, err := r.db.Exec(ctx, `
+		DELETE FROM login_attempts
+		WHERE window_start < $1 AND (blocked_until IS NULL OR blocked_until < $2)
+	`, windowStart, time.Now())
+	return n, mapErr(err)
+}
+
+type SecurityEventRepo struct {
+	db   database.Querier
+	read database.Querier
+}
+
+func (r *SecurityEventRepo) Create(ctx context.Context, e *models.SecurityEvent) error {
+	query := `
+		INSERT INTO security_events (type, user_id, ip, login, detail, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING id, created_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		e.Type, e.UserID, e.IP, e.Login, e.Detail,
+	).Scan(&e.ID, &e.CreatedAt))
+}
+
+func (r *SecurityEventRepo) List(ctx context.Context, f store.SecurityEventFilter, opts store.ListOptions) (store.Page[models.SecurityEvent], error) {
+	opts, err := store.SecurityEventSorts.Normalize(opts)
+	if err != nil {
+		return store.Page[models.SecurityEvent]{}, err
+	}
+
+	var w conds
+	if f.Type != "" {
+		w.add("type = ?", f.Type)
+	}
+	if f.UserID != 0 {
+		w.add("user_id = ?", f.UserID)
+	}
+	if f.IP != "" {
+		w.add("ip = ?", f.IP)
+	}
+	order := w.paginate(opts)
+
+	rows, err := r.read.Query(ctx, `
+		SELECT id, type, user_id, ip, login, detail, created_at
+		FROM security_events `+w.String()+` `+order, w.args...)
+	if err != nil {
+		return store.Page[models.SecurityEvent]{}, mapErr(err)
+	}
+	defer rows.Close()
+
+	var events []models.SecurityEvent
+	for rows.Next() {
+		var e models.SecurityEvent
+		if err := rows.Scan(&e.ID, &e.Type, &e.UserID, &e.IP, &e.Login, &e.Detail, &e.CreatedAt); err != nil {
+			return store.Page[models.SecurityEvent]{}, mapErr(err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return store.Page[models.SecurityEvent]{}, mapErr(err)
+	}
+	return store.SecurityEventSorts.Page(events, opts), nil
+}
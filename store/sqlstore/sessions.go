@@ -0,0 +1,79 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+)
+
+type SessionRepo struct {
+	db database.Querier
+}
+
+const sessionColumns = `id, user_id, family_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at`
+
+func scanSession(row interface{ Scan(...any) error }) (*models.Session, error) {
+	var s models.Session
+	err := row.Scan(&s.ID, &s.UserID, &s.FamilyID, &s.UserAgent, &s.IP,
+		&s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &s, nil
+}
+
+func (r *SessionRepo) Create(ctx context.Context, s *models.Session) error {
+	query := `
+		INSERT INTO user_sessions (user_id, family_id, user_agent, ip, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW(), $5)
+		RETURNING id, created_at, last_seen_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		s.UserID, s.FamilyID, s.UserAgent, s.IP, s.ExpiresAt,
+	).Scan(&s.ID, &s.CreatedAt, &s.LastSeenAt))
+}
+
+func (r *SessionRepo) GetByID(ctx context.Context, id int) (*models.Session, error) {
+	return scanSession(r.db.QueryRow(ctx,
+		`SELECT `+sessionColumns+` FROM user_sessions WHERE id = $1`, id))
+}
+
+func (r *SessionRepo) GetByFamily(ctx context.Context, familyID string) (*models.Session, error) {
+	return scanSession(r.db.QueryRow(ctx,
+		`SELECT `+sessionColumns+` FROM user_sessions WHERE family_id = $1`, familyID))
+}
+
+func (r *SessionRepo) ListActive(ctx context.Context, userID int) ([]models.Session, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+sessionColumns+` FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC, id DESC
+	`, userID, time.Now())
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	return sessions, mapErr(rows.Err())
+}
+
+func (r *SessionRepo) Touch(ctx context.Context, id int) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE user_sessions SET last_seen_at = NOW() WHERE id = $1`, id))
+}
+
+func (r *SessionRepo) Extend(ctx context.Context, id int, ip string, expiresAt time.Time) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE user_sessions SET last_seen_at = NOW(), ip = $2, expires_at = $3 WHERE id = $1`,
+		id, ip, expiresAt))
+}
@@ -0,0 +1,91 @@
+// Package sqlstore implements the store repositories on top of any
+// database.Querier, so the same queries serve Postgres and SQLite.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/store"
+)
+
+// New returns a Store backed by db. Read-only lookups, listings and
+// search go through database.Reader(db), so they use read replicas when db
+// has them. User and token lookups stay on the primary so that a fresh
+// signup can log in straight away and a revoked token is rejected at once.
+func New(db database.DB) *store.Store {
+	s := newStore(db, database.Reader(db))
+	s.Transactor = txRunner{db: db}
+	return s
+}
+
+// newStore builds the repositories. q takes writes and read is used for
+// read-only queries; inside a transaction both are the transaction.
+func newStore(q, read database.Querier) *store.Store {
+	return &store.Store{
+		Users:          &UserRepo{db: q},
+		Tokens:         &TokenRepo{db: q},
+		Sessions:       &SessionRepo{db: q},
+		Identities:     &IdentityRepo{db: q},
+		MFA:            &MFARepo{db: q},
+		Attempts:       &LoginAttemptRepo{db: q},
+		SecurityEvents: &SecurityEventRepo{db: q, read: read},
+		JobRuns:        &JobRunRepo{db: q, read: read},
+		APIKeys:        &APIKeyRepo{db: q, read: read},
+		Organizations:  &OrganizationRepo{db: q, read: read},
+		Profiles:       &ProfileRepo{db: q, read: read},
+		Campaigns:      &CampaignRepo{db: q, read: read},
+		Applications:   &ApplicationRepo{db: q, read: read},
+		Deliverables:   &DeliverableRepo{db: q, read: read},
+		Search:         &SearchRepo{db: read},
+	}
+}
+
+type txRunner struct {
+	db database.DB
+}
+
+func (r txRunner) WithTx(ctx context.Context, fn func(tx *store.Store) error) error {
+	return database.InTx(ctx, r.db, func(tx database.Tx) error {
+		s := newStore(tx, tx)
+		s.Transactor = inTx{s}
+		return fn(s)
+	})
+}
+
+// inTx is the Transactor of a Store that is already inside a transaction.
+type inTx struct {
+	s *store.Store
+}
+
+func (t inTx) WithTx(ctx context.Context, fn func(tx *store.Store) error) error {
+	return fn(t.s)
+}
+
+// mapErr converts driver errors into store errors. pgx.ErrNoRows also
+// matches sql.ErrNoRows.
+func mapErr(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return store.ErrNotFound
+	case database.IsUniqueViolation(err):
+		return fmt.Errorf("%w: %w", store.ErrConflict, err)
+	}
+	return err
+}
+
+// affected reports ErrNotFound when a write matched no rows.
+func affected(n int64, err error) error {
+	if err != nil {
+		return mapErr(err)
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
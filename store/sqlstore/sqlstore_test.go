@@ -0,0 +1,141 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"InfluenceIQ/config"
+	"InfluenceIQ/database"
+	"InfluenceIQ/migrations"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+// newSQLiteStore returns a store on a migrated SQLite database in a
+// temporary directory.
+func newSQLiteStore(t *testing.T) *store.Store {
+	t.Helper()
+	ctx := context.Background()
+	db, err := database.OpenSQLite(ctx, config.DatabaseConfig{URL: filepath.Join(t.TempDir(), "test.db"), MaxConns: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	m, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return New(db)
+}
+
+func newUser(t *testing.T, st *store.Store, username, email, role string) *models.User {
+	t.Helper()
+	u := &models.User{Username: username, Email: email, Role: role}
+	if err := st.Users.Create(context.Background(), u); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestApplicationUnique(t *testing.T) {
+	ctx := context.Background()
+	st := newSQLiteStore(t)
+	brand := newUser(t, st, "acme", "acme@example.com", models.RoleBrand)
+	influencer := newUser(t, st, "ada", "ada@example.com", models.RoleInfluencer)
+	c := &models.Campaign{BrandID: brand.ID, Title: "Launch", Budget: 100, Deadline: time.Now().Add(time.Hour), Status: models.CampaignPublished}
+	if err := st.Campaigns.Create(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent applications by the same influencer: exactly one wins,
+	// the others hit the unique constraint.
+	const n = 8
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = st.WithTx(ctx, func(tx *store.Store) error {
+				if _, err := tx.Campaigns.GetForUpdate(ctx, c.ID); err != nil {
+					return err
+				}
+				return tx.Applications.Create(ctx, &models.CampaignApplication{CampaignID: c.ID, InfluencerID: influencer.ID})
+			})
+		}()
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, store.ErrConflict):
+			t.Errorf("Create = %v, want nil or %v", err, store.ErrConflict)
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d applications created, want 1", created)
+	}
+
+	other := newUser(t, st, "bob", "bob@example.com", models.RoleInfluencer)
+	if err := st.Applications.Create(ctx, &models.CampaignApplication{CampaignID: c.ID, InfluencerID: other.ID}); err != nil {
+		t.Errorf("another influencer's application: %v", err)
+	}
+}
+
+func TestUserLookupIgnoresEmailCase(t *testing.T) {
+	ctx := context.Background()
+	st := newSQLiteStore(t)
+	ada := newUser(t, st, "ada", "Ada@Example.com", models.RoleViewer)
+	// A legacy username that is someone else's address.
+	newUser(t, st, "grace@example.com", "mallory@example.com", models.RoleViewer)
+	grace := newUser(t, st, "grace", "grace@example.com", models.RoleViewer)
+
+	tests := []struct {
+		name  string
+		find  func(string) (*models.User, error)
+		login string
+		want  *models.User
+	}{
+		{"email in another case", func(s string) (*models.User, error) { return st.Users.GetByEmail(ctx, s) }, "ada@EXAMPLE.com", ada},
+		{"email not a username", func(s string) (*models.User, error) { return st.Users.GetByEmail(ctx, s) }, "grace@example.com", grace},
+		{"unknown email", func(s string) (*models.User, error) { return st.Users.GetByEmail(ctx, s) }, "ada", nil},
+		{"login by email in another case", func(s string) (*models.User, error) { return st.Users.GetByLogin(ctx, s) }, "ADA@example.com", ada},
+		{"login prefers the email", func(s string) (*models.User, error) { return st.Users.GetByLogin(ctx, s) }, "grace@example.com", grace},
+		{"login by username", func(s string) (*models.User, error) { return st.Users.GetByLogin(ctx, s) }, "grace", grace},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.find(tt.login)
+			if tt.want == nil {
+				if !errors.Is(err, store.ErrNotFound) {
+					t.Errorf("got %v, %v, want %v", got, err, store.ErrNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != tt.want.ID {
+				t.Errorf("found user %d, want %d", got.ID, tt.want.ID)
+			}
+		})
+	}
+
+	exists, err := st.Users.Exists(ctx, "ADA@example.COM", "someone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Error("Exists ignores an email in another case")
+	}
+}
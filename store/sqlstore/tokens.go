@@ -0,0 +1,172 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+	"InfluenceIQ/store"
+)
+
+type TokenRepo struct {
+	db database.Querier
+}
+
+const refreshTokenColumns = `id, user_id, family_id, token_hash, expires_at, created_at, used_at, revoked_at`
+
+func scanRefreshToken(row interface{ Scan(...any) error }) (*models.RefreshToken, error) {
+	var t models.RefreshToken
+	err := row.Scan(
+		&t.ID, &t.UserID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt, &t.CreatedAt, &t.UsedAt, &t.RevokedAt,
+	)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &t, nil
+}
+
+func (r *TokenRepo) CreateRefresh(ctx context.Context, t *models.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		t.UserID, t.FamilyID, t.TokenHash, t.ExpiresAt,
+	).Scan(&t.ID, &t.CreatedAt))
+}
+
+func (r *TokenRepo) GetRefreshByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	return scanRefreshToken(r.db.QueryRow(ctx,
+		`SELECT `+refreshTokenColumns+` FROM refresh_tokens WHERE token_hash = $1`, hash))
+}
+
+func (r *TokenRepo) UseRefresh(ctx context.Context, id int) error {
+	n, err := r.db.Exec(ctx, `
+		UPDATE refresh_tokens SET used_at = NOW()
+		WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return mapErr(err)
+	}
+	if n == 0 {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (r *TokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID)
+	if err != nil {
+		return mapErr(err)
+	}
+	_, err = r.db.Exec(ctx, `
+		UPDATE user_sessions SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID)
+	return mapErr(err)
+}
+
+func (r *TokenRepo) RevokeUserRefresh(ctx context.Context, userID int) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return mapErr(err)
+	}
+	_, err = r.db.Exec(ctx, `
+		UPDATE user_sessions SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	return mapErr(err)
+}
+
+func (r *TokenRepo) Deny(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO revoked_access_tokens (jti, expires_at) VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+	`, jti, expiresAt)
+	return mapErr(err)
+}
+
+func (r *TokenRepo) IsDenied(ctx context.Context, jti string) (bool, error) {
+	var denied bool
+	err := r.db.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM revoked_access_tokens WHERE jti = $1)`, jti).Scan(&denied)
+	return denied, err
+}
+
+const userTokenColumns = `id, user_id, purpose, token_hash, expires_at, created_at, used_at`
+
+func (r *TokenRepo) CreateUserToken(ctx context.Context, t *models.UserToken) error {
+	query := `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		t.UserID, t.Purpose, t.TokenHash, t.ExpiresAt,
+	).Scan(&t.ID, &t.CreatedAt))
+}
+
+func (r *TokenRepo) ConsumeUserToken(ctx context.Context, purpose, hash string) (*models.UserToken, error) {
+	var t models.UserToken
+	err := r.db.QueryRow(ctx, `
+		UPDATE user_tokens SET used_at = NOW()
+		WHERE purpose = $1 AND token_hash = $2 AND used_at IS NULL AND expires_at > $3
+		RETURNING `+userTokenColumns,
+		purpose, hash, time.Now(),
+	).Scan(&t.ID, &t.UserID, &t.Purpose, &t.TokenHash, &t.ExpiresAt, &t.CreatedAt, &t.UsedAt)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &t, nil
+}
+
+func (r *TokenRepo) DeleteUserTokens(ctx context.Context, userID int, purpose string) error {
+	_, err := r.db.Exec(ctx,
+		`DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2`, userID, purpose)
+	return mapErr(err)
+}
+
+func (r *TokenRepo) CreateOAuthState(ctx context.Context, s *models.OAuthState) error {
+	query := `
+		INSERT INTO oauth_states (state_hash, provider, nonce, code_verifier, user_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING id, created_at
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		s.StateHash, s.Provider, s.Nonce, s.CodeVerifier, s.UserID, s.ExpiresAt,
+	).Scan(&s.ID, &s.CreatedAt))
+}
+
+func (r *TokenRepo) ConsumeOAuthState(ctx context.Context, hash string) (*models.OAuthState, error) {
+	var s models.OAuthState
+	err := r.db.QueryRow(ctx, `
+		DELETE FROM oauth_states
+		WHERE state_hash = $1 AND expires_at > $2
+		RETURNING id, state_hash, provider, nonce, code_verifier, user_id, expires_at, created_at
+	`, hash, time.Now(),
+	).Scan(&s.ID, &s.StateHash, &s.Provider, &s.Nonce, &s.CodeVerifier, &s.UserID, &s.ExpiresAt, &s.CreatedAt)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return &s, nil
+}
+
+func (r *TokenRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	var total int64
+	for _, table := range []string{"refresh_tokens", "user_sessions", "revoked_access_tokens", "user_tokens", "oauth_states", "mfa_challenges", "organization_invitations"} {
+		n, err := r.db.Exec(ctx, `DELETE FROM `+table+` WHERE expires_at < $1`, before)
+		if err != nil {
+			return total, mapErr(err)
+		}
+		total += n
+	}
+	return total, nil
+}
@@ -0,0 +1,81 @@
+package sqlstore
+
+import (
+	"context"
+
+	"InfluenceIQ/database"
+	"InfluenceIQ/models"
+)
+
+type UserRepo struct {
+	db database.Querier
+}
+
+const userColumns = `user_id, username, email, full_name, password, role, email_verified_at`
+
+func scanUser(row interface{ Scan(...any) error }) (*models.User, error) {
+	var u models.User
+	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.FullName, &u.PasswordHash, &u.Role, &u.EmailVerifiedAt); err != nil {
+		return nil, mapErr(err)
+	}
+	return &u, nil
+}
+
+func (r *UserRepo) Create(ctx context.Context, u *models.User) error {
+	query := `
+		INSERT INTO users (username, email, password, full_name, role)
+		VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'viewer'))
+		RETURNING user_id, role
+	`
+	return mapErr(r.db.QueryRow(ctx, query,
+		u.Username, u.Email, u.PasswordHash, u.FullName, u.Role,
+	).Scan(&u.ID, &u.Role))
+}
+
+func (r *UserRepo) GetByID(ctx context.Context, id int) (*models.User, error) {
+	return scanUser(r.db.QueryRow(ctx,
+		`SELECT `+userColumns+` FROM users WHERE user_id = $1`, id))
+}
+
+// Accounts created before usernames had to be free of "@" may use another
+// user's email address as their username, so matches are ordered to find
+// the owner of the address first.
+func (r *UserRepo) GetByLogin(ctx context.Context, login string) (*models.User, error) {
+	return scanUser(r.db.QueryRow(ctx, `
+		SELECT `+userColumns+` FROM users
+		WHERE lower(email) = lower($1) OR username = $1
+		ORDER BY CASE WHEN lower(email) = lower($1) THEN 0 ELSE 1 END, user_id
+		LIMIT 1
+	`, login))
+}
+
+func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return scanUser(r.db.QueryRow(ctx, `
+		SELECT `+userColumns+` FROM users WHERE lower(email) = lower($1) ORDER BY user_id LIMIT 1
+	`, email))
+}
+
+func (r *UserRepo) Exists(ctx context.Context, email, username string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE lower(email) = lower($1) OR username = $2)`,
+		email, username).Scan(&exists)
+	return exists, err
+}
+
+func (r *UserRepo) SetEmailVerified(ctx context.Context, id int) error {
+	return affected(r.db.Exec(ctx, `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+		WHERE user_id = $1
+	`, id))
+}
+
+func (r *UserRepo) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE users SET password = $1, updated_at = NOW() WHERE user_id = $2`, passwordHash, id))
+}
+
+func (r *UserRepo) SetRole(ctx context.Context, id int, role string) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE users SET role = $1, updated_at = NOW() WHERE user_id = $2`, role, id))
+}
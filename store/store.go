@@ -0,0 +1,369 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"InfluenceIQ/models"
+)
+
+var (
+	// ErrNotFound is returned when a lookup matches no record.
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a write collides with existing data,
+	// e.g. a unique constraint or a concurrent status change.
+	ErrConflict = errors.New("record conflicts with existing data")
+)
+
+// UserRepository persists user accounts.
+type UserRepository interface {
+	Create(ctx context.Context, u *models.User) error
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	// GetByLogin finds a user whose email or username equals login,
+	// preferring an email match. Emails are compared ignoring case.
+	GetByLogin(ctx context.Context, login string) (*models.User, error)
+	// GetByEmail finds the user with the email address, ignoring case.
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// Exists reports whether the email (ignoring case) or the username is
+	// already taken.
+	Exists(ctx context.Context, email, username string) (bool, error)
+	// SetEmailVerified marks the user's email address as verified, keeping
+	// the original time if it already was. UpdatePassword replaces the
+	// password hash. Both return ErrNotFound for unknown users.
+	SetEmailVerified(ctx context.Context, id int) error
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+	// SetRole changes the user's role and returns ErrNotFound for unknown
+	// users.
+	SetRole(ctx context.Context, id int, role string) error
+}
+
+// TokenRepository persists refresh tokens, the denylist of revoked access
+// tokens, the single-use tokens mailed to users and the state of logins
+// in progress at OpenID Connect providers.
+type TokenRepository interface {
+	CreateRefresh(ctx context.Context, t *models.RefreshToken) error
+	GetRefreshByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// UseRefresh marks a token as used. It returns ErrConflict if the token
+	// was already used or revoked.
+	UseRefresh(ctx context.Context, id int) error
+	// RevokeFamily revokes every refresh token in the family and the
+	// family's session.
+	RevokeFamily(ctx context.Context, familyID string) error
+	// RevokeUserRefresh revokes every refresh token and session of the user.
+	RevokeUserRefresh(ctx context.Context, userID int) error
+	// Deny adds an access token ID to the denylist until expiresAt. Denying
+	// the same ID twice is not an error.
+	Deny(ctx context.Context, jti string, expiresAt time.Time) error
+	IsDenied(ctx context.Context, jti string) (bool, error)
+
+	CreateUserToken(ctx context.Context, t *models.UserToken) error
+	// ConsumeUserToken marks the unused, unexpired token with the given
+	// purpose and hash as used and returns it, or returns ErrNotFound.
+	ConsumeUserToken(ctx context.Context, purpose, hash string) (*models.UserToken, error)
+	// DeleteUserTokens removes the user's tokens with the given purpose.
+	DeleteUserTokens(ctx context.Context, userID int, purpose string) error
+
+	CreateOAuthState(ctx context.Context, s *models.OAuthState) error
+	// ConsumeOAuthState deletes the unexpired state with the given hash and
+	// returns it, or returns ErrNotFound.
+	ConsumeOAuthState(ctx context.Context, hash string) (*models.OAuthState, error)
+
+	// DeleteExpired removes refresh tokens, sessions, denylist entries, user
+	// tokens, OAuth states, MFA challenges and organization invitations
+	// that expired before the given time and reports how many it removed.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// SessionRepository persists logins, one per refresh token family. They
+// are revoked through TokenRepository.RevokeFamily and RevokeUserRefresh
+// so that a session and its refresh tokens always end together.
+type SessionRepository interface {
+	Create(ctx context.Context, s *models.Session) error
+	GetByID(ctx context.Context, id int) (*models.Session, error)
+	GetByFamily(ctx context.Context, familyID string) (*models.Session, error)
+	// ListActive returns the user's unrevoked, unexpired sessions, most
+	// recently seen first.
+	ListActive(ctx context.Context, userID int) ([]models.Session, error)
+	// Touch records that the session was just used.
+	Touch(ctx context.Context, id int) error
+	// Extend records a refresh: the session was used from ip and now lasts
+	// until expiresAt.
+	Extend(ctx context.Context, id int, ip string, expiresAt time.Time) error
+}
+
+// IdentityRepository persists the OpenID Connect identities linked to
+// users.
+type IdentityRepository interface {
+	// Create returns ErrConflict if the provider's subject is already
+	// linked or the user already has an identity at the provider.
+	Create(ctx context.Context, i *models.Identity) error
+	GetBySubject(ctx context.Context, provider, subject string) (*models.Identity, error)
+	ListByUser(ctx context.Context, userID int) ([]models.Identity, error)
+	// TouchLogin records a login through the identity.
+	TouchLogin(ctx context.Context, id int) error
+	// Delete unlinks the user's identity at the provider and returns
+	// ErrNotFound if there is none.
+	Delete(ctx context.Context, userID int, provider string) error
+}
+
+// MFARepository persists two-factor enrollments, recovery codes, pending
+// second-factor challenges and the policies that require 2FA.
+type MFARepository interface {
+	GetTOTP(ctx context.Context, userID int) (*models.TOTP, error)
+	// SaveTOTP starts a pending enrollment, replacing any earlier one.
+	SaveTOTP(ctx context.Context, t *models.TOTP) error
+	EnableTOTP(ctx context.Context, userID int) error
+	// UseTOTPStep records that the code for step was used. It returns
+	// ErrConflict unless step is later than the last one used.
+	UseTOTPStep(ctx context.Context, userID int, step int64) error
+	// DeleteTOTP removes the enrollment and the user's recovery codes.
+	DeleteTOTP(ctx context.Context, userID int) error
+
+	// ReplaceRecoveryCodes swaps the user's recovery codes for new ones.
+	ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error
+	// UseRecoveryCode marks the user's unused code with the given hash as
+	// used, or returns ErrNotFound.
+	UseRecoveryCode(ctx context.Context, userID int, hash string) error
+	CountRecoveryCodes(ctx context.Context, userID int) (int, error)
+
+	CreateChallenge(ctx context.Context, ch *models.MFAChallenge) error
+	// GetChallenge returns the unexpired challenge with the given hash, or
+	// ErrNotFound.
+	GetChallenge(ctx context.Context, hash string) (*models.MFAChallenge, error)
+	// FailChallenge counts a wrong code and returns the attempts so far.
+	FailChallenge(ctx context.Context, id int) (int, error)
+	// DeleteChallenge returns ErrNotFound if the challenge is already gone,
+	// so that only one attempt can complete it.
+	DeleteChallenge(ctx context.Context, id int) error
+
+	ListPolicies(ctx context.Context) ([]models.MFAPolicy, error)
+	// ReplacePolicies sets the values of scope that require 2FA.
+	ReplacePolicies(ctx context.Context, scope string, values []string) error
+	// Required reports whether a policy covers the value in scope.
+	Required(ctx context.Context, scope, value string) (bool, error)
+}
+
+// LoginAttemptRepository counts failed logins per bucket, e.g. an account
+// or a client IP, and keeps the blocks placed on buckets. Unknown buckets
+// read as zero records rather than ErrNotFound.
+type LoginAttemptRepository interface {
+	Get(ctx context.Context, bucket string) (*models.LoginAttempts, error)
+	// Fail counts a failed login and returns the updated record. Counting
+	// starts over if the current count began before windowStart.
+	Fail(ctx context.Context, bucket string, windowStart time.Time) (*models.LoginAttempts, error)
+	// Block rejects the bucket's logins until the given time.
+	Block(ctx context.Context, bucket string, until time.Time) error
+	// Reset forgets the bucket's failures and lifts its block.
+	Reset(ctx context.Context, bucket string) error
+	// Purge removes records whose count began before windowStart and that
+	// are no longer blocked, and reports how many it removed.
+	Purge(ctx context.Context, windowStart time.Time) (int64, error)
+}
+
+// SecurityEventRepository persists the security audit log.
+type SecurityEventRepository interface {
+	Create(ctx context.Context, e *models.SecurityEvent) error
+	// List returns one page of the events matching f.
+	List(ctx context.Context, f SecurityEventFilter, opts ListOptions) (Page[models.SecurityEvent], error)
+}
+
+// JobRunRepository persists the history of scheduled job runs.
+type JobRunRepository interface {
+	// Start records a run of r.Job for r.ScheduledAt. It returns ErrConflict
+	// if that scheduled time already has a run.
+	Start(ctx context.Context, r *models.JobRun) error
+	// Finish records r.Status, r.Result, r.Error and r.DurationMS.
+	Finish(ctx context.Context, r *models.JobRun) error
+	// List returns one page of the runs matching f.
+	List(ctx context.Context, f JobRunFilter, opts ListOptions) (Page[models.JobRun], error)
+	// Purge removes the runs started before t and reports how many it
+	// removed.
+	Purge(ctx context.Context, t time.Time) (int64, error)
+}
+
+// APIKeyRepository persists the API keys brands use for server-to-server
+// integrations.
+type APIKeyRepository interface {
+	Create(ctx context.Context, k *models.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	// ListByUser returns the user's keys, newest first.
+	ListByUser(ctx context.Context, userID int) ([]models.APIKey, error)
+	// CountActive counts the user's keys that are neither revoked nor
+	// expired.
+	CountActive(ctx context.Context, userID int) (int, error)
+	// TouchLastUsed records that the key was used.
+	TouchLastUsed(ctx context.Context, id int) error
+	// Revoke revokes the user's key and returns ErrNotFound if the user has
+	// no such key or it is already revoked.
+	Revoke(ctx context.Context, id, userID int) error
+}
+
+// OrganizationRepository persists brand organizations, their members and
+// the invitations to join them.
+type OrganizationRepository interface {
+	Create(ctx context.Context, o *models.Organization) error
+	GetByID(ctx context.Context, id int) (*models.Organization, error)
+	// Rename and Delete return ErrNotFound for unknown organizations.
+	Rename(ctx context.Context, id int, name string) error
+	Delete(ctx context.Context, id int) error
+
+	// AddMember returns ErrConflict if the user already belongs to an
+	// organization.
+	AddMember(ctx context.Context, m *models.OrganizationMember) error
+	// GetMembership returns the user's membership, or ErrNotFound if the
+	// user belongs to no organization.
+	GetMembership(ctx context.Context, userID int) (*models.OrganizationMember, error)
+	ListMembers(ctx context.Context, orgID int) ([]models.OrganizationMember, error)
+	// SetMemberRole and RemoveMember return ErrNotFound if the user isn't a
+	// member of the organization.
+	SetMemberRole(ctx context.Context, orgID, userID int, role string) error
+	RemoveMember(ctx context.Context, orgID, userID int) error
+	CountOwners(ctx context.Context, orgID int) (int, error)
+
+	// CreateInvitation replaces any pending invitation of the same email
+	// address to the organization.
+	CreateInvitation(ctx context.Context, inv *models.OrganizationInvitation) error
+	// ListInvitations returns the organization's unexpired invitations.
+	ListInvitations(ctx context.Context, orgID int) ([]models.OrganizationInvitation, error)
+	// ConsumeInvitation deletes the unexpired invitation with the given hash
+	// and returns it, or returns ErrNotFound.
+	ConsumeInvitation(ctx context.Context, hash string) (*models.OrganizationInvitation, error)
+	// DeleteInvitation returns ErrNotFound if the organization has no such
+	// invitation.
+	DeleteInvitation(ctx context.Context, orgID, id int) error
+}
+
+// ProfileRepository persists user profiles, one per user.
+type ProfileRepository interface {
+	Create(ctx context.Context, p *models.Profile) error
+	GetByUserID(ctx context.Context, userID int) (*models.Profile, error)
+	// Update and Delete return ErrNotFound if the user has no profile.
+	// Update leaves VerifiedAt alone.
+	Update(ctx context.Context, p *models.Profile) error
+	Delete(ctx context.Context, userID int) error
+	// SetVerified marks the user's profile as verified now, or not
+	// verified. It returns ErrNotFound if the user has no profile.
+	SetVerified(ctx context.Context, userID int, verified bool) error
+}
+
+// CampaignRepository persists brand campaigns.
+type CampaignRepository interface {
+	Create(ctx context.Context, c *models.Campaign) error
+	GetByID(ctx context.Context, id int) (*models.Campaign, error)
+	// GetForUpdate is GetByID for use in a transaction that acts on the
+	// campaign's status: the row stays locked until the transaction ends,
+	// so the status can't change meanwhile.
+	GetForUpdate(ctx context.Context, id int) (*models.Campaign, error)
+	// List returns one page of the campaigns matching f.
+	List(ctx context.Context, f CampaignFilter, opts ListOptions) (Page[models.Campaign], error)
+	// Update and Delete return ErrNotFound for unknown campaigns. Callers
+	// check that the user may manage the campaign first.
+	//
+	// Update writes the editable fields (not the status) if the campaign is
+	// still at c.Version and returns ErrConflict otherwise. On success c
+	// carries the new version.
+	Update(ctx context.Context, c *models.Campaign) error
+	Delete(ctx context.Context, id int) error
+	// AdoptCampaigns moves the brand's campaigns that belong to no
+	// organization into orgID.
+	AdoptCampaigns(ctx context.Context, brandID, orgID int) error
+	// IncrementCounters adds the given deltas to the campaign's application
+	// and accepted counters.
+	IncrementCounters(ctx context.Context, id int, applications, accepted int) error
+	// SetStatus moves a campaign from one status to another. It returns
+	// ErrConflict if the status is no longer from.
+	SetStatus(ctx context.Context, id int, from, to string) error
+	AddTransition(ctx context.Context, t *models.CampaignTransition) error
+	// ListTransitions returns the campaign's status changes, oldest first.
+	ListTransitions(ctx context.Context, campaignID int) ([]models.CampaignTransition, error)
+	// ClaimDeadlineReminder records that the reminder for the campaign's
+	// current deadline is being sent. It reports false if it already was, or
+	// if the campaign doesn't exist.
+	ClaimDeadlineReminder(ctx context.Context, id int) (bool, error)
+}
+
+// ApplicationRepository persists influencer applications to campaigns.
+type ApplicationRepository interface {
+	Create(ctx context.Context, a *models.CampaignApplication) error
+	GetByID(ctx context.Context, id int) (*models.CampaignApplication, error)
+	GetByCampaignAndInfluencer(ctx context.Context, campaignID, influencerID int) (*models.CampaignApplication, error)
+	// List returns one page of the applications matching f.
+	List(ctx context.Context, f ApplicationFilter, opts ListOptions) (Page[models.CampaignApplication], error)
+	// UpdateStatus moves an application from one status to another. It
+	// returns ErrConflict if the status is no longer from.
+	UpdateStatus(ctx context.Context, id int, from, to string) error
+	// RejectPending rejects the campaign's pending applications and reports
+	// how many there were.
+	RejectPending(ctx context.Context, campaignID int) (int64, error)
+	// ExpirePending expires the pending applications created before t and
+	// reports how many there were.
+	ExpirePending(ctx context.Context, t time.Time) (int64, error)
+}
+
+// SubmissionFilter selects deliverable submissions. Set ApplicationID for
+// one application's, or CampaignID for those of every application accepted
+// to the campaign; DeliverableID narrows either.
+type SubmissionFilter struct {
+	ApplicationID int
+	CampaignID    int
+	DeliverableID int
+}
+
+// DeliverableRepository persists campaign deliverables and the work
+// influencers submit for them.
+type DeliverableRepository interface {
+	Create(ctx context.Context, d *models.Deliverable) error
+	GetByID(ctx context.Context, id int) (*models.Deliverable, error)
+	// ListByCampaign returns the campaign's deliverables in the order they
+	// were added.
+	ListByCampaign(ctx context.Context, campaignID int) ([]models.Deliverable, error)
+	Update(ctx context.Context, d *models.Deliverable) error
+	Delete(ctx context.Context, id int) error
+
+	CreateSubmission(ctx context.Context, s *models.DeliverableSubmission) error
+	GetSubmission(ctx context.Context, id int) (*models.DeliverableSubmission, error)
+	// ListSubmissions returns the submissions matching f, oldest first.
+	ListSubmissions(ctx context.Context, f SubmissionFilter) ([]models.DeliverableSubmission, error)
+	CountSubmissions(ctx context.Context, deliverableID int) (int, error)
+	// ReviewSubmission records s.Status, s.Comment and s.ReviewedBy on a
+	// pending submission. It returns ErrConflict if the submission was
+	// already reviewed.
+	ReviewSubmission(ctx context.Context, s *models.DeliverableSubmission) error
+}
+
+// SearchRepository runs keyword searches. Postgres uses full-text search;
+// other backends fall back to a Matcher.
+type SearchRepository interface {
+	Search(ctx context.Context, q SearchQuery) (*models.SearchResults, error)
+}
+
+// Transactor runs multi-step operations atomically.
+type Transactor interface {
+	// WithTx calls fn with a Store whose repositories share one transaction.
+	// The transaction commits if fn returns nil and rolls back otherwise.
+	// Calling WithTx on the Store passed to fn runs inside the same
+	// transaction.
+	WithTx(ctx context.Context, fn func(tx *Store) error) error
+}
+
+// Store bundles the repositories a backend provides.
+type Store struct {
+	Users          UserRepository
+	Tokens         TokenRepository
+	Sessions       SessionRepository
+	Identities     IdentityRepository
+	MFA            MFARepository
+	Attempts       LoginAttemptRepository
+	SecurityEvents SecurityEventRepository
+	JobRuns        JobRunRepository
+	APIKeys        APIKeyRepository
+	Organizations  OrganizationRepository
+	Profiles       ProfileRepository
+	Campaigns      CampaignRepository
+	Applications   ApplicationRepository
+	Deliverables   DeliverableRepository
+	Search         SearchRepository
+
+	Transactor
+}